@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dnephin/dobi/tasks"
+	"github.com/spf13/cobra"
+)
+
+type artifactsOptions struct {
+	json bool
+}
+
+func newArtifactsCommand(opts *dobiOptions) *cobra.Command {
+	var artifactsOpts artifactsOptions
+	cmd := &cobra.Command{
+		Use:   "artifacts",
+		Short: "List the artifacts of all resources",
+		Long: "Print the artifact of every resource, and whether it exists. " +
+			"Resources are not run.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runArtifacts(opts, artifactsOpts)
+		},
+	}
+	flags := cmd.Flags()
+	flags.BoolVar(&artifactsOpts.json, "json", false, "Print artifacts as json")
+	return cmd
+}
+
+func runArtifacts(opts *dobiOptions, artifactsOpts artifactsOptions) error {
+	conf, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	artifacts, err := tasks.Artifacts(conf, opts.envFiles)
+	if err != nil {
+		return err
+	}
+	if artifactsOpts.json {
+		out, err := json.MarshalIndent(artifacts, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	for _, artifact := range artifacts {
+		fmt.Printf("  %-20s %s (%s)\n", artifact.Resource, artifact.Path, artifactStatus(artifact))
+	}
+	return nil
+}
+
+func artifactStatus(artifact tasks.Artifact) string {
+	if artifact.Exists {
+		return "exists"
+	}
+	return "missing"
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks"
+	"github.com/dnephin/dobi/tasks/history"
+	"github.com/spf13/cobra"
+)
+
+type cleanOptions struct {
+	state    bool
+	networks bool
+	volumes  bool
+	services bool
+}
+
+func newCleanCommand(opts *dobiOptions) *cobra.Command {
+	var cleanOpts cleanOptions
+	cmd := &cobra.Command{
+		Use:   "clean [RESOURCE...]",
+		Short: "Remove files, networks, volumes, services, and resources created by dobi",
+		Long: "Remove files, networks, volumes, and services created by dobi. When " +
+			"resources are named, only the images, containers, artifacts, compose " +
+			"projects, and state of those resources are removed.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runClean(opts, cleanOpts, args)
+		},
+	}
+	flags := cmd.Flags()
+	flags.BoolVar(&cleanOpts.state, "state", false,
+		"Remove the state used by --since-last-success")
+	flags.BoolVar(&cleanOpts.networks, "networks", false,
+		"Remove the networks created by network resources")
+	flags.BoolVar(&cleanOpts.volumes, "volumes", false,
+		"Remove the volumes created by volume resources")
+	flags.BoolVar(&cleanOpts.services, "services", false,
+		"Remove the containers left by service resources")
+	return cmd
+}
+
+func (o cleanOptions) isSet() bool {
+	return o.state || o.networks || o.volumes || o.services
+}
+
+func runClean(opts *dobiOptions, cleanOpts cleanOptions, resources []string) error {
+	switch {
+	case len(resources) > 0 && cleanOpts.isSet():
+		return fmt.Errorf(
+			"--state, --networks, --volumes, and --services can not be used with resources")
+	case len(resources) == 0 && !cleanOpts.isSet():
+		return fmt.Errorf(
+			"Nothing to clean, use --state, --networks, --volumes, --services, or name a resource")
+	}
+
+	conf, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+	if len(resources) > 0 {
+		return cleanResources(conf, opts, resources)
+	}
+	if cleanOpts.services {
+		if err := removeResources(conf, opts, isService); err != nil {
+			return err
+		}
+	}
+	if cleanOpts.networks {
+		if err := removeResources(conf, opts, isNetwork); err != nil {
+			return err
+		}
+	}
+	if cleanOpts.volumes {
+		if err := removeResources(conf, opts, isVolume); err != nil {
+			return err
+		}
+	}
+	if cleanOpts.state {
+		if err := history.Remove(conf.WorkingDir); err != nil {
+			return fmt.Errorf("Failed to remove state: %s", err)
+		}
+		fmt.Printf("Removed %s\n", history.StatePath(conf.WorkingDir))
+	}
+	return nil
+}
+
+func isNetwork(resource config.Resource) bool {
+	_, ok := resource.(*config.NetworkConfig)
+	return ok
+}
+
+func isService(resource config.Resource) bool {
+	_, ok := resource.(*config.ServiceConfig)
+	return ok
+}
+
+func isVolume(resource config.Resource) bool {
+	_, ok := resource.(*config.VolumeConfig)
+	return ok
+}
+
+// cleanResources runs the remove task of each resource, and removes the state
+// of the resource used by --since-last-success. The state of a resource with a
+// cleanup policy of never is kept.
+func cleanResources(conf *config.Config, opts *dobiOptions, resources []string) error {
+	names := []string{}
+	for _, name := range resources {
+		if _, ok := conf.Resources[name]; !ok {
+			return fmt.Errorf("Resource %q does not exist", name)
+		}
+		names = append(names, name+":rm")
+	}
+	if err := runRemoveTasks(conf, opts, names); err != nil {
+		return err
+	}
+
+	store, err := history.Load(conf.WorkingDir)
+	if err != nil {
+		return fmt.Errorf("Failed to load state: %s", err)
+	}
+	for _, name := range resources {
+		if conf.OptionsFor(name).Cleanup.Never {
+			continue
+		}
+		if err := store.RemoveResource(name); err != nil {
+			return fmt.Errorf("Failed to remove the state of %q: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// removeResources runs the remove task of every resource which matches
+func removeResources(
+	conf *config.Config,
+	opts *dobiOptions,
+	matches func(config.Resource) bool,
+) error {
+	names := []string{}
+	for _, name := range conf.Sorted() {
+		if matches(conf.Resources[name]) {
+			names = append(names, name+":rm")
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return runRemoveTasks(conf, opts, names)
+}
+
+func runRemoveTasks(conf *config.Config, opts *dobiOptions, names []string) error {
+	client, err := buildClient(conf.Meta.Engine, opts.dumpCalls)
+	if err != nil {
+		return fmt.Errorf("Failed to create client: %s", err)
+	}
+	return tasks.Run(tasks.RunOptions{
+		Client: client,
+		Config: conf,
+		Tasks:  names,
+		Quiet:  opts.quiet,
+	})
+}
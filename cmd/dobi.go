@@ -3,19 +3,28 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/dnephin/dobi/config"
 	"github.com/dnephin/dobi/logging"
 	"github.com/dnephin/dobi/tasks"
 	"github.com/dnephin/dobi/tasks/client"
-	docker "github.com/fsouza/go-dockerclient"
+	"github.com/dnephin/dobi/tasks/events"
+	"github.com/dnephin/dobi/tasks/job"
+	"github.com/dnephin/dobi/utils/fs"
+	"github.com/dnephin/dobi/utils/mask"
+	shlex "github.com/kballard/go-shellquote"
 	"github.com/spf13/cobra"
 )
 
 const (
 	// DefaultDockerAPIVersion is the default version of the docker API to use
 	DefaultDockerAPIVersion = "1.23"
+
+	tmpDirEnvVar = "DOBI_TMPDIR"
 )
 
 var (
@@ -25,11 +34,36 @@ var (
 )
 
 type dobiOptions struct {
-	filename string
-	verbose  bool
-	quiet    bool
-	tasks    []string
-	version  bool
+	filename       string
+	configEnv      string
+	verbose        bool
+	quiet          bool
+	tasks          []string
+	version        bool
+	parallelImages int
+	parallel       int
+	parallelPull   int
+	tmpDir         string
+	envPassthrough []string
+	envFiles       []string
+	sinceSuccess   bool
+	failOnPause    bool
+	onlyStale      bool
+	dryRun         bool
+	listStale      bool
+	force          bool
+	execCommand    string
+	printEnv       string
+	failOnWarnings bool
+	explainCache   bool
+	interactive    *bool
+	timeout        time.Duration
+	dumpCalls      bool
+	noRemove       bool
+	planFile       string
+	watch          bool
+	watchInitial   bool
+	output         string
 }
 
 // NewRootCommand returns a new root command
@@ -45,22 +79,85 @@ func NewRootCommand() *cobra.Command {
 		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.tasks = args
+			if !cmd.Flags().Changed("interactive") {
+				opts.interactive = nil
+			}
 			return runDobi(opts)
 		},
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			initLogging(opts.verbose, opts.quiet)
-			return nil
+			initLogging(opts.verbose || opts.dumpCalls, opts.quiet || opts.output == "json")
+			if opts.configEnv == "" {
+				return nil
+			}
+			var err error
+			opts.filename, err = variantFilename(opts.filename, opts.configEnv)
+			return err
 		},
 	}
 
 	flags := cmd.Flags()
 	flags.StringVarP(&opts.filename, "filename", "f", "dobi.yaml", "Path to config file")
+	flags.StringVar(&opts.configEnv, "config-env", "",
+		"Use the config file variant dobi.<NAME>.yaml instead of the config file")
 	flags.BoolVarP(&opts.verbose, "verbose", "v", false, "Verbose")
 	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Quiet")
 	flags.BoolVar(&opts.version, "version", false, "Print version and exit")
+	flags.IntVar(&opts.parallelImages, "parallel-images", 1,
+		"Maximum number of independent images to build concurrently")
+	flags.IntVarP(&opts.parallel, "parallel", "j", 1,
+		"Maximum number of independent tasks of each type to run concurrently")
+	flags.IntVar(&opts.parallelPull, "parallel-pull", 0,
+		"Pull the images used by the tasks, N at a time, before running any tasks")
+	flags.StringVar(&opts.tmpDir, "tmp-dir", os.Getenv(tmpDirEnvVar),
+		"Directory used for intermediate files (default $"+tmpDirEnvVar+" or the system temp dir)")
+	flags.StringSliceVar(&opts.envPassthrough, "env-passthrough", nil,
+		"Name of a host environment variable, or a prefix followed by *, to pass to all jobs (may be repeated)")
+	flags.StringSliceVar(&opts.envFiles, "env-file", nil,
+		"Path to a file of variables used by {env.NAME} (may be repeated)")
+	flags.BoolVar(&opts.sinceSuccess, "since-last-success", false,
+		"Skip jobs and image builds which are unchanged since their last success")
+	flags.BoolVar(&opts.explainCache, "explain-cache", false,
+		"Print why each task is skipped or run by --since-last-success")
+	flags.BoolVar(&opts.failOnPause, "fail-on-pause", false,
+		"Fail when a resource pauses and stdin is not a terminal")
+	flags.BoolVar(&opts.onlyStale, "only-stale", false,
+		"Only run the tasks which are stale, or depend on a stale task")
+	flags.BoolVar(&opts.dryRun, "dry-run", false,
+		"Print the tasks which would run, without running them")
+	flags.StringVar(&opts.planFile, "plan-file", "",
+		"Write the execution plan of the tasks to a json file before they run")
+	flags.BoolVar(&opts.watch, "watch", false,
+		"Run the tasks again each time the sources of a job, shell, or image change")
+	flags.BoolVar(&opts.watchInitial, "watch-initial", true,
+		"Run the tasks when --watch starts, set to false to wait for the first change")
+	flags.StringVar(&opts.output, "output", "text",
+		"Output format, one of: text, json. json prints an event for each task on stdout")
+	flags.BoolVar(&opts.listStale, "list-stale", false,
+		"Print the name of each stale resource, and fail if any are stale")
+	flags.BoolVar(&opts.force, "force", false,
+		"Run jobs with run-once, even if they have already run")
+	flags.StringVar(&opts.execCommand, "command", "",
+		"Command to run in the running container of a job with the exec action")
+	flags.StringVar(&opts.printEnv, "print-env", "",
+		"Print the environment of a job, without running it")
+	flags.BoolVar(&opts.failOnWarnings, "fail-on-warnings", false,
+		"Fail when the config has validation warnings")
+	flags.DurationVar(&opts.timeout, "resource-timeout", 0,
+		"Maximum time to run the task of any resource without its own timeout")
+	flags.BoolVar(&opts.dumpCalls, "dump-docker-calls", false,
+		"Log each call to the Docker API, with its parameters (implies --verbose)")
+	flags.BoolVar(&opts.noRemove, "no-remove", false,
+		"Keep the container of every job after it runs, and print its id")
+	opts.interactive = flags.Bool("interactive", false,
+		"Override the interactive setting of every job (default from the config)")
 
 	flags.SetInterspersed(false)
 	cmd.AddCommand(newListCommand(&opts))
+	cmd.AddCommand(newValidateCommand(&opts))
+	cmd.AddCommand(newCleanCommand(&opts))
+	cmd.AddCommand(newWhyCommand(&opts))
+	cmd.AddCommand(newGraphCommand(&opts))
+	cmd.AddCommand(newArtifactsCommand(&opts))
 	return cmd
 }
 
@@ -70,24 +167,123 @@ func runDobi(opts dobiOptions) error {
 		return nil
 	}
 
-	conf, err := config.Load(opts.filename)
+	conf, err := loadConfig(&opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.explainCache && !opts.sinceSuccess {
+		return fmt.Errorf("--explain-cache requires --since-last-success")
+	}
+	var eventWriter *events.Writer
+	switch opts.output {
+	case "text":
+	case "json":
+		eventWriter = events.NewWriter(os.Stdout)
+	default:
+		return fmt.Errorf("Invalid --output %q, must be one of: text, json", opts.output)
+	}
+	if err := job.ValidatePassthrough(opts.envPassthrough); err != nil {
+		return fmt.Errorf("Invalid --env-passthrough: %s", err)
+	}
+	if opts.tmpDir != "" {
+		if err := fs.ValidateWritableDir(opts.tmpDir); err != nil {
+			return fmt.Errorf("Invalid temp directory: %s", err)
+		}
+	}
+
+	masker, err := mask.New(conf.Meta.Mask)
 	if err != nil {
 		return err
 	}
+	setLogMasker(masker)
 
-	client, err := buildClient()
+	if opts.printEnv != "" {
+		return tasks.PrintEnv(tasks.RunOptions{
+			Config:         conf,
+			EnvPassthrough: opts.envPassthrough,
+			EnvFiles:       opts.envFiles,
+			Masker:         masker,
+		}, opts.printEnv, os.Stdout)
+	}
+
+	execCommand, err := shlex.Split(opts.execCommand)
+	if err != nil {
+		return fmt.Errorf("Failed to parse --command %q: %s", opts.execCommand, err)
+	}
+
+	client, err := buildClient(conf.Meta.Engine, opts.dumpCalls)
 	if err != nil {
 		return fmt.Errorf("Failed to create client: %s", err)
 	}
 
-	return tasks.Run(tasks.RunOptions{
+	run := tasks.Run
+	if opts.watch {
+		run = tasks.Watch
+	}
+	return run(tasks.RunOptions{
 		Client: client,
 		Config: conf,
 		Tasks:  opts.tasks,
 		Quiet:  opts.quiet,
+
+		ParallelImages: opts.parallelImages,
+		Parallel:       opts.parallel,
+		ParallelPull:   opts.parallelPull,
+		TempDir:        opts.tmpDir,
+		EnvPassthrough: opts.envPassthrough,
+		EnvFiles:       opts.envFiles,
+		Masker:         masker,
+
+		SinceLastSuccess: opts.sinceSuccess,
+		ExplainCache:     opts.explainCache,
+		FailOnPause:      opts.failOnPause,
+		OnlyStale:        opts.onlyStale,
+		DryRun:           opts.dryRun,
+		PlanFile:         opts.planFile,
+		ListStale:        opts.listStale,
+		Force:            opts.force,
+		ExecCommand:      execCommand,
+		Interactive:      opts.interactive,
+		ResourceTimeout:  opts.timeout,
+		NoRemove:         opts.noRemove,
+		SkipInitialRun:   !opts.watchInitial,
+		Events:           eventWriter,
 	})
 }
 
+// loadConfig loads the config file and logs any validation warnings. In strict
+// mode, set by --fail-on-warnings or meta.strict, warnings are an error.
+func loadConfig(opts *dobiOptions) (*config.Config, error) {
+	conf, err := config.Load(opts.filename)
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range conf.Warnings {
+		logging.Log.Warn(warning)
+	}
+	if len(conf.Warnings) > 0 && (opts.failOnWarnings || conf.Meta.Strict) {
+		return nil, fmt.Errorf(
+			"Failed to load config from %q: %d validation warnings in strict mode",
+			opts.filename, len(conf.Warnings))
+	}
+	return conf, nil
+}
+
+// variantFilename returns the filename of the config variant for env, which is
+// the filename with .<env> added before the extension
+func variantFilename(filename, env string) (string, error) {
+	if env == "." || env == ".." || strings.ContainsAny(env, `/\`) {
+		return "", fmt.Errorf("Invalid --config-env %q, must not be a path", env)
+	}
+	ext := filepath.Ext(filename)
+	variant := strings.TrimSuffix(filename, ext) + "." + env + ext
+	if _, err := os.Stat(variant); err != nil {
+		return "", fmt.Errorf("Invalid --config-env %q: %s", env, err)
+	}
+	return variant, nil
+}
+
 func initLogging(verbose, quiet bool) {
 	logger := logging.Log
 	if verbose {
@@ -103,18 +299,31 @@ func initLogging(verbose, quiet bool) {
 	logger.Formatter = formatter
 }
 
-func buildClient() (client.DockerClient, error) {
+// setLogMasker sets the masker used to redact log and error messages
+func setLogMasker(masker *mask.Masker) {
+	if formatter, ok := logging.Log.Formatter.(*logging.Formatter); ok {
+		formatter.Masker = masker
+	}
+}
+
+// buildClient returns a client for the API of the engine, which is set by the
+// DOBI_ENGINE environment variable or the engine from the config. If dumpCalls
+// is true the client logs each call.
+func buildClient(engine string, dumpCalls bool) (client.DockerClient, error) {
 	apiVersion := os.Getenv("DOCKER_API_VERSION")
 	if apiVersion == "" {
 		apiVersion = DefaultDockerAPIVersion
 	}
-	// TODO: args for client
-	client, err := docker.NewVersionedClientFromEnv(apiVersion)
+	engine = client.ResolveEngine(engine)
+	dockerClient, err := client.New(engine, apiVersion)
 	if err != nil {
 		return nil, err
 	}
-	log.Debug("Docker client created")
-	return client, nil
+	log.Debugf("%s client created", engine)
+	if dumpCalls {
+		return client.NewLoggingClient(dockerClient), nil
+	}
+	return dockerClient, nil
 }
 
 func printVersion() {
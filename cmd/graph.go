@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dnephin/dobi/tasks"
+	"github.com/spf13/cobra"
+)
+
+type graphOptions struct {
+	format string
+}
+
+func newGraphCommand(opts *dobiOptions) *cobra.Command {
+	var graphOpts graphOptions
+	cmd := &cobra.Command{
+		Use:   "graph [TARGET...]",
+		Short: "Print the dependency graph of the tasks",
+		Long: "Print the graph of the TARGET tasks and all their dependencies, " +
+			"after variables are resolved, with whether each task is stale. " +
+			"Without a TARGET the default task is used, or every resource if " +
+			"there is no default. Tasks are not run.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGraph(opts, graphOpts, args)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&graphOpts.format, "format", "dot",
+		"Output format, one of: "+strings.Join(tasks.GraphFormats, ", "))
+	return cmd
+}
+
+func runGraph(opts *dobiOptions, graphOpts graphOptions, targets []string) error {
+	conf, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	client, err := buildClient(conf.Meta.Engine, opts.dumpCalls)
+	if err != nil {
+		return fmt.Errorf("Failed to create client: %s", err)
+	}
+	return tasks.Graph(tasks.RunOptions{
+		Client:   client,
+		Config:   conf,
+		Tasks:    targets,
+		TempDir:  opts.tmpDir,
+		EnvFiles: opts.envFiles,
+	}, graphOpts.format, os.Stdout)
+}
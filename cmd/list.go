@@ -2,33 +2,101 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
 	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/utils/mask"
 	"github.com/spf13/cobra"
 )
 
+type listOptions struct {
+	resolved bool
+}
+
 func newListCommand(opts *dobiOptions) *cobra.Command {
+	var listOpts listOptions
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all tasks",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runList(opts)
+			return runList(opts, listOpts)
 		},
 	}
+	flags := cmd.Flags()
+	flags.BoolVar(&listOpts.resolved, "resolved", false,
+		"Resolve variables, and show the resolved values with meta.mask applied")
 	return cmd
 }
 
-func runList(opts *dobiOptions) error {
-	conf, err := config.Load(opts.filename)
+func runList(opts *dobiOptions, listOpts listOptions) error {
+	conf, err := loadConfig(opts)
 	if err != nil {
 		return err
 	}
 
-	printTasks(conf)
+	if !listOpts.resolved {
+		printTasks(os.Stdout, conf, conf.Resources, nil)
+		return nil
+	}
+	resources, err := resolveResources(conf, opts.envFiles)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve variables in %q:\n%s", opts.filename, err)
+	}
+	masker, err := mask.New(conf.Meta.Mask)
+	if err != nil {
+		return err
+	}
+	printTasks(os.Stdout, conf, resources, masker)
 	return nil
 }
 
-func printTasks(config *config.Config) {
+// resolveResources returns every resource in the config with its variables
+// resolved. Resources which use captured variables are returned unresolved,
+// because they are only resolved when they run.
+func resolveResources(conf *config.Config, envFiles []string) (map[string]config.Resource, error) {
+	execEnv, err := execenv.NewExecEnvFromConfig(
+		conf.Meta.ExecID, conf.Meta.Project, conf.WorkingDir, conf.Meta.AutoloadDotenv,
+		envFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := &config.ErrorList{}
+	resources := make(map[string]config.Resource, len(conf.Resources))
+	for _, name := range conf.Sorted() {
+		resource, err := config.ResolveResource(name, conf.Resources[name], execEnv)
+		switch {
+		case err != nil && len(execEnv.MissingCaptures()) > 0:
+			resources[name] = conf.Resources[name]
+		case err != nil:
+			errs.Add(err)
+		default:
+			resources[name] = resource
+		}
+	}
+	return resources, errs.ErrorOrNil()
+}
+
+func printTasks(out io.Writer, config *config.Config, resources map[string]config.Resource, masker *mask.Masker) {
 	for _, name := range config.Sorted() {
-		fmt.Printf("  %-20s %s\n", name, config.Resources[name])
+		line := fmt.Sprintf("  %-20s %s%s\n",
+			name, resources[name], formatLabels(config.OptionsFor(name).Labels))
+		fmt.Fprint(out, masker.MaskString(line))
+	}
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	items := []string{}
+	for key, value := range labels {
+		items = append(items, key+"="+value)
 	}
+	sort.Strings(items)
+	return " [" + strings.Join(items, ", ") + "]"
 }
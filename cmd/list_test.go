@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/utils/mask"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintTasksResolved(t *testing.T) {
+	os.Setenv("DOBI_TEST_COMMAND", "deploy --token ghp_abc123")
+	defer os.Unsetenv("DOBI_TEST_COMMAND")
+
+	conf, err := config.LoadFromBytes([]byte(`
+meta:
+  mask: ['ghp_[a-z0-9]+']
+image=builder:
+  image: example/builder
+  context: .
+  dockerfile: Dockerfile
+job=deploy:
+  use: builder
+  command: '{env.DOBI_TEST_COMMAND}'
+`))
+	if !assert.Nil(t, err) {
+		return
+	}
+	resources, err := resolveResources(conf, nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	masker, err := mask.New(conf.Meta.Mask)
+	assert.Nil(t, err)
+
+	out := &bytes.Buffer{}
+	printTasks(out, conf, resources, masker)
+	expected := "  builder              Build image 'example/builder' from 'Dockerfile'\n" +
+		"  deploy               Run 'deploy --token ******' using the 'builder' image\n"
+	assert.Equal(t, expected, out.String())
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/spf13/cobra"
+)
+
+type validateOptions struct {
+	format string
+}
+
+func newValidateCommand(opts *dobiOptions) *cobra.Command {
+	var validateOpts validateOptions
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the config without running any tasks",
+		Long: "Load the config, resolve variables, and validate all resources. " +
+			"The Docker daemon is never contacted.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch validateOpts.format {
+			case "text":
+				return runValidate(opts)
+			case "json":
+				return runValidateJSON(opts)
+			default:
+				return fmt.Errorf("Invalid format %q, must be one of: text, json",
+					validateOpts.format)
+			}
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&validateOpts.format, "format", "text",
+		"Output format, one of: text, json")
+	return cmd
+}
+
+func runValidate(opts *dobiOptions) error {
+	conf, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := resolveAll(conf, opts.envFiles); err != nil {
+		return fmt.Errorf("Failed to resolve variables in %q:\n%s", opts.filename, err)
+	}
+	fmt.Printf("%s is valid\n", opts.filename)
+	return nil
+}
+
+// resolveAll resolves variables in every resource in the config, and returns
+// all the errors
+func resolveAll(conf *config.Config, envFiles []string) error {
+	execEnv, err := execenv.NewExecEnvFromConfig(
+		conf.Meta.ExecID, conf.Meta.Project, conf.WorkingDir, conf.Meta.AutoloadDotenv,
+		envFiles)
+	if err != nil {
+		return err
+	}
+
+	errs := &config.ErrorList{}
+	for _, name := range conf.Sorted() {
+		resource, err := config.ResolveResource(name, conf.Resources[name], execEnv)
+		switch {
+		case err != nil && len(execEnv.MissingCaptures()) > 0:
+			// Resources which use captured variables are resolved when they run
+			continue
+		case err != nil:
+			errs.Add(err)
+			continue
+		}
+		errs.Add(config.ValidateResolved(name, resource, conf, execEnv))
+	}
+	return errs.ErrorOrNil()
+}
+
+// runValidateJSON prints every error and warning in the config as a json list,
+// and returns an error if there are any errors
+func runValidateJSON(opts *dobiOptions) error {
+	conf, err := config.Load(opts.filename)
+	problems := config.Problems(err, nil)
+	if err == nil {
+		problems = config.Problems(resolveAll(conf, opts.envFiles), conf.Warnings)
+	}
+
+	out, err := json.MarshalIndent(problems, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+
+	for _, problem := range problems {
+		isError := problem.Severity == config.SeverityError
+		isStrictWarning := opts.failOnWarnings || (conf != nil && conf.Meta.Strict)
+		if isError || isStrictWarning {
+			return fmt.Errorf("%s is not valid", opts.filename)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newWhyCommand(opts *dobiOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "why TARGET RESOURCE",
+		Short: "Show why a resource is included when running a target",
+		Long: "Print every path of dependencies from the TARGET resource to " +
+			"RESOURCE.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWhy(opts, args[0], args[1])
+		},
+	}
+	return cmd
+}
+
+func runWhy(opts *dobiOptions, target, resource string) error {
+	conf, err := loadConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{target, resource} {
+		if _, ok := conf.Resources[name]; !ok {
+			return fmt.Errorf("Resource %q does not exist", name)
+		}
+	}
+
+	paths := conf.DependencyPaths(target, resource)
+	if len(paths) == 0 {
+		fmt.Printf("%q is not a dependency of %q\n", resource, target)
+		return nil
+	}
+	for _, path := range paths {
+		fmt.Printf("  %s\n", strings.Join(path, " -> "))
+	}
+	return nil
+}
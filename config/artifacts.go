@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ArtifactSources is a config type for a mapping of artifacts to the list of
+// sources used to create each artifact
+type ArtifactSources struct {
+	paths   []string
+	sources map[string][]string
+}
+
+// TransformConfig sets the artifacts from a mapping of artifact paths to lists
+// of sources
+func (a *ArtifactSources) TransformConfig(raw reflect.Value) error {
+	values, ok := raw.Interface().(map[interface{}]interface{})
+	if !ok {
+		return fmt.Errorf("must be a mapping of artifacts to sources, not %T", raw.Interface())
+	}
+	a.sources = make(map[string][]string)
+	for key, value := range values {
+		path, ok := key.(string)
+		if !ok {
+			return fmt.Errorf("artifact must be a string, not %T", key)
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("sources of %q must be a list of strings, not %T", path, value)
+		}
+		sources := []string{}
+		for i, item := range items {
+			source, ok := item.(string)
+			if !ok {
+				return fmt.Errorf(
+					"sources of %q must be a list of strings, item %d is %T", path, i, item)
+			}
+			sources = append(sources, source)
+		}
+		a.add(path, sources)
+	}
+	sort.Strings(a.paths)
+	return nil
+}
+
+func (a *ArtifactSources) add(path string, sources []string) {
+	a.paths = append(a.paths, path)
+	a.sources[path] = sources
+}
+
+// Empty returns true if there are no artifacts
+func (a *ArtifactSources) Empty() bool {
+	return len(a.paths) == 0
+}
+
+// Paths returns the artifact paths, sorted
+func (a *ArtifactSources) Paths() []string {
+	return a.paths
+}
+
+// Sources returns the sources of the artifact path
+func (a *ArtifactSources) Sources(path string) []string {
+	return a.sources[path]
+}
+
+// Validate checks that every artifact has at least one source
+func (a *ArtifactSources) Validate() error {
+	for _, path := range a.paths {
+		if len(a.sources[path]) == 0 {
+			return fmt.Errorf("sources of %q must not be empty", path)
+		}
+	}
+	return nil
+}
+
+func (a ArtifactSources) resolve(resolver *fieldResolver, field string) ArtifactSources {
+	if a.Empty() {
+		return a
+	}
+	resolved := ArtifactSources{sources: make(map[string][]string)}
+	for _, path := range a.paths {
+		resolved.add(
+			resolver.resolvePath(field, path),
+			resolver.resolvePaths(field+"."+path, a.sources[path]))
+	}
+	sort.Strings(resolved.paths)
+	return resolved
+}
@@ -0,0 +1,27 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// defaultTrue is a config type for a bool which is true when it is not set
+type defaultTrue struct {
+	set   bool
+	value bool
+}
+
+func (b *defaultTrue) TransformConfig(raw reflect.Value) error {
+	switch value := raw.Interface().(type) {
+	case bool:
+		b.set, b.value = true, value
+	default:
+		return fmt.Errorf("must be a bool, not %T", value)
+	}
+	return nil
+}
+
+// Value returns the value, or true if it is not set
+func (b defaultTrue) Value() bool {
+	return !b.set || b.value
+}
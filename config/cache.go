@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+
+	"github.com/dnephin/dobi/execenv"
+)
+
+// CacheConfig is a config type for the remote cache of job artifacts
+type CacheConfig struct {
+	// URL is an http or https url, or a directory, where artifacts are stored
+	URL string
+	// Headers are set on every request to an http cache
+	Headers map[string]string
+	// ReadOnly restores artifacts from the cache, but never stores them
+	ReadOnly bool
+}
+
+// TransformConfig sets the fields of the cache from a mapping
+func (c *CacheConfig) TransformConfig(raw reflect.Value) error {
+	values, ok := raw.Interface().(map[interface{}]interface{})
+	if !ok {
+		return fmt.Errorf("must be a mapping, not %T", raw.Interface())
+	}
+	for key, value := range values {
+		var err error
+		switch key {
+		case "url":
+			c.URL, ok = value.(string)
+			if !ok {
+				err = fmt.Errorf("url must be a string, not %T", value)
+			}
+		case "headers":
+			c.Headers, err = transformStringMap(value)
+			if err != nil {
+				err = fmt.Errorf("headers %s", err)
+			}
+		case "read-only":
+			c.ReadOnly, ok = value.(bool)
+			if !ok {
+				err = fmt.Errorf("read-only must be a bool, not %T", value)
+			}
+		default:
+			err = fmt.Errorf("unexpected key %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func transformStringMap(raw interface{}) (map[string]string, error) {
+	values, ok := raw.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must be a mapping, not %T", raw)
+	}
+	result := make(map[string]string, len(values))
+	for key, value := range values {
+		keyString, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("key %v must be a string, not %T", key, key)
+		}
+		valueString, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of %q must be a string, not %T", keyString, value)
+		}
+		result[keyString] = valueString
+	}
+	return result, nil
+}
+
+// Validate checks that the url is set, and is an http url or a path
+func (c *CacheConfig) Validate() error {
+	switch {
+	case c.IsZero():
+		return nil
+	case c.URL == "":
+		return fmt.Errorf("url is required")
+	}
+	parsed, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url %q: %s", c.URL, err)
+	}
+	switch parsed.Scheme {
+	case "", "http", "https", "file":
+		return nil
+	default:
+		return fmt.Errorf("invalid url %q, the scheme must be http, https, or file",
+			c.URL)
+	}
+}
+
+// IsZero returns true if the cache is not configured
+func (c *CacheConfig) IsZero() bool {
+	return c.URL == "" && len(c.Headers) == 0 && !c.ReadOnly
+}
+
+// Resolve returns a copy of the cache with variables resolved in the url and
+// the values of the headers
+func (c *CacheConfig) Resolve(env *execenv.ExecEnv) (CacheConfig, error) {
+	resolver := newFieldResolver(env)
+	resolved := CacheConfig{
+		URL:      resolver.resolve("meta.cache.url", c.URL),
+		Headers:  make(map[string]string, len(c.Headers)),
+		ReadOnly: c.ReadOnly,
+	}
+	for key, value := range c.Headers {
+		resolved.Headers[key] = resolver.resolve("meta.cache.headers."+key, value)
+	}
+	return resolved, resolver.err()
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/dnephin/dobi/execenv"
+	"github.com/renstrom/dedent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFromBytesWithCache(t *testing.T) {
+	conf := dedent.Dedent(`
+		meta:
+		  project: webapp
+		  cache:
+		    url: https://cache.example.com/dobi
+		    headers:
+		      Authorization: 'Bearer {env.CACHE_TOKEN}'
+		    read-only: true
+	`)
+
+	config, err := LoadFromBytes([]byte(conf))
+	assert.Nil(t, err)
+	assert.Equal(t, CacheConfig{
+		URL:      "https://cache.example.com/dobi",
+		Headers:  map[string]string{"Authorization": "Bearer {env.CACHE_TOKEN}"},
+		ReadOnly: true,
+	}, config.Meta.Cache)
+}
+
+func TestCacheConfigValidate(t *testing.T) {
+	for _, tc := range []struct {
+		cache    CacheConfig
+		expected string
+	}{
+		{cache: CacheConfig{}},
+		{cache: CacheConfig{URL: "https://cache.example.com"}},
+		{cache: CacheConfig{URL: "/mnt/cache"}},
+		{cache: CacheConfig{ReadOnly: true}, expected: "url is required"},
+		{
+			cache:    CacheConfig{URL: "s3://bucket/dobi"},
+			expected: `invalid url "s3://bucket/dobi", the scheme must be http, https, or file`,
+		},
+	} {
+		err := tc.cache.Validate()
+		if tc.expected == "" {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.EqualError(t, err, tc.expected)
+	}
+}
+
+func TestCacheConfigResolve(t *testing.T) {
+	cache := &CacheConfig{
+		URL:     "https://cache.example.com/{project}",
+		Headers: map[string]string{"Authorization": "Bearer {env.DOBI_TEST_TOKEN:token}"},
+	}
+	resolved, err := cache.Resolve(execenv.NewExecEnv("exec", "webapp", "."))
+	assert.Nil(t, err)
+	assert.Equal(t, CacheConfig{
+		URL:     "https://cache.example.com/webapp",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}, resolved)
+}
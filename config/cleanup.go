@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Cleanup is a config type for the cleanup policy of a resource, which is
+// applied by the remove task of the resource
+type Cleanup struct {
+	// Never prevents the remove task from removing anything
+	Never bool
+	// KeepTags is the number of the most recently created tags of an image
+	// which are not removed
+	KeepTags int
+}
+
+// TransformConfig sets the fields of the policy from a mapping
+func (c *Cleanup) TransformConfig(raw reflect.Value) error {
+	values, ok := raw.Interface().(map[interface{}]interface{})
+	if !ok {
+		return fmt.Errorf("must be a mapping, not %T", raw.Interface())
+	}
+	for key, value := range values {
+		switch key {
+		case "never":
+			if c.Never, ok = value.(bool); !ok {
+				return fmt.Errorf("never must be a bool, not %T", value)
+			}
+		case "keep-tags":
+			if c.KeepTags, ok = value.(int); !ok {
+				return fmt.Errorf("keep-tags must be a number, not %T", value)
+			}
+		default:
+			return fmt.Errorf("unexpected key %q", key)
+		}
+	}
+	return nil
+}
+
+// validateCleanup checks that the policy is supported by the type of the
+// resource
+func validateCleanup(path Path, resType string, cleanup Cleanup) error {
+	switch {
+	case cleanup.KeepTags < 0:
+		return PathErrorf(path.add("keep-tags"), "must be a positive number")
+	case cleanup.KeepTags > 0 && resType != "image":
+		return PathErrorf(path.add("keep-tags"), "is only supported by image resources")
+	case cleanup.KeepTags > 0 && cleanup.Never:
+		return PathErrorf(path.add("keep-tags"), "can not be used with never")
+	}
+	return nil
+}
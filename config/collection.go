@@ -2,8 +2,14 @@ package config
 
 // ResourceCollection holds resource configs that are used by other resources
 type ResourceCollection struct {
-	mounts map[string]*MountConfig
-	images map[string]*ImageConfig
+	mounts     map[string]*MountConfig
+	images     map[string]*ImageConfig
+	volumes    map[string]*VolumeConfig
+	registries map[string]*RegistryConfig
+	// kept is the set of jobs used by the volumes-from of another job
+	kept map[string]bool
+	// cleanup maps the name of a resource to its cleanup policy
+	cleanup map[string]Cleanup
 }
 
 func (c *ResourceCollection) add(name string, resource Resource) {
@@ -12,6 +18,14 @@ func (c *ResourceCollection) add(name string, resource Resource) {
 		c.mounts[name] = resource
 	case *ImageConfig:
 		c.images[name] = resource
+	case *VolumeConfig:
+		c.volumes[name] = resource
+	case *RegistryConfig:
+		c.registries[name] = resource
+	case *JobConfig:
+		for _, job := range resource.VolumesFrom {
+			c.kept[job] = true
+		}
 	}
 }
 
@@ -25,6 +39,31 @@ func (c *ResourceCollection) Image(name string) *ImageConfig {
 	return c.images[name]
 }
 
+// Volume returns a VolumeConfig by name
+func (c *ResourceCollection) Volume(name string) *VolumeConfig {
+	return c.volumes[name]
+}
+
+// Registry returns a RegistryConfig by name
+func (c *ResourceCollection) Registry(name string) *RegistryConfig {
+	return c.registries[name]
+}
+
+// KeepContainer returns true if the container of the job should not be removed
+// when it exits, because another job uses its volumes
+func (c *ResourceCollection) KeepContainer(name string) bool {
+	return c.kept[name]
+}
+
+func (c *ResourceCollection) setCleanup(name string, cleanup Cleanup) {
+	c.cleanup[name] = cleanup
+}
+
+// Cleanup returns the cleanup policy of the resource
+func (c *ResourceCollection) Cleanup(name string) Cleanup {
+	return c.cleanup[name]
+}
+
 type eachMountFunc func(name string, vol *MountConfig)
 
 // EachMount iterates all the mounts in names and calls f for each
@@ -37,7 +76,11 @@ func (c *ResourceCollection) EachMount(names []string, f eachMountFunc) {
 
 func newResourceCollection() *ResourceCollection {
 	return &ResourceCollection{
-		mounts: make(map[string]*MountConfig),
-		images: make(map[string]*ImageConfig),
+		mounts:     make(map[string]*MountConfig),
+		images:     make(map[string]*ImageConfig),
+		volumes:    make(map[string]*VolumeConfig),
+		registries: make(map[string]*RegistryConfig),
+		kept:       make(map[string]bool),
+		cleanup:    make(map[string]Cleanup),
 	}
 }
@@ -2,21 +2,23 @@ package config
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 
 	"github.com/dnephin/dobi/execenv"
 )
 
-// ComposeConfig A **compose** resource runs ``docker-compose`` to create an
-// isolated environment. The **compose** resource keeps containers running
+// ComposeConfig A **compose** resource runs ``docker compose``, or
+// ``docker-compose``, to create an isolated environment. The **compose** resource keeps containers running
 // until **dobi** exits so the containers can be used by other tasks that depend
 // on the **compose** resource, or are listed after it in an `alias`_.
 //
 // .. note::
 //
-//     `Docker Compose <https://github.com/docker/compose>`_ must be installed
-//     and available in ``$PATH`` to use this resource.
+//     `Docker Compose <https://github.com/docker/compose>`_ must be installed,
+//     either as a plugin of the ``docker`` CLI, or as ``docker-compose`` in
+//     ``$PATH``, to use this resource.
 //
 // name: compose
 // example: Start a Compose environment setting the project name to ``web-devenv``
@@ -35,12 +37,120 @@ type ComposeConfig struct {
 	// Project The project name used by Compose. This field supports
 	// :doc:`variables`.
 	Project string `config:"required"`
+	// ProjectDirectory The directory used by Compose to resolve relative
+	// paths in the Compose files. This field supports :doc:`variables`.
+	// default: *the directory of the first Compose file*
+	ProjectDirectory string
+	// Profiles The Compose profiles to enable. This field supports
+	// :doc:`variables`.
+	// type: list of profile names
+	Profiles []string
+	// EnvFiles Files of variables used by Compose to resolve variables in the
+	// Compose files. More than one file requires the Compose plugin. This
+	// field supports :doc:`variables`.
+	// type: list of filenames
+	EnvFiles []string
+	// Binary The Compose command to run, either ``plugin`` to run
+	// ``docker compose``, ``standalone`` to run ``docker-compose``, or
+	// ``auto`` to run the plugin if it is installed, and otherwise
+	// ``docker-compose``.
+	// default: ``auto``
+	Binary string `config:"validate"`
 	// StopGrace Seconds to wait for containers to stop before killing them.
 	// default: ``5``
 	StopGrace int
+	// Before A command to run on the host before the Compose project is
+	// started. When it fails the project is not started. The command runs in
+	// the directory of the ``dobi.yaml``, with ``COMPOSE_PROJECT_NAME`` set to
+	// the **project**.
+	// type: shell quoted string, or list of strings
+	// example: ``"./scripts/seed-volumes.sh"``
+	Before ShlexSlice
+	// After A command to run on the host after the Compose project is
+	// started, like **before**. A failure of this command is logged, but does
+	// not fail the task.
+	// type: shell quoted string, or list of strings
+	After ShlexSlice
+	// OnFailure A command to run on the host when the Compose project fails to
+	// start, like **before**. A failure of this command is logged, but does
+	// not replace the error from Compose.
+	// type: shell quoted string, or list of strings
+	// example: ``"docker compose logs"``
+	OnFailure ShlexSlice
 	// Depends The list of resource dependencies.
 	// type: list of resource names
 	Depends []string
+	// Enabled When ``false`` the tasks of the **compose** resource do nothing,
+	// for example when the Compose project is already running outside of
+	// **dobi**. The dependencies of the resource, and the resources which
+	// depend on it, still run, and can use the networks and containers of the
+	// running project. This field supports :doc:`variables`, and must be
+	// ``true`` or ``false`` once the variables are resolved.
+	// type: bool, or string with variables
+	// default: ``true``
+	// example: ``"{env.CI:false}"``
+	Enabled enabled `config:"validate"`
+}
+
+// enabled is a config type for a bool which can also be set from a string
+// with variables
+type enabled struct {
+	value string
+}
+
+func (e *enabled) TransformConfig(raw reflect.Value) error {
+	switch value := raw.Interface().(type) {
+	case bool:
+		e.value = strconv.FormatBool(value)
+	case string:
+		e.value = value
+	default:
+		return fmt.Errorf("must be a bool or string, not %T", value)
+	}
+	return nil
+}
+
+// IsEnabled returns true if the tasks of the resource should run
+func (c *ComposeConfig) IsEnabled() bool {
+	enabled, err := strconv.ParseBool(c.Enabled.value)
+	return c.Enabled.value == "" || (err == nil && enabled)
+}
+
+// ValidateEnabled validates that Enabled is a bool, unless it contains
+// variables
+func (c *ComposeConfig) ValidateEnabled() error {
+	if hasVariables(c.Enabled.value) {
+		return nil
+	}
+	return validateEnabled(c.Enabled.value)
+}
+
+func validateEnabled(enabled string) error {
+	if enabled == "" {
+		return nil
+	}
+	if _, err := strconv.ParseBool(enabled); err != nil {
+		return fmt.Errorf("must be true or false, not %q", enabled)
+	}
+	return nil
+}
+
+// Values of Binary
+const (
+	ComposeBinaryAuto       = "auto"
+	ComposeBinaryPlugin     = "plugin"
+	ComposeBinaryStandalone = "standalone"
+)
+
+// ValidateBinary validates that Binary is one of the supported values
+func (c *ComposeConfig) ValidateBinary() error {
+	switch c.Binary {
+	case "", ComposeBinaryAuto, ComposeBinaryPlugin, ComposeBinaryStandalone:
+		return nil
+	default:
+		return fmt.Errorf("must be one of: %s, %s, %s, not %q",
+			ComposeBinaryAuto, ComposeBinaryPlugin, ComposeBinaryStandalone, c.Binary)
+	}
 }
 
 // StopGraceString returns StopGrace as a string
@@ -65,13 +175,17 @@ func (c *ComposeConfig) String() string {
 
 // Resolve resolves variables in the resource
 func (c *ComposeConfig) Resolve(env *execenv.ExecEnv) (Resource, error) {
-	var err error
-	c.Files, err = env.ResolveSlice(c.Files)
-	if err != nil {
-		return c, err
-	}
-	c.Project, err = env.Resolve(c.Project)
-	return c, err
+	resolver := newFieldResolver(env)
+	c.Files = resolver.resolveSlice("files", c.Files)
+	c.Project = resolver.resolve("project", c.Project)
+	c.ProjectDirectory = resolver.resolve("project-directory", c.ProjectDirectory)
+	c.Profiles = resolver.resolveSlice("profiles", c.Profiles)
+	c.EnvFiles = resolver.resolveSlice("env-files", c.EnvFiles)
+	c.Enabled.value = resolver.resolve("enabled", c.Enabled.value)
+	c.Before = resolver.resolveCommand("before", c.Before)
+	c.After = resolver.resolveCommand("after", c.After)
+	c.OnFailure = resolver.resolveCommand("on-failure", c.OnFailure)
+	return c, resolver.err()
 }
 
 func composeFromConfig(name string, values map[string]interface{}) (Resource, error) {
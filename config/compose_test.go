@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dnephin/dobi/execenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposeFromConfigEnabled(t *testing.T) {
+	resource, err := composeFromConfig("compose=devenv", map[string]interface{}{})
+	assert.Nil(t, err)
+	assert.True(t, resource.(*ComposeConfig).IsEnabled())
+
+	resource, err = composeFromConfig("compose=devenv", map[string]interface{}{
+		"enabled": false,
+	})
+	assert.Nil(t, err)
+	assert.False(t, resource.(*ComposeConfig).IsEnabled())
+
+	_, err = composeFromConfig("compose=devenv", map[string]interface{}{
+		"enabled": 1,
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "must be a bool or string, not int")
+	}
+}
+
+func TestComposeConfigValidateEnabled(t *testing.T) {
+	compose := &ComposeConfig{Enabled: enabled{value: "{env.CI:false}"}}
+	assert.Nil(t, compose.ValidateEnabled())
+
+	compose.Enabled.value = "sometimes"
+	err := compose.ValidateEnabled()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `must be true or false, not "sometimes"`)
+	}
+}
+
+func TestComposeConfigResolveEnabled(t *testing.T) {
+	defer os.Unsetenv("DOBI_TEST_CI")
+	compose := &ComposeConfig{Enabled: enabled{value: "{env.DOBI_TEST_CI:false}"}}
+	env := execenv.NewExecEnv("exec", "project", ".")
+
+	resolved, err := compose.Resolve(env)
+	assert.Nil(t, err)
+	assert.Nil(t, ValidateResolved("devenv", resolved, NewConfig(), env))
+	assert.False(t, resolved.(*ComposeConfig).IsEnabled())
+
+	os.Setenv("DOBI_TEST_CI", "yes")
+	compose = &ComposeConfig{Enabled: enabled{value: "{env.DOBI_TEST_CI:false}"}}
+	resolved, err = compose.Resolve(env.WithEnv(nil))
+	assert.Nil(t, err)
+	err = ValidateResolved("devenv", resolved, NewConfig(), env)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `Error at devenv.enabled: must be true or false, not "yes"`)
+	}
+}
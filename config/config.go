@@ -27,19 +27,52 @@ type Config struct {
 	Resources  map[string]Resource
 	WorkingDir string
 	Collection *ResourceCollection
+	// Options maps a resource name to the ResourceOptions of the resource
+	Options map[string]*ResourceOptions
+	// Warnings are problems found by validation which do not prevent the
+	// config from being used
+	Warnings []string
+	// includedFrom maps the name of a resource to the file which defined it,
+	// for resources from an included file
+	includedFrom map[string]string
+	// matrixes maps the name of the alias created for each matrix to the type
+	// of the resources expanded from the matrix
+	matrixes map[string]string
+}
+
+// ResourceOptions are the fields which are accepted by every resource type
+type ResourceOptions struct {
+	// Labels are metadata about the resource. They are not used for execution.
+	Labels map[string]string
+	// Pause prompts the user to continue after the tasks of the resource run
+	Pause bool
+	// Require is a list of host commands which must succeed before the tasks of
+	// the resource run
+	Require []string
+	// When is a condition which must be true for the tasks of the resource to
+	// run. The resource is skipped when the condition is false.
+	When string
+	// Cleanup is the policy applied by the remove task of the resource
+	Cleanup Cleanup
 }
 
 // NewConfig returns a new Config object
 func NewConfig() *Config {
 	return &Config{
-		Resources:  make(map[string]Resource),
-		Meta:       &MetaConfig{},
-		Collection: newResourceCollection(),
+		Resources:    make(map[string]Resource),
+		Meta:         &MetaConfig{},
+		Collection:   newResourceCollection(),
+		Options:      make(map[string]*ResourceOptions),
+		includedFrom: make(map[string]string),
+		matrixes:     make(map[string]string),
 	}
 }
 
 func (c *Config) add(name string, resource Resource) error {
 	if c.contains(name) {
+		if file, ok := c.includedFrom[name]; ok {
+			return fmt.Errorf("duplicate resource name %q, also defined in %q", name, file)
+		}
 		return fmt.Errorf("duplicate resource name %q", name)
 	}
 	c.Resources[name] = resource
@@ -47,6 +80,19 @@ func (c *Config) add(name string, resource Resource) error {
 	return nil
 }
 
+func (c *Config) setOptions(name string, options *ResourceOptions) {
+	c.Options[name] = options
+	c.Collection.setCleanup(name, options.Cleanup)
+}
+
+// OptionsFor returns the ResourceOptions of the resource name
+func (c *Config) OptionsFor(name string) *ResourceOptions {
+	if options, ok := c.Options[name]; ok {
+		return options
+	}
+	return &ResourceOptions{}
+}
+
 func (c *Config) contains(name string) bool {
 	_, exists := c.Resources[name]
 	return exists
@@ -62,10 +108,46 @@ func (c *Config) Sorted() []string {
 	return names
 }
 
+// DependencyPaths returns every path of dependencies from the resource from to
+// the resource to. Each path starts with from and ends with to.
+func (c *Config) DependencyPaths(from, to string) [][]string {
+	paths := [][]string{}
+	var walk func(path []string)
+	walk = func(path []string) {
+		current := path[len(path)-1]
+		if current == to {
+			paths = append(paths, append([]string{}, path...))
+			return
+		}
+		resource, ok := c.Resources[current]
+		if !ok {
+			return
+		}
+		for _, dep := range resource.Dependencies() {
+			dep = common.ParseTaskName(dep).Resource()
+			if dep == "" || inSlice(path, dep) {
+				continue
+			}
+			walk(append(path, dep))
+		}
+	}
+	walk([]string{from})
+	return paths
+}
+
+func inSlice(items []string, item string) bool {
+	for _, value := range items {
+		if value == item {
+			return true
+		}
+	}
+	return false
+}
+
 // Load a configuration from a filename
 func Load(filename string) (*Config, error) {
 	fmtError := func(err error) error {
-		return fmt.Errorf("Failed to load config from %q: %s", filename, err)
+		return &LoadError{filename: filename, err: err}
 	}
 
 	config, err := loadConfig(filename)
@@ -82,6 +164,7 @@ func Load(filename string) (*Config, error) {
 	if err = validate(config); err != nil {
 		return nil, fmtError(err)
 	}
+	config.Warnings = validateWarnings(config)
 	return config, nil
 }
 
@@ -98,30 +181,165 @@ func loadConfig(filename string) (*Config, error) {
 	return config, nil
 }
 
-// validate validates all the resources in the config
+// validate validates all the resources in the config, and returns an
+// ErrorList with all the validation errors.
 func validate(config *Config) error {
-	for name, resource := range config.Resources {
-		path := NewPath(name)
+	errs := &ErrorList{}
+	for _, name := range config.Sorted() {
+		err := validateResource(name, config.Resources[name], config)
+		errs.Add(inFile(err, config.includedFrom[name]))
+	}
+	errs.Add(config.Meta.Validate(config))
+	return errs.ErrorOrNil()
+}
+
+// validateWarnings returns a warning for each problem with the config which is
+// not an error
+func validateWarnings(config *Config) []string {
+	warnings := []string{}
+	if config.Meta.Project == "" {
+		warnings = append(warnings, fmt.Sprintf(
+			"meta.project is not set. Using default %q.", filepath.Base(config.WorkingDir)))
+	}
+	return warnings
+}
+
+func validateResource(name string, resource Resource, config *Config) error {
+	path := NewPath(name)
+
+	if err := ValidateFields(path, resource); err != nil {
+		return err
+	}
+	if err := ValidateResourcesExist(path, config, resource.Dependencies()); err != nil {
+		return err
+	}
+	if err := resource.Validate(path, config); err != nil {
+		return err
+	}
+	return nil
+}
 
-		if err := ValidateFields(path, resource); err != nil {
+// ValidateResolved validates the fields of a resource which reference other
+// resources, or which are paths, after variables in the resource have been
+// resolved. These fields are not validated by Load when they contain variables.
+func ValidateResolved(
+	name string,
+	resource Resource,
+	config *Config,
+	env *execenv.ExecEnv,
+) error {
+	switch resource := resource.(type) {
+	case *JobConfig:
+		path := NewPath(name)
+		if err := resource.validateReferences(path, config); err != nil {
 			return err
 		}
-		if err := ValidateResourcesExist(path, config, resource.Dependencies()); err != nil {
-			return err
+		if err := resource.validateMountPaths(config, env); err != nil {
+			return PathErrorf(path.add("mounts"), err.Error())
+		}
+		if err := validateArtifact(resource.Artifact); err != nil {
+			return PathErrorf(path.add("artifact"), err.Error())
+		}
+		for _, artifact := range resource.Artifacts.Paths() {
+			if err := validateArtifact(artifact); err != nil {
+				return PathErrorf(path.add("artifacts"), err.Error())
+			}
+		}
+		if err := validateUser(resource.User); err != nil {
+			return PathErrorf(path.add("user"), err.Error())
+		}
+		if err := validateMacAddress(resource.MacAddress); err != nil {
+			return PathErrorf(path.add("mac-address"), err.Error())
+		}
+		if err := validateContainerPath(resource.ArtifactFromContainer); err != nil {
+			return PathErrorf(path.add("artifact-from-container"), err.Error())
+		}
+		if err := validateMemory(resource.Memory); err != nil {
+			return PathErrorf(path.add("memory"), err.Error())
+		}
+		if err := validateCPUs(resource.Cpus); err != nil {
+			return PathErrorf(path.add("cpus"), err.Error())
 		}
-		if err := resource.Validate(path, config); err != nil {
+		if err := validateUlimits(resource.Ulimits); err != nil {
+			return PathErrorf(path.add("ulimits"), err.Error())
+		}
+		if err := validatePorts(resource.Ports); err != nil {
+			return PathErrorf(path.add("ports"), err.Error())
+		}
+	case *ServiceConfig:
+		path := NewPath(name)
+		job := resource.Job()
+		if err := job.validateReferences(path, config); err != nil {
 			return err
 		}
+		if err := job.validateMountPaths(config, env); err != nil {
+			return PathErrorf(path.add("mounts"), err.Error())
+		}
+		if err := validateUser(resource.User); err != nil {
+			return PathErrorf(path.add("user"), err.Error())
+		}
+		if err := validatePorts(resource.Ports); err != nil {
+			return PathErrorf(path.add("ports"), err.Error())
+		}
+	case *ShellConfig:
+		path := NewPath(name)
+		if err := validateArtifact(resource.Artifact); err != nil {
+			return PathErrorf(path.add("artifact"), err.Error())
+		}
+	case *ComposeConfig:
+		path := NewPath(name)
+		if err := validateEnabled(resource.Enabled.value); err != nil {
+			return PathErrorf(path.add("enabled"), err.Error())
+		}
+	case *MountConfig:
+		path := NewPath(name)
+		if err := validateMountPath(resource.Path); err != nil {
+			return PathErrorf(path.add("path"), err.Error())
+		}
+	case *ImageConfig:
+		path := NewPath(name)
+		if err := resource.ValidatePlatforms(); err != nil {
+			return PathErrorf(path.add("platforms"), err.Error())
+		}
+		if resource.Digest != "" {
+			if err := validateDigest(resource.Digest); err != nil {
+				return PathErrorf(path.add("digest"), err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// validateArtifact checks that a resolved artifact is not the working directory
+// or the root directory, because the rm action removes the artifact
+func validateArtifact(artifact string) error {
+	if artifact == "" {
+		return nil
+	}
+	switch filepath.Clean(artifact) {
+	case ".", "/", filepath.VolumeName(artifact) + string(filepath.Separator):
+		return fmt.Errorf(
+			"invalid artifact %q, must not be the working directory or the root directory",
+			artifact)
 	}
-	config.Meta.Validate(config)
 	return nil
 }
 
+// hasVariables returns true if the value contains a variable which has not
+// been resolved
+func hasVariables(value string) bool {
+	return strings.Contains(value, "{")
+}
+
 // ValidateResourcesExist checks that the list of resources is defined in the
-// config and returns an error if a resources is not defined.
+// config and returns an error if a resources is not defined. Names which
+// contain variables are ignored.
 func ValidateResourcesExist(path Path, c *Config, names []string) error {
 	missing := []string{}
 	for _, name := range names {
+		if hasVariables(name) {
+			continue
+		}
 		resource := common.ParseTaskName(name).Resource()
 		if _, ok := c.Resources[resource]; !ok {
 			missing = append(missing, resource)
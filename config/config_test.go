@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/dnephin/dobi/execenv"
@@ -46,6 +47,23 @@ func (s *ConfigSuite) TestSorted() {
 	s.Equal([]string{"alpha", "beta", "cabo"}, sorted)
 }
 
+func (s *ConfigSuite) TestDependencyPaths() {
+	s.config.Resources = map[string]Resource{
+		"all":     &AliasConfig{Tasks: []string{"test", "release:push"}},
+		"test":    &AliasConfig{Tasks: []string{"builder"}},
+		"release": &AliasConfig{Tasks: []string{"builder", "test"}},
+		"builder": StubResource{},
+		"other":   StubResource{},
+	}
+	s.Equal([][]string{
+		{"all", "test", "builder"},
+		{"all", "release", "builder"},
+		{"all", "release", "test", "builder"},
+	}, s.config.DependencyPaths("all", "builder"))
+	s.Equal([][]string{}, s.config.DependencyPaths("all", "other"))
+	s.Equal([][]string{{"test"}}, s.config.DependencyPaths("test", "test"))
+}
+
 type Something struct {
 	First     string   `config:"required"`
 	Second    string   `config:"required,validate"`
@@ -161,3 +179,128 @@ func TestValidateFieldsBadValidationFuncArgs(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "must be of type \"func() error\"")
 }
+
+func TestValidateReturnsAllErrors(t *testing.T) {
+	config := NewConfig()
+	config.Resources = map[string]Resource{
+		"one":   &AliasConfig{Tasks: []string{"missing"}},
+		"two":   &MountConfig{Bind: "."},
+		"three": &AliasConfig{Tasks: []string{"one"}},
+	}
+	config.Meta.Default = "bogus"
+
+	err := validate(config)
+	assert.Error(t, err)
+	list, ok := err.(*ErrorList)
+	assert.True(t, ok)
+	assert.Len(t, list.Errors(), 3)
+	assert.Contains(t, err.Error(), "Error at one: missing dependencies: missing")
+	assert.Contains(t, err.Error(), "Error at two.path: a value is required")
+	assert.Contains(t, err.Error(), "Undefined default resource: bogus")
+}
+
+func TestValidateInvalidMask(t *testing.T) {
+	config := NewConfig()
+	config.Meta.Mask = []string{"token-[0-9a-f]+", "bad("}
+
+	err := validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid mask pattern")
+}
+
+func TestValidateLimits(t *testing.T) {
+	config := NewConfig()
+	config.Meta.Limits = map[string]int{"image": 2, "job": 8}
+	assert.Nil(t, validate(config))
+}
+
+func TestValidateInvalidLimits(t *testing.T) {
+	var testcases = []struct {
+		limits   map[string]int
+		expected string
+	}{
+		{
+			limits:   map[string]int{"compose": 2},
+			expected: `Invalid limit for "compose", must be one of: image, job, shell`,
+		},
+		{
+			limits:   map[string]int{"job": 0},
+			expected: `Invalid limit for "job", must be a positive number, not 0`,
+		},
+	}
+	for _, testcase := range testcases {
+		config := NewConfig()
+		config.Meta.Limits = testcase.limits
+
+		err := validate(config)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), testcase.expected)
+		}
+	}
+}
+
+func TestValidateWarningsProjectNotSet(t *testing.T) {
+	config := NewConfig()
+	config.WorkingDir = "/work/webapp"
+	assert.Equal(t,
+		[]string{`meta.project is not set. Using default "webapp".`},
+		validateWarnings(config))
+
+	config.Meta.Project = "webapp"
+	assert.Len(t, validateWarnings(config), 0)
+}
+
+func TestValidateInvalidDefaultShell(t *testing.T) {
+	config := NewConfig()
+	assert.Nil(t, config.Meta.DefaultShell.TransformConfig(reflect.ValueOf("'' -c")))
+
+	err := validate(config)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `Invalid default-shell: invalid shell "'' -c"`)
+	}
+}
+
+func TestMetaConfigResolveLabels(t *testing.T) {
+	meta := &MetaConfig{Labels: map[string]string{
+		"pipeline": "{env.DOBI_TEST_PIPELINE:local}",
+		"project":  "{project}",
+	}}
+	env := execenv.NewExecEnv("exec", "webapp", ".")
+
+	labels, err := meta.ResolveLabels(env)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"pipeline": "local", "project": "webapp"}, labels)
+	assert.Equal(t, "{project}", meta.Labels["project"])
+}
+
+func TestMetaConfigInvalidLabels(t *testing.T) {
+	config := NewConfig()
+	config.Meta.Labels = map[string]string{"-bad": "value"}
+
+	err := validate(config)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `Invalid label: invalid name "-bad"`)
+	}
+}
+
+func TestMetaConfigInvalidStaleCheck(t *testing.T) {
+	config := NewConfig()
+	config.Meta.StaleCheck = "checksum"
+
+	err := validate(config)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `Invalid stale-check: invalid stale-check "checksum"`)
+	}
+}
+
+func TestResourceCollectionKeepContainer(t *testing.T) {
+	config, err := LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n" +
+			"job=build:\n  use: builder\n" +
+			"job=test:\n  use: builder\n  volumes-from: [build]\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, config.Collection.KeepContainer("build"))
+	assert.False(t, config.Collection.KeepContainer("test"))
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dnephin/dobi/execenv"
+)
+
+var sha256Regex = regexp.MustCompile("^[0-9a-f]{64}$")
+
+// DownloadConfig A **download** resource downloads a file from a url to a
+// host path. The file is only downloaded if it doesn't exist, or if the
+// checksum of the existing file doesn't match **sha256**. Without **sha256**,
+// an existing file which was downloaded with an ``ETag`` or ``Last-Modified``
+// header is only downloaded again if the server reports that the file was
+// modified. The **dest** file is an artifact, so tasks which depend on the
+// **download** resource are run again when the file is downloaded.
+// name: download
+// example: Download a tool binary and verify the checksum
+//
+// .. code-block:: yaml
+//
+//     download=tool:
+//         url: https://example.com/releases/tool-1.2.0-linux-amd64
+//         dest: .dobi/bin/tool
+//         sha256: 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//
+type DownloadConfig struct {
+	// URL The ``http`` or ``https`` url of the file to download. This field
+	// supports :doc:`variables`.
+	URL string `config:"required,validate"`
+	// Dest The host path of the downloaded file. Paths are relative to the
+	// current working directory. This field supports :doc:`variables`.
+	Dest string `config:"required"`
+	// SHA256 The hex encoded sha256 checksum of the file. If the checksum of
+	// the downloaded file doesn't match, the download fails.
+	SHA256 string `config:"validate"`
+	// Timeout The maximum time to wait for each attempt to download the file.
+	// When set, ``meta.default-timeout`` does not apply to the download.
+	// type: duration string
+	// example: ``30s``
+	// default: *no timeout*
+	Timeout duration
+	// Retries The number of times to retry a download which failed.
+	// default: ``0``
+	Retries int `config:"validate"`
+	// RetryDelay The time to wait before the first retry. The delay is
+	// doubled for each retry after the first.
+	// type: duration string
+	// default: ``1s``
+	RetryDelay duration
+	// Depends The list of resource dependencies.
+	// type: list of resource names
+	Depends []string
+}
+
+// Dependencies returns the list of tasks
+func (c *DownloadConfig) Dependencies() []string {
+	return c.Depends
+}
+
+// Validate the resource
+func (c *DownloadConfig) Validate(path Path, config *Config) *PathError {
+	return nil
+}
+
+// ValidateURL validates that URL is an http or https url. Urls which contain
+// variables are validated when they are used.
+func (c *DownloadConfig) ValidateURL() error {
+	if hasVariables(c.URL) {
+		return nil
+	}
+	return validateHTTPURL(c.URL)
+}
+
+func validateHTTPURL(value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	switch {
+	case parsed.Scheme != "http" && parsed.Scheme != "https":
+		return fmt.Errorf("unsupported url scheme %q, must be http or https",
+			parsed.Scheme)
+	case parsed.Host == "":
+		return fmt.Errorf("url %q is missing a host", value)
+	}
+	return nil
+}
+
+// isHTTPURL returns true if value looks like an http or https url
+func isHTTPURL(value string) bool {
+	return strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")
+}
+
+// ValidateRetries validates that Retries is not negative
+func (c *DownloadConfig) ValidateRetries() error {
+	if c.Retries < 0 {
+		return fmt.Errorf("must not be negative, not %d", c.Retries)
+	}
+	return nil
+}
+
+// ValidateSHA256 validates that SHA256 is a hex encoded sha256 checksum
+func (c *DownloadConfig) ValidateSHA256() error {
+	if c.SHA256 == "" || sha256Regex.MatchString(c.SHA256) {
+		return nil
+	}
+	return fmt.Errorf("%q is not a hex encoded sha256 checksum", c.SHA256)
+}
+
+func (c *DownloadConfig) String() string {
+	return fmt.Sprintf("Download %q to %q", c.URL, c.Dest)
+}
+
+// Resolve resolves variables in the resource
+func (c *DownloadConfig) Resolve(env *execenv.ExecEnv) (Resource, error) {
+	resolver := newFieldResolver(env)
+	c.URL = resolver.resolve("url", c.URL)
+	c.Dest = resolver.resolve("dest", c.Dest)
+	return c, resolver.err()
+}
+
+func downloadFromConfig(name string, values map[string]interface{}) (Resource, error) {
+	download := &DownloadConfig{RetryDelay: duration{value: time.Second}}
+	return download, Transform(name, values, download)
+}
+
+func init() {
+	RegisterResource("download", downloadFromConfig)
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadConfigValidateURL(t *testing.T) {
+	for _, value := range []string{
+		"http://example.com/tool",
+		"https://example.com/releases/tool-1.0.tgz",
+		"https://example.com/{env.VERSION}/tool",
+	} {
+		download := &DownloadConfig{URL: value}
+		assert.Nil(t, download.ValidateURL(), value)
+	}
+}
+
+func TestDownloadConfigValidateURLInvalid(t *testing.T) {
+	for value, expected := range map[string]string{
+		"ftp://example.com/tool": "unsupported url scheme \"ftp\"",
+		"example.com/tool":       "unsupported url scheme \"\"",
+		"http:///tool":           "is missing a host",
+	} {
+		download := &DownloadConfig{URL: value}
+		err := download.ValidateURL()
+		if assert.Error(t, err, value) {
+			assert.Contains(t, err.Error(), expected)
+		}
+	}
+}
+
+func TestDownloadConfigValidateSHA256(t *testing.T) {
+	download := &DownloadConfig{}
+	assert.Nil(t, download.ValidateSHA256())
+
+	download.SHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	assert.Nil(t, download.ValidateSHA256())
+
+	download.SHA256 = "9f86d081"
+	err := download.ValidateSHA256()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "is not a hex encoded sha256 checksum")
+	}
+}
+
+func TestDownloadFromConfig(t *testing.T) {
+	resource, err := downloadFromConfig("download=tool", map[string]interface{}{
+		"url":     "https://example.com/tool",
+		"dest":    "bin/tool",
+		"timeout": "30s",
+		"retries": 3,
+	})
+	assert.Nil(t, err)
+	download := resource.(*DownloadConfig)
+	assert.Equal(t, 30*time.Second, download.Timeout.Duration())
+	assert.Equal(t, time.Second, download.RetryDelay.Duration())
+	assert.Equal(t, 3, download.Retries)
+}
+
+func TestDownloadFromConfigInvalidDuration(t *testing.T) {
+	_, err := downloadFromConfig("download=tool", map[string]interface{}{
+		"retry-delay": "soon",
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Error at download=tool.retry-delay: invalid duration \"soon\"")
+	}
+}
+
+func TestDownloadConfigValidateRetries(t *testing.T) {
+	download := &DownloadConfig{Retries: -1}
+	assert.Error(t, download.ValidateRetries())
+}
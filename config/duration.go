@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// duration is a config type for a time.Duration which is set from a string
+// like "30s" or "5m"
+type duration struct {
+	value time.Duration
+}
+
+func (d *duration) TransformConfig(raw reflect.Value) error {
+	switch value := raw.Interface().(type) {
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %s", value, err)
+		}
+		if parsed < 0 {
+			return fmt.Errorf("invalid duration %q: must not be negative", value)
+		}
+		d.value = parsed
+	default:
+		return fmt.Errorf("must be a string, not %T", value)
+	}
+	return nil
+}
+
+// Duration returns the value as a time.Duration. The zero value is 0.
+func (d duration) Duration() time.Duration {
+	return d.value
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dnephin/dobi/execenv"
+)
+
+var envDeclRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(=.*)?$`)
+
+// EnvConfig An **env** resource loads environment variables from files, and
+// checks that required variables are set. The variables are used to resolve
+// ``{env.<variable>}`` in the resources which depend on the **env** resource,
+// and in every resource which runs after it. Variables from the host
+// environment take precedence over variables from the **files**, which take
+// precedence over the default values in **variables**.
+// name: env
+// example: Load the settings for an environment, and require a deploy token
+//
+// .. code-block:: yaml
+//
+//     env=settings:
+//         files: ['.env', 'deploy/{env.STAGE:dev}.env']
+//         variables: [DEPLOY_TOKEN, 'REGION=us-east-1']
+//
+//     job=deploy:
+//         use: deployer
+//         env: ['TOKEN={env.DEPLOY_TOKEN}', 'REGION={env.REGION}']
+//         depends: [settings]
+//
+type EnvConfig struct {
+	// Files A list of files of environment variables, in the same format as
+	// the ``.env`` file. Paths are relative to the directory of the
+	// ``dobi.yaml``. Variables from a later file take precedence over
+	// variables from an earlier file. The task fails if a file does not
+	// exist. This field supports :doc:`variables`.
+	// type: list of filepaths
+	Files []string
+	// Variables A list of variables. A ``NAME`` is required, and the task
+	// fails if the variable is not set by the host environment or one of
+	// the **files**. A ``NAME=value`` sets a default value, which is used
+	// when the variable is not set. Default values support :doc:`variables`.
+	// type: list of ``NAME`` or ``NAME=value`` strings
+	Variables []string `config:"validate"`
+	// Depends The list of resource dependencies.
+	// type: list of resource names
+	Depends []string
+}
+
+// Dependencies returns the list of tasks
+func (c *EnvConfig) Dependencies() []string {
+	return c.Depends
+}
+
+// Validate checks that a file or a variable is set
+func (c *EnvConfig) Validate(path Path, config *Config) *PathError {
+	if len(c.Files) == 0 && len(c.Variables) == 0 {
+		return PathErrorf(path, "one of files or variables is required")
+	}
+	return nil
+}
+
+// ValidateVariables validates that each variable is a NAME or NAME=value
+func (c *EnvConfig) ValidateVariables() error {
+	for _, variable := range c.Variables {
+		if !envDeclRegex.MatchString(variable) {
+			return fmt.Errorf("invalid variable %q, must be NAME or NAME=value", variable)
+		}
+	}
+	return nil
+}
+
+// Required returns the names of the variables which do not have a default
+func (c *EnvConfig) Required() []string {
+	required := []string{}
+	for _, variable := range c.Variables {
+		if !strings.Contains(variable, "=") {
+			required = append(required, variable)
+		}
+	}
+	return required
+}
+
+// Defaults returns the default values of variables
+func (c *EnvConfig) Defaults() map[string]string {
+	defaults := map[string]string{}
+	for _, variable := range c.Variables {
+		if parts := strings.SplitN(variable, "=", 2); len(parts) == 2 {
+			defaults[parts[0]] = parts[1]
+		}
+	}
+	return defaults
+}
+
+func (c *EnvConfig) String() string {
+	sources := append([]string{}, c.Files...)
+	return fmt.Sprintf("Set environment variables from %s",
+		strings.Join(append(sources, c.Variables...), ", "))
+}
+
+// Resolve resolves variables in the resource
+func (c *EnvConfig) Resolve(env *execenv.ExecEnv) (Resource, error) {
+	resolver := newFieldResolver(env)
+	c.Files = resolver.resolvePaths("files", c.Files)
+	c.Variables = resolver.resolveSlice("variables", c.Variables)
+	return c, resolver.err()
+}
+
+func envFromConfig(name string, values map[string]interface{}) (Resource, error) {
+	env := &EnvConfig{}
+	return env, Transform(name, values, env)
+}
+
+func init() {
+	RegisterResource("env", envFromConfig)
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvConfigValidateVariables(t *testing.T) {
+	conf := &EnvConfig{Variables: []string{"TOKEN", "REGION=us-east-1", "EMPTY="}}
+	assert.Nil(t, conf.ValidateVariables())
+	assert.Equal(t, []string{"TOKEN"}, conf.Required())
+	assert.Equal(t, map[string]string{"REGION": "us-east-1", "EMPTY": ""}, conf.Defaults())
+
+	conf.Variables = []string{"1TOKEN"}
+	err := conf.ValidateVariables()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `invalid variable "1TOKEN"`)
+	}
+}
+
+func TestEnvConfigValidate(t *testing.T) {
+	conf := &EnvConfig{}
+	err := conf.Validate(NewPath("env=settings"), NewConfig())
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "one of files or variables is required")
+	}
+}
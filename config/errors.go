@@ -32,18 +32,151 @@ func NewPath(root string) Path {
 type PathError struct {
 	path Path
 	msg  string
+	// file is the included config file which defined the path, if the path is
+	// not from the main config file
+	file string
 }
 
 func (e *PathError) Error() string {
+	if e.file != "" {
+		return fmt.Sprintf("Error at %s in %q: %s", e.path.String(), e.file, e.msg)
+	}
 	return fmt.Sprintf("Error at %s: %s", e.path.String(), e.msg)
 }
 
+// File returns the included config file where the error occurred, or an empty
+// string if the error is from the main config file
+func (e *PathError) File() string {
+	return e.file
+}
+
+// inFile returns err with the name of the included config file which defined
+// the resource with the error
+func inFile(err error, file string) error {
+	if err == nil || file == "" {
+		return err
+	}
+	switch typed := err.(type) {
+	case *PathError:
+		return &PathError{path: typed.path, msg: typed.msg, file: file}
+	case *ErrorList:
+		errs := &ErrorList{}
+		for _, item := range typed.errors {
+			errs.Add(inFile(item, file))
+		}
+		return errs
+	default:
+		return fmt.Errorf("%s, in %q", err, file)
+	}
+}
+
 // Path returns the config path where the error occurred
 func (e *PathError) Path() Path {
 	return e.path
 }
 
+// ErrorList is an error which holds one or more errors
+type ErrorList struct {
+	errors []error
+}
+
+// Add an error to the list. nil errors are ignored.
+func (e *ErrorList) Add(err error) {
+	if err == nil {
+		return
+	}
+	if list, ok := err.(*ErrorList); ok {
+		e.errors = append(e.errors, list.errors...)
+		return
+	}
+	e.errors = append(e.errors, err)
+}
+
+// Errors returns all the errors in the list
+func (e *ErrorList) Errors() []error {
+	return e.errors
+}
+
+// ErrorOrNil returns nil if the list is empty, otherwise it returns the list
+func (e *ErrorList) ErrorOrNil() error {
+	if len(e.errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ErrorList) Error() string {
+	msgs := []string{}
+	for _, err := range e.errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
 // PathErrorf returns a new PathError with a formatted message
 func PathErrorf(path Path, msg string, args ...interface{}) *PathError {
 	return &PathError{path: path, msg: fmt.Sprintf(msg, args...)}
 }
+
+// LoadError is an error from loading a config file
+type LoadError struct {
+	filename string
+	err      error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("Failed to load config from %q: %s", e.filename, e.err)
+}
+
+// Problem is an error or warning about the config, in a form which can be
+// encoded as json
+type Problem struct {
+	Resource string `json:"resource"`
+	Path     string `json:"path"`
+	File     string `json:"file,omitempty"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+const (
+	// SeverityError is the severity of a problem which prevents the config
+	// from being used
+	SeverityError = "error"
+	// SeverityWarning is the severity of a problem which does not prevent the
+	// config from being used
+	SeverityWarning = "warning"
+)
+
+// Problems returns a Problem for each error in err, and for each warning
+func Problems(err error, warnings []string) []Problem {
+	if loadErr, ok := err.(*LoadError); ok {
+		err = loadErr.err
+	}
+	errs := &ErrorList{}
+	errs.Add(err)
+
+	problems := []Problem{}
+	for _, err := range errs.Errors() {
+		problems = append(problems, newProblem(err))
+	}
+	for _, warning := range warnings {
+		problems = append(problems, Problem{Message: warning, Severity: SeverityWarning})
+	}
+	return problems
+}
+
+func newProblem(err error) Problem {
+	pathErr, ok := err.(*PathError)
+	if !ok {
+		return Problem{Message: err.Error(), Severity: SeverityError}
+	}
+	path := pathErr.path.Path()
+	problem := Problem{Message: pathErr.msg, Severity: SeverityError, File: pathErr.file}
+	if len(path) > 0 {
+		// Errors from loading the config use the section key, like job=name
+		parts := strings.SplitN(path[0], "=", 2)
+		problem.Resource = parts[len(parts)-1]
+		problem.Path = strings.Join(path[1:], ".")
+	}
+	return problem
+}
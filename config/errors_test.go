@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblems(t *testing.T) {
+	path := NewPath("job=test")
+	errs := &ErrorList{}
+	errs.Add(PathErrorf(path.add("pids-limit"), "must not be negative"))
+	errs.Add(fmt.Errorf("no path"))
+	err := &LoadError{filename: "dobi.yaml", err: errs}
+
+	expected := []Problem{
+		{Resource: "test", Path: "pids-limit", Message: "must not be negative",
+			Severity: SeverityError},
+		{Message: "no path", Severity: SeverityError},
+		{Message: "project is not set", Severity: SeverityWarning},
+	}
+	assert.Equal(t, expected, Problems(err, []string{"project is not set"}))
+}
+
+func TestProblemsNoErrors(t *testing.T) {
+	assert.Equal(t, []Problem{}, Problems(nil, nil))
+}
@@ -4,11 +4,21 @@ import (
 	"fmt"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dnephin/dobi/execenv"
 )
 
+var (
+	digestRegex = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+	platformRegex = regexp.MustCompile(`^[a-z0-9_]+(/[a-z0-9_]+){0,2}$`)
+)
+
 // ImageConfig An **image** resource provides actions for working with a Docker
 // image. If an image is buildable it is considered up-to-date if all files in
 // the build context have a modified time older than the created time of the
@@ -29,11 +39,25 @@ type ImageConfig struct {
 	// Image The name of the **image** without any tags
 	Image string `config:"required"`
 	// Dockerfile The path to the ``Dockerfile`` used to build the image. This
-	// path is relative to the **context**.
+	// path is relative to the **context**, and may be outside of the
+	// **context**, like ``../Dockerfile``, so that a small **context** can be
+	// used with a ``Dockerfile`` from another directory. The value may also be
+	// an ``http`` or ``https`` url, in which case the ``Dockerfile`` is
+	// downloaded into the **context** before each build, and removed after
+	// the build.
 	Dockerfile string
 	// Context The build context used to build the image.
 	// default: ``.``
 	Context string
+	// StaleCheck How to determine if the image is stale. With ``mtime`` the
+	// modified time of the files in the **context** is compared to the time of
+	// the last build. With ``content`` a checksum of the contents of the
+	// **context** is recorded after each build, and the image is stale when
+	// the checksum changes. The **stale-check** field of `meta`_ sets the
+	// default for every **image**.
+	// default: ``mtime``
+	// example: ``content``
+	StaleCheck string `config:"validate"`
 	// Args Build args used to build the image. Values in the mapping support
 	// :doc:`variables`.
 	// type: mapping ``key: value``
@@ -56,13 +80,99 @@ type ImageConfig struct {
 	// default: ``['{unique}']``
 	// type: list of tags
 	Tags []string
+	// Auth The name of a `registry`_ resource. The credentials of the
+	// **registry** are used to pull and push the image, instead of the
+	// credentials from the docker client config file. Multi-platform images
+	// are pushed with the credentials of the ``docker`` CLI.
+	// type: registry resource name
+	Auth string
+	// Digest The expected digest of the image, as ``sha256:<hex>``. After the
+	// image is pulled, the ``pull`` task fails if the digest of the image in
+	// the registry is different. This field supports :doc:`variables`.
+	// example: ``sha256:4bd0...``
+	Digest string `config:"validate"`
+	// PinDigest Record the digest of the image the first time it is pulled,
+	// in ``.dobi/images``, and fail any later ``pull`` which finds a
+	// different digest, so that every run uses the same image. Run the
+	// ``rm`` task of the image to remove the recorded digest, and accept a
+	// new digest on the next ``pull``. Can not be used with **digest**.
+	PinDigest bool
 	// Depends The list of resource dependencies
 	// type: list of resources
 	Depends []string
+	// Labels Labels to set on the image. Values in the mapping support
+	// :doc:`variables`. Labels are only supported by BuildKit builds, so an
+	// image with labels is built with BuildKit unless **buildkit** is
+	// ``false``.
+	// type: mapping ``key: value``
+	Labels map[string]string
+	// OCILabels Set the standard ``org.opencontainers.image`` labels:
+	// ``revision`` from ``{git.sha}``, ``created`` from the time **dobi**
+	// started, and ``version`` from the first of the **tags**. Values in
+	// **labels** take precedence over these labels. The build context must
+	// be in a git repository.
+	OCILabels bool `config:"oci-labels"`
+	// Contexts Additional named build contexts, which can be used in the
+	// ``Dockerfile`` with ``COPY --from=<name>`` or ``FROM <name>``. Each value
+	// is a path to a local directory, or a ``docker-image://``,
+	// ``oci-layout://``, ``http://`` or ``https://`` url. Named contexts are
+	// only supported by BuildKit, so an image with contexts is built with
+	// BuildKit, and **buildkit** must not be ``false``.
+	// type: mapping ``name: path or url``
+	// example: ``{shared: ../shared, base: 'docker-image://alpine:3.19'}``
+	Contexts map[string]string `config:"validate"`
+	// Secrets Secrets which are available to ``RUN --mount=type=secret``
+	// instructions in the ``Dockerfile``, without being stored in the layers
+	// of the image. Each item is the ``id`` of the secret and its source,
+	// either a file (``src``) or an environment variable (``env``). Secrets are
+	// only supported by BuildKit, so an image with secrets is built with
+	// BuildKit, and **buildkit** must not be ``false``. Each item in the list
+	// supports :doc:`variables`.
+	// type: list of ``id=<id>,src=<path>`` or ``id=<id>,env=<variable>``
+	// example: ``['id=npmrc,src={env.HOME}/.npmrc', 'id=token,env=GITHUB_TOKEN']``
+	Secrets []string `config:"validate"`
+	// CacheFrom External cache sources used by the build, in the format of
+	// ``docker build --cache-from``. Requires BuildKit, like **secrets**. Each
+	// item in the list supports :doc:`variables`.
+	// type: list of cache sources
+	// example: ``['type=registry,ref=example/app:cache']``
+	CacheFrom []string
+	// CacheTo Export the build cache, in the format of ``docker build
+	// --cache-to``. Requires BuildKit, like **secrets**. Exporting a cache
+	// may require a ``docker buildx`` builder which supports the cache type.
+	// Each item in the list supports :doc:`variables`.
+	// type: list of cache destinations
+	// example: ``['type=registry,ref=example/app:cache,mode=max']``
+	CacheTo []string
+	// Platforms The platforms to build the image for, of the form
+	// ``os[/arch[/variant]]``. Requires BuildKit, like **secrets**. An image with
+	// one platform is built and stored like any other image. An image with
+	// more than one platform is built with ``docker buildx``, and is not
+	// stored in the local image store, so it can not be used by a `job`_. The
+	// ``push`` task builds the image again, from the build cache, and pushes a
+	// manifest list with an image for each platform to every tag, using the
+	// credentials of the ``docker`` CLI. Each item in the list supports
+	// :doc:`variables`.
+	// type: list of platforms
+	// example: ``[linux/amd64, linux/arm64]``
+	Platforms []string `config:"validate"`
+	// Buildkit Build the image with BuildKit. The value may be one of:
+	// * ``auto`` - use BuildKit if the ``Dockerfile`` has a ``# syntax=``
+	//   directive or uses ``RUN --mount``
+	// * ``true`` - always use BuildKit
+	// * ``false`` - never use BuildKit
+	// BuildKit builds are run with the ``docker`` CLI, which must be installed
+	// and available in ``$PATH``.
+	// type: string
+	// default: ``auto``
+	Buildkit buildKit
 }
 
 // Dependencies returns the list of implicit and explicit dependencies
 func (c *ImageConfig) Dependencies() []string {
+	if c.Auth != "" {
+		return append([]string{c.Auth}, c.Depends...)
+	}
 	return c.Depends
 }
 
@@ -73,9 +183,191 @@ func (c *ImageConfig) Validate(path Path, config *Config) *PathError {
 	if err := c.validateBuildOrPull(); err != nil {
 		return PathErrorf(path, err.Error())
 	}
+	if err := c.validateAuth(config); err != nil {
+		return PathErrorf(path.add("auth"), err.Error())
+	}
+	if c.PinDigest && c.Digest != "" {
+		return PathErrorf(path.add("pin-digest"), "can not be used with digest")
+	}
+	if c.IsDockerfileURL() {
+		if err := validateHTTPURL(c.Dockerfile); err != nil {
+			return PathErrorf(path.add("dockerfile"), err.Error())
+		}
+	}
+	if len(c.Contexts) > 0 && c.Buildkit.IsDisabled() {
+		return PathErrorf(path.add("contexts"), "named build contexts require buildkit")
+	}
+	for _, field := range []struct {
+		name   string
+		values []string
+	}{
+		{"secrets", c.Secrets},
+		{"cache-from", c.CacheFrom},
+		{"cache-to", c.CacheTo},
+		{"platforms", c.Platforms},
+	} {
+		if len(field.values) > 0 && c.Buildkit.IsDisabled() {
+			return PathErrorf(path.add(field.name), "%s requires buildkit", field.name)
+		}
+	}
+	return nil
+}
+
+func (c *ImageConfig) validateAuth(config *Config) error {
+	if c.Auth == "" {
+		return nil
+	}
+	if _, ok := config.Resources[c.Auth].(*RegistryConfig); !ok {
+		return fmt.Errorf("%s is not a registry resource", c.Auth)
+	}
+	return nil
+}
+
+// ValidateDigest validates that the digest is a sha256 digest, unless it
+// contains variables
+func (c *ImageConfig) ValidateDigest() error {
+	if c.Digest == "" || hasVariables(c.Digest) {
+		return nil
+	}
+	return validateDigest(c.Digest)
+}
+
+func validateDigest(digest string) error {
+	if !digestRegex.MatchString(digest) {
+		return fmt.Errorf("invalid digest %q, must be sha256:<64 hex characters>", digest)
+	}
+	return nil
+}
+
+// RequiresBuildKit returns true if the image uses a field which is only
+// supported by BuildKit builds
+func (c *ImageConfig) RequiresBuildKit() bool {
+	return len(c.Contexts) > 0 || len(c.Secrets) > 0 ||
+		len(c.CacheFrom) > 0 || len(c.CacheTo) > 0 || len(c.Platforms) > 0
+}
+
+// IsMultiPlatform returns true if the image is built for more than one
+// platform
+func (c *ImageConfig) IsMultiPlatform() bool {
+	return len(c.Platforms) > 1
+}
+
+// ValidatePlatforms validates that each platform which does not contain
+// variables is of the form os[/arch[/variant]]
+func (c *ImageConfig) ValidatePlatforms() error {
+	for _, platform := range c.Platforms {
+		if hasVariables(platform) {
+			continue
+		}
+		if err := validatePlatform(platform); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePlatform(platform string) error {
+	if !platformRegex.MatchString(platform) {
+		return fmt.Errorf(
+			"%q must be of the form os[/arch[/variant]], ex: linux/amd64", platform)
+	}
 	return nil
 }
 
+// secretKeys are the keys which may be used in the value of a secret
+var secretKeys = []string{"id", "src", "source", "env", "type"}
+
+// ValidateSecrets validates that each secret has an id, and only uses known
+// keys
+func (c *ImageConfig) ValidateSecrets() error {
+	for _, secret := range c.Secrets {
+		id := ""
+		for _, part := range strings.Split(secret, ",") {
+			pair := strings.SplitN(part, "=", 2)
+			if len(pair) != 2 || !inSlice(secretKeys, pair[0]) {
+				return fmt.Errorf("invalid secret %q, %q must be one of %s=<value>",
+					secret, part, strings.Join(secretKeys, ", "))
+			}
+			if pair[0] == "id" {
+				id = pair[1]
+			}
+		}
+		if id == "" {
+			return fmt.Errorf("invalid secret %q, an id is required", secret)
+		}
+	}
+	return nil
+}
+
+// ValidateStaleCheck validates that StaleCheck is mtime or content
+func (c *ImageConfig) ValidateStaleCheck() error {
+	return validateStaleCheck(c.StaleCheck)
+}
+
+// contextURLPrefixes are the prefixes of named build contexts which are not
+// local paths
+var contextURLPrefixes = []string{"docker-image://", "oci-layout://", "http://", "https://"}
+
+// ValidateContexts validates the names and values of Contexts
+func (c *ImageConfig) ValidateContexts() error {
+	if err := validateOptionNames(c.Contexts); err != nil {
+		return err
+	}
+	for name, value := range c.Contexts {
+		if value == "" {
+			return fmt.Errorf("a path or url is required for %q", name)
+		}
+	}
+	return nil
+}
+
+// ContextPaths returns the local paths of the named build contexts, sorted by
+// name
+func (c *ImageConfig) ContextPaths() []string {
+	names := []string{}
+	for name, value := range c.Contexts {
+		if !isContextURL(value) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	paths := []string{}
+	for _, name := range names {
+		paths = append(paths, c.Contexts[name])
+	}
+	return paths
+}
+
+func isContextURL(value string) bool {
+	for _, prefix := range contextURLPrefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExternalDockerfile returns the path to the Dockerfile if it is outside of the
+// context, otherwise it returns an empty string
+func (c *ImageConfig) ExternalDockerfile() string {
+	if c.Dockerfile == "" || c.IsDockerfileURL() {
+		return ""
+	}
+	if filepath.IsAbs(c.Dockerfile) {
+		return c.Dockerfile
+	}
+	rel := filepath.Clean(c.Dockerfile)
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.Join(c.Context, rel)
+	}
+	return ""
+}
+
+// IsDockerfileURL returns true if Dockerfile is a url instead of a path
+func (c *ImageConfig) IsDockerfileURL() bool {
+	return isHTTPURL(c.Dockerfile)
+}
+
 func (c *ImageConfig) validateBuildOrPull() error {
 	if c.Dockerfile == "" && c.Context == "" && !c.Pull.IsSet() {
 		return fmt.Errorf("one of dockerfile, context, or pull is required")
@@ -91,24 +383,55 @@ func (c *ImageConfig) validateBuildOrPull() error {
 
 func (c *ImageConfig) String() string {
 	dir := filepath.Join(c.Context, c.Dockerfile)
+	if c.IsDockerfileURL() {
+		dir = c.Dockerfile
+	}
 	return fmt.Sprintf("Build image '%s' from '%s'", c.Image, dir)
 }
 
 // Resolve resolves variables in the resource
 func (c *ImageConfig) Resolve(env *execenv.ExecEnv) (Resource, error) {
-	var err error
-	c.Tags, err = env.ResolveSlice(c.Tags)
-	if err != nil {
-		return c, err
+	resolver := newFieldResolver(env)
+	c.Tags = resolver.resolveSlice("tags", c.Tags)
+	c.Digest = resolver.resolve("digest", c.Digest)
+	c.Secrets = resolver.resolveSlice("secrets", c.Secrets)
+	c.CacheFrom = resolver.resolveSlice("cache-from", c.CacheFrom)
+	c.CacheTo = resolver.resolveSlice("cache-to", c.CacheTo)
+	c.Platforms = resolver.resolveSlice("platforms", c.Platforms)
+	for key, value := range c.Args {
+		c.Args[key] = resolver.resolve("args."+key, value)
+	}
+	for key, value := range c.Labels {
+		c.Labels[key] = resolver.resolve("labels."+key, value)
 	}
+	if c.OCILabels {
+		c.addOCILabels(resolver)
+	}
+	return c, resolver.err()
+}
 
-	for key, value := range c.Args {
-		c.Args[key], err = env.Resolve(value)
-		if err != nil {
-			return c, err
+// addOCILabels adds the standard OCI labels to Labels, without replacing any
+// labels which are already set. Tags must already be resolved.
+func (c *ImageConfig) addOCILabels(resolver *fieldResolver) {
+	version := "{unique}"
+	if len(c.Tags) > 0 {
+		version = c.Tags[0]
+	}
+	templates := map[string]string{
+		"org.opencontainers.image.revision": "{git.sha}",
+		// The trailing colon is an empty default, so the colons in the time
+		// format are not mistaken for a default
+		"org.opencontainers.image.created": "{time.YYYY-MM-DDThh:mm:ssZZ:}",
+		"org.opencontainers.image.version": version,
+	}
+	if c.Labels == nil {
+		c.Labels = make(map[string]string)
+	}
+	for key, tmpl := range templates {
+		if _, ok := c.Labels[key]; !ok {
+			c.Labels[key] = resolver.resolve("oci-labels", tmpl)
 		}
 	}
-	return c, nil
 }
 
 // NewImageConfig creates a new ImageConfig with default values
@@ -173,6 +496,45 @@ func (p pullAfter) doPull(lastPull *time.Time) bool {
 	return lastPull.Before(time.Now().Add(-p.duration))
 }
 
+type buildKit struct {
+	value string
+}
+
+func (b *buildKit) TransformConfig(raw reflect.Value) error {
+	switch value := raw.Interface().(type) {
+	case bool:
+		b.value = strconv.FormatBool(value)
+	case string:
+		switch value {
+		case "auto", "true", "false":
+			b.value = value
+		default:
+			return fmt.Errorf("invalid buildkit value %q, must be one of: auto, true, false", value)
+		}
+	default:
+		return fmt.Errorf("must be a bool or string, not %T", value)
+	}
+	return nil
+}
+
+// Enabled returns true if BuildKit should be used for the build. detected is
+// true if the Dockerfile requires BuildKit.
+func (b *buildKit) Enabled(detected bool) bool {
+	switch b.value {
+	case "true":
+		return true
+	case "false":
+		return false
+	default:
+		return detected
+	}
+}
+
+// IsDisabled returns true if BuildKit was explicitly disabled
+func (b *buildKit) IsDisabled() bool {
+	return b.value == "false"
+}
+
 func imageFromConfig(name string, values map[string]interface{}) (Resource, error) {
 	image := NewImageConfig()
 	return image, Transform(name, values, image)
@@ -2,9 +2,11 @@ package config
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/dnephin/dobi/execenv"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
@@ -49,6 +51,37 @@ func (s *ImageConfigSuite) TestValidateMissingOneOfRequired() {
 
 }
 
+func TestBuildKitTransformConfig(t *testing.T) {
+	for _, item := range []struct {
+		raw      interface{}
+		detected bool
+		expected bool
+	}{
+		{true, false, true},
+		{false, true, false},
+		{"true", false, true},
+		{"auto", true, true},
+		{"auto", false, false},
+	} {
+		b := buildKit{}
+		assert.Nil(t, b.TransformConfig(reflect.ValueOf(item.raw)))
+		assert.Equal(t, item.expected, b.Enabled(item.detected))
+	}
+}
+
+func TestBuildKitTransformConfigInvalid(t *testing.T) {
+	b := buildKit{}
+	err := b.TransformConfig(reflect.ValueOf("sometimes"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid buildkit value")
+}
+
+func TestBuildKitDefaultIsAuto(t *testing.T) {
+	b := buildKit{}
+	assert.Equal(t, true, b.Enabled(true))
+	assert.Equal(t, false, b.Enabled(false))
+}
+
 func TestPullWithDuration(t *testing.T) {
 	p := pull{}
 	now := time.Now()
@@ -59,3 +92,147 @@ func TestPullWithDuration(t *testing.T) {
 	assert.Equal(t, p.Required(&old), true)
 	assert.Equal(t, p.Required(nil), true)
 }
+
+func (s *ImageConfigSuite) TestValidateDockerfileURL() {
+	s.image.Dockerfile = "https://example.com/dockerfiles/base"
+	s.Nil(s.image.Validate(NewPath("image"), NewConfig()))
+	s.Equal("Build image 'example' from 'https://example.com/dockerfiles/base'",
+		s.image.String())
+}
+
+func (s *ImageConfigSuite) TestValidateDockerfileURLInvalid() {
+	s.image.Dockerfile = "https:///base"
+	err := s.image.Validate(NewPath("image"), NewConfig())
+	s.Error(err)
+	s.Contains(err.Error(), "Error at image.dockerfile: url \"https:///base\" is missing a host")
+}
+
+func TestImageConfigResolveOCILabels(t *testing.T) {
+	image := &ImageConfig{
+		Tags:      []string{"v{env.DOBI_TEST_VERSION:1.2.3}"},
+		OCILabels: true,
+		Labels:    map[string]string{"team": "{project}"},
+	}
+	image.Labels["org.opencontainers.image.revision"] = "abcdef"
+	env := execenv.NewExecEnv("exec", "project", ".")
+
+	_, err := image.Resolve(env)
+	assert.Nil(t, err)
+	assert.Equal(t, "abcdef", image.Labels["org.opencontainers.image.revision"])
+	assert.Equal(t, "v1.2.3", image.Labels["org.opencontainers.image.version"])
+	assert.Equal(t, "project", image.Labels["team"])
+	created, err := time.Parse(time.RFC3339, image.Labels["org.opencontainers.image.created"])
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now(), created, time.Minute)
+}
+
+func (s *ImageConfigSuite) TestValidateContexts() {
+	s.image.Contexts = map[string]string{
+		"shared": "../shared",
+		"base":   "docker-image://alpine:3.19",
+	}
+	s.Nil(s.image.ValidateContexts())
+	s.Nil(s.image.Validate(NewPath("image"), NewConfig()))
+	s.Equal([]string{"../shared"}, s.image.ContextPaths())
+}
+
+func (s *ImageConfigSuite) TestValidateContextsInvalid() {
+	s.image.Contexts = map[string]string{"bad name": "."}
+	err := s.image.ValidateContexts()
+	if s.Error(err) {
+		s.Contains(err.Error(), `invalid name "bad name"`)
+	}
+
+	s.image.Contexts = map[string]string{"shared": ""}
+	err = s.image.ValidateContexts()
+	if s.Error(err) {
+		s.Contains(err.Error(), `a path or url is required for "shared"`)
+	}
+}
+
+func (s *ImageConfigSuite) TestValidateSecrets() {
+	s.image.Secrets = []string{"id=npmrc,src=/home/user/.npmrc", "id=token,env=GITHUB_TOKEN"}
+	s.Nil(s.image.ValidateSecrets())
+
+	s.image.Secrets = []string{"src=/home/user/.npmrc"}
+	err := s.image.ValidateSecrets()
+	if s.Error(err) {
+		s.Contains(err.Error(), "an id is required")
+	}
+
+	s.image.Secrets = []string{"id=npmrc,path=/home/user/.npmrc"}
+	err = s.image.ValidateSecrets()
+	if s.Error(err) {
+		s.Contains(err.Error(), `"path=/home/user/.npmrc" must be one of`)
+	}
+}
+
+func (s *ImageConfigSuite) TestValidatePlatforms() {
+	s.image.Platforms = []string{"linux/amd64", "linux/arm/v7", "{env.PLATFORM}"}
+	s.Nil(s.image.ValidatePlatforms())
+
+	s.image.Platforms = []string{"linux/amd64", "Linux AMD64"}
+	err := s.image.ValidatePlatforms()
+	if s.Error(err) {
+		s.Contains(err.Error(), `"Linux AMD64" must be of the form os[/arch[/variant]]`)
+	}
+}
+
+func (s *ImageConfigSuite) TestValidateDigest() {
+	s.image.Digest = "sha256:" + strings.Repeat("a", 64)
+	s.Nil(s.image.ValidateDigest())
+
+	s.image.Digest = "{env.DIGEST}"
+	s.Nil(s.image.ValidateDigest())
+
+	s.image.Digest = "sha256:abc"
+	err := s.image.ValidateDigest()
+	if s.Error(err) {
+		s.Contains(err.Error(), `invalid digest "sha256:abc"`)
+	}
+}
+
+func (s *ImageConfigSuite) TestValidatePinDigestWithDigest() {
+	s.image.Digest = "sha256:" + strings.Repeat("a", 64)
+	s.image.PinDigest = true
+	err := s.image.Validate(NewPath("image"), NewConfig())
+	if s.Error(err) {
+		s.Contains(err.Error(), "image.pin-digest: can not be used with digest")
+	}
+}
+
+func (s *ImageConfigSuite) TestValidateSecretsBuildKitDisabled() {
+	s.image.Secrets = []string{"id=token,env=GITHUB_TOKEN"}
+	s.Nil(s.image.Buildkit.TransformConfig(reflect.ValueOf(false)))
+	err := s.image.Validate(NewPath("image"), NewConfig())
+	if s.Error(err) {
+		s.Contains(err.Error(), "image.secrets: secrets requires buildkit")
+	}
+}
+
+func (s *ImageConfigSuite) TestValidateContextsBuildKitDisabled() {
+	s.image.Contexts = map[string]string{"shared": "../shared"}
+	s.Nil(s.image.Buildkit.TransformConfig(reflect.ValueOf(false)))
+	err := s.image.Validate(NewPath("image"), NewConfig())
+	if s.Error(err) {
+		s.Contains(err.Error(), "image.contexts: named build contexts require buildkit")
+	}
+}
+
+func TestImageConfigExternalDockerfile(t *testing.T) {
+	var testcases = []struct {
+		dockerfile string
+		expected   string
+	}{
+		{dockerfile: "Dockerfile", expected: ""},
+		{dockerfile: "docker/../Dockerfile", expected: ""},
+		{dockerfile: "https://example.com/Dockerfile", expected: ""},
+		{dockerfile: "../Dockerfile", expected: "Dockerfile"},
+		{dockerfile: "../../ci/Dockerfile.build", expected: "../ci/Dockerfile.build"},
+		{dockerfile: "/work/Dockerfile", expected: "/work/Dockerfile"},
+	}
+	for _, testcase := range testcases {
+		image := &ImageConfig{Context: "app", Dockerfile: testcase.dockerfile}
+		assert.Equal(t, testcase.expected, image.ExternalDockerfile(), testcase.dockerfile)
+	}
+}
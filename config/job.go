@@ -2,12 +2,31 @@ package config
 
 import (
 	"fmt"
+	posixpath "path"
 	"reflect"
+	"regexp"
+	"strings"
 
 	"github.com/dnephin/dobi/execenv"
 	shlex "github.com/kballard/go-shellquote"
 )
 
+const netModeContainerPrefix = "container:"
+
+var (
+	captureRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+
+	shellRegex = regexp.MustCompile(`^[A-Za-z0-9_.+/-]+$`)
+
+	singleVariableRegex = regexp.MustCompile(`^\{[^{}]+\}$`)
+
+	userRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+(:[A-Za-z0-9_.-]+)?$`)
+
+	stopSignalRegex = regexp.MustCompile(`^([A-Z][A-Z0-9+]*|[0-9]+)$`)
+
+	macAddressRegex = regexp.MustCompile(`^([0-9A-Fa-f]{2}:){5}[0-9A-Fa-f]{2}$`)
+)
+
 // JobConfig A **job** resource uses an `image`_ to run a job in a conatiner.
 // A **job** resource that doesn't have an **artifact** is never considered
 // up-to-date and will always run.  If a job resource has an **artifact**
@@ -30,67 +49,392 @@ import (
 //
 type JobConfig struct {
 	// Use The name of an `image`_ resource. The referenced image is used
-	// to created the container for the **job**.
+	// to created the container for the **job**. This field supports
+	// :doc:`variables`.
 	Use string `config:"required"`
 	// Artifact A host path to a file or directory that is the output of this
-	// **job**. Paths are relative to the current working directory.
+	// **job**. Paths are relative to the current working directory. This
+	// field supports :doc:`variables`, which must not resolve to an empty
+	// path.
+	// example: ``dist/app{exe-suffix}``
 	Artifact string
-	// Command The command to run in the container.
-	// type: shell quoted string
-	// example: ``"bash -c 'echo something'"``
+	// ArtifactFromContainer An absolute path in the container which is
+	// copied to the **artifact** on the host after the container exits with a
+	// zero status. Use this field instead of a bind mount when the host paths
+	// are not available to the docker daemon, like when **dobi** runs in a
+	// container with a remote daemon. A directory is copied into the
+	// **artifact** directory, and a file is copied to the **artifact** file.
+	// Requires **artifact**, and can not be used with **wait-for**. This field
+	// supports :doc:`variables`.
+	// example: ``/go/bin/app``
+	ArtifactFromContainer string `config:"validate"`
+	// Command The command to run in the container. A command which is a single
+	// variable, like ``"{env.TEST_CMD}"``, is resolved and then split into
+	// arguments. Other commands are not resolved, so that braces in the
+	// command are passed to the container unchanged. The command can also be
+	// a list of arguments, which are used as they are, and are not resolved.
+	// type: shell quoted string, or list of strings
+	// example: ``"bash -c 'echo something'"``, or ``[bash, -c, echo something]``
 	Command ShlexSlice
+	// User The user, and optionally the group, used to run the command in the
+	// container, as ``uid``, ``uid:gid``, ``username``, or
+	// ``username:groupname``. This field supports :doc:`variables`.
+	// default: *the user of the image*
+	// example: ``"{user.uid}:{user.gid}"``
+	User string `config:"validate"`
+	// Groups Additional groups, by name or gid, for the user which runs the
+	// command in the container. Each item in the list supports
+	// :doc:`variables`.
+	// type: list of group names or ids
+	// example: ``[docker, '{env.VIDEO_GID}']``
+	Groups []string
+	// RunAsCurrentUser Run the command with the uid and gid of the user who
+	// runs **dobi**, so that files written to bind mounts are owned by that
+	// user instead of ``root``. This is the same as setting **user** to
+	// ``"{user.uid}:{user.gid}"``, and can not be used with **user**.
+	RunAsCurrentUser bool
 	// Entrypoint Override the image entrypoint
-	// type: shell quoted string
+	// type: shell quoted string, or list of strings
 	Entrypoint ShlexSlice
 	// Sources A list of files or directories which are used to create the
 	// artifact. The modified time of these files are compared to the modified time
 	// of the artifact to determine if the **job** is stale. If the **sources**
 	// list is defined the modified time of **mounts** and the **use** image are
-	// ignored.
+	// ignored. See **stale-check** to compare the contents of the files
+	// instead. This field supports :doc:`variables`.
 	// type: list of files or directories
+	// example: ``[src/, 'config/{env.GOOS}.yaml']``
 	Sources []string
+	// Artifacts A mapping of host paths, which are outputs of this **job**, to
+	// the list of files or directories used to create each of them. The
+	// **job** is stale if any artifact is older than its own sources, so a
+	// change to the sources of one artifact does not make the other artifacts
+	// stale. The **job** still runs as a single container, which creates every
+	// artifact. This field can not be used with **artifact** or **sources**.
+	// This field supports :doc:`variables`.
+	// type: mapping of host paths to lists of files or directories
+	// example: ``{dist/app: [cmd/, pkg/], dist/docs: [docs/]}``
+	Artifacts ArtifactSources `config:"validate"`
+	// StaleCheck How to determine if the **artifact** is stale. With ``mtime``
+	// the modified time of the **artifact** is compared to the modified time of
+	// the **sources**. With ``content`` a checksum of the contents of the
+	// **sources**, or of the sources of every item in **artifacts**, and of the
+	// command and entrypoint, is recorded in ``.dobi/jobs/`` after the **job**
+	// runs. The **job** is stale when the checksum changes, or when an artifact
+	// does not exist. When no sources are set the contents of the **mounts**
+	// are used, and the **job** is also stale when the **use** image changes.
+	// The **stale-check** field of `meta`_ sets the default for every **job**.
+	// default: ``mtime``
+	// example: ``content``
+	StaleCheck string `config:"validate"`
 	// Mounts A list of `mount`_ resources to use when creating the container.
 	// type: list of mount resources
 	Mounts []string
+	// VolumesFrom A list of `job`_ resources. The volumes of the container of
+	// each **job** are mounted in this container. The container of a **job**
+	// in this list is not removed when it exits, so that its volumes are
+	// available when this **job** runs. The container is removed by the
+	// ``rm`` action of the **job**, or before the **job** runs again.
+	// type: list of job resources
+	VolumesFrom []string
 	// Privileged Gives extended privileges to the container
 	Privileged bool
-	// Interactive Makes the container interative and enables a tty.
+	// CapAdd A list of Linux capabilities to add to the container. A
+	// **privileged** container has every capability, so this field has no
+	// effect when **privileged** is set.
+	// type: list of capability names
+	// example: ``[NET_ADMIN, SYS_PTRACE]``
+	CapAdd []string `config:"validate"`
+	// CapDrop A list of Linux capabilities to remove from the container. This
+	// field has no effect when **privileged** is set.
+	// type: list of capability names
+	// example: ``[MKNOD]``
+	CapDrop []string `config:"validate"`
+	// RunOnce Run the **job** only once for each project and exec-id, for
+	// setup like seeding a database. When the **job** succeeds the run is
+	// recorded in ``.dobi/state.yml`` with the value of ``{unique}`` (see
+	// :doc:`variables`), and later runs with the same ``{unique}`` skip the
+	// **job**. Run with ``--force``, or remove the state with ``dobi clean
+	// --state``, to run it again.
+	RunOnce bool
+	// Capture The name of a variable which is set to the output of the
+	// **command**. When the **job** runs, ``{capture.<name>}`` is set to the
+	// stdout of the container, with leading and trailing whitespace removed.
+	// Resources which depend on the **job** can use the variable in any field
+	// which supports :doc:`variables`. The value is saved in
+	// ``.dobi/state.yml``, and the saved value is used when the **job** is
+	// fresh and does not run. A **job** with **capture** can not be
+	// **interactive**, or set **wait-for**.
+	// type: variable name
+	// example: ``build.version``
+	Capture string `config:"validate"`
+	// Interactive Makes the container interative and enables a tty. The
+	// ``--interactive`` flag overrides this field for every job.
 	Interactive bool
 	// Depends The list of resources dependencies
 	// type: list of resource names
 	Depends []string
 	// Env Environment variables to pass to the container. This field
-	// supports :doc:`variables`.
+	// supports :doc:`variables`. Entries are resolved in order, so an entry
+	// can use the value of an earlier entry with ``{env.<key>}``.
 	// type: list of ``key=value`` strings
+	// example: ``['PATH=/tools:{env.PATH}']``
 	Env []string
+	// EnvFile A list of host files with environment variables to pass to the
+	// container. Each line of a file is a ``KEY=VALUE``. Blank lines, and
+	// lines which start with ``#``, are ignored. Paths are relative to the
+	// directory of the ``dobi.yaml``. Variables in **env** take precedence
+	// over variables from the files. This field supports :doc:`variables`.
+	// type: list of filepaths
+	EnvFile []string
 	// ProvideDocker Exposes the docker engine to the container by either
 	// mounting the unix socket or setting the **DOCKER_HOST** environment
 	// variable.
 	ProvideDocker bool
-	// NetMode The network mode to use. This field supports :doc:`variables`.
+	// ProvideSSHAgent Exposes the host SSH agent to the container by mounting
+	// the socket from **SSH_AUTH_SOCK** and setting the **SSH_AUTH_SOCK**
+	// environment variable in the container. The **job** fails if
+	// **SSH_AUTH_SOCK** is not set on the host.
+	ProvideSSHAgent bool `config:"provide-ssh-agent"`
+	// NetMode The network mode to use. One of ``host``, ``none``, ``bridge``,
+	// ``default``, ``container:<job>`` to use the network of the container for
+	// another **job** or `service`_ resource, or the name of a network created
+	// by a `compose`_ or `network`_ resource listed in **depends**. This field
+	// supports :doc:`variables`.
 	NetMode string
+	// MacAddress The MAC address of the container, as six pairs of hex digits
+	// separated by colons. This field supports :doc:`variables`.
+	// default: *a MAC address generated by Docker*
+	// example: ``"02:42:ac:11:00:02"``
+	MacAddress string `config:"validate"`
+	// Ports Publish ports of the container on the host. Each port is a
+	// ``container-port``, ``host-port:container-port``, or
+	// ``host-ip:host-port:container-port``, with an optional ``/tcp``,
+	// ``/udp``, or ``/sctp`` protocol. A port without a host port is
+	// published on a random port of the host. Each item in the list supports
+	// :doc:`variables`.
+	// type: list of ports
+	// example: ``["8080:80", "5432", "127.0.0.1:9000:9000/udp"]``
+	Ports []string `config:"validate"`
 	// WorkingDir The directory to set as the active working directory in the
 	// container. This field supports :doc:`variables`.
 	WorkingDir string
+	// Before A command to run before the **command** of the **job**, in a new
+	// container created from the same image, with the same mounts,
+	// environment, and network. It runs once, before the first attempt. When
+	// it exits with a non-zero status the **job** fails, and the **command**
+	// does not run. The command is run like the **command**, using the
+	// **entrypoint** or **shell** of the **job**.
+	// type: shell quoted string, or list of strings
+	// example: ``"./scripts/migrate.sh"``
+	Before ShlexSlice
+	// After A command to run after the **command** of the **job** succeeds, or
+	// the **wait-for** condition is met, in a new container created like the
+	// container for **before**. A failure of this command is logged, but does
+	// not fail the **job**.
+	// type: shell quoted string, or list of strings
+	// example: ``"./scripts/notify.sh success"``
+	After ShlexSlice
+	// OnFailure A command to run on the host when the **job** fails. The
+	// command runs after the container exits, but before it is removed, so it
+	// can be used to collect diagnostics. ``{job.container-id}`` in the command
+	// is replaced with the id of the failed container. A failure of this
+	// command is logged, but does not replace the error from the **job**.
+	// type: shell quoted string
+	// example: ``"docker logs {job.container-id}"``
+	OnFailure ShlexSlice
+	// Retries The number of times to run the command again, in a new
+	// container, when it exits with a non-zero status. The container of the
+	// failed attempt is removed before the next attempt starts, unless
+	// **retry-reuse-container** is set, and **on-failure** runs after each
+	// failed attempt.
+	// default: ``0``
+	Retries int `config:"validate"`
+	// Timeout The maximum time to wait for each attempt to run the command.
+	// When an attempt runs for longer, the container is stopped, and the
+	// attempt fails. When set, ``meta.default-timeout`` does not apply to the
+	// **job**.
+	// type: duration string
+	// example: ``10m``
+	// default: *no timeout*
+	Timeout duration
+	// RetryDelay The time to wait between attempts.
+	// type: duration string
+	// default: *no delay*
+	// example: ``5s``
+	RetryDelay duration
+	// RetryReuseContainer Run each retry by starting the container of the
+	// failed attempt again, instead of creating a new container. Files written
+	// by the failed attempt stay in the container, so caches are still warm
+	// for the next attempt. This is unsafe when the command is not idempotent,
+	// because each attempt starts from the state left by the one before it,
+	// like a partially written file, a stale lock file, or a database
+	// migration which was only partly applied. An attempt which times out is
+	// stopped and removed, so the next attempt runs in a new container.
+	// Requires **retries**.
+	RetryReuseContainer bool `config:"validate"`
+	// WaitFor A condition which is checked while the container runs, to wait
+	// for a service in the container to be ready. The condition is met when a
+	// connection to ``tcp`` succeeds, when a request to the ``http`` url
+	// returns a ``2xx`` or ``3xx`` status, or when the ``exec`` command exits
+	// with a zero status in the container. Exactly one of ``tcp``, ``http``,
+	// or ``exec`` must be set. ``tcp`` may be ``host:port`` or
+	// ``tcp://host:port``. ``tcp`` and ``http`` support :doc:`variables`.
+	// When **wait-for** is set the **job** is complete once the condition is
+	// met, and the container keeps running until all the tasks are done. The
+	// **job** fails if the container exits, or the condition is not met before
+	// the ``timeout``.
+	// type: mapping with keys ``tcp``, ``http``, ``exec``, and ``timeout``
+	// default: ``timeout: 1m``
+	// example: ``{tcp: "localhost:5432", timeout: 30s}``
+	WaitFor WaitFor `config:"validate"`
+	// StopSignal The signal sent to the container to stop it, when the
+	// container of a **job** with **wait-for** is stopped after all the tasks
+	// are done, or by the ``stop`` action.
+	// default: *the stop signal of the image, or* ``SIGTERM``
+	// example: ``SIGINT``
+	StopSignal string `config:"validate"`
+	// ForwardSignals Send ``SIGINT`` and ``SIGTERM`` received by **dobi** to
+	// the container while the **job** runs. ``SIGTERM`` is sent as the
+	// **stop-signal** instead, when it is a number, or one of ``SIGABRT``,
+	// ``SIGALRM``, ``SIGHUP``, ``SIGINT``, ``SIGKILL``, or ``SIGQUIT``. When
+	// ``false`` the signals are not sent to the container.
+	// default: ``true``
+	ForwardSignals defaultTrue
+	// StopTimeout The time to wait for the container to exit after the
+	// **stop-signal** is sent, before the container is killed. The containers
+	// of jobs with **wait-for** are stopped one at a time, in the reverse
+	// order of their dependencies, so a service is stopped before the
+	// services it depends on.
+	// type: duration string
+	// default: ``10s``
+	StopTimeout duration
+	// OOMKillDisable Disables the OOM killer for the container.
+	OOMKillDisable bool `config:"oom-kill-disable"`
+	// OOMScoreAdj Adjusts the preference of the OOM killer for killing the
+	// container. Must be between ``-1000`` and ``1000``. A higher value makes
+	// the container more likely to be killed.
+	OOMScoreAdj int `config:"oom-score-adj,validate"`
+	// PidsLimit The maximum number of processes the container can create.
+	// Must be a positive number.
+	// default: *no limit*
+	PidsLimit int `config:"validate"`
+	// Memory The maximum amount of memory the container can use, as a number
+	// of bytes with an optional unit of ``b``, ``k``, ``m``, or ``g``. This
+	// field supports :doc:`variables`.
+	// default: *no limit*
+	// example: ``2g``
+	Memory string `config:"validate"`
+	// Cpus The number of cpus the container can use, which may be a fraction.
+	// This field supports :doc:`variables`.
+	// default: *no limit*
+	// example: ``1.5``
+	Cpus string `config:"validate"`
+	// Ulimits Resource limits of the container, in the form
+	// ``name=soft[:hard]``. When the hard limit is not set it is the same as
+	// the soft limit. This field supports :doc:`variables`.
+	// type: list of ulimits
+	// example: ``[nofile=1024:4096, nproc=512]``
+	Ulimits []string `config:"validate"`
+	// AllowShadowedMounts Allow more than one of the **mounts** to use the
+	// same container path. By default this is an error, because only the
+	// last of the mounts is visible in the container.
+	AllowShadowedMounts bool
+	// Shell The shell used to run the **command**, as ``<shell> -c
+	// <command>``. Overrides ``meta.default-shell``. Can not be used with
+	// **entrypoint**.
+	// type: shell quoted string
+	// example: ``bash``
+	Shell ShlexSlice `config:"validate"`
 }
 
 // Dependencies returns the list of implicit and explicit dependencies
 func (c *JobConfig) Dependencies() []string {
-	return append([]string{c.Use}, append(c.Depends, c.Mounts...)...)
+	deps := append([]string{c.Use}, append(c.Depends, c.Mounts...)...)
+	return append(deps, c.VolumesFrom...)
 }
 
 // Validate checks that all fields have acceptable values
 func (c *JobConfig) Validate(path Path, config *Config) *PathError {
-	if err := c.validateUse(config); err != nil {
-		return PathErrorf(path.add("use"), err.Error())
+	if err := c.validateReferences(path, config); err != nil {
+		return err
 	}
 	if err := c.validateMounts(config); err != nil {
 		return PathErrorf(path.add("mounts"), err.Error())
 	}
+	if err := c.validateVolumesFrom(config); err != nil {
+		return PathErrorf(path.add("volumes-from"), err.Error())
+	}
+	if err := c.validateCapture(config); err != nil {
+		return PathErrorf(path.add("capture"), err.Error())
+	}
+	if c.RunAsCurrentUser && c.User != "" {
+		return PathErrorf(path.add("run-as-current-user"), "can not be used with user")
+	}
+	if !c.WaitFor.IsZero() && c.Interactive {
+		return PathErrorf(path.add("wait-for"), "can not be used with interactive")
+	}
+	if !c.Artifacts.Empty() && (c.Artifact != "" || len(c.Sources) != 0) {
+		return PathErrorf(path.add("artifacts"), "can not be used with artifact or sources")
+	}
+	if c.ArtifactFromContainer != "" {
+		switch {
+		case c.Artifact == "":
+			return PathErrorf(path.add("artifact-from-container"), "requires artifact")
+		case !c.WaitFor.IsZero():
+			return PathErrorf(path.add("artifact-from-container"),
+				"can not be used with wait-for")
+		}
+	}
+	return nil
+}
+
+// ValidateArtifactFromContainer validates that ArtifactFromContainer is an
+// absolute path, unless it contains variables
+func (c *JobConfig) ValidateArtifactFromContainer() error {
+	return validateContainerPath(c.ArtifactFromContainer)
+}
+
+func validateContainerPath(value string) error {
+	if value == "" || hasVariables(value) {
+		return nil
+	}
+	if !posixpath.IsAbs(value) {
+		return fmt.Errorf("%q must be an absolute path", value)
+	}
+	return nil
+}
+
+// ValidateArtifacts validates that every artifact has sources
+func (c *JobConfig) ValidateArtifacts() error {
+	return c.Artifacts.Validate()
+}
+
+// ArtifactPaths returns the host paths of the artifacts of the job, from either
+// artifact or artifacts
+func (c *JobConfig) ArtifactPaths() []string {
+	if c.Artifact != "" {
+		return []string{c.Artifact}
+	}
+	return c.Artifacts.Paths()
+}
+
+// validateReferences validates the fields which may reference other resources
+// using variables
+func (c *JobConfig) validateReferences(path Path, config *Config) *PathError {
+	if err := c.validateUse(config); err != nil {
+		return PathErrorf(path.add("use"), err.Error())
+	}
+	if err := c.validateNetMode(config); err != nil {
+		return PathErrorf(path.add("net-mode"), err.Error())
+	}
 	return nil
 }
 
 func (c *JobConfig) validateUse(config *Config) error {
+	if hasVariables(c.Use) {
+		return nil
+	}
 	err := fmt.Errorf("%s is not an image resource", c.Use)
 
 	res, ok := config.Resources[c.Use]
@@ -98,8 +442,14 @@ func (c *JobConfig) validateUse(config *Config) error {
 		return err
 	}
 
-	switch res.(type) {
+	switch res := res.(type) {
 	case *ImageConfig:
+	case *AliasConfig:
+		if config.matrixes[c.Use] == "image" {
+			return fmt.Errorf("%s is a matrix of images, use one of the images "+
+				"from the matrix: %s", c.Use, strings.Join(res.Tasks, ", "))
+		}
+		return err
 	default:
 		return err
 	}
@@ -125,10 +475,353 @@ func (c *JobConfig) validateMounts(config *Config) error {
 	return nil
 }
 
+func (c *JobConfig) validateVolumesFrom(config *Config) error {
+	for _, name := range c.VolumesFrom {
+		if _, ok := config.Resources[name].(*JobConfig); !ok {
+			return fmt.Errorf("%s is not a job resource", name)
+		}
+	}
+	return nil
+}
+
+// validateMountPaths checks that each of the mounts uses a different container
+// path. The mount resources may not be resolved yet, so the paths are resolved
+// with env.
+func (c *JobConfig) validateMountPaths(config *Config, env *execenv.ExecEnv) error {
+	if c.AllowShadowedMounts {
+		return nil
+	}
+	names := make(map[string]string)
+	for _, name := range c.Mounts {
+		mount, ok := config.Resources[name].(*MountConfig)
+		if !ok {
+			continue
+		}
+		mountPath, err := env.Resolve(mount.Path)
+		if err != nil {
+			return err
+		}
+		mountPath = posixpath.Clean(mountPath)
+		if other, ok := names[mountPath]; ok {
+			return fmt.Errorf(
+				"%s and %s both mount %q, set allow-shadowed-mounts to allow this",
+				other, name, mountPath)
+		}
+		names[mountPath] = name
+	}
+	return nil
+}
+
+var builtinNetModes = map[string]bool{
+	"":        true,
+	"host":    true,
+	"none":    true,
+	"bridge":  true,
+	"default": true,
+}
+
+// ValidateOOMScoreAdj validates that OOMScoreAdj is in the range accepted by
+// the kernel
+func (c *JobConfig) ValidateOOMScoreAdj() error {
+	if c.OOMScoreAdj < -1000 || c.OOMScoreAdj > 1000 {
+		return fmt.Errorf("must be between -1000 and 1000, not %d", c.OOMScoreAdj)
+	}
+	return nil
+}
+
+// ValidateUser validates that User is a user, and an optional group, unless it
+// contains variables
+func (c *JobConfig) ValidateUser() error {
+	if hasVariables(c.User) {
+		return nil
+	}
+	return validateUser(c.User)
+}
+
+func validateUser(user string) error {
+	if user != "" && !userRegex.MatchString(user) {
+		return fmt.Errorf("invalid user %q, must be one of: uid, uid:gid, "+
+			"username, username:groupname", user)
+	}
+	return nil
+}
+
+// ValidateMacAddress validates that MacAddress is a MAC address, unless it
+// contains variables
+func (c *JobConfig) ValidateMacAddress() error {
+	if hasVariables(c.MacAddress) {
+		return nil
+	}
+	return validateMacAddress(c.MacAddress)
+}
+
+func validateMacAddress(address string) error {
+	if address != "" && !macAddressRegex.MatchString(address) {
+		return fmt.Errorf("invalid MAC address %q, must be six pairs of hex "+
+			"digits separated by colons, like 02:42:ac:11:00:02", address)
+	}
+	return nil
+}
+
+// ValidatePorts validates that each port which does not contain variables is
+// a valid port mapping
+func (c *JobConfig) ValidatePorts() error {
+	ports := []string{}
+	for _, port := range c.Ports {
+		if !hasVariables(port) {
+			ports = append(ports, port)
+		}
+	}
+	return validatePorts(ports)
+}
+
+// ValidateStaleCheck validates that StaleCheck is mtime or content
+func (c *JobConfig) ValidateStaleCheck() error {
+	return validateStaleCheck(c.StaleCheck)
+}
+
+func validateStaleCheck(value string) error {
+	switch value {
+	case "", "mtime", "content":
+		return nil
+	}
+	return fmt.Errorf("invalid stale-check %q, must be one of: mtime, content", value)
+}
+
+// ValidateCapture validates that Capture is a valid variable name
+func (c *JobConfig) ValidateCapture() error {
+	if c.Capture != "" && !captureRegex.MatchString(c.Capture) {
+		return fmt.Errorf("invalid variable name %q, must be one or more "+
+			"words separated by a dot", c.Capture)
+	}
+	return nil
+}
+
+// validateCapture validates that a job with capture is not interactive, and
+// that no other job captures the same variable
+func (c *JobConfig) validateCapture(config *Config) error {
+	if c.Capture == "" {
+		return nil
+	}
+	if c.Interactive {
+		return fmt.Errorf("can not be used with interactive")
+	}
+	if !c.WaitFor.IsZero() {
+		return fmt.Errorf("can not be used with wait-for")
+	}
+	for _, name := range config.Sorted() {
+		other, ok := config.Resources[name].(*JobConfig)
+		if ok && other != c && other.Capture == c.Capture {
+			return fmt.Errorf("%q is already captured by %q", c.Capture, name)
+		}
+	}
+	return nil
+}
+
+// ValidateWaitFor validates that exactly one condition is set in WaitFor
+func (c *JobConfig) ValidateWaitFor() error {
+	return c.WaitFor.Validate()
+}
+
+// ValidateRetries validates that Retries is not negative
+func (c *JobConfig) ValidateRetries() error {
+	if c.Retries < 0 {
+		return fmt.Errorf("must not be negative, not %d", c.Retries)
+	}
+	return nil
+}
+
+// ValidateRetryReuseContainer validates that RetryReuseContainer is only set
+// for a job with retries
+func (c *JobConfig) ValidateRetryReuseContainer() error {
+	if c.RetryReuseContainer && c.Retries == 0 {
+		return fmt.Errorf("can only be set when retries is set")
+	}
+	return nil
+}
+
+// ValidateStopSignal validates that StopSignal is a signal name or number
+func (c *JobConfig) ValidateStopSignal() error {
+	if c.StopSignal == "" || stopSignalRegex.MatchString(c.StopSignal) {
+		return nil
+	}
+	return fmt.Errorf("invalid stop signal %q, must be a signal name like SIGTERM, or a number",
+		c.StopSignal)
+}
+
+// ValidatePidsLimit validates that PidsLimit is positive, if it is set
+func (c *JobConfig) ValidatePidsLimit() error {
+	if c.PidsLimit < 0 {
+		return fmt.Errorf("must be a positive number, not %d", c.PidsLimit)
+	}
+	return nil
+}
+
+// linuxCapabilities are the names of the Linux capabilities, without the CAP_
+// prefix, and ALL
+var linuxCapabilities = map[string]bool{
+	"ALL": true, "AUDIT_CONTROL": true, "AUDIT_READ": true, "AUDIT_WRITE": true,
+	"BLOCK_SUSPEND": true, "BPF": true, "CHECKPOINT_RESTORE": true, "CHOWN": true,
+	"DAC_OVERRIDE": true, "DAC_READ_SEARCH": true, "FOWNER": true, "FSETID": true,
+	"IPC_LOCK": true, "IPC_OWNER": true, "KILL": true, "LEASE": true,
+	"LINUX_IMMUTABLE": true, "MAC_ADMIN": true, "MAC_OVERRIDE": true, "MKNOD": true,
+	"NET_ADMIN": true, "NET_BIND_SERVICE": true, "NET_BROADCAST": true,
+	"NET_RAW": true, "PERFMON": true, "SETFCAP": true, "SETGID": true,
+	"SETPCAP": true, "SETUID": true, "SYS_ADMIN": true, "SYS_BOOT": true,
+	"SYS_CHROOT": true, "SYS_MODULE": true, "SYS_NICE": true, "SYS_PACCT": true,
+	"SYS_PTRACE": true, "SYS_RAWIO": true, "SYS_RESOURCE": true, "SYS_TIME": true,
+	"SYS_TTY_CONFIG": true, "SYSLOG": true, "WAKE_ALARM": true,
+}
+
+// ValidateCapAdd validates that each capability is a Linux capability
+func (c *JobConfig) ValidateCapAdd() error {
+	return validateCapabilities(c.CapAdd)
+}
+
+// ValidateCapDrop validates that each capability is a Linux capability
+func (c *JobConfig) ValidateCapDrop() error {
+	return validateCapabilities(c.CapDrop)
+}
+
+func validateCapabilities(capabilities []string) error {
+	for _, capability := range capabilities {
+		name := strings.TrimPrefix(strings.ToUpper(capability), "CAP_")
+		if !linuxCapabilities[name] {
+			return fmt.Errorf("unknown capability %q", capability)
+		}
+	}
+	return nil
+}
+
+// ValidateMemory validates that Memory is a number of bytes, unless it
+// contains variables
+func (c *JobConfig) ValidateMemory() error {
+	if hasVariables(c.Memory) {
+		return nil
+	}
+	return validateMemory(c.Memory)
+}
+
+// ValidateCpus validates that Cpus is a positive number, unless it contains
+// variables
+func (c *JobConfig) ValidateCpus() error {
+	if hasVariables(c.Cpus) {
+		return nil
+	}
+	return validateCPUs(c.Cpus)
+}
+
+// ValidateUlimits validates that each ulimit is of the form name=soft[:hard],
+// unless it contains variables
+func (c *JobConfig) ValidateUlimits() error {
+	for _, ulimit := range c.Ulimits {
+		if hasVariables(ulimit) {
+			continue
+		}
+		if _, err := parseUlimit(ulimit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemoryBytes returns Memory as a number of bytes, or 0 if it is not set
+func (c *JobConfig) MemoryBytes() int64 {
+	if c.Memory == "" {
+		return 0
+	}
+	memory, _ := parseMemory(c.Memory)
+	return memory
+}
+
+// CPUQuota returns the CFS period and quota which limit the container to Cpus,
+// or zeros if Cpus is not set
+func (c *JobConfig) CPUQuota() (int64, int64) {
+	if c.Cpus == "" {
+		return 0, 0
+	}
+	cpus, _ := parseCPUs(c.Cpus)
+	return cpuPeriod, int64(cpus * cpuPeriod)
+}
+
+// UlimitValues returns the parsed Ulimits
+func (c *JobConfig) UlimitValues() []Ulimit {
+	ulimits := []Ulimit{}
+	for _, value := range c.Ulimits {
+		ulimit, _ := parseUlimit(value)
+		ulimits = append(ulimits, ulimit)
+	}
+	return ulimits
+}
+
+// ValidateShell validates that the shell is not set with an entrypoint
+func (c *JobConfig) ValidateShell() error {
+	if c.Shell.Empty() {
+		return nil
+	}
+	if !c.Entrypoint.Empty() {
+		return fmt.Errorf("can not be set when entrypoint is set")
+	}
+	return validateShell(&c.Shell)
+}
+
+// validateShell validates that the first word of the shell is the name or path
+// of an executable
+func validateShell(shell *ShlexSlice) error {
+	args := shell.Value()
+	if len(args) == 0 || !shellRegex.MatchString(args[0]) {
+		return fmt.Errorf("invalid shell %q, must be the name or path of a shell",
+			shell.String())
+	}
+	return nil
+}
+
+// validateNetMode checks that a net-mode which references a container or a
+// named network references a resource in the config. Values with variables
+// can't be checked until they are resolved.
+func (c *JobConfig) validateNetMode(config *Config) error {
+	if builtinNetModes[c.NetMode] || hasVariables(c.NetMode) {
+		return nil
+	}
+
+	if strings.HasPrefix(c.NetMode, netModeContainerPrefix) {
+		name := strings.TrimPrefix(c.NetMode, netModeContainerPrefix)
+		switch config.Resources[name].(type) {
+		case *JobConfig, *ServiceConfig:
+			return nil
+		}
+		return fmt.Errorf("%s is not a job or service resource", name)
+	}
+
+	for _, dep := range c.Depends {
+		switch resource := config.Resources[dep].(type) {
+		case *ComposeConfig:
+			return nil
+		case *NetworkConfig:
+			if resource.Name == c.NetMode || hasVariables(resource.Name) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf(
+		"network %q is not created by any compose or network resource in depends",
+		c.NetMode)
+}
+
+// NetModeContainer returns the name of the job resource used for the network
+// of this container, or an empty string if net-mode does not reference
+// a container.
+func (c *JobConfig) NetModeContainer() string {
+	if !strings.HasPrefix(c.NetMode, netModeContainerPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(c.NetMode, netModeContainerPrefix)
+}
+
 func (c *JobConfig) String() string {
 	artifact, command := "", ""
-	if c.Artifact != "" {
-		artifact = fmt.Sprintf(" to create '%s'", c.Artifact)
+	if paths := c.ArtifactPaths(); len(paths) != 0 {
+		artifact = fmt.Sprintf(" to create '%s'", strings.Join(paths, "', '"))
 	}
 	// TODO: look for entrypoint as well as command
 	if !c.Command.Empty() {
@@ -139,17 +832,32 @@ func (c *JobConfig) String() string {
 
 // Resolve resolves variables in the resource
 func (c *JobConfig) Resolve(env *execenv.ExecEnv) (Resource, error) {
-	var err error
-	c.Env, err = env.ResolveSlice(c.Env)
-	if err != nil {
-		return c, err
-	}
-	c.WorkingDir, err = env.Resolve(c.WorkingDir)
-	if err != nil {
-		return c, err
+	resolver := newFieldResolver(env)
+	c.Use = resolver.resolve("use", c.Use)
+	c.Command = resolver.resolveCommand("command", c.Command)
+	c.Before = resolver.resolveCommand("before", c.Before)
+	c.After = resolver.resolveCommand("after", c.After)
+	if c.RunAsCurrentUser && c.User == "" {
+		c.User = "{user.uid}:{user.gid}"
 	}
-	c.NetMode, err = env.Resolve(c.NetMode)
-	return c, err
+	c.User = resolver.resolve("user", c.User)
+	c.Groups = resolver.resolveSlice("groups", c.Groups)
+	c.Artifact = resolver.resolveNonEmpty("artifact", c.Artifact)
+	c.ArtifactFromContainer = resolver.resolve("artifact-from-container", c.ArtifactFromContainer)
+	c.Sources = resolver.resolvePaths("sources", c.Sources)
+	c.Artifacts = c.Artifacts.resolve(resolver, "artifacts")
+	c.Env = resolver.resolveEnv("env", c.Env)
+	c.EnvFile = resolver.resolveSlice("env-file", c.EnvFile)
+	c.WorkingDir = resolver.resolve("working-dir", c.WorkingDir)
+	c.NetMode = resolver.resolve("net-mode", c.NetMode)
+	c.MacAddress = resolver.resolve("mac-address", c.MacAddress)
+	c.Ports = resolver.resolveSlice("ports", c.Ports)
+	c.Memory = resolver.resolve("memory", c.Memory)
+	c.Cpus = resolver.resolve("cpus", c.Cpus)
+	c.Ulimits = resolver.resolveSlice("ulimits", c.Ulimits)
+	c.WaitFor.TCP = resolver.resolve("wait-for.tcp", c.WaitFor.TCP)
+	c.WaitFor.HTTP = resolver.resolve("wait-for.http", c.WaitFor.HTTP)
+	return c, resolver.err()
 }
 
 // ShlexSlice is a type used for config transforming a string into a []string
@@ -174,22 +882,49 @@ func (s *ShlexSlice) Empty() bool {
 }
 
 // TransformConfig is used to transform a string from a config file into a
-// sliced value, using shlex.
+// sliced value, using shlex. A list of strings is used as the sliced value,
+// and the original value is the list joined as a shell quoted string.
 func (s *ShlexSlice) TransformConfig(raw reflect.Value) error {
-	var err error
 	switch value := raw.Interface().(type) {
 	case string:
-		s.original = value
-		s.parsed, err = shlex.Split(value)
-		if err != nil {
-			return fmt.Errorf("failed to parse command %q: %s", value, err)
-		}
+		return s.set(value)
+	case []interface{}:
+		return s.setList(value)
 	default:
-		return fmt.Errorf("must be a string, not %T", value)
+		return fmt.Errorf("must be a string or list of strings, not %T", value)
 	}
+}
+
+func (s *ShlexSlice) setList(values []interface{}) error {
+	parsed := []string{}
+	for index, value := range values {
+		item, ok := value.(string)
+		if !ok {
+			return fmt.Errorf(
+				"must be a string or list of strings, item %d is %T", index, value)
+		}
+		parsed = append(parsed, item)
+	}
+	s.original = shlex.Join(parsed...)
+	s.parsed = parsed
+	return nil
+}
+
+func (s *ShlexSlice) set(value string) error {
+	parsed, err := shlex.Split(value)
+	if err != nil {
+		return fmt.Errorf("failed to parse command %q: %s", value, err)
+	}
+	s.original = value
+	s.parsed = parsed
 	return nil
 }
 
+// isVariable returns true if the value is a single variable
+func (s *ShlexSlice) isVariable() bool {
+	return singleVariableRegex.MatchString(s.original)
+}
+
 func jobFromConfig(name string, values map[string]interface{}) (Resource, error) {
 	cmd := &JobConfig{}
 	return cmd, Transform(name, values, cmd)
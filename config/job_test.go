@@ -1,8 +1,13 @@
 package config
 
 import (
+	"fmt"
+	"os"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/dnephin/dobi/execenv"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -48,6 +53,105 @@ func (s *JobConfigSuite) TestValidateMissingMount() {
 	s.Contains(err.Error(), "one is not a mount resource")
 }
 
+func (s *JobConfigSuite) TestValidateNetModeBuiltin() {
+	s.conf.Resources["example"] = NewImageConfig()
+	s.job.Use = "example"
+
+	for _, mode := range []string{"", "host", "none", "bridge", "default", "{env.NET}"} {
+		s.job.NetMode = mode
+		s.Nil(s.job.Validate(NewPath(""), s.conf))
+	}
+}
+
+func (s *JobConfigSuite) TestValidateNetModeContainer() {
+	s.conf.Resources["example"] = NewImageConfig()
+	s.conf.Resources["db"] = &JobConfig{}
+	s.job.Use = "example"
+
+	s.job.NetMode = "container:db"
+	s.Nil(s.job.Validate(NewPath(""), s.conf))
+
+	s.job.NetMode = "container:example"
+	err := s.job.Validate(NewPath("res"), s.conf)
+	s.Error(err)
+	s.Contains(err.Error(), "res.net-mode: example is not a job or service resource")
+}
+
+func (s *JobConfigSuite) TestValidateVolumesFrom() {
+	s.conf.Resources["example"] = NewImageConfig()
+	s.conf.Resources["build"] = &JobConfig{}
+	s.job.Use = "example"
+
+	s.job.VolumesFrom = []string{"build"}
+	s.Nil(s.job.Validate(NewPath(""), s.conf))
+	s.Equal([]string{"example", "build"}, s.job.Dependencies())
+
+	s.job.VolumesFrom = []string{"build", "example"}
+	err := s.job.Validate(NewPath("res"), s.conf)
+	s.Error(err)
+	s.Contains(err.Error(), "res.volumes-from: example is not a job resource")
+}
+
+func (s *JobConfigSuite) TestValidateCapture() {
+	for _, value := range []string{"", "version", "build.version", "build_1.git-sha"} {
+		s.job.Capture = value
+		s.Nil(s.job.ValidateCapture())
+	}
+	for _, value := range []string{".version", "build.", "build..version", "a:b", "{x}"} {
+		s.job.Capture = value
+		s.Error(s.job.ValidateCapture(), value)
+	}
+}
+
+func (s *JobConfigSuite) TestValidateCaptureConflicts() {
+	s.conf.Resources["example"] = NewImageConfig()
+	s.conf.Resources["other"] = &JobConfig{Use: "example", Capture: "version"}
+	s.conf.Resources["job"] = s.job
+	s.job.Use = "example"
+	s.job.Capture = "build.version"
+	s.Nil(s.job.Validate(NewPath("job"), s.conf))
+
+	s.job.Interactive = true
+	err := s.job.Validate(NewPath("job"), s.conf)
+	s.Error(err)
+	s.Contains(err.Error(), "job.capture: can not be used with interactive")
+
+	s.job.Interactive = false
+	s.job.Capture = "version"
+	err = s.job.Validate(NewPath("job"), s.conf)
+	s.Error(err)
+	s.Contains(err.Error(), `job.capture: "version" is already captured by "other"`)
+}
+
+func (s *JobConfigSuite) TestValidateNetModeNetwork() {
+	s.conf.Resources["example"] = NewImageConfig()
+	s.conf.Resources["devenv"] = &ComposeConfig{}
+	s.job.Use = "example"
+	s.job.NetMode = "devenv_default"
+
+	err := s.job.Validate(NewPath("res"), s.conf)
+	s.Error(err)
+	s.Contains(err.Error(), "is not created by any compose or network resource")
+
+	s.job.Depends = []string{"devenv"}
+	s.Nil(s.job.Validate(NewPath(""), s.conf))
+}
+
+func (s *JobConfigSuite) TestValidateNetModeNetworkResource() {
+	s.conf.Resources["example"] = NewImageConfig()
+	s.conf.Resources["backend"] = &NetworkConfig{Name: "backend-net"}
+	s.job.Use = "example"
+	s.job.Depends = []string{"backend"}
+
+	s.job.NetMode = "backend-net"
+	s.Nil(s.job.Validate(NewPath(""), s.conf))
+
+	s.job.NetMode = "other-net"
+	err := s.job.Validate(NewPath("res"), s.conf)
+	s.Error(err)
+	s.Contains(err.Error(), `network "other-net" is not created by any compose or network resource`)
+}
+
 func (s *JobConfigSuite) TestRunFromConfig() {
 	values := map[string]interface{}{
 		"use":        "image-res",
@@ -63,3 +167,670 @@ func (s *JobConfigSuite) TestRunFromConfig() {
 	s.Equal(job.Command.Value(), []string{"echo", "foo"})
 	s.Equal(job.Entrypoint.Value(), []string{"bash", "-c"})
 }
+
+func (s *JobConfigSuite) TestValidateOOMScoreAdj() {
+	for _, value := range []int{-1000, 0, 1000} {
+		s.job.OOMScoreAdj = value
+		s.Nil(s.job.ValidateOOMScoreAdj())
+	}
+}
+
+func (s *JobConfigSuite) TestValidateOOMScoreAdjOutOfRange() {
+	for _, value := range []int{-1001, 1001} {
+		s.job.OOMScoreAdj = value
+		err := s.job.ValidateOOMScoreAdj()
+		s.Error(err)
+		s.Contains(err.Error(), "must be between -1000 and 1000")
+	}
+}
+
+func (s *JobConfigSuite) TestValidateRetries() {
+	s.job.Retries = 3
+	s.Nil(s.job.ValidateRetries())
+
+	s.job.Retries = -1
+	err := s.job.ValidateRetries()
+	if s.Error(err) {
+		s.Contains(err.Error(), "must not be negative, not -1")
+	}
+}
+
+func (s *JobConfigSuite) TestValidateRetryReuseContainer() {
+	s.job.RetryReuseContainer = true
+	err := s.job.ValidateRetryReuseContainer()
+	if s.Error(err) {
+		s.Contains(err.Error(), "can only be set when retries is set")
+	}
+
+	s.job.Retries = 2
+	s.Nil(s.job.ValidateRetryReuseContainer())
+}
+
+func (s *JobConfigSuite) TestJobFromConfigRetryDelay() {
+	resource, err := jobFromConfig("job=test", map[string]interface{}{
+		"use": "builder", "retries": 2, "retry-delay": "5s",
+	})
+	s.Require().Nil(err)
+	s.Equal(5*time.Second, resource.(*JobConfig).RetryDelay.Duration())
+
+	_, err = jobFromConfig("job=test", map[string]interface{}{
+		"use": "builder", "retry-delay": "soon",
+	})
+	if s.Error(err) {
+		s.Contains(err.Error(), `Error at job=test.retry-delay: invalid duration "soon"`)
+	}
+}
+
+func (s *JobConfigSuite) TestJobFromConfigWaitFor() {
+	resource, err := jobFromConfig("job=db", map[string]interface{}{
+		"use": "postgres",
+		"wait-for": map[interface{}]interface{}{
+			"exec": "pg_isready -q", "timeout": "30s",
+		},
+	})
+	s.Require().Nil(err)
+	waitFor := resource.(*JobConfig).WaitFor
+	s.Equal([]string{"pg_isready", "-q"}, waitFor.Exec.Value())
+	s.Equal(30*time.Second, waitFor.Timeout())
+	s.Equal("exec pg_isready -q", waitFor.String())
+
+	_, err = jobFromConfig("job=db", map[string]interface{}{
+		"use": "postgres",
+		"wait-for": map[interface{}]interface{}{
+			"tcp": "localhost:5432", "timeout": "later",
+		},
+	})
+	if s.Error(err) {
+		s.Contains(err.Error(), `Error at job=db.wait-for: invalid duration "later"`)
+	}
+}
+
+func (s *JobConfigSuite) TestValidateWaitFor() {
+	s.Nil(s.job.ValidateWaitFor())
+	s.Equal(time.Minute, s.job.WaitFor.Timeout())
+
+	s.job.WaitFor = WaitFor{TCP: "localhost:5432"}
+	s.Nil(s.job.ValidateWaitFor())
+
+	s.job.WaitFor.Exec = ShlexSlice{original: "pg_isready"}
+	err := s.job.ValidateWaitFor()
+	if s.Error(err) {
+		s.Contains(err.Error(), "only one of tcp, http, or exec can be set")
+	}
+
+	s.job.WaitFor = WaitFor{timeout: duration{value: time.Second}}
+	err = s.job.ValidateWaitFor()
+	if s.Error(err) {
+		s.Contains(err.Error(), "one of tcp, http, or exec is required")
+	}
+}
+
+func (s *JobConfigSuite) TestResolveWaitForTCP() {
+	defer os.Unsetenv("DOBI_TEST_DB_PORT")
+	os.Setenv("DOBI_TEST_DB_PORT", "5432")
+	s.job.WaitFor = WaitFor{TCP: "localhost:{env.DOBI_TEST_DB_PORT}"}
+
+	resolved, err := s.job.Resolve(execenv.NewExecEnv("exec", "project", "."))
+	s.Nil(err)
+	s.Equal("localhost:5432", resolved.(*JobConfig).WaitFor.TCP)
+}
+
+func (s *JobConfigSuite) TestValidateStopSignal() {
+	for _, value := range []string{"", "SIGTERM", "TERM", "SIGRTMIN+3", "15"} {
+		s.job.StopSignal = value
+		s.Nil(s.job.ValidateStopSignal())
+	}
+	for _, value := range []string{"sigterm", "SIG TERM", "-9"} {
+		s.job.StopSignal = value
+		err := s.job.ValidateStopSignal()
+		if s.Error(err) {
+			s.Contains(err.Error(), "invalid stop signal")
+		}
+	}
+}
+
+func (s *JobConfigSuite) TestValidatePidsLimit() {
+	for _, value := range []int{0, 1, 512} {
+		s.job.PidsLimit = value
+		s.Nil(s.job.ValidatePidsLimit())
+	}
+
+	s.job.PidsLimit = -1
+	err := s.job.ValidatePidsLimit()
+	if s.Error(err) {
+		s.Contains(err.Error(), "must be a positive number, not -1")
+	}
+}
+
+func (s *JobConfigSuite) TestValidateStaleCheck() {
+	for _, value := range []string{"", "mtime", "content"} {
+		s.job.StaleCheck = value
+		s.Nil(s.job.ValidateStaleCheck())
+	}
+
+	s.job.StaleCheck = "hash"
+	err := s.job.ValidateStaleCheck()
+	if s.Error(err) {
+		s.Contains(err.Error(), `invalid stale-check "hash"`)
+	}
+}
+
+func (s *JobConfigSuite) TestValidateMemory() {
+	for _, value := range []string{"", "1024", "512m", "2G", "{env.MEMORY}"} {
+		s.job.Memory = value
+		s.Nil(s.job.ValidateMemory())
+	}
+
+	s.job.Memory = "2gb"
+	err := s.job.ValidateMemory()
+	if s.Error(err) {
+		s.Contains(err.Error(), `invalid memory "2gb"`)
+	}
+}
+
+func (s *JobConfigSuite) TestValidateCpus() {
+	for _, value := range []string{"", "1", "0.5", "{env.CPUS}"} {
+		s.job.Cpus = value
+		s.Nil(s.job.ValidateCpus())
+	}
+
+	for _, value := range []string{"0", "-1", "two"} {
+		s.job.Cpus = value
+		err := s.job.ValidateCpus()
+		if s.Error(err) {
+			s.Contains(err.Error(), "must be a positive number")
+		}
+	}
+}
+
+func (s *JobConfigSuite) TestValidateUlimits() {
+	s.job.Ulimits = []string{"nofile=1024:2048", "nproc=512", "core={env.CORE}"}
+	s.Nil(s.job.ValidateUlimits())
+
+	for _, value := range []string{"nofile", "nofile=many", "nofile=2048:1024"} {
+		s.job.Ulimits = []string{value}
+		err := s.job.ValidateUlimits()
+		if s.Error(err) {
+			s.Contains(err.Error(), fmt.Sprintf("invalid ulimit %q", value))
+		}
+	}
+}
+
+func (s *JobConfigSuite) TestLimitValues() {
+	s.job.Memory = "512m"
+	s.job.Cpus = "1.5"
+	s.job.Ulimits = []string{"nofile=1024:2048", "nproc=512"}
+
+	s.Equal(int64(512*1024*1024), s.job.MemoryBytes())
+	period, quota := s.job.CPUQuota()
+	s.Equal(int64(100000), period)
+	s.Equal(int64(150000), quota)
+	s.Equal([]Ulimit{
+		{Name: "nofile", Soft: 1024, Hard: 2048},
+		{Name: "nproc", Soft: 512, Hard: 512},
+	}, s.job.UlimitValues())
+}
+
+func (s *JobConfigSuite) TestResolveLimits() {
+	defer os.Unsetenv("DOBI_TEST_MEMORY")
+	os.Setenv("DOBI_TEST_MEMORY", "lots")
+	s.conf.Resources["builder"] = NewImageConfig()
+	s.job.Use = "builder"
+	s.job.Memory = "{env.DOBI_TEST_MEMORY}"
+	s.job.Cpus = "{env.DOBI_TEST_CPUS:2}"
+
+	env := execenv.NewExecEnv("exec", "project", ".")
+	resolved, err := s.job.Resolve(env)
+	s.Nil(err)
+	s.Equal("2", resolved.(*JobConfig).Cpus)
+	err = ValidateResolved("job", resolved, s.conf, env)
+	if s.Error(err) {
+		s.Contains(err.Error(), `invalid memory "lots"`)
+	}
+}
+
+func (s *JobConfigSuite) TestValidateArtifactFromContainer() {
+	s.conf.Resources["builder"] = NewImageConfig()
+	s.job.Use = "builder"
+	s.job.ArtifactFromContainer = "/go/bin/app"
+	s.Nil(s.job.ValidateArtifactFromContainer())
+	err := s.job.Validate(NewPath("job=compile"), s.conf)
+	if s.Error(err) {
+		s.Contains(err.Error(), "requires artifact")
+	}
+
+	s.job.Artifact = "dist/app"
+	s.Nil(s.job.Validate(NewPath("job=compile"), s.conf))
+
+	s.job.ArtifactFromContainer = "bin/app"
+	if err := s.job.ValidateArtifactFromContainer(); s.Error(err) {
+		s.Contains(err.Error(), `"bin/app" must be an absolute path`)
+	}
+}
+
+func (s *JobConfigSuite) TestValidateCapabilities() {
+	s.job.CapAdd = []string{"NET_ADMIN", "cap_sys_ptrace"}
+	s.job.CapDrop = []string{"ALL"}
+	s.Nil(s.job.ValidateCapAdd())
+	s.Nil(s.job.ValidateCapDrop())
+
+	s.job.CapDrop = []string{"MKNOD", "NET_MAGIC"}
+	err := s.job.ValidateCapDrop()
+	if s.Error(err) {
+		s.Contains(err.Error(), `unknown capability "NET_MAGIC"`)
+	}
+}
+
+func (s *JobConfigSuite) TestValidateCapAddUnknownPath() {
+	s.job.Use = "builder"
+	s.job.CapAdd = []string{"SUPERUSER"}
+	err := ValidateFields(NewPath("job=test"), s.job)
+	if s.Error(err) {
+		s.Contains(err.Error(), `job=test.cap-add: failed validation: unknown capability "SUPERUSER"`)
+	}
+}
+
+func (s *JobConfigSuite) TestValidateUseWithVariable() {
+	s.job.Use = "{env.BUILDER:builder}"
+	s.Nil(s.job.Validate(NewPath(""), s.conf))
+	s.Nil(ValidateResourcesExist(NewPath(""), s.conf, s.job.Dependencies()))
+}
+
+func (s *JobConfigSuite) TestResolveUse() {
+	s.conf.Resources["builder"] = NewImageConfig()
+	s.job.Use = "{env.DOBI_TEST_BUILDER:builder}"
+
+	env := execenv.NewExecEnv("exec", "project", ".")
+	resolved, err := s.job.Resolve(env)
+	s.Nil(err)
+	s.Equal("builder", s.job.Use)
+	s.Equal([]string{"builder"}, resolved.Dependencies())
+	s.Nil(ValidateResolved("job", resolved, s.conf, env))
+}
+
+func (s *JobConfigSuite) TestResolveUseNotAnImage() {
+	s.conf.Resources["other"] = &AliasConfig{}
+	s.job.Use = "{env.DOBI_TEST_BUILDER:other}"
+
+	env := execenv.NewExecEnv("exec", "project", ".")
+	resolved, err := s.job.Resolve(env)
+	s.Nil(err)
+	err = ValidateResolved("job", resolved, s.conf, env)
+	s.Error(err)
+	s.Contains(err.Error(), "other is not an image resource")
+}
+
+func (s *JobConfigSuite) TestValidateResolvedDuplicateMountPaths() {
+	s.conf.Resources["builder"] = NewImageConfig()
+	s.conf.Resources["source"] = &MountConfig{Path: "/app"}
+	s.conf.Resources["cache"] = &MountConfig{Path: "{env.DOBI_TEST_PATH:/app/}"}
+	s.job.Use = "builder"
+	s.job.Mounts = []string{"source", "cache"}
+
+	err := ValidateResolved("job", s.job, s.conf, execenv.NewExecEnv("exec", "project", "."))
+	s.Error(err)
+	s.Contains(err.Error(),
+		`Error at job.mounts: source and cache both mount "/app", set allow-shadowed-mounts to allow this`)
+}
+
+func (s *JobConfigSuite) TestValidateResolvedAllowShadowedMounts() {
+	s.conf.Resources["builder"] = NewImageConfig()
+	s.conf.Resources["source"] = &MountConfig{Path: "/app"}
+	s.conf.Resources["cache"] = &MountConfig{Path: "/app"}
+	s.job.Use = "builder"
+	s.job.Mounts = []string{"source", "cache"}
+	s.job.AllowShadowedMounts = true
+
+	s.Nil(ValidateResolved("job", s.job, s.conf, execenv.NewExecEnv("exec", "project", ".")))
+}
+
+func (s *JobConfigSuite) TestValidateResolvedArtifact() {
+	s.conf.Resources["builder"] = NewImageConfig()
+	s.job.Use = "builder"
+	s.job.Artifact = "dist/app{exe-suffix}"
+
+	env := execenv.NewExecEnv("exec", "project", ".")
+	resolved, err := s.job.Resolve(env)
+	s.Nil(err)
+	s.Nil(ValidateResolved("job", resolved, s.conf, env))
+
+	s.job.Artifact = "{env.DOBI_TEST_ARTIFACT:./}"
+	resolved, err = s.job.Resolve(env)
+	s.Nil(err)
+	err = ValidateResolved("job", resolved, s.conf, env)
+	if s.Error(err) {
+		s.Contains(err.Error(), `job.artifact: invalid artifact "./"`)
+	}
+}
+
+func (s *JobConfigSuite) TestValidateShell() {
+	s.Nil(s.job.Shell.TransformConfig(reflect.ValueOf("/bin/bash -e")))
+	s.Nil(s.job.ValidateShell())
+
+	s.Nil(s.job.Entrypoint.TransformConfig(reflect.ValueOf("/init")))
+	err := s.job.ValidateShell()
+	if s.Error(err) {
+		s.Contains(err.Error(), "can not be set when entrypoint is set")
+	}
+}
+
+func (s *JobConfigSuite) TestValidateShellInvalid() {
+	s.Nil(s.job.Shell.TransformConfig(reflect.ValueOf("$SHELL")))
+	err := s.job.ValidateShell()
+	if s.Error(err) {
+		s.Contains(err.Error(), `invalid shell "$SHELL"`)
+	}
+}
+
+func (s *JobConfigSuite) TestResolveCommandFromVariable() {
+	defer os.Unsetenv("DOBI_TEST_CMD")
+	os.Setenv("DOBI_TEST_CMD", "go test -run 'TestOne|TestTwo' ./...")
+	s.Nil(s.job.Command.TransformConfig(reflect.ValueOf("{env.DOBI_TEST_CMD}")))
+
+	_, err := s.job.Resolve(execenv.NewExecEnv("exec", "project", "."))
+	s.Nil(err)
+	s.Equal([]string{"go", "test", "-run", "TestOne|TestTwo", "./..."}, s.job.Command.Value())
+	s.Equal("go test -run 'TestOne|TestTwo' ./...", s.job.Command.String())
+}
+
+func (s *JobConfigSuite) TestResolveCommandWithBracesUnchanged() {
+	s.Nil(s.job.Command.TransformConfig(reflect.ValueOf("sh -c 'echo ${HOME}'")))
+
+	_, err := s.job.Resolve(execenv.NewExecEnv("exec", "project", "."))
+	s.Nil(err)
+	s.Equal([]string{"sh", "-c", "echo ${HOME}"}, s.job.Command.Value())
+}
+
+func (s *JobConfigSuite) TestCommandListForm() {
+	list := []interface{}{"bash", "-c", "echo hi"}
+	s.Nil(s.job.Command.TransformConfig(reflect.ValueOf(list)))
+	s.Equal([]string{"bash", "-c", "echo hi"}, s.job.Command.Value())
+	s.Equal("bash -c 'echo hi'", s.job.Command.String())
+	s.False(s.job.Command.Empty())
+
+	fromString := ShlexSlice{}
+	s.Nil(fromString.TransformConfig(reflect.ValueOf(s.job.Command.String())))
+	s.Equal(s.job.Command, fromString)
+
+	s.Nil(s.job.Command.TransformConfig(reflect.ValueOf([]interface{}{})))
+	s.True(s.job.Command.Empty())
+	s.Len(s.job.Command.Value(), 0)
+}
+
+func (s *JobConfigSuite) TestCommandListFormIsNotResolved() {
+	list := []interface{}{"{env.DOBI_TEST_CMD}"}
+	s.Nil(s.job.Command.TransformConfig(reflect.ValueOf(list)))
+
+	_, err := s.job.Resolve(execenv.NewExecEnv("exec", "project", "."))
+	s.Nil(err)
+	s.Equal([]string{"{env.DOBI_TEST_CMD}"}, s.job.Command.Value())
+}
+
+func (s *JobConfigSuite) TestCommandListFormInvalidItem() {
+	err := s.job.Command.TransformConfig(reflect.ValueOf([]interface{}{"echo", 1}))
+	if s.Error(err) {
+		s.Contains(err.Error(), "must be a string or list of strings, item 1 is int")
+	}
+	err = s.job.Command.TransformConfig(reflect.ValueOf(3))
+	if s.Error(err) {
+		s.Contains(err.Error(), "must be a string or list of strings, not int")
+	}
+}
+
+func (s *JobConfigSuite) TestJobFromConfigEntrypointList() {
+	resource, err := jobFromConfig("job=test", map[string]interface{}{
+		"use":        "builder",
+		"entrypoint": []interface{}{"/bin/sh", "-c"},
+		"command":    "echo hi",
+	})
+	s.Require().Nil(err)
+	job := resource.(*JobConfig)
+	s.Equal([]string{"/bin/sh", "-c"}, job.Entrypoint.Value())
+	s.Equal([]string{"echo", "hi"}, job.Command.Value())
+}
+
+func (s *JobConfigSuite) TestResolveCommandUnsetVariable() {
+	s.Nil(s.job.Command.TransformConfig(reflect.ValueOf("{env.DOBI_TEST_CMD}")))
+
+	_, err := s.job.Resolve(execenv.NewExecEnv("exec", "project", "."))
+	if s.Error(err) {
+		s.Contains(err.Error(), `command: A value is required for variable "env.DOBI_TEST_CMD"`)
+	}
+	s.Equal("{env.DOBI_TEST_CMD}", s.job.Command.String())
+}
+
+func (s *JobConfigSuite) TestValidateUser() {
+	for _, value := range []string{"", "1000", "1000:1000", "builder", "builder:staff"} {
+		s.job.User = value
+		s.Nil(s.job.ValidateUser())
+	}
+}
+
+func (s *JobConfigSuite) TestValidateUserInvalid() {
+	for _, value := range []string{":", "1000:", ":1000", "a:b:c", "some user"} {
+		s.job.User = value
+		err := s.job.ValidateUser()
+		if s.Error(err) {
+			s.Contains(err.Error(), "invalid user")
+		}
+	}
+}
+
+func (s *JobConfigSuite) TestResolveUser() {
+	s.conf.Resources["builder"] = NewImageConfig()
+	s.job.Use = "builder"
+	s.job.User = "{user.uid}:{user.gid}"
+	s.Nil(s.job.ValidateUser())
+
+	env := execenv.NewExecEnv("exec", "project", ".")
+	resolved, err := s.job.Resolve(env)
+	s.Nil(err)
+	s.Equal(fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()), s.job.User)
+	s.Nil(ValidateResolved("job", resolved, s.conf, env))
+
+	defer os.Unsetenv("DOBI_TEST_USER")
+	os.Setenv("DOBI_TEST_USER", ":")
+	s.job.User = "{env.DOBI_TEST_USER}"
+	resolved, err = s.job.Resolve(env)
+	s.Nil(err)
+	err = ValidateResolved("job", resolved, s.conf, env)
+	if s.Error(err) {
+		s.Contains(err.Error(), `job.user: invalid user ":"`)
+	}
+}
+
+func (s *JobConfigSuite) TestValidateMacAddress() {
+	for _, value := range []string{"", "02:42:ac:11:00:02", "02:42:AC:11:00:FF", "{env.MAC}"} {
+		s.job.MacAddress = value
+		s.Nil(s.job.ValidateMacAddress())
+	}
+	for _, value := range []string{"02:42:ac:11:00", "02-42-ac-11-00-02", "0242.ac11.0002", "02:42:ac:11:00:0g"} {
+		s.job.MacAddress = value
+		err := s.job.ValidateMacAddress()
+		if s.Error(err) {
+			s.Contains(err.Error(), "invalid MAC address")
+		}
+	}
+}
+
+func (s *JobConfigSuite) TestResolveMacAddress() {
+	s.conf.Resources["builder"] = NewImageConfig()
+	s.job.Use = "builder"
+	defer os.Unsetenv("DOBI_TEST_MAC")
+	os.Setenv("DOBI_TEST_MAC", "02:42:ac:11:00:02")
+	s.job.MacAddress = "{env.DOBI_TEST_MAC}"
+
+	env := execenv.NewExecEnv("exec", "project", ".")
+	resolved, err := s.job.Resolve(env)
+	s.Nil(err)
+	s.Equal("02:42:ac:11:00:02", s.job.MacAddress)
+	s.Nil(ValidateResolved("job", resolved, s.conf, env))
+
+	os.Setenv("DOBI_TEST_MAC", "not-a-mac")
+	s.job.MacAddress = "{env.DOBI_TEST_MAC}"
+	env = execenv.NewExecEnv("exec", "project", ".")
+	resolved, err = s.job.Resolve(env)
+	s.Nil(err)
+	err = ValidateResolved("job", resolved, s.conf, env)
+	if s.Error(err) {
+		s.Contains(err.Error(), `job.mac-address: invalid MAC address "not-a-mac"`)
+	}
+}
+
+func (s *JobConfigSuite) TestResolveRunAsCurrentUser() {
+	s.job.Use = "builder"
+	s.job.RunAsCurrentUser = true
+	s.job.Groups = []string{"{env.DOBI_TEST_GID}"}
+	defer os.Unsetenv("DOBI_TEST_GID")
+	os.Setenv("DOBI_TEST_GID", "999")
+
+	_, err := s.job.Resolve(execenv.NewExecEnv("exec", "project", "."))
+	s.Nil(err)
+	s.Equal(fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()), s.job.User)
+	s.Equal([]string{"999"}, s.job.Groups)
+}
+
+func (s *JobConfigSuite) TestValidateRunAsCurrentUserWithUser() {
+	s.conf.Resources["example"] = NewImageConfig()
+	s.job.Use = "example"
+	s.job.RunAsCurrentUser = true
+	s.job.User = "1000"
+	err := s.job.Validate(NewPath("job"), s.conf)
+	if s.Error(err) {
+		s.Contains(err.Error(), "job.run-as-current-user: can not be used with user")
+	}
+}
+
+func (s *JobConfigSuite) TestResolvePorts() {
+	s.conf.Resources["builder"] = NewImageConfig()
+	s.job.Use = "builder"
+	defer os.Unsetenv("DOBI_TEST_PORT")
+	os.Setenv("DOBI_TEST_PORT", "8080")
+	s.job.Ports = []string{"{env.DOBI_TEST_PORT}:80", "5432"}
+	s.Nil(s.job.ValidatePorts())
+
+	env := execenv.NewExecEnv("exec", "project", ".")
+	resolved, err := s.job.Resolve(env)
+	s.Nil(err)
+	s.Equal([]string{"8080:80", "5432"}, s.job.Ports)
+	s.Nil(ValidateResolved("job", resolved, s.conf, env))
+
+	s.job.Ports = []string{"{env.DOBI_TEST_PORT}"}
+	os.Setenv("DOBI_TEST_PORT", "http")
+	env = execenv.NewExecEnv("exec", "project", ".")
+	resolved, err = s.job.Resolve(env)
+	s.Nil(err)
+	err = ValidateResolved("job", resolved, s.conf, env)
+	if s.Error(err) {
+		s.Contains(err.Error(), `job.ports: invalid port "http"`)
+	}
+}
+
+func (s *JobConfigSuite) TestValidatePortsInvalid() {
+	s.job.Ports = []string{"8080:80", "abc:def"}
+	err := s.job.ValidatePorts()
+	if s.Error(err) {
+		s.Contains(err.Error(), `invalid port "abc:def"`)
+	}
+}
+
+func (s *JobConfigSuite) TestJobFromConfigForwardSignals() {
+	resource, err := jobFromConfig("job=test", map[string]interface{}{"use": "builder"})
+	s.Require().Nil(err)
+	s.True(resource.(*JobConfig).ForwardSignals.Value())
+
+	resource, err = jobFromConfig("job=test", map[string]interface{}{
+		"use":             "builder",
+		"forward-signals": false,
+	})
+	s.Require().Nil(err)
+	s.False(resource.(*JobConfig).ForwardSignals.Value())
+
+	_, err = jobFromConfig("job=test", map[string]interface{}{
+		"use":             "builder",
+		"forward-signals": "no",
+	})
+	if s.Error(err) {
+		s.Contains(err.Error(), "must be a bool, not string")
+	}
+}
+
+func (s *JobConfigSuite) TestJobFromConfigArtifacts() {
+	resource, err := jobFromConfig("job=test", map[string]interface{}{
+		"use": "builder",
+		"artifacts": map[interface{}]interface{}{
+			"dist/docs": []interface{}{"docs/"},
+			"dist/app":  []interface{}{"cmd/", "pkg/"},
+		},
+	})
+	s.Require().Nil(err)
+	job := resource.(*JobConfig)
+	s.Equal([]string{"dist/app", "dist/docs"}, job.ArtifactPaths())
+	s.Equal([]string{"cmd/", "pkg/"}, job.Artifacts.Sources("dist/app"))
+	s.Equal("Run the 'builder' image to create 'dist/app', 'dist/docs'", job.String())
+}
+
+func (s *JobConfigSuite) TestJobFromConfigArtifactsInvalid() {
+	var testcases = []struct {
+		artifacts interface{}
+		expected  string
+	}{
+		{
+			artifacts: []interface{}{"dist/app"},
+			expected:  "must be a mapping of artifacts to sources, not []interface {}",
+		},
+		{
+			artifacts: map[interface{}]interface{}{"dist/app": "cmd/"},
+			expected:  `sources of "dist/app" must be a list of strings, not string`,
+		},
+		{
+			artifacts: map[interface{}]interface{}{"dist/app": []interface{}{"cmd/", 1}},
+			expected:  `sources of "dist/app" must be a list of strings, item 1 is int`,
+		},
+	}
+	for _, testcase := range testcases {
+		_, err := jobFromConfig("job=test", map[string]interface{}{
+			"use":       "builder",
+			"artifacts": testcase.artifacts,
+		})
+		if s.Error(err) {
+			s.Contains(err.Error(), testcase.expected)
+		}
+	}
+}
+
+func (s *JobConfigSuite) TestValidateArtifactsEmptySources() {
+	s.Nil(s.job.Artifacts.TransformConfig(reflect.ValueOf(
+		map[interface{}]interface{}{"dist/app": []interface{}{}})))
+
+	err := s.job.ValidateArtifacts()
+	if s.Error(err) {
+		s.Contains(err.Error(), `sources of "dist/app" must not be empty`)
+	}
+}
+
+func (s *JobConfigSuite) TestValidateArtifactsWithArtifact() {
+	s.conf.Resources["builder"] = NewImageConfig()
+	s.job.Use = "builder"
+	s.job.Artifact = "dist/app"
+	s.Nil(s.job.Artifacts.TransformConfig(reflect.ValueOf(
+		map[interface{}]interface{}{"dist/docs": []interface{}{"docs/"}})))
+
+	err := s.job.Validate(NewPath("job"), s.conf)
+	if s.Error(err) {
+		s.Contains(err.Error(), "can not be used with artifact or sources")
+	}
+}
+
+func (s *JobConfigSuite) TestResolveArtifacts() {
+	defer os.Unsetenv("DOBI_TEST_DIST")
+	os.Setenv("DOBI_TEST_DIST", "dist")
+	s.Nil(s.job.Artifacts.TransformConfig(reflect.ValueOf(
+		map[interface{}]interface{}{"{env.DOBI_TEST_DIST}/app": []interface{}{"{env.DOBI_TEST_DIST}.go"}})))
+
+	resolved, err := s.job.Resolve(execenv.NewExecEnv("exec", "project", "."))
+	s.Nil(err)
+	job := resolved.(*JobConfig)
+	s.Equal([]string{"dist/app"}, job.ArtifactPaths())
+	s.Equal([]string{"dist.go"}, job.Artifacts.Sources("dist/app"))
+}
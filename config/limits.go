@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// memoryRegex matches a number of bytes with an optional unit
+var memoryRegex = regexp.MustCompile(`^([0-9]+)([bkmg]?)$`)
+
+var memoryUnits = map[string]int64{
+	"":  1,
+	"b": 1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+}
+
+// parseMemory returns the number of bytes from a value like 512m or 2g
+func parseMemory(value string) (int64, error) {
+	match := memoryRegex.FindStringSubmatch(strings.ToLower(value))
+	if match == nil {
+		return 0, fmt.Errorf("invalid memory %q, must be a number with an "+
+			"optional unit of b, k, m, or g", value)
+	}
+	number, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory %q: %s", value, err)
+	}
+	return number * memoryUnits[match[2]], nil
+}
+
+func validateMemory(value string) error {
+	if value == "" {
+		return nil
+	}
+	_, err := parseMemory(value)
+	return err
+}
+
+// cpuPeriod is the CFS scheduler period used to limit the cpus of a container
+const cpuPeriod = 100000
+
+// parseCPUs returns the number of cpus from a value like 1.5
+func parseCPUs(value string) (float64, error) {
+	cpus, err := strconv.ParseFloat(value, 64)
+	if err != nil || cpus <= 0 {
+		return 0, fmt.Errorf("invalid cpus %q, must be a positive number", value)
+	}
+	return cpus, nil
+}
+
+func validateCPUs(value string) error {
+	if value == "" {
+		return nil
+	}
+	_, err := parseCPUs(value)
+	return err
+}
+
+// Ulimit is a resource limit of a container
+type Ulimit struct {
+	Name string
+	Soft int64
+	Hard int64
+}
+
+// parseUlimit returns the Ulimit from a value like nofile=1024 or
+// nofile=1024:2048
+func parseUlimit(value string) (Ulimit, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return Ulimit{}, fmt.Errorf("invalid ulimit %q, must be of the form "+
+			"name=soft[:hard]", value)
+	}
+	limits := strings.SplitN(parts[1], ":", 2)
+	soft, err := strconv.ParseInt(limits[0], 10, 64)
+	if err != nil {
+		return Ulimit{}, fmt.Errorf("invalid ulimit %q, %q is not a number", value, limits[0])
+	}
+	hard := soft
+	if len(limits) == 2 {
+		if hard, err = strconv.ParseInt(limits[1], 10, 64); err != nil {
+			return Ulimit{}, fmt.Errorf("invalid ulimit %q, %q is not a number",
+				value, limits[1])
+		}
+	}
+	if soft > hard {
+		return Ulimit{}, fmt.Errorf("invalid ulimit %q, the soft limit must not "+
+			"be greater than the hard limit", value)
+	}
+	return Ulimit{Name: parts[0], Soft: soft, Hard: hard}, nil
+}
+
+func validateUlimits(values []string) error {
+	for _, value := range values {
+		if _, err := parseUlimit(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// matrixVariableRegex matches a matrix variable of the form {matrix.name}
+var matrixVariableRegex = regexp.MustCompile(`\{matrix\.([^{}]+)\}`)
+
+// matrixResourceTypes are the types of resources which support a matrix
+var matrixResourceTypes = map[string]bool{"job": true, "image": true}
+
+// matrixAxis is one key of a matrix, and the list of values of the key
+type matrixAxis struct {
+	name   string
+	values []string
+}
+
+// expandMatrix replaces each resource with a matrix by a resource for every
+// combination of the values in the matrix, and an alias with the original name
+// which runs all of them. Matrix variables in the resource are replaced by the
+// values of the combination before the resource is transformed. It returns a
+// map of the name of each matrix to the type of its resources.
+func expandMatrix(values map[string]map[string]interface{}) (map[string]string, error) {
+	matrixes := map[string]string{}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		raw, ok := value["matrix"]
+		if !ok {
+			continue
+		}
+		resType, resName, err := parseResourceName(key)
+		if err != nil {
+			return nil, err
+		}
+		root := NewPath(key)
+		path := root.add("matrix")
+		if !matrixResourceTypes[resType] {
+			return nil, PathErrorf(path, "matrix is not supported by %s resources", resType)
+		}
+		axes, err := parseMatrix(raw)
+		if err != nil {
+			return nil, PathErrorf(path, err.Error())
+		}
+		delete(value, "matrix")
+
+		tasks := []string{}
+		for _, combination := range matrixCombinations(axes) {
+			name := matrixResourceName(resName, axes, combination)
+			expandedKey := resType + "=" + name
+			if _, exists := values[expandedKey]; exists {
+				return nil, PathErrorf(path,
+					"matrix resource %q conflicts with an existing resource", name)
+			}
+			expanded, err := substituteMatrix(NewPath(expandedKey), value, combination)
+			if err != nil {
+				return nil, err
+			}
+			values[expandedKey] = expanded.(map[string]interface{})
+			tasks = append(tasks, name)
+		}
+		delete(values, key)
+		if _, exists := values["alias="+resName]; exists {
+			return nil, PathErrorf(path,
+				"the alias for the matrix conflicts with an existing resource %q", resName)
+		}
+		values["alias="+resName] = map[string]interface{}{
+			"tasks": toInterfaceSlice(tasks),
+		}
+		matrixes[resName] = resType
+	}
+	return matrixes, nil
+}
+
+// parseMatrix returns the axes of a matrix, sorted by name
+func parseMatrix(raw interface{}) ([]matrixAxis, error) {
+	mapping, ok := raw.(map[interface{}]interface{})
+	if !ok || len(mapping) == 0 {
+		return nil, fmt.Errorf("must be a mapping of names to lists of values")
+	}
+	names := []string{}
+	items := map[string]interface{}{}
+	for key, value := range mapping {
+		name := fmt.Sprintf("%v", key)
+		names = append(names, name)
+		items[name] = value
+	}
+	sort.Strings(names)
+
+	axes := []matrixAxis{}
+	for _, name := range names {
+		list, ok := items[name].([]interface{})
+		if !ok || len(list) == 0 {
+			return nil, fmt.Errorf("%q must be a list of values", name)
+		}
+		axis := matrixAxis{name: name}
+		for _, item := range list {
+			switch item.(type) {
+			case map[interface{}]interface{}, []interface{}, nil:
+				return nil, fmt.Errorf(
+					"values of %q must be strings or numbers, not %T", name, item)
+			}
+			axis.values = append(axis.values, fmt.Sprintf("%v", item))
+		}
+		axes = append(axes, axis)
+	}
+	return axes, nil
+}
+
+// matrixCombinations returns every combination of the values of the axes, with
+// the values of the last axis changing the fastest
+func matrixCombinations(axes []matrixAxis) []map[string]string {
+	combinations := []map[string]string{{}}
+	for _, axis := range axes {
+		next := []map[string]string{}
+		for _, combination := range combinations {
+			for _, value := range axis.values {
+				expanded := map[string]string{axis.name: value}
+				for key, existing := range combination {
+					expanded[key] = existing
+				}
+				next = append(next, expanded)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// matrixResourceName returns the name of a resource expanded from a matrix,
+// which is the original name followed by each value of the combination
+func matrixResourceName(name string, axes []matrixAxis, combination map[string]string) string {
+	parts := []string{name}
+	for _, axis := range axes {
+		parts = append(parts, combination[axis.name])
+	}
+	return strings.Join(parts, "-")
+}
+
+// substituteMatrix returns a copy of the raw config values with every matrix
+// variable replaced by its value in the combination
+func substituteMatrix(path Path, value interface{}, combination map[string]string) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		var err error
+		replaced := matrixVariableRegex.ReplaceAllStringFunc(value, func(match string) string {
+			name := matrixVariableRegex.FindStringSubmatch(match)[1]
+			item, ok := combination[name]
+			if !ok && err == nil {
+				err = PathErrorf(path, "matrix variable %q is not defined", name)
+			}
+			return item
+		})
+		return replaced, err
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			item, err := substituteMatrix(path.add(key), item, combination)
+			if err != nil {
+				return nil, err
+			}
+			copied[key] = item
+		}
+		return copied, nil
+	case map[interface{}]interface{}:
+		copied := make(map[interface{}]interface{}, len(value))
+		for key, item := range value {
+			item, err := substituteMatrix(path.add(fmt.Sprintf("%v", key)), item, combination)
+			if err != nil {
+				return nil, err
+			}
+			copied[key] = item
+		}
+		return copied, nil
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, item := range value {
+			item, err := substituteMatrix(path.add(strconv.Itoa(i)), item, combination)
+			if err != nil {
+				return nil, err
+			}
+			copied[i] = item
+		}
+		return copied, nil
+	default:
+		return value, nil
+	}
+}
+
+func toInterfaceSlice(values []string) []interface{} {
+	items := make([]interface{}, len(values))
+	for i, value := range values {
+		items[i] = value
+	}
+	return items
+}
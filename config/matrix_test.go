@@ -0,0 +1,160 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/renstrom/dedent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFromBytesWithMatrix(t *testing.T) {
+	conf := dedent.Dedent(`
+		image=builder:
+		  image: example/builder
+		  tags: ['go{matrix.go}']
+		  args:
+		    GO_VERSION: '{matrix.go}'
+		  matrix:
+		    go: ['1.20', '1.21']
+
+		job=test:
+		  use: builder-{matrix.go}
+		  env: ['DB={matrix.db}']
+		  command: go test -tags {matrix.db} ./...
+		  labels:
+		    owner: platform-team
+		  matrix:
+		    go: ['1.20', '1.21']
+		    db: [mysql, postgres]
+	`)
+
+	config, err := LoadFromBytes([]byte(conf))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{
+		"builder",
+		"builder-1.20",
+		"builder-1.21",
+		"test",
+		"test-mysql-1.20",
+		"test-mysql-1.21",
+		"test-postgres-1.20",
+		"test-postgres-1.21",
+	}, config.Sorted())
+
+	builder := config.Resources["builder"].(*AliasConfig)
+	assert.Equal(t, []string{"builder-1.20", "builder-1.21"}, builder.Tasks)
+	image := config.Resources["builder-1.21"].(*ImageConfig)
+	assert.Equal(t, []string{"go1.21"}, image.Tags)
+	assert.Equal(t, map[string]string{"GO_VERSION": "1.21"}, image.Args)
+
+	test := config.Resources["test"].(*AliasConfig)
+	assert.Equal(t, []string{
+		"test-mysql-1.20",
+		"test-mysql-1.21",
+		"test-postgres-1.20",
+		"test-postgres-1.21",
+	}, test.Tasks)
+	job := config.Resources["test-postgres-1.20"].(*JobConfig)
+	assert.Equal(t, "builder-1.20", job.Use)
+	assert.Equal(t, []string{"DB=postgres"}, job.Env)
+	assert.Equal(t, []string{"go", "test", "-tags", "postgres", "./..."}, job.Command.Value())
+	assert.Equal(t, map[string]string{"owner": "platform-team"},
+		config.OptionsFor("test-postgres-1.20").Labels)
+}
+
+func TestLoadFromBytesWithInvalidMatrix(t *testing.T) {
+	var testcases = []struct {
+		doc      string
+		conf     string
+		expected string
+	}{
+		{
+			doc: "unsupported resource type",
+			conf: `
+				alias=all:
+				  tasks: [one]
+				  matrix:
+				    go: ['1.20']
+			`,
+			expected: "matrix is not supported by alias resources",
+		},
+		{
+			doc: "not a mapping",
+			conf: `
+				job=test:
+				  use: builder
+				  matrix: [one, two]
+			`,
+			expected: "must be a mapping of names to lists of values",
+		},
+		{
+			doc: "values not a list",
+			conf: `
+				job=test:
+				  use: builder
+				  matrix:
+				    go: '1.20'
+			`,
+			expected: `"go" must be a list of values`,
+		},
+		{
+			doc: "undefined variable",
+			conf: `
+				job=test:
+				  use: builder
+				  command: 'echo {matrix.db}'
+				  matrix:
+				    go: ['1.20']
+			`,
+			expected: `matrix variable "db" is not defined`,
+		},
+		{
+			doc: "conflicting resource",
+			conf: `
+				job=test:
+				  use: builder
+				  matrix:
+				    go: ['1.20']
+
+				job=test-1.20:
+				  use: builder
+			`,
+			expected: `matrix resource "test-1.20" conflicts with an existing resource`,
+		},
+	}
+
+	for _, testcase := range testcases {
+		_, err := LoadFromBytes([]byte(dedent.Dedent(testcase.conf)))
+		if assert.Error(t, err, testcase.doc) {
+			assert.Contains(t, err.Error(), testcase.expected, testcase.doc)
+		}
+	}
+}
+
+func TestValidateUseMatrixOfImages(t *testing.T) {
+	conf := dedent.Dedent(`
+		image=builder:
+		  image: example/builder
+		  pull: always
+		  tags: ['go{matrix.go}']
+		  matrix:
+		    go: ['1.20', '1.21']
+
+		job=test:
+		  use: builder
+	`)
+	config, err := LoadFromBytes([]byte(conf))
+	if !assert.Nil(t, err) {
+		return
+	}
+	err = validate(config)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "builder is a matrix of images, "+
+			"use one of the images from the matrix: builder-1.20, builder-1.21")
+	}
+
+	config.Resources["test"].(*JobConfig).Use = "builder-1.21"
+	assert.Nil(t, validate(config))
+}
@@ -1,6 +1,12 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/utils/mask"
+)
 
 // MetaConfig Configure **dobi** and include other config files.
 // name: meta
@@ -26,7 +32,11 @@ type MetaConfig struct {
 	// Include A list of dobi configuration files to include. Paths are
 	// relative to the current working directory. Includs can be partial
 	// configs that depend on resources in any of the other included files.
-	// type: list of filepaths
+	// A path may be a glob pattern, like ``services/*/dobi.yaml``, which
+	// includes every matching file. A resource name must be unique across
+	// all the included files, and errors in an included resource show the
+	// name of the file.
+	// type: list of filepaths or glob patterns
 	Include []string
 
 	// ExecID A template value used as part of unique identifiers for image tags
@@ -34,20 +44,165 @@ type MetaConfig struct {
 	// be overridden with the ``$DOBI_EXEC_ID`` environment variable.
 	// default: ``{env.USER}``
 	ExecID string `config:"exec-id"`
+
+	// Mask A list of regular expressions. Any output from jobs, and any log
+	// or error message, which matches one of the expressions is replaced
+	// with ``******`` before it is printed. Output from **interactive** jobs
+	// is not masked.
+	// type: list of regular expressions
+	// example: ``['ghp_[A-Za-z0-9]+', 'password=\S+']``
+	Mask []string
+
+	// Values A mapping of values which are shared by other resources. Other
+	// fields can reference a value with ``${meta.values.<key>}``. See
+	// :doc:`variables`.
+	// type: mapping ``key: value``
+	// example: ``{memory: 2g, retries: 3}``
+	Values valueMap
+
+	// Limits The maximum number of tasks of each resource type which may run
+	// concurrently. Independent **image** builds, **job** resources which
+	// are not **interactive**, and **shell** resources can run concurrently. The ``--parallel`` flag
+	// overrides the limit for every type, and the ``--parallel-images`` flag
+	// overrides the limit for **image**.
+	// type: mapping ``type: number``
+	// default: ``1`` *for each type*
+	// example: ``{image: 2, job: 8, shell: 4}``
+	Limits map[string]int
+
+	// AutoloadDotenv Load variables from the ``.env`` file in the same
+	// directory as the ``dobi.yaml``. The variables can be used as
+	// ``{env.<variable>}`` in any field which supports :doc:`variables`.
+	// Variables from the host environment take precedence over variables
+	// from the ``.env`` file.
+	AutoloadDotenv bool
+
+	// DefaultShell The shell used to run the **command** of every `job`_
+	// which does not set **shell** or **entrypoint**, as ``<shell> -c
+	// <command>``. By default the command is run by the entrypoint of the
+	// image.
+	// type: shell quoted string
+	// example: ``bash``
+	DefaultShell ShlexSlice
+
+	// DefaultTimeout The maximum time to run the task of any resource which
+	// does not set its own **timeout**. A task which runs for longer fails,
+	// and the container of a **job** is stopped. Tasks of other resources can
+	// not be interrupted, so they fail only once they finish. The
+	// ``--resource-timeout`` flag overrides this value.
+	// type: duration string
+	// default: *no timeout*
+	// example: ``30m``
+	DefaultTimeout duration
+
+	// Strict Treat validation warnings as errors. The ``--fail-on-warnings``
+	// flag also enables strict mode.
+	Strict bool
+
+	// StaleCheck The default **stale-check** of every `job`_ and `image`_
+	// which does not set its own. Set it to ``content`` to compare a checksum
+	// of the contents of files, instead of their modified time, to determine
+	// if a resource is stale. Modified times are not reliable after a git
+	// checkout, or when files are restored from a CI cache.
+	// default: ``mtime``
+	StaleCheck string
+
+	// Labels Labels to set on every container and image created by **dobi**.
+	// Labels set by a resource take precedence. Values in the mapping support
+	// :doc:`variables`. Labels are only supported by BuildKit builds, and these
+	// labels do not select BuildKit for an image, so images which are built
+	// without BuildKit are built without these labels.
+	// type: mapping ``key: value``
+	// example: ``{ci.pipeline: '{env.PIPELINE_ID}'}``
+	Labels map[string]string
+
+	// Cache A remote cache for the artifacts of `job`_ resources. Before a
+	// stale **job** with an **artifact** or **artifacts** runs, the cache is
+	// checked for an archive of the artifacts, keyed by a checksum of the
+	// config and sources of the job. When the archive exists the artifacts
+	// are restored and the job is skipped, otherwise the job runs and the
+	// artifacts are stored in the cache. The **url** is an http or https url,
+	// where archives are read with ``GET`` and stored with ``PUT``, or a
+	// directory, like a volume shared by CI machines. **headers** are set on
+	// every http request, and their values support :doc:`variables`. Set
+	// **read-only** to restore artifacts without storing them.
+	// type: mapping with keys ``url``, ``headers``, and ``read-only``
+	// example: ``{url: 'https://cache.example.com/dobi', read-only: true}``
+	Cache CacheConfig
+
+	// Engine The container engine used to build images and run containers.
+	// One of ``docker`` or ``podman``. Podman is used through its Docker
+	// compatible API, from the socket in ``$CONTAINER_HOST``, or the socket
+	// started by ``systemctl --user start podman.socket``, or
+	// ``/run/podman/podman.sock``. The ``$DOBI_ENGINE`` environment variable
+	// overrides this value.
+	// default: ``docker``
+	// example: ``podman``
+	Engine string
 }
 
+// limitTypes are the resource types which can run concurrently
+var limitTypes = []string{"image", "job", "shell"}
+
+// engines are the names of the supported container engines
+var engines = []string{"docker", "podman"}
+
 // Validate the MetaConfig
 func (m *MetaConfig) Validate(config *Config) error {
 	if _, ok := config.Resources[m.Default]; m.Default != "" && !ok {
 		return fmt.Errorf("Undefined default resource: %s", m.Default)
 	}
+	if _, err := mask.New(m.Mask); err != nil {
+		return fmt.Errorf("Invalid mask pattern: %s", err)
+	}
+	if !m.DefaultShell.Empty() {
+		if err := validateShell(&m.DefaultShell); err != nil {
+			return fmt.Errorf("Invalid default-shell: %s", err)
+		}
+	}
+	if err := validateStaleCheck(m.StaleCheck); err != nil {
+		return fmt.Errorf("Invalid stale-check: %s", err)
+	}
+	if err := validateOptionNames(m.Labels); err != nil {
+		return fmt.Errorf("Invalid label: %s", err)
+	}
+	if err := m.Cache.Validate(); err != nil {
+		return fmt.Errorf("Invalid cache: %s", err)
+	}
+	if m.Engine != "" && !inSlice(engines, m.Engine) {
+		return fmt.Errorf("Invalid engine %q, must be one of: %s",
+			m.Engine, strings.Join(engines, ", "))
+	}
+	for resourceType, limit := range m.Limits {
+		if !inSlice(limitTypes, resourceType) {
+			return fmt.Errorf("Invalid limit for %q, must be one of: %s",
+				resourceType, strings.Join(limitTypes, ", "))
+		}
+		if limit < 1 {
+			return fmt.Errorf("Invalid limit for %q, must be a positive number, not %d",
+				resourceType, limit)
+		}
+	}
 	return nil
 }
 
 // IsZero returns true if the struct contains only zero values, except for
 // Includes which is ignored
 func (m *MetaConfig) IsZero() bool {
-	return m.Default == "" && m.Project == "" && m.ExecID == ""
+	return m.Default == "" && m.Project == "" && m.ExecID == "" && len(m.Mask) == 0 &&
+		m.Values.IsZero() && len(m.Limits) == 0 && !m.AutoloadDotenv && !m.Strict &&
+		m.DefaultShell.Empty() && m.DefaultTimeout.Duration() == 0 && len(m.Labels) == 0 &&
+		m.StaleCheck == "" && m.Cache.IsZero() && m.Engine == ""
+}
+
+// ResolveLabels returns a copy of Labels with variables resolved
+func (m *MetaConfig) ResolveLabels(env *execenv.ExecEnv) (map[string]string, error) {
+	resolver := newFieldResolver(env)
+	labels := make(map[string]string, len(m.Labels))
+	for key, value := range m.Labels {
+		labels[key] = resolver.resolve("meta.labels."+key, value)
+	}
+	return labels, resolver.err()
 }
 
 // NewMetaConfig returns a new MetaConfig from config values
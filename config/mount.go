@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	posixpath "path"
 
 	"github.com/dnephin/dobi/execenv"
 	"github.com/dnephin/dobi/utils/fs"
@@ -20,9 +21,14 @@ import (
 //
 type MountConfig struct {
 	// Bind The host path to create and mount. This field supports expansion of
-	// `~` to the current users home directory.
-	Bind string `config:"required"`
-	// Path The container path of the mount
+	// `~` to the current users home directory. One of **bind** or **volume**
+	// is required.
+	Bind string
+	// Volume The name of a `volume`_ resource to mount instead of a host
+	// path.
+	Volume string
+	// Path The container path of the mount. The path must be absolute. This
+	// field supports :doc:`variables`.
 	Path string `config:"required"`
 	// ReadOnly Set the mount to be read-only
 	ReadOnly bool
@@ -34,13 +40,40 @@ type MountConfig struct {
 	Mode int `config:"validate"`
 }
 
-// Dependencies returns an empty list, Mount resources have no dependencies
+// Dependencies returns the volume resource of the mount. Mounts of a host path
+// have no dependencies.
 func (c *MountConfig) Dependencies() []string {
+	if c.Volume != "" {
+		return []string{c.Volume}
+	}
 	return []string{}
 }
 
 // Validate checks that all fields have acceptable values
 func (c *MountConfig) Validate(path Path, config *Config) *PathError {
+	switch {
+	case c.Bind == "" && c.Volume == "":
+		return PathErrorf(path, "one of bind or volume is required")
+	case c.Bind != "" && c.Volume != "":
+		return PathErrorf(path, "bind and volume can not both be set")
+	case c.Volume != "":
+		if _, ok := config.Resources[c.Volume].(*VolumeConfig); !ok {
+			return PathErrorf(path.add("volume"), "%s is not a volume resource", c.Volume)
+		}
+	}
+	if !hasVariables(c.Path) {
+		if err := validateMountPath(c.Path); err != nil {
+			return PathErrorf(path.add("path"), err.Error())
+		}
+	}
+	return nil
+}
+
+// validateMountPath checks that the container path of a mount is absolute
+func validateMountPath(mountPath string) error {
+	if mountPath != "" && !posixpath.IsAbs(mountPath) {
+		return fmt.Errorf("invalid path %q, must be an absolute path", mountPath)
+	}
 	return nil
 }
 
@@ -59,6 +92,9 @@ func (c *MountConfig) ValidateMode() error {
 }
 
 func (c *MountConfig) String() string {
+	if c.Volume != "" {
+		return fmt.Sprintf("Mount volume %q at %q", c.Volume, c.Path)
+	}
 	var filetype string
 	switch c.File {
 	case true:
@@ -71,13 +107,13 @@ func (c *MountConfig) String() string {
 
 // Resolve resolves variables in the resource
 func (c *MountConfig) Resolve(env *execenv.ExecEnv) (Resource, error) {
+	resolver := newFieldResolver(env)
+	c.Path = resolver.resolve("path", c.Path)
 	var err error
-	c.Path, err = env.Resolve(c.Path)
-	if err != nil {
-		return c, err
+	if c.Bind, err = fs.ExpandUser(c.Bind); err != nil {
+		resolver.errs.Add(PathErrorf(NewPath("bind"), "%s", err))
 	}
-	c.Bind, err = fs.ExpandUser(c.Bind)
-	return c, err
+	return c, resolver.err()
 }
 
 func mountFromConfig(name string, values map[string]interface{}) (Resource, error) {
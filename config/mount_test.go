@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/dnephin/dobi/execenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountConfigValidatePath(t *testing.T) {
+	mount := &MountConfig{Bind: ".", Path: "app"}
+	err := mount.Validate(NewPath("mount"), NewConfig())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `mount.path: invalid path "app", must be an absolute path`)
+	}
+
+	mount.Path = "{env.DOBI_TEST_MODULE:app}"
+	assert.Nil(t, mount.Validate(NewPath("mount"), NewConfig()))
+}
+
+func TestMountConfigValidateResolvedPath(t *testing.T) {
+	env := execenv.NewExecEnv("exec", "project", ".")
+	conf := NewConfig()
+
+	mount := &MountConfig{Bind: ".", Path: "/workspace/{env.DOBI_TEST_MODULE:app}"}
+	resolved, err := mount.Resolve(env)
+	assert.Nil(t, err)
+	assert.Equal(t, "/workspace/app", resolved.(*MountConfig).Path)
+	assert.Nil(t, ValidateResolved("mount", resolved, conf, env))
+
+	mount = &MountConfig{Bind: ".", Path: "{env.DOBI_TEST_MODULE:app}"}
+	resolved, err = mount.Resolve(env)
+	assert.Nil(t, err)
+	err = ValidateResolved("mount", resolved, conf, env)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "mount.path: invalid path \"app\"")
+	}
+}
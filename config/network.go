@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dnephin/dobi/execenv"
+)
+
+var networkDrivers = []string{"bridge", "overlay", "macvlan", "ipvlan"}
+
+// NetworkConfig A **network** resource creates a user-defined Docker network.
+// The network is only created if a network with the same **name** does not
+// exist. A `job`_ can join the network by setting **net-mode** to the
+// **name** of the network, and listing the **network** resource in
+// **depends**.
+// name: network
+// example: Create a network shared by a database and the tests which use it
+//
+// .. code-block:: yaml
+//
+//     network=backend:
+//         name: '{project}-backend'
+//         options:
+//             com.docker.network.bridge.enable_icc: 'true'
+//
+//     job=test:
+//         use: builder
+//         net-mode: '{project}-backend'
+//         depends: [backend]
+//
+type NetworkConfig struct {
+	// Name The name of the Docker network. This field supports
+	// :doc:`variables`.
+	Name string `config:"required"`
+	// Driver The network driver, one of ``bridge``, ``overlay``,
+	// ``macvlan``, or ``ipvlan``.
+	// default: ``bridge``
+	Driver string `config:"validate"`
+	// Options Driver specific options for the network.
+	// type: mapping ``key: value``
+	Options map[string]string `config:"validate"`
+	// Depends The list of resource dependencies.
+	// type: list of resource names
+	Depends []string
+}
+
+// Dependencies returns the list of tasks
+func (c *NetworkConfig) Dependencies() []string {
+	return c.Depends
+}
+
+// Validate the resource
+func (c *NetworkConfig) Validate(path Path, config *Config) *PathError {
+	return nil
+}
+
+// ValidateDriver validates that Driver is a driver which can create a network
+func (c *NetworkConfig) ValidateDriver() error {
+	if inSlice(networkDrivers, c.Driver) {
+		return nil
+	}
+	return fmt.Errorf("invalid driver %q, must be one of: %s",
+		c.Driver, strings.Join(networkDrivers, ", "))
+}
+
+// ValidateOptions validates the names of the driver options
+func (c *NetworkConfig) ValidateOptions() error {
+	return validateOptionNames(c.Options)
+}
+
+func (c *NetworkConfig) String() string {
+	return fmt.Sprintf("Create %s network %q", c.Driver, c.Name)
+}
+
+// Resolve resolves variables in the resource
+func (c *NetworkConfig) Resolve(env *execenv.ExecEnv) (Resource, error) {
+	resolver := newFieldResolver(env)
+	c.Name = resolver.resolve("name", c.Name)
+	return c, resolver.err()
+}
+
+func networkFromConfig(name string, values map[string]interface{}) (Resource, error) {
+	network := &NetworkConfig{Driver: "bridge"}
+	return network, Transform(name, values, network)
+}
+
+func init() {
+	RegisterResource("network", networkFromConfig)
+}
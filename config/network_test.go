@@ -0,0 +1,37 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkFromConfig(t *testing.T) {
+	resource, err := networkFromConfig("network=backend", map[string]interface{}{
+		"name": "backend-net",
+	})
+	assert.Nil(t, err)
+	network := resource.(*NetworkConfig)
+	assert.Equal(t, "bridge", network.Driver)
+	assert.Nil(t, ValidateFields(NewPath("backend"), network))
+}
+
+func TestNetworkConfigValidateDriver(t *testing.T) {
+	network := &NetworkConfig{Driver: "bogus"}
+	err := network.ValidateDriver()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			`invalid driver "bogus", must be one of: bridge, overlay, macvlan, ipvlan`)
+	}
+}
+
+func TestNetworkConfigValidateOptions(t *testing.T) {
+	network := &NetworkConfig{Options: map[string]string{"com.docker.network.mtu": "1400"}}
+	assert.Nil(t, network.ValidateOptions())
+
+	network.Options = map[string]string{"bad option": "value"}
+	err := network.ValidateOptions()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `invalid name "bad option"`)
+	}
+}
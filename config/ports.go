@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PortMapping is a port of a container which is published on the host
+type PortMapping struct {
+	HostIP        string
+	HostPort      string
+	ContainerPort string
+	Protocol      string
+}
+
+// ParsePort parses a port of the form [[host-ip:]host-port:]container-port,
+// with an optional /protocol. The host-ip may be an IPv6 address in brackets.
+// The protocol defaults to tcp.
+func ParsePort(spec string) (PortMapping, error) {
+	port := PortMapping{Protocol: "tcp"}
+	value := spec
+	if index := strings.LastIndex(value, "/"); index != -1 {
+		port.Protocol = value[index+1:]
+		value = value[:index]
+	}
+	switch port.Protocol {
+	case "tcp", "udp", "sctp":
+	default:
+		return port, fmt.Errorf("invalid port %q, protocol must be tcp, udp, or sctp", spec)
+	}
+
+	if strings.HasPrefix(value, "[") {
+		index := strings.Index(value, "]:")
+		if index == -1 {
+			return port, fmt.Errorf("invalid port %q, missing the host port", spec)
+		}
+		port.HostIP = value[1:index]
+		value = value[index+2:]
+		if strings.Count(value, ":") != 1 {
+			return port, fmt.Errorf("invalid port %q, must be "+
+				"[[host-ip:]host-port:]container-port", spec)
+		}
+	}
+
+	parts := strings.Split(value, ":")
+	switch len(parts) {
+	case 1:
+		port.ContainerPort = parts[0]
+	case 2:
+		port.HostPort, port.ContainerPort = parts[0], parts[1]
+	case 3:
+		port.HostIP, port.HostPort, port.ContainerPort = parts[0], parts[1], parts[2]
+	default:
+		return port, fmt.Errorf("invalid port %q, must be "+
+			"[[host-ip:]host-port:]container-port", spec)
+	}
+
+	if !isPortNumber(port.ContainerPort) {
+		return port, fmt.Errorf("invalid port %q, container port must be a number "+
+			"between 1 and 65535", spec)
+	}
+	if port.HostPort != "" && !isPortNumber(port.HostPort) {
+		return port, fmt.Errorf("invalid port %q, host port must be a number "+
+			"between 1 and 65535", spec)
+	}
+	if len(parts) == 2 && port.HostPort == "" {
+		return port, fmt.Errorf("invalid port %q, host port must not be empty", spec)
+	}
+	return port, nil
+}
+
+func isPortNumber(value string) bool {
+	number, err := strconv.Atoi(value)
+	return err == nil && number > 0 && number < 65536
+}
+
+func validatePorts(ports []string) error {
+	for _, port := range ports {
+		if _, err := ParsePort(port); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePort(t *testing.T) {
+	for spec, expected := range map[string]PortMapping{
+		"80":                  {ContainerPort: "80", Protocol: "tcp"},
+		"8080:80":             {HostPort: "8080", ContainerPort: "80", Protocol: "tcp"},
+		"53:53/udp":           {HostPort: "53", ContainerPort: "53", Protocol: "udp"},
+		"127.0.0.1:9000:90":   {HostIP: "127.0.0.1", HostPort: "9000", ContainerPort: "90", Protocol: "tcp"},
+		"127.0.0.1::90":       {HostIP: "127.0.0.1", ContainerPort: "90", Protocol: "tcp"},
+		"[::1]:5432:5432":     {HostIP: "::1", HostPort: "5432", ContainerPort: "5432", Protocol: "tcp"},
+		"[::1]:5432:5432/tcp": {HostIP: "::1", HostPort: "5432", ContainerPort: "5432", Protocol: "tcp"},
+	} {
+		port, err := ParsePort(spec)
+		assert.Nil(t, err, spec)
+		assert.Equal(t, expected, port, spec)
+	}
+}
+
+func TestParsePortInvalid(t *testing.T) {
+	for spec, message := range map[string]string{
+		"":         "container port must be a number",
+		"http":     "container port must be a number",
+		"80/icmp":  "protocol must be tcp, udp, or sctp",
+		":80":      "host port must not be empty",
+		"70000:80": "host port must be a number",
+		"a:b:c:d":  "must be [[host-ip:]host-port:]container-port",
+		"[::1]:80": "must be [[host-ip:]host-port:]container-port",
+		"[::1]80":  "missing the host port",
+		"8080:0":   "container port must be a number",
+		"abc:def":  "container port must be a number",
+	} {
+		_, err := ParsePort(spec)
+		if assert.Error(t, err, spec) {
+			assert.Contains(t, err.Error(), message, spec)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// referenceRegex matches a reference of the form ${resource.field[.key...]}.
+// Text which does not match, like the shell expansion ${VERSION:-1.0}, is not a
+// reference.
+var referenceRegex = regexp.MustCompile(`\$\{([a-zA-Z0-9_-]+(?:\.[a-zA-Z0-9_-]+)+)\}`)
+
+// referenceFields are the fields which may contain references. Fields which
+// are run by a shell, or passed to the container, like command and env, are not
+// included, because ${} is also the syntax of shell parameter expansion.
+var referenceFields = map[string]bool{
+	"artifact":      true,
+	"args":          true,
+	"bind":          true,
+	"context":       true,
+	"cpus":          true,
+	"depends":       true,
+	"dest":          true,
+	"dockerfile":    true,
+	"files":         true,
+	"image":         true,
+	"labels":        true,
+	"memory":        true,
+	"mounts":        true,
+	"net-mode":      true,
+	"oom-score-adj": true,
+	"path":          true,
+	"pids-limit":    true,
+	"ports":         true,
+	"project":       true,
+	"retries":       true,
+	"retry-delay":   true,
+	"sources":       true,
+	"stop-timeout":  true,
+	"tags":          true,
+	"timeout":       true,
+	"ulimits":       true,
+	"url":           true,
+	"use":           true,
+	"user":          true,
+	"working-dir":   true,
+}
+
+// references replaces references in the raw config values with the value of
+// another field in the same config file. References are resolved before the
+// config values are transformed into resources, so a reference which is the
+// entire value of a field keeps the type of the referenced value.
+type references struct {
+	resources map[string]interface{}
+	resolving map[string]bool
+}
+
+func newReferences(values map[string]map[string]interface{}) *references {
+	resources := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		name := key
+		if parts := strings.SplitN(key, "=", 2); len(parts) == 2 {
+			name = parts[1]
+		}
+		resources[name] = value
+	}
+	return &references{resources: resources, resolving: make(map[string]bool)}
+}
+
+// resolveReferences replaces the references in the referenceFields of the raw
+// config values
+func resolveReferences(values map[string]map[string]interface{}) error {
+	refs := newReferences(values)
+	for key, value := range values {
+		root := NewPath(key)
+		for field, item := range value {
+			if !referenceFields[field] {
+				continue
+			}
+			resolved, err := refs.resolve(root.add(field), item)
+			if err != nil {
+				return err
+			}
+			value[field] = resolved
+		}
+	}
+	return nil
+}
+
+func (r *references) resolve(path Path, value interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		return r.resolveString(path, value)
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			item, err := r.resolve(path.add(key), item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = item
+		}
+		return resolved, nil
+	case map[interface{}]interface{}:
+		resolved := make(map[interface{}]interface{}, len(value))
+		for key, item := range value {
+			item, err := r.resolve(path.add(fmt.Sprintf("%v", key)), item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = item
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(value))
+		for i, item := range value {
+			item, err := r.resolve(path.add(strconv.Itoa(i)), item)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = item
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+func (r *references) resolveString(path Path, value string) (interface{}, error) {
+	matches := referenceRegex.FindAllStringSubmatchIndex(value, -1)
+	switch {
+	case len(matches) == 0:
+		return value, nil
+	case len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(value):
+		return r.lookup(path, value[matches[0][2]:matches[0][3]])
+	}
+
+	buff := &bytes.Buffer{}
+	last := 0
+	for _, match := range matches {
+		ref := value[match[2]:match[3]]
+		resolved, err := r.lookup(path, ref)
+		if err != nil {
+			return nil, err
+		}
+		switch kind := reflect.ValueOf(resolved).Kind(); kind {
+		case reflect.Map, reflect.Slice:
+			return nil, PathErrorf(path,
+				"reference ${%s} is a %s, and can not be used as part of a string",
+				ref, kind)
+		}
+		buff.WriteString(value[last:match[0]])
+		buff.WriteString(fmt.Sprintf("%v", resolved))
+		last = match[1]
+	}
+	buff.WriteString(value[last:])
+	return buff.String(), nil
+}
+
+// lookup returns the resolved value of the field referenced by ref
+func (r *references) lookup(path Path, ref string) (interface{}, error) {
+	if r.resolving[ref] {
+		return nil, PathErrorf(path, "reference ${%s} refers to itself", ref)
+	}
+
+	parts := strings.Split(ref, ".")
+	value, ok := r.resources[parts[0]]
+	if !ok {
+		return nil, PathErrorf(path,
+			"invalid reference ${%s}, resource %q does not exist", ref, parts[0])
+	}
+	for i, key := range parts[1:] {
+		value, ok = lookupKey(value, key)
+		if !ok {
+			return nil, PathErrorf(path,
+				"invalid reference ${%s}, %q does not exist",
+				ref, strings.Join(parts[:i+2], "."))
+		}
+	}
+
+	r.resolving[ref] = true
+	defer delete(r.resolving, ref)
+	return r.resolve(path, value)
+}
+
+func lookupKey(value interface{}, key string) (interface{}, bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		item, ok := value[key]
+		return item, ok
+	case map[interface{}]interface{}:
+		item, ok := value[key]
+		return item, ok
+	case []interface{}:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(value) {
+			return nil, false
+		}
+		return value[index], true
+	default:
+		return nil, false
+	}
+}
+
+// valueMap is a config type for a mapping of arbitrary values
+type valueMap struct {
+	values map[interface{}]interface{}
+}
+
+func (v *valueMap) TransformConfig(raw reflect.Value) error {
+	switch value := raw.Interface().(type) {
+	case map[interface{}]interface{}:
+		v.values = value
+	default:
+		return fmt.Errorf("must be a mapping, not %T", value)
+	}
+	return nil
+}
+
+// IsZero returns true if there are no values
+func (v valueMap) IsZero() bool {
+	return len(v.values) == 0
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/renstrom/dedent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFromBytesWithReferences(t *testing.T) {
+	conf := dedent.Dedent(`
+		meta:
+		  values:
+		    score: 500
+		    workdir: /app
+		    memory: 2g
+
+		image=builder:
+		  image: example/builder
+		  args:
+		    VERSION: "3.3"
+
+		job=first:
+		  use: builder
+		  oom-score-adj: ${meta.values.score}
+		  working-dir: ${meta.values.workdir}/src
+		  memory: ${meta.values.memory}
+
+		image=release:
+		  image: example/app
+		  tags: ['${builder.args.VERSION}']
+
+		job=second:
+		  use: ${first.use}
+		  oom-score-adj: ${first.oom-score-adj}
+		  working-dir: ${first.working-dir}
+		  command: sh -c 'echo ${HOME}'
+	`)
+
+	config, err := LoadFromBytes([]byte(conf))
+	if !assert.Nil(t, err) {
+		return
+	}
+	first := config.Resources["first"].(*JobConfig)
+	assert.Equal(t, 500, first.OOMScoreAdj)
+	assert.Equal(t, "/app/src", first.WorkingDir)
+	assert.Equal(t, "2g", first.Memory)
+
+	release := config.Resources["release"].(*ImageConfig)
+	assert.Equal(t, []string{"3.3"}, release.Tags)
+
+	second := config.Resources["second"].(*JobConfig)
+	assert.Equal(t, "builder", second.Use)
+	assert.Equal(t, 500, second.OOMScoreAdj)
+	assert.Equal(t, "/app/src", second.WorkingDir)
+	assert.Equal(t, []string{"sh", "-c", "echo ${HOME}"}, second.Command.Value())
+}
+
+func TestLoadFromBytesReferencesOnlyInReferenceFields(t *testing.T) {
+	conf := dedent.Dedent(`
+		image=builder:
+		  image: example/builder
+
+		job=first:
+		  use: builder
+		  command: "sh -c \"echo ${VERSION:-1.0} ${f%.tar.gz} ${builder.image}\""
+		  env: ['IMAGE=${builder.image}']
+		  working-dir: ${HOME:-/root}
+	`)
+
+	config, err := LoadFromBytes([]byte(conf))
+	if !assert.Nil(t, err) {
+		return
+	}
+	first := config.Resources["first"].(*JobConfig)
+	assert.Equal(t,
+		[]string{"sh", "-c", "echo ${VERSION:-1.0} ${f%.tar.gz} ${builder.image}"},
+		first.Command.Value())
+	assert.Equal(t, []string{"IMAGE=${builder.image}"}, first.Env)
+	assert.Equal(t, "${HOME:-/root}", first.WorkingDir)
+}
+
+func TestLoadFromBytesWithInvalidReferences(t *testing.T) {
+	var testcases = []struct {
+		doc      string
+		conf     string
+		expected string
+	}{
+		{
+			doc: "missing resource",
+			conf: `
+				job=first:
+				  use: ${builder.image}
+			`,
+			expected: `Error at job=first.use: invalid reference ${builder.image}, resource "builder" does not exist`,
+		},
+		{
+			doc: "missing field",
+			conf: `
+				meta:
+				  values: {memory: 2g}
+				job=first:
+				  use: ${meta.values.score}
+			`,
+			expected: `Error at job=first.use: invalid reference ${meta.values.score}, "meta.values.score" does not exist`,
+		},
+		{
+			doc: "circular reference",
+			conf: `
+				job=first:
+				  use: ${first.use}
+			`,
+			expected: `Error at job=first.use: reference ${first.use} refers to itself`,
+		},
+		{
+			doc: "mapping in a string",
+			conf: `
+				image=builder:
+				  image: example/builder
+				  args: {VERSION: "3.3"}
+				job=first:
+				  use: builder-${builder.args}
+			`,
+			expected: `Error at job=first.use: reference ${builder.args} is a map, and can not be used as part of a string`,
+		},
+	}
+
+	for _, testcase := range testcases {
+		_, err := LoadFromBytes([]byte(dedent.Dedent(testcase.conf)))
+		if assert.Error(t, err, testcase.doc) {
+			assert.Contains(t, err.Error(), testcase.expected, testcase.doc)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/dnephin/dobi/execenv"
+)
+
+// RegistryConfig A **registry** resource provides the credentials used to
+// authenticate with a Docker registry. An `image`_ which sets **auth** to the
+// name of the **registry** uses the credentials to pull and push the image,
+// instead of the credentials from the docker client config file. The
+// credentials are either a **username** and **password**, or the name of a
+// **credential-helper**.
+// name: registry
+// example: Push an image to a private registry with credentials from the
+// environment
+//
+// .. code-block:: yaml
+//
+//     registry=private:
+//         server: registry.example.com
+//         username: '{env.REGISTRY_USER}'
+//         password: '{env.REGISTRY_PASSWORD}'
+//
+//     image=app:
+//         image: registry.example.com/team/app
+//         context: .
+//         auth: private
+//
+type RegistryConfig struct {
+	// Server The hostname, and optionally the port, of the registry. This
+	// field supports :doc:`variables`.
+	// example: ``registry.example.com:5000``
+	Server string `config:"required"`
+	// Username The username used to authenticate with the registry. This
+	// field supports :doc:`variables`.
+	Username string
+	// Password The password used to authenticate with the registry. This
+	// field supports :doc:`variables`, and should be set from an environment
+	// variable, so that the password is not stored in the config file.
+	// example: ``{env.REGISTRY_PASSWORD}``
+	Password string
+	// CredentialHelper The name of a docker credential helper, which is run
+	// as ``docker-credential-<name>`` to get the credentials for the
+	// **server** each time they are used.
+	// example: ``ecr-login``
+	CredentialHelper string
+	// Depends The list of resource dependencies.
+	// type: list of resource names
+	Depends []string
+}
+
+// Dependencies returns the list of tasks
+func (c *RegistryConfig) Dependencies() []string {
+	return c.Depends
+}
+
+// Validate checks that the credentials are a username and password, or a
+// credential helper
+func (c *RegistryConfig) Validate(path Path, config *Config) *PathError {
+	switch {
+	case c.CredentialHelper != "" && (c.Username != "" || c.Password != ""):
+		return PathErrorf(path.add("credential-helper"),
+			"can not be used with username or password")
+	case c.CredentialHelper == "" && (c.Username == "" || c.Password == ""):
+		return PathErrorf(path,
+			"one of credential-helper, or username and password, is required")
+	}
+	return nil
+}
+
+func (c *RegistryConfig) String() string {
+	return fmt.Sprintf("Authenticate with registry %q", c.Server)
+}
+
+// Resolve resolves variables in the resource
+func (c *RegistryConfig) Resolve(env *execenv.ExecEnv) (Resource, error) {
+	resolver := newFieldResolver(env)
+	c.Server = resolver.resolve("server", c.Server)
+	c.Username = resolver.resolve("username", c.Username)
+	c.Password = resolver.resolve("password", c.Password)
+	return c, resolver.err()
+}
+
+func registryFromConfig(name string, values map[string]interface{}) (Resource, error) {
+	registry := &RegistryConfig{}
+	return registry, Transform(name, values, registry)
+}
+
+func init() {
+	RegisterResource("registry", registryFromConfig)
+}
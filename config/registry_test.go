@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dnephin/dobi/execenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryValidate(t *testing.T) {
+	conf := NewConfig()
+	registry := &RegistryConfig{Server: "registry.example.com", CredentialHelper: "ecr-login"}
+	assert.Nil(t, registry.Validate(NewPath("private"), conf))
+
+	registry = &RegistryConfig{Server: "registry.example.com", Username: "deploy"}
+	err := registry.Validate(NewPath("private"), conf)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"one of credential-helper, or username and password, is required")
+	}
+
+	registry.CredentialHelper = "ecr-login"
+	err = registry.Validate(NewPath("private"), conf)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			"private.credential-helper: can not be used with username or password")
+	}
+}
+
+func TestRegistryResolve(t *testing.T) {
+	defer os.Unsetenv("DOBI_TEST_PASSWORD")
+	os.Setenv("DOBI_TEST_PASSWORD", "secret")
+	registry := &RegistryConfig{
+		Server:   "registry.example.com",
+		Username: "deploy",
+		Password: "{env.DOBI_TEST_PASSWORD}",
+	}
+	resolved, err := registry.Resolve(execenv.NewExecEnv("exec", "project", "."))
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", resolved.(*RegistryConfig).Password)
+}
+
+func TestImageConfigValidateAuth(t *testing.T) {
+	conf := NewConfig()
+	conf.Resources["private"] = &RegistryConfig{}
+	conf.Resources["data"] = &MountConfig{}
+
+	image := &ImageConfig{Image: "registry.example.com/app", Auth: "private"}
+	assert.Nil(t, image.validateAuth(conf))
+	assert.Equal(t, []string{"private"}, image.Dependencies())
+
+	image.Auth = "data"
+	assert.EqualError(t, image.validateAuth(conf), "data is not a registry resource")
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dnephin/dobi/execenv"
+)
+
+// envVariableRegex matches the name of each env variable in a template
+var envVariableRegex = regexp.MustCompile(`\{env\.([^:{}]+)`)
+
+// ResolveResource resolves variables in the resource. If any fields can not be
+// resolved the error is an ErrorList with a PathError for each field, so that
+// all the unresolved variables can be reported together.
+func ResolveResource(name string, resource Resource, env *execenv.ExecEnv) (Resource, error) {
+	resolved, err := resource.Resolve(env)
+	if err == nil {
+		return resolved, nil
+	}
+	errs := &ErrorList{}
+	errs.Add(err)
+	prefixed := &ErrorList{}
+	for _, err := range errs.Errors() {
+		prefixed.Add(prefixPath(name, err))
+	}
+	return resolved, prefixed
+}
+
+func prefixPath(name string, err error) *PathError {
+	root := NewPath(name)
+	pathErr, ok := err.(*PathError)
+	if !ok {
+		return PathErrorf(root, "%s", err)
+	}
+	return &PathError{path: Path{path: append(root.path, pathErr.path.path...)}, msg: pathErr.msg}
+}
+
+// fieldResolver resolves variables in the fields of a resource. A field which
+// can not be resolved keeps its original value, and the error is collected so
+// that the remaining fields are still resolved.
+type fieldResolver struct {
+	env  *execenv.ExecEnv
+	errs *ErrorList
+}
+
+func newFieldResolver(env *execenv.ExecEnv) *fieldResolver {
+	return &fieldResolver{env: env, errs: &ErrorList{}}
+}
+
+func (r *fieldResolver) resolve(field string, tmpl string) string {
+	value, err := r.env.Resolve(tmpl)
+	if err != nil {
+		r.errs.Add(PathErrorf(NewPath(field), "%s", err))
+		return tmpl
+	}
+	return value
+}
+
+func (r *fieldResolver) resolveSlice(field string, tmpls []string) []string {
+	resolved := []string{}
+	for _, tmpl := range tmpls {
+		resolved = append(resolved, r.resolve(field, tmpl))
+	}
+	return resolved
+}
+
+// resolvePath resolves a host path. A path with variables which resolves to an
+// empty path, or to the current directory, is an error, so that an empty
+// variable does not silently change the path to the whole project.
+func (r *fieldResolver) resolvePath(field string, tmpl string) string {
+	value, err := r.env.Resolve(tmpl)
+	if err != nil {
+		r.errs.Add(PathErrorf(NewPath(field), "%s", err))
+		return tmpl
+	}
+	if hasVariables(tmpl) && (value == "" || filepath.Clean(value) == ".") {
+		r.errs.Add(PathErrorf(NewPath(field),
+			"path %q resolved to %q, check that the variables are set", tmpl, value))
+		return tmpl
+	}
+	return value
+}
+
+func (r *fieldResolver) resolvePaths(field string, tmpls []string) []string {
+	resolved := []string{}
+	for _, tmpl := range tmpls {
+		resolved = append(resolved, r.resolvePath(field, tmpl))
+	}
+	return resolved
+}
+
+// resolveNonEmpty resolves a value which must not be empty. A value with
+// variables which resolves to an empty string is an error.
+func (r *fieldResolver) resolveNonEmpty(field string, tmpl string) string {
+	value, err := r.env.Resolve(tmpl)
+	if err != nil {
+		r.errs.Add(PathErrorf(NewPath(field), "%s", err))
+		return tmpl
+	}
+	if hasVariables(tmpl) && value == "" {
+		r.errs.Add(PathErrorf(NewPath(field),
+			"%q resolved to an empty value, check that the variables are set", tmpl))
+		return tmpl
+	}
+	return value
+}
+
+// resolveEnv resolves a list of KEY=value entries in order. In addition to the
+// host environment, an entry can use the value of an earlier entry with
+// {env.KEY}. A reference to a variable which is only set by a later entry is an
+// error.
+func (r *fieldResolver) resolveEnv(field string, entries []string) []string {
+	defined := make(map[string]string)
+	resolved := []string{}
+	for i, entry := range entries {
+		value, err := r.resolveEnvEntry(entry, entries[i+1:], defined)
+		if err != nil {
+			r.errs.Add(PathErrorf(NewPath(field), "%s", err))
+			resolved = append(resolved, entry)
+			continue
+		}
+		if parts := strings.SplitN(value, "=", 2); len(parts) == 2 {
+			defined[parts[0]] = parts[1]
+		}
+		resolved = append(resolved, value)
+	}
+	return resolved
+}
+
+func (r *fieldResolver) resolveEnvEntry(entry string, later []string, defined map[string]string) (string, error) {
+	name := envName(entry)
+	for _, match := range envVariableRegex.FindAllStringSubmatch(entry, -1) {
+		ref := match[1]
+		if _, ok := defined[ref]; ok || ref == name {
+			continue
+		}
+		for _, other := range later {
+			if envName(other) == ref {
+				return "", fmt.Errorf(
+					"variable \"env.%s\" is set by a later entry, and can not be used before it is set", ref)
+			}
+		}
+	}
+	return r.env.WithEnv(defined).Resolve(entry)
+}
+
+func envName(entry string) string {
+	return strings.SplitN(entry, "=", 2)[0]
+}
+
+func (r *fieldResolver) err() error {
+	return r.errs.ErrorOrNil()
+}
+
+// resolveCommand resolves a command which is a single variable, and splits the
+// resolved value into arguments. Other commands are returned unchanged.
+func (r *fieldResolver) resolveCommand(field string, command ShlexSlice) ShlexSlice {
+	if !command.isVariable() {
+		return command
+	}
+	value, err := r.env.Resolve(command.original)
+	if err != nil {
+		r.errs.Add(PathErrorf(NewPath(field), "%s", err))
+		return command
+	}
+	resolved := ShlexSlice{}
+	if err := resolved.set(value); err != nil {
+		r.errs.Add(PathErrorf(NewPath(field), "%s", err))
+		return command
+	}
+	return resolved
+}
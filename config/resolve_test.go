@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/dnephin/dobi/execenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveResourceReportsAllFields(t *testing.T) {
+	download := &DownloadConfig{
+		URL:  "https://example.com/{env.DOBI_TEST_VERSION}/tool",
+		Dest: "{env.DOBI_TEST_DEST}",
+	}
+	env := execenv.NewExecEnv("exec", "project", ".")
+
+	_, err := ResolveResource("tool", download, env)
+	list, ok := err.(*ErrorList)
+	if assert.True(t, ok) {
+		assert.Len(t, list.Errors(), 2)
+	}
+	assert.Contains(t, err.Error(),
+		`Error at tool.url: A value is required for variable "env.DOBI_TEST_VERSION"`)
+	assert.Contains(t, err.Error(),
+		`Error at tool.dest: A value is required for variable "env.DOBI_TEST_DEST"`)
+	assert.Equal(t, "{env.DOBI_TEST_DEST}", download.Dest)
+}
+
+func TestResolveResourceNoErrors(t *testing.T) {
+	network := &NetworkConfig{Name: "{project}-net"}
+	env := execenv.NewExecEnv("exec", "project", ".")
+
+	resolved, err := ResolveResource("net", network, env)
+	assert.Nil(t, err)
+	assert.Equal(t, "project-net", resolved.(*NetworkConfig).Name)
+}
+
+func TestResolveEnvReferencesEarlierEntries(t *testing.T) {
+	defer os.Unsetenv("DOBI_TEST_PATH")
+	os.Setenv("DOBI_TEST_PATH", "/usr/bin")
+	job := &JobConfig{Env: []string{
+		"DOBI_TEST_PATH=/tools:{env.DOBI_TEST_PATH}",
+		"TOOLS={env.DOBI_TEST_PATH}",
+		"DOBI_TEST_PATH=/more:{env.DOBI_TEST_PATH}",
+	}}
+	env := execenv.NewExecEnv("exec", "project", ".")
+
+	_, err := ResolveResource("job", job, env)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"DOBI_TEST_PATH=/tools:/usr/bin",
+		"TOOLS=/tools:/usr/bin",
+		"DOBI_TEST_PATH=/more:/tools:/usr/bin",
+	}, job.Env)
+}
+
+func TestResolveEnvForwardReference(t *testing.T) {
+	job := &JobConfig{Env: []string{
+		"URL=http://{env.HOST}/",
+		"HOST=example.com",
+	}}
+	env := execenv.NewExecEnv("exec", "project", ".")
+
+	_, err := ResolveResource("job", job, env)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			`Error at job.env: variable "env.HOST" is set by a later entry`)
+	}
+	assert.Equal(t, []string{"URL=http://{env.HOST}/", "HOST=example.com"}, job.Env)
+}
+
+func TestResolveJobPaths(t *testing.T) {
+	defer os.Unsetenv("DOBI_TEST_GOOS")
+	os.Setenv("DOBI_TEST_GOOS", "linux")
+	job := &JobConfig{
+		Artifact: "dist/app-{env.DOBI_TEST_GOOS}",
+		Sources:  []string{"src/", "config/{env.DOBI_TEST_GOOS}.yaml"},
+	}
+	env := execenv.NewExecEnv("exec", "project", ".")
+
+	_, err := ResolveResource("job", job, env)
+	assert.Nil(t, err)
+	assert.Equal(t, "dist/app-linux", job.Artifact)
+	assert.Equal(t, []string{"src/", "config/linux.yaml"}, job.Sources)
+}
+
+func TestResolveJobArtifactEmptyVariable(t *testing.T) {
+	job := &JobConfig{Artifact: "{env.DOBI_TEST_DIST_DIR:}"}
+	env := execenv.NewExecEnv("exec", "project", ".")
+
+	_, err := ResolveResource("job", job, env)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			`Error at job.artifact: "{env.DOBI_TEST_DIST_DIR:}" resolved to an empty value`)
+	}
+	assert.Equal(t, "{env.DOBI_TEST_DIST_DIR:}", job.Artifact)
+}
+
+func TestResolveJobArtifactsEmptyVariable(t *testing.T) {
+	job := &JobConfig{}
+	assert.Nil(t, job.Artifacts.TransformConfig(reflect.ValueOf(map[interface{}]interface{}{
+		"{env.DOBI_TEST_DIST_DIR:}": []interface{}{"src/"},
+		"dist/docs":                 []interface{}{"{env.DOBI_TEST_DOCS_DIR:}"},
+	})))
+	env := execenv.NewExecEnv("exec", "project", ".")
+
+	_, err := ResolveResource("job", job, env)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			`Error at job.artifacts: path "{env.DOBI_TEST_DIST_DIR:}" resolved to ""`)
+		assert.Contains(t, err.Error(),
+			`Error at job.artifacts.dist/docs: path "{env.DOBI_TEST_DOCS_DIR:}" resolved to ""`)
+	}
+}
+
+func TestResolveJobSourcesEmptyVariable(t *testing.T) {
+	job := &JobConfig{
+		Sources: []string{"src/", "{env.DOBI_TEST_SOURCE:}", "./{env.DOBI_TEST_SOURCE:}"},
+	}
+	env := execenv.NewExecEnv("exec", "project", ".")
+
+	_, err := ResolveResource("job", job, env)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			`Error at job.sources: path "{env.DOBI_TEST_SOURCE:}" resolved to ""`)
+		assert.Contains(t, err.Error(),
+			`Error at job.sources: path "./{env.DOBI_TEST_SOURCE:}" resolved to "./"`)
+	}
+	assert.Equal(t, []string{"src/", "{env.DOBI_TEST_SOURCE:}", "./{env.DOBI_TEST_SOURCE:}"},
+		job.Sources)
+}
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/dnephin/dobi/execenv"
+)
+
+// ServiceConfig A **service** resource starts a long running container, like a
+// database or a queue, which is used by other resources. The container runs in
+// the background until all the tasks are done, and is then stopped and
+// removed. A resource which lists the **service** in **depends** runs after the
+// service is ready. The service is ready when the **wait-for** condition is
+// met, or as soon as the container starts when **wait-for** is not set.
+// name: service
+// example: A database used by integration tests
+//
+// .. code-block:: yaml
+//
+//     service=db:
+//         use: postgres
+//         ports: ['5432:5432']
+//         wait-for: {tcp: 'localhost:5432', timeout: 30s}
+//
+//     job=test:
+//         use: builder
+//         command: go test ./integration
+//         depends: [db]
+//
+type ServiceConfig struct {
+	// Use The name of an `image`_ resource. The referenced image is used
+	// to create the container for the **service**.
+	Use string `config:"required"`
+	// Command The command to run in the container.
+	// type: shell quoted string, or list of strings
+	// example: ``"postgres -c fsync=off"``
+	Command ShlexSlice
+	// Entrypoint Override the image entrypoint
+	// type: shell quoted string, or list of strings
+	Entrypoint ShlexSlice
+	// User The user, and optionally the group, used to run the command in the
+	// container, as ``uid``, ``uid:gid``, ``username``, or
+	// ``username:groupname``. This field supports :doc:`variables`.
+	// default: *the user of the image*
+	User string `config:"validate"`
+	// WorkingDir The directory to set as the active working directory in the
+	// container. This field supports :doc:`variables`.
+	WorkingDir string
+	// Env Environment variables to pass to the container. This field
+	// supports :doc:`variables`.
+	// type: list of ``key=value`` strings
+	Env []string
+	// Mounts A list of `mount`_ resources to use when creating the container.
+	// type: list of mount resources
+	Mounts []string
+	// Ports Publish ports of the container on the host, in the same format
+	// as the **ports** of a `job`_. Each item in the list supports
+	// :doc:`variables`.
+	// type: list of ports
+	// example: ``["5432:5432"]``
+	Ports []string `config:"validate"`
+	// NetMode The network mode to use, in the same format as the
+	// **net-mode** of a `job`_. This field supports :doc:`variables`.
+	NetMode string
+	// WaitFor The condition which must be met before the **service** is
+	// ready, in the same format as the **wait-for** of a `job`_.
+	// type: mapping with keys ``tcp``, ``http``, ``exec``, and ``timeout``
+	// default: *ready when the container starts*
+	// example: ``{exec: "pg_isready", timeout: 30s}``
+	WaitFor WaitFor `config:"validate"`
+	// StopSignal The signal sent to the container to stop it.
+	// default: *the stop signal of the image, or* ``SIGTERM``
+	StopSignal string `config:"validate"`
+	// StopTimeout The time to wait for the container to exit after the
+	// **stop-signal** is sent, before the container is killed.
+	// type: duration string
+	// default: ``10s``
+	StopTimeout duration
+	// Depends The list of resource dependencies
+	// type: list of resource names
+	Depends []string
+}
+
+// Dependencies returns the list of implicit and explicit dependencies
+func (c *ServiceConfig) Dependencies() []string {
+	return c.Job().Dependencies()
+}
+
+// Validate checks that all fields have acceptable values
+func (c *ServiceConfig) Validate(path Path, config *Config) *PathError {
+	return c.Job().Validate(path, config)
+}
+
+// ValidateUser validates that User is a user, and an optional group, unless it
+// contains variables
+func (c *ServiceConfig) ValidateUser() error {
+	return c.Job().ValidateUser()
+}
+
+// ValidatePorts validates that each port which does not contain variables is
+// a valid port mapping
+func (c *ServiceConfig) ValidatePorts() error {
+	return c.Job().ValidatePorts()
+}
+
+// ValidateWaitFor validates the wait-for condition, if it is set
+func (c *ServiceConfig) ValidateWaitFor() error {
+	return c.Job().ValidateWaitFor()
+}
+
+// ValidateStopSignal validates that StopSignal is a signal name or number
+func (c *ServiceConfig) ValidateStopSignal() error {
+	return c.Job().ValidateStopSignal()
+}
+
+// Job returns a job config with the same values as the service, which is used
+// to create and stop the container of the service
+func (c *ServiceConfig) Job() *JobConfig {
+	return &JobConfig{
+		Use:         c.Use,
+		Command:     c.Command,
+		Entrypoint:  c.Entrypoint,
+		User:        c.User,
+		WorkingDir:  c.WorkingDir,
+		Env:         c.Env,
+		Mounts:      c.Mounts,
+		Ports:       c.Ports,
+		NetMode:     c.NetMode,
+		WaitFor:     c.WaitFor,
+		StopSignal:  c.StopSignal,
+		StopTimeout: c.StopTimeout,
+		Depends:     c.Depends,
+	}
+}
+
+func (c *ServiceConfig) String() string {
+	return fmt.Sprintf("Start a service from the '%s' image", c.Use)
+}
+
+// Resolve resolves variables in the resource
+func (c *ServiceConfig) Resolve(env *execenv.ExecEnv) (Resource, error) {
+	resolver := newFieldResolver(env)
+	c.Use = resolver.resolve("use", c.Use)
+	c.User = resolver.resolve("user", c.User)
+	c.WorkingDir = resolver.resolve("working-dir", c.WorkingDir)
+	c.Env = resolver.resolveEnv("env", c.Env)
+	c.Ports = resolver.resolveSlice("ports", c.Ports)
+	c.NetMode = resolver.resolve("net-mode", c.NetMode)
+	c.WaitFor.TCP = resolver.resolve("wait-for.tcp", c.WaitFor.TCP)
+	c.WaitFor.HTTP = resolver.resolve("wait-for.http", c.WaitFor.HTTP)
+	return c, resolver.err()
+}
+
+func serviceFromConfig(name string, values map[string]interface{}) (Resource, error) {
+	service := &ServiceConfig{}
+	return service, Transform(name, values, service)
+}
+
+func init() {
+	RegisterResource("service", serviceFromConfig)
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dnephin/dobi/execenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceFromConfig(t *testing.T) {
+	resource, err := serviceFromConfig("service=db", map[string]interface{}{
+		"use":      "postgres",
+		"ports":    []interface{}{"5432:5432"},
+		"mounts":   []interface{}{"data"},
+		"depends":  []interface{}{"network"},
+		"wait-for": map[interface{}]interface{}{"tcp": "localhost:5432"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	service := resource.(*ServiceConfig)
+	assert.Equal(t, []string{"postgres", "network", "data"}, service.Dependencies())
+
+	job := service.Job()
+	assert.Equal(t, "postgres", job.Use)
+	assert.Equal(t, []string{"5432:5432"}, job.Ports)
+	assert.Equal(t, "localhost:5432", job.WaitFor.TCP)
+}
+
+func TestServiceValidate(t *testing.T) {
+	conf := NewConfig()
+	conf.Resources["postgres"] = NewImageConfig()
+	conf.Resources["data"] = &MountConfig{}
+
+	service := &ServiceConfig{Use: "postgres", Mounts: []string{"data"}}
+	assert.Nil(t, service.Validate(NewPath("db"), conf))
+
+	service = &ServiceConfig{Use: "data"}
+	err := service.Validate(NewPath("db"), conf)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "db.use: data is not an image resource")
+	}
+}
+
+func TestServiceResolve(t *testing.T) {
+	defer os.Unsetenv("DOBI_TEST_PORT")
+	os.Setenv("DOBI_TEST_PORT", "5433")
+	service := &ServiceConfig{
+		Use:   "postgres",
+		Ports: []string{"{env.DOBI_TEST_PORT}:5432"},
+		Env:   []string{"PGPORT={env.DOBI_TEST_PORT}"},
+	}
+	_, err := service.Resolve(execenv.NewExecEnv("exec", "project", "."))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"5433:5432"}, service.Ports)
+	assert.Equal(t, []string{"PGPORT=5433"}, service.Env)
+}
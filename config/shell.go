@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/dnephin/dobi/execenv"
+)
+
+// ShellConfig A **shell** resource runs a command on the host, instead of in a
+// container. Like a `job`_, a **shell** resource that doesn't have an
+// **artifact** is never considered up-to-date and will always run. If it has
+// an **artifact** it runs when the **artifact** is older than any of the
+// **sources**, or when one of its dependencies was modified.
+// name: shell
+// example: Generate code on the host before building an image
+//
+// .. code-block:: yaml
+//
+//     shell=generate:
+//         command: ./script/generate
+//         sources: [api/schema.json]
+//         artifact: api/generated.go
+//
+type ShellConfig struct {
+	// Command The command to run on the host.
+	// type: shell quoted string
+	// example: ``"bash -c 'echo something'"``
+	Command ShlexSlice
+	// Artifact A host path to a file or directory that is the output of this
+	// **shell**. Paths are relative to the current working directory. This
+	// field supports :doc:`variables`.
+	Artifact string
+	// Sources A list of files or directories which are used to create the
+	// artifact. The modified time of these files are compared to the modified
+	// time of the artifact to determine if the **shell** is stale.
+	// type: list of files or directories
+	Sources []string
+	// Env Environment variables to set for the command, in addition to the
+	// environment of **dobi**. This field supports :doc:`variables`. Entries
+	// are resolved in order, so an entry can use the value of an earlier
+	// entry with ``{env.<key>}``.
+	// type: list of ``key=value`` strings
+	Env []string
+	// WorkingDir The directory where the command runs. Paths are relative to
+	// the directory of the ``dobi.yaml``. This field supports
+	// :doc:`variables`.
+	// default: *the directory of the* ``dobi.yaml``
+	WorkingDir string
+	// Depends The list of resource dependencies
+	// type: list of resource names
+	Depends []string
+}
+
+// Dependencies returns the list of tasks
+func (c *ShellConfig) Dependencies() []string {
+	return c.Depends
+}
+
+// Validate checks that all fields have acceptable values
+func (c *ShellConfig) Validate(path Path, config *Config) *PathError {
+	if len(c.Command.Value()) == 0 {
+		return PathErrorf(path.add("command"), "a command is required")
+	}
+	return nil
+}
+
+func (c *ShellConfig) String() string {
+	if c.Artifact != "" {
+		return fmt.Sprintf("Run '%s' on the host to create %s", c.Command.String(), c.Artifact)
+	}
+	return fmt.Sprintf("Run '%s' on the host", c.Command.String())
+}
+
+// Resolve resolves variables in the resource
+func (c *ShellConfig) Resolve(env *execenv.ExecEnv) (Resource, error) {
+	resolver := newFieldResolver(env)
+	c.Artifact = resolver.resolve("artifact", c.Artifact)
+	c.Env = resolver.resolveEnv("env", c.Env)
+	c.WorkingDir = resolver.resolve("working-dir", c.WorkingDir)
+	return c, resolver.err()
+}
+
+func shellFromConfig(name string, values map[string]interface{}) (Resource, error) {
+	shell := &ShellConfig{}
+	return shell, Transform(name, values, shell)
+}
+
+func init() {
+	RegisterResource("shell", shellFromConfig)
+}
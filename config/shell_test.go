@@ -0,0 +1,34 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellFromConfig(t *testing.T) {
+	resource, err := shellFromConfig("shell=generate", map[string]interface{}{
+		"command":  "./script/generate --out api",
+		"artifact": "api/generated.go",
+	})
+	assert.Nil(t, err)
+	shell := resource.(*ShellConfig)
+	assert.Equal(t, []string{"./script/generate", "--out", "api"}, shell.Command.Value())
+	assert.Nil(t, shell.Validate(NewPath("generate"), NewConfig()))
+}
+
+func TestShellConfigValidateEmptyCommand(t *testing.T) {
+	for _, command := range []interface{}{nil, "", "  "} {
+		values := map[string]interface{}{}
+		if command != nil {
+			values["command"] = command
+		}
+		resource, err := shellFromConfig("shell=generate", values)
+		assert.Nil(t, err)
+
+		err = resource.Validate(NewPath("generate"), NewConfig())
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "generate.command: a command is required")
+		}
+	}
+}
@@ -174,11 +174,16 @@ func transformSlice(path Path, raw reflect.Value, target reflect.Value) error {
 
 func transformMap(path Path, raw reflect.Value, target reflect.Value) error {
 	elementType := target.Type().Elem()
+	keyType := target.Type().Key()
 
 	target.Set(reflect.MakeMap(target.Type()))
 	for _, key := range raw.MapKeys() {
-		// TODO: how to I check keys Kind? against target key.Kind()
 		key = key.Elem()
+		if key.Kind() != keyType.Kind() {
+			return PathErrorf(path.add(fmt.Sprintf("%v", key.Interface())),
+				"key in the map is of wrong type %q, expected %q",
+				key.Kind(), keyType)
+		}
 		item := raw.MapIndex(key).Elem()
 
 		if item.Kind() != elementType.Kind() {
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
+	shlex "github.com/kballard/go-shellquote"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -14,12 +16,19 @@ const (
 
 var (
 	reservedNames = map[string]bool{
+		"artifacts": true,
 		"autoclean": true,
+		"clean":     true,
+		"graph":     true,
 		"list":      true,
+		"validate":  true,
+		"why":       true,
 		META:        true,
 	}
 
 	resourceTypeRegistry = map[string]resourceFactory{}
+
+	resourceOptionKeys = []string{"cleanup", "labels", "pause", "require", "when"}
 )
 
 type resourceFactory func(string, map[string]interface{}) (Resource, error)
@@ -43,6 +52,16 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		// TODO: better error message on unmarshal failure
 		return err
 	}
+	if err := resolveReferences(values); err != nil {
+		return err
+	}
+	matrixes, err := expandMatrix(values)
+	if err != nil {
+		return err
+	}
+	for name, resType := range matrixes {
+		c.matrixes[name] = resType
+	}
 
 	if value, ok := values[META]; ok {
 		if err := c.loadMeta(value); err != nil {
@@ -60,6 +79,10 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			return err
 		}
 
+		options, err := unmarshalOptions(name, value)
+		if err != nil {
+			return fmt.Errorf("Invalid config for resource %q:\n%s", name, err)
+		}
 		resource, err := unmarshalResource(name, resType, value)
 		if err != nil {
 			return fmt.Errorf("Invalid config for resource %q:\n%s", name, err)
@@ -67,6 +90,7 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		if err := c.add(resName, resource); err != nil {
 			return err
 		}
+		c.setOptions(resName, options)
 	}
 	return nil
 }
@@ -78,8 +102,11 @@ func (c *Config) loadMeta(value map[string]interface{}) error {
 		return fmt.Errorf("Invalid \"meta\" config: %s", err)
 	}
 
-	// TODO: prevent infinite recursive includes
-	for _, include := range c.Meta.Include {
+	includes, err := expandIncludes(c.Meta.Include)
+	if err != nil {
+		return err
+	}
+	for _, include := range includes {
 		config, err := loadConfig(include)
 		if err != nil {
 			return fmt.Errorf("error including %q: %s", include, err)
@@ -91,11 +118,44 @@ func (c *Config) loadMeta(value map[string]interface{}) error {
 			if err := c.add(name, resource); err != nil {
 				return fmt.Errorf("error including %q: %s", include, err)
 			}
+			c.setOptions(name, config.OptionsFor(name))
+			c.includedFrom[name] = include
+			if resType, ok := config.matrixes[name]; ok {
+				c.matrixes[name] = resType
+			}
 		}
 	}
 	return nil
 }
 
+// expandIncludes returns the list of files to include. Includes which are glob
+// patterns are replaced by the files which match the pattern, in sorted order.
+// Each file is only included once.
+func expandIncludes(includes []string) ([]string, error) {
+	seen := map[string]bool{}
+	files := []string{}
+	for _, include := range includes {
+		matches := []string{include}
+		if strings.ContainsAny(include, "*?[") {
+			var err error
+			matches, err = filepath.Glob(include)
+			switch {
+			case err != nil:
+				return nil, fmt.Errorf("invalid include pattern %q: %s", include, err)
+			case len(matches) == 0:
+				return nil, fmt.Errorf("no files match include pattern %q", include)
+			}
+		}
+		for _, file := range matches {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
 func parseResourceName(value string) (string, string, error) {
 	parts := strings.SplitN(value, "=", 2)
 	if len(parts) != 2 {
@@ -111,6 +171,54 @@ func RegisterResource(name string, typeFunc resourceFactory) {
 	resourceTypeRegistry[name] = typeFunc
 }
 
+// unmarshalOptions removes the fields of ResourceOptions from the resource
+// values and returns them as ResourceOptions
+func unmarshalOptions(name string, values map[string]interface{}) (*ResourceOptions, error) {
+	raw := make(map[string]interface{})
+	for _, key := range resourceOptionKeys {
+		if value, ok := values[key]; ok {
+			raw[key] = value
+			delete(values, key)
+		}
+	}
+	options := &ResourceOptions{}
+	if err := Transform(name, raw, options); err != nil {
+		return options, err
+	}
+	path := NewPath(name)
+	if err := validateRequire(path.add("require"), options.Require); err != nil {
+		return options, err
+	}
+	if options.When != "" {
+		if _, err := parseWhen(options.When); err != nil {
+			return options, PathErrorf(path.add("when"), err.Error())
+		}
+	}
+	resType, _, err := parseResourceName(name)
+	if err != nil {
+		return options, err
+	}
+	if err := validateCleanup(path.add("cleanup"), resType, options.Cleanup); err != nil {
+		return options, err
+	}
+	return options, nil
+}
+
+// validateRequire checks that each required command is a valid shell quoted
+// string
+func validateRequire(path Path, commands []string) error {
+	for _, command := range commands {
+		parsed, err := shlex.Split(command)
+		switch {
+		case err != nil:
+			return PathErrorf(path, "failed to parse command %q: %s", command, err)
+		case len(parsed) == 0:
+			return PathErrorf(path, "a command is required")
+		}
+	}
+	return nil
+}
+
 func unmarshalResource(name, resType string, value map[string]interface{}) (Resource, error) {
 	fromConfigFunc, ok := resourceTypeRegistry[resType]
 	if !ok {
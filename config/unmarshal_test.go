@@ -1,9 +1,13 @@
 package config
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
 	"github.com/renstrom/dedent"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 func TestLoadFromBytes(t *testing.T) {
@@ -76,6 +80,17 @@ func TestLoadFromBytesWithReservedName(t *testing.T) {
 	assert.Contains(t, err.Error(), "\"autoclean\" is reserved")
 }
 
+func TestLoadFromBytesWithCommandName(t *testing.T) {
+	conf := dedent.Dedent(`
+		alias=graph:
+		  tasks: []
+	`)
+
+	_, err := LoadFromBytes([]byte(conf))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "\"graph\" is reserved")
+}
+
 func TestLoadFromBytesWithInvalidName(t *testing.T) {
 	conf := dedent.Dedent(`
 		image=image:latest:
@@ -87,3 +102,181 @@ func TestLoadFromBytesWithInvalidName(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Invalid character \":\"")
 }
+
+func TestLoadFromBytesWithResourceOptions(t *testing.T) {
+	conf := dedent.Dedent(`
+		mount=vol-def:
+		  bind: dist/
+		  path: /target
+		  labels:
+		    owner: team-a
+		    purpose: build output
+
+		alias=alias-def:
+		  tasks: [vol-def]
+		  pause: true
+	`)
+
+	config, err := LoadFromBytes([]byte(conf))
+	assert.Nil(t, err)
+	assert.Equal(t,
+		map[string]string{"owner": "team-a", "purpose": "build output"},
+		config.OptionsFor("vol-def").Labels)
+	assert.Equal(t, &ResourceOptions{Pause: true}, config.OptionsFor("alias-def"))
+}
+
+func TestLoadFromBytesWithRequire(t *testing.T) {
+	conf := dedent.Dedent(`
+		alias=deploy:
+		  tasks: []
+		  require: ["ping -c 1 vpn.internal"]
+	`)
+
+	config, err := LoadFromBytes([]byte(conf))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ping -c 1 vpn.internal"}, config.OptionsFor("deploy").Require)
+}
+
+func TestLoadFromBytesWithInvalidRequire(t *testing.T) {
+	conf := dedent.Dedent(`
+		alias=deploy:
+		  tasks: []
+		  require: ["ping 'vpn"]
+	`)
+
+	_, err := LoadFromBytes([]byte(conf))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(),
+		"Error at alias=deploy.require: failed to parse command \"ping 'vpn\"")
+}
+
+func TestLoadFromBytesWithInvalidLabelKey(t *testing.T) {
+	conf := dedent.Dedent(`
+		alias=alias-def:
+		  tasks: []
+		  labels:
+		    1: one
+	`)
+
+	_, err := LoadFromBytes([]byte(conf))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(),
+		"Error at alias=alias-def.labels.1: key in the map is of wrong type")
+}
+
+func writeIncludes(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "include-test")
+	assert.Nil(t, err)
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(dedent.Dedent(content)), 0644))
+	}
+	return dir
+}
+
+func TestLoadFromBytesWithIncludeGlob(t *testing.T) {
+	dir := writeIncludes(t, map[string]string{
+		"api/dobi.yaml": `
+			alias=api:
+			  tasks: []
+		`,
+		"web/dobi.yaml": `
+			alias=web:
+			  tasks: []
+		`,
+	})
+	defer os.RemoveAll(dir)
+
+	conf, err := LoadFromBytes([]byte("meta:\n  include: ['" + dir + "/*/dobi.yaml']\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"api", "web"}, conf.Sorted())
+	assert.Equal(t, filepath.Join(dir, "web/dobi.yaml"), conf.includedFrom["web"])
+}
+
+func TestLoadFromBytesWithIncludeGlobNoMatches(t *testing.T) {
+	_, err := LoadFromBytes([]byte("meta:\n  include: ['/does/not/exist/*.yaml']\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `no files match include pattern "/does/not/exist/*.yaml"`)
+}
+
+func TestLoadFromBytesWithDuplicateIncludedResource(t *testing.T) {
+	dir := writeIncludes(t, map[string]string{
+		"api.yaml": `
+			alias=shared:
+			  tasks: []
+		`,
+	})
+	defer os.RemoveAll(dir)
+	include := filepath.Join(dir, "api.yaml")
+
+	_, err := LoadFromBytes([]byte(dedent.Dedent(`
+		meta:
+		  include: ['` + include + `']
+		alias=shared:
+		  tasks: []
+	`)))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(),
+		`duplicate resource name "shared", also defined in "`+include+`"`)
+}
+
+func TestValidateIncludedResourceErrorHasFile(t *testing.T) {
+	dir := writeIncludes(t, map[string]string{
+		"api.yaml": `
+			alias=api:
+			  tasks: [missing]
+		`,
+	})
+	defer os.RemoveAll(dir)
+	include := filepath.Join(dir, "api.yaml")
+
+	conf, err := LoadFromBytes([]byte("meta:\n  include: ['" + include + "']\n"))
+	assert.Nil(t, err)
+	err = validate(conf)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `Error at api in "`+include+`": missing dependencies: missing`)
+	assert.Equal(t, include, Problems(err, nil)[0].File)
+}
+
+func TestLoadFromBytesWithInvalidWhen(t *testing.T) {
+	conf := dedent.Dedent(`
+		alias=deploy:
+		  tasks: []
+		  when: "{env.BRANCH} == main == other"
+	`)
+
+	_, err := LoadFromBytes([]byte(conf))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Error at alias=deploy.when: invalid expression")
+}
+
+func TestLoadFromBytesWithCleanup(t *testing.T) {
+	conf := dedent.Dedent(`
+		image=base:
+		  image: example/base
+		  cleanup: {never: true}
+
+		image=app:
+		  image: example/app
+		  cleanup: {keep-tags: 3}
+	`)
+
+	config, err := LoadFromBytes([]byte(conf))
+	assert.Nil(t, err)
+	assert.Equal(t, Cleanup{Never: true}, config.OptionsFor("base").Cleanup)
+	assert.Equal(t, Cleanup{KeepTags: 3}, config.Collection.Cleanup("app"))
+}
+
+func TestLoadFromBytesWithInvalidCleanup(t *testing.T) {
+	conf := dedent.Dedent(`
+		job=test:
+		  use: builder
+		  cleanup: {keep-tags: 3}
+	`)
+
+	_, err := LoadFromBytes([]byte(conf))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(),
+		"Error at job=test.cleanup.keep-tags: is only supported by image resources")
+}
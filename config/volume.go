@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/dnephin/dobi/execenv"
+)
+
+var (
+	volumeDriverRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:/-]*$`)
+
+	optionNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+)
+
+// VolumeConfig A **volume** resource creates a named Docker volume. The volume
+// is only created if a volume with the same **name** does not exist. A
+// `mount`_ resource can mount the volume in a container by setting
+// **volume** to the name of the **volume** resource.
+// name: volume
+// example: Create a volume for a package cache, and mount it in a job
+//
+// .. code-block:: yaml
+//
+//     volume=cache:
+//         name: '{project}-cache'
+//
+//     mount=cache:
+//         volume: cache
+//         path: /root/.cache
+//
+//     job=test:
+//         use: builder
+//         mounts: [cache]
+//
+type VolumeConfig struct {
+	// Name The name of the Docker volume. This field supports
+	// :doc:`variables`.
+	Name string `config:"required"`
+	// Driver The volume driver.
+	// default: ``local``
+	Driver string `config:"validate"`
+	// DriverOpts Driver specific options for the volume.
+	// type: mapping ``key: value``
+	DriverOpts map[string]string `config:"driver-opts,validate"`
+	// Labels Labels to set on the volume.
+	// type: mapping ``key: value``
+	Labels map[string]string `config:"validate"`
+	// Depends The list of resource dependencies.
+	// type: list of resource names
+	Depends []string
+}
+
+// Dependencies returns the list of tasks
+func (c *VolumeConfig) Dependencies() []string {
+	return c.Depends
+}
+
+// Validate the resource
+func (c *VolumeConfig) Validate(path Path, config *Config) *PathError {
+	return nil
+}
+
+// ValidateDriver validates the name of the volume driver
+func (c *VolumeConfig) ValidateDriver() error {
+	if !volumeDriverRegex.MatchString(c.Driver) {
+		return fmt.Errorf("invalid driver %q", c.Driver)
+	}
+	return nil
+}
+
+// ValidateDriverOpts validates the names of the driver options
+func (c *VolumeConfig) ValidateDriverOpts() error {
+	return validateOptionNames(c.DriverOpts)
+}
+
+// ValidateLabels validates the names of the labels
+func (c *VolumeConfig) ValidateLabels() error {
+	return validateOptionNames(c.Labels)
+}
+
+func validateOptionNames(options map[string]string) error {
+	for key := range options {
+		if !optionNameRegex.MatchString(key) {
+			return fmt.Errorf("invalid name %q", key)
+		}
+	}
+	return nil
+}
+
+func (c *VolumeConfig) String() string {
+	return fmt.Sprintf("Create %s volume %q", c.Driver, c.Name)
+}
+
+// Resolve resolves variables in the resource
+func (c *VolumeConfig) Resolve(env *execenv.ExecEnv) (Resource, error) {
+	resolver := newFieldResolver(env)
+	c.Name = resolver.resolve("name", c.Name)
+	return c, resolver.err()
+}
+
+func volumeFromConfig(name string, values map[string]interface{}) (Resource, error) {
+	volume := &VolumeConfig{Driver: "local"}
+	return volume, Transform(name, values, volume)
+}
+
+func init() {
+	RegisterResource("volume", volumeFromConfig)
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolumeFromConfig(t *testing.T) {
+	resource, err := volumeFromConfig("volume=cache", map[string]interface{}{
+		"name":        "project-cache",
+		"driver-opts": map[interface{}]interface{}{"type": "tmpfs"},
+	})
+	assert.Nil(t, err)
+	volume := resource.(*VolumeConfig)
+	assert.Equal(t, "local", volume.Driver)
+	assert.Equal(t, map[string]string{"type": "tmpfs"}, volume.DriverOpts)
+	assert.Nil(t, ValidateFields(NewPath("cache"), volume))
+}
+
+func TestVolumeConfigValidateDriver(t *testing.T) {
+	volume := &VolumeConfig{Driver: "vieux/sshfs:latest"}
+	assert.Nil(t, volume.ValidateDriver())
+
+	volume.Driver = "bad driver"
+	err := volume.ValidateDriver()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `invalid driver "bad driver"`)
+	}
+}
+
+func TestVolumeConfigValidateLabels(t *testing.T) {
+	volume := &VolumeConfig{Labels: map[string]string{"com.example.team": "ci"}}
+	assert.Nil(t, volume.ValidateLabels())
+
+	volume.Labels = map[string]string{"": "ci"}
+	err := volume.ValidateLabels()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `invalid name ""`)
+	}
+}
+
+func TestMountConfigValidateVolume(t *testing.T) {
+	conf := NewConfig()
+	conf.Resources["cache"] = &VolumeConfig{Name: "project-cache"}
+	conf.Resources["image"] = NewImageConfig()
+
+	mount := &MountConfig{Volume: "cache", Path: "/cache"}
+	assert.Nil(t, mount.Validate(NewPath("mount"), conf))
+	assert.Equal(t, []string{"cache"}, mount.Dependencies())
+
+	mount.Volume = "image"
+	err := mount.Validate(NewPath("mount"), conf)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "mount.volume: image is not a volume resource")
+	}
+}
+
+func TestMountConfigValidateBindOrVolume(t *testing.T) {
+	conf := NewConfig()
+	conf.Resources["cache"] = &VolumeConfig{Name: "project-cache"}
+
+	mount := &MountConfig{Path: "/cache"}
+	err := mount.Validate(NewPath("mount"), conf)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "one of bind or volume is required")
+	}
+
+	mount = &MountConfig{Bind: "cache", Volume: "cache", Path: "/cache"}
+	err = mount.Validate(NewPath("mount"), conf)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "bind and volume can not both be set")
+	}
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dnephin/dobi/execenv"
+)
+
+// WaitConfig A **wait** resource checks a condition until it is met, so that
+// the resources which depend on it do not run until a service is ready. Use a
+// **wait** resource to wait for a service which is started by another
+// resource, like a `compose`_ project. The condition is met when a connection
+// to **tcp** succeeds, when a request to **http** returns a ``2xx`` or ``3xx``
+// status, or when the **exec** command exits with a zero status. Exactly one
+// of **tcp**, **http**, or **exec** must be set. The task fails if the
+// condition is not met before the **timeout**.
+// name: wait
+// example: Wait for a database started by compose before running tests
+//
+// .. code-block:: yaml
+//
+//     compose=db:
+//         files: [docker-compose.yml]
+//
+//     wait=db-ready:
+//         tcp: localhost:5432
+//         timeout: 30s
+//         depends: [db]
+//
+//     job=test:
+//         use: builder
+//         command: go test ./integration
+//         depends: [db-ready]
+//
+type WaitConfig struct {
+	// TCP The address to connect to, as ``host:port`` or
+	// ``tcp://host:port``. This field supports :doc:`variables`.
+	// example: ``localhost:5432``
+	TCP string
+	// HTTP The url to request. This field supports :doc:`variables`.
+	// example: ``http://localhost:8080/healthz``
+	HTTP string
+	// Exec A command to run on the host, from the directory of the
+	// ``dobi.yaml``.
+	// type: shell quoted string, or list of strings
+	// example: ``"pg_isready -h localhost"``
+	Exec ShlexSlice
+	// Timeout The maximum time to wait for the condition.
+	// type: duration string
+	// default: ``1m``
+	// example: ``30s``
+	Timeout duration
+	// Interval The time to wait between checks of the condition.
+	// type: duration string
+	// default: ``1s``
+	Interval duration
+	// Depends The list of resource dependencies.
+	// type: list of resource names
+	Depends []string
+}
+
+// Dependencies returns the list of tasks
+func (c *WaitConfig) Dependencies() []string {
+	return c.Depends
+}
+
+// Validate checks that exactly one condition is set
+func (c *WaitConfig) Validate(path Path, config *Config) *PathError {
+	if c.TCP == "" && c.HTTP == "" && c.Exec.Empty() {
+		return PathErrorf(path, "one of tcp, http, or exec is required")
+	}
+	if err := c.WaitFor().Validate(); err != nil {
+		return PathErrorf(path, err.Error())
+	}
+	return nil
+}
+
+// WaitFor returns the condition of the resource
+func (c *WaitConfig) WaitFor() *WaitFor {
+	return &WaitFor{TCP: c.TCP, HTTP: c.HTTP, Exec: c.Exec, timeout: c.Timeout}
+}
+
+// CheckInterval returns the time to wait between checks of the condition
+func (c *WaitConfig) CheckInterval() time.Duration {
+	if c.Interval.Duration() == 0 {
+		return time.Second
+	}
+	return c.Interval.Duration()
+}
+
+func (c *WaitConfig) String() string {
+	return fmt.Sprintf("Wait for %s", c.WaitFor())
+}
+
+// Resolve resolves variables in the resource
+func (c *WaitConfig) Resolve(env *execenv.ExecEnv) (Resource, error) {
+	resolver := newFieldResolver(env)
+	c.TCP = resolver.resolve("tcp", c.TCP)
+	c.HTTP = resolver.resolve("http", c.HTTP)
+	return c, resolver.err()
+}
+
+// validateHTTPCheck checks that the url of an http condition is an http or
+// https url
+func validateHTTPCheck(value string) error {
+	if value == "" {
+		return nil
+	}
+	parsed, err := url.Parse(value)
+	switch {
+	case err != nil:
+		return fmt.Errorf("invalid url %q: %s", value, err)
+	case parsed.Scheme != "http" && parsed.Scheme != "https":
+		return fmt.Errorf("invalid url %q, the scheme must be http or https", value)
+	case parsed.Host == "":
+		return fmt.Errorf("invalid url %q, a host is required", value)
+	}
+	return nil
+}
+
+// tcpAddress returns the address of a tcp condition without the optional
+// tcp:// scheme
+func tcpAddress(value string) string {
+	return strings.TrimPrefix(value, "tcp://")
+}
+
+func waitFromConfig(name string, values map[string]interface{}) (Resource, error) {
+	wait := &WaitConfig{}
+	return wait, Transform(name, values, wait)
+}
+
+func init() {
+	RegisterResource("wait", waitFromConfig)
+}
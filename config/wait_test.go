@@ -0,0 +1,55 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitConfigValidate(t *testing.T) {
+	var testcases = []struct {
+		doc      string
+		wait     *WaitConfig
+		expected string
+	}{
+		{
+			doc:  "tcp",
+			wait: &WaitConfig{TCP: "tcp://localhost:5432"},
+		},
+		{
+			doc:  "http with variables",
+			wait: &WaitConfig{HTTP: "{env.HEALTH_URL}"},
+		},
+		{
+			doc:      "no condition",
+			wait:     &WaitConfig{},
+			expected: "one of tcp, http, or exec is required",
+		},
+		{
+			doc:      "more than one condition",
+			wait:     &WaitConfig{TCP: "localhost:5432", HTTP: "http://localhost"},
+			expected: "only one of tcp, http, or exec can be set",
+		},
+		{
+			doc:      "invalid http scheme",
+			wait:     &WaitConfig{HTTP: "localhost:8080/healthz"},
+			expected: "the scheme must be http or https",
+		},
+	}
+
+	for _, testcase := range testcases {
+		err := testcase.wait.Validate(NewPath("wait=ready"), NewConfig())
+		if testcase.expected == "" {
+			assert.Nil(t, err, testcase.doc)
+			continue
+		}
+		if assert.NotNil(t, err, testcase.doc) {
+			assert.Contains(t, err.Error(), testcase.expected, testcase.doc)
+		}
+	}
+}
+
+func TestWaitConfigString(t *testing.T) {
+	wait := &WaitConfig{TCP: "tcp://localhost:5432"}
+	assert.Equal(t, "Wait for tcp localhost:5432", wait.String())
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// defaultWaitTimeout is the timeout of a wait-for condition which does not set
+// one
+const defaultWaitTimeout = time.Minute
+
+// WaitFor is a config type for the condition which must be met before a job
+// is complete
+type WaitFor struct {
+	TCP     string
+	HTTP    string
+	Exec    ShlexSlice
+	timeout duration
+}
+
+// TransformConfig sets the fields of the condition from a mapping
+func (w *WaitFor) TransformConfig(raw reflect.Value) error {
+	values, ok := raw.Interface().(map[interface{}]interface{})
+	if !ok {
+		return fmt.Errorf("must be a mapping, not %T", raw.Interface())
+	}
+	for key, value := range values {
+		var err error
+		switch key {
+		case "tcp":
+			w.TCP, ok = value.(string)
+			if !ok {
+				err = fmt.Errorf("tcp must be a string, not %T", value)
+			}
+		case "http":
+			w.HTTP, ok = value.(string)
+			if !ok {
+				err = fmt.Errorf("http must be a string, not %T", value)
+			}
+		case "exec":
+			err = w.Exec.TransformConfig(reflect.ValueOf(value))
+		case "timeout":
+			err = w.timeout.TransformConfig(reflect.ValueOf(value))
+		default:
+			err = fmt.Errorf("unexpected key %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks that exactly one of tcp, http, or exec is set
+func (w *WaitFor) Validate() error {
+	if w.IsZero() {
+		return nil
+	}
+	count := 0
+	for _, isSet := range []bool{w.TCP != "", w.HTTP != "", !w.Exec.Empty()} {
+		if isSet {
+			count++
+		}
+	}
+	switch {
+	case count == 0:
+		return fmt.Errorf("one of tcp, http, or exec is required")
+	case count > 1:
+		return fmt.Errorf("only one of tcp, http, or exec can be set")
+	}
+	if hasVariables(w.HTTP) {
+		return nil
+	}
+	return validateHTTPCheck(w.HTTP)
+}
+
+// IsZero returns true if no condition is set
+func (w *WaitFor) IsZero() bool {
+	return w.TCP == "" && w.HTTP == "" && w.Exec.Empty() && w.timeout.Duration() == 0
+}
+
+// Address returns the address of the tcp condition, without the optional
+// tcp:// scheme
+func (w *WaitFor) Address() string {
+	return tcpAddress(w.TCP)
+}
+
+// Timeout returns the maximum time to wait for the condition
+func (w *WaitFor) Timeout() time.Duration {
+	if w.timeout.Duration() == 0 {
+		return defaultWaitTimeout
+	}
+	return w.timeout.Duration()
+}
+
+func (w *WaitFor) String() string {
+	switch {
+	case w.TCP != "":
+		return "tcp " + w.Address()
+	case w.HTTP != "":
+		return "http " + w.HTTP
+	}
+	return "exec " + w.Exec.String()
+}
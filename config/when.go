@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dnephin/dobi/execenv"
+)
+
+// whenExpression is a parsed when condition. A condition with an operator
+// compares the left and right values, otherwise the left value is true when it
+// is not empty, "false", or "0".
+type whenExpression struct {
+	left     string
+	operator string
+	right    string
+}
+
+func parseWhen(expr string) (whenExpression, error) {
+	for _, operator := range []string{"==", "!="} {
+		parts := strings.Split(expr, operator)
+		switch len(parts) {
+		case 1:
+			continue
+		case 2:
+			return whenExpression{
+				left:     unquote(parts[0]),
+				operator: operator,
+				right:    unquote(parts[1]),
+			}, nil
+		default:
+			return whenExpression{}, fmt.Errorf(
+				"invalid expression %q, %q may only be used once", expr, operator)
+		}
+	}
+	if strings.TrimSpace(expr) == "" {
+		return whenExpression{}, fmt.Errorf("an expression is required")
+	}
+	return whenExpression{left: unquote(expr)}, nil
+}
+
+// unquote removes surrounding whitespace, and a matching pair of single or
+// double quotes, from a value
+func unquote(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 {
+		first, last := value[0], value[len(value)-1]
+		if first == last && (first == '"' || first == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+func (w whenExpression) eval(env *execenv.ExecEnv) (bool, error) {
+	left, err := env.Resolve(w.left)
+	if err != nil {
+		return false, err
+	}
+	right, err := env.Resolve(w.right)
+	if err != nil {
+		return false, err
+	}
+	switch w.operator {
+	case "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	default:
+		return left != "" && left != "false" && left != "0", nil
+	}
+}
+
+// EvalWhen resolves the variables in the when condition of a resource, and
+// returns true if the resource should run. An empty condition is always true.
+func EvalWhen(expr string, env *execenv.ExecEnv) (bool, error) {
+	if expr == "" {
+		return true, nil
+	}
+	when, err := parseWhen(expr)
+	if err != nil {
+		return false, err
+	}
+	return when.eval(env)
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dnephin/dobi/execenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvalWhen(t *testing.T) {
+	defer os.Unsetenv("DOBI_TEST_BRANCH")
+	os.Setenv("DOBI_TEST_BRANCH", "main")
+	env := execenv.NewExecEnv("exec", "project", ".")
+
+	for _, tc := range []struct {
+		expr     string
+		expected bool
+	}{
+		{expr: "", expected: true},
+		{expr: "{env.DOBI_TEST_BRANCH} == main", expected: true},
+		{expr: "'{env.DOBI_TEST_BRANCH}' == \"main\"", expected: true},
+		{expr: "{env.DOBI_TEST_BRANCH} == release", expected: false},
+		{expr: "{env.DOBI_TEST_BRANCH} != main", expected: false},
+		{expr: "{env.DOBI_TEST_BRANCH}", expected: true},
+		{expr: "{env.DOBI_TEST_UNSET:}", expected: false},
+		{expr: "false", expected: false},
+		{expr: "0", expected: false},
+	} {
+		actual, err := EvalWhen(tc.expr, env)
+		assert.Nil(t, err, tc.expr)
+		assert.Equal(t, tc.expected, actual, tc.expr)
+	}
+}
+
+func TestParseWhenInvalid(t *testing.T) {
+	_, err := parseWhen("  ")
+	assert.EqualError(t, err, "an expression is required")
+
+	_, err = parseWhen("a != b != c")
+	assert.EqualError(t, err, `invalid expression "a != b != c", "!=" may only be used once`)
+}
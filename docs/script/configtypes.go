@@ -33,6 +33,14 @@ func writeDocs() error {
 		{"image.rst", config.ImageConfig{}},
 		{"mount.rst", config.MountConfig{}},
 		{"job.rst", config.JobConfig{}},
+		{"download.rst", config.DownloadConfig{}},
+		{"network.rst", config.NetworkConfig{}},
+		{"volume.rst", config.VolumeConfig{}},
+		{"service.rst", config.ServiceConfig{}},
+		{"registry.rst", config.RegistryConfig{}},
+		{"wait.rst", config.WaitConfig{}},
+		{"env.rst", config.EnvConfig{}},
+		{"shell.rst", config.ShellConfig{}},
 	} {
 		fmt.Printf("Generating doc %q\n", basePath+item.filename)
 		if err := write(basePath+item.filename, item.source); err != nil {
@@ -0,0 +1,130 @@
+package execenv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const dotenvFilename = ".env"
+
+var dotenvKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// loadDotenv reads the variables from the .env file in workingDir. A missing
+// file is not an error.
+func loadDotenv(workingDir string) (map[string]string, error) {
+	filename := filepath.Join(workingDir, dotenvFilename)
+	file, err := os.Open(filename)
+	switch {
+	case os.IsNotExist(err):
+		return map[string]string{}, nil
+	case err != nil:
+		return nil, err
+	}
+	defer file.Close()
+
+	values, err := parseDotenv(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse %q: %s", filename, err)
+	}
+	return values, nil
+}
+
+// loadEnvFiles reads the variables from each env file. Variables from a later
+// file take precedence over variables from an earlier file.
+func loadEnvFiles(filenames []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, filename := range filenames {
+		env, err := LoadEnvFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		for _, variable := range env {
+			parts := strings.SplitN(variable, "=", 2)
+			values[parts[0]] = parts[1]
+		}
+	}
+	return values, nil
+}
+
+// LoadEnvFile reads the variables from an env file, which has the same format
+// as the .env file, and returns them as KEY=VALUE strings in the order of the
+// file.
+func LoadEnvFile(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	variables, err := parseEnvFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse %q: %s", filename, err)
+	}
+	env := []string{}
+	for _, variable := range variables {
+		env = append(env, variable.key+"="+variable.value)
+	}
+	return env, nil
+}
+
+func parseDotenv(reader io.Reader) (map[string]string, error) {
+	variables, err := parseEnvFile(reader)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for _, variable := range variables {
+		values[variable.key] = variable.value
+	}
+	return values, nil
+}
+
+type envVariable struct {
+	key   string
+	value string
+}
+
+// parseEnvFile parses lines of KEY=VALUE. Blank lines, and lines which start
+// with a #, are ignored. Keys may have an export prefix, and values may be
+// wrapped in single or double quotes.
+func parseEnvFile(reader io.Reader) ([]envVariable, error) {
+	variables := []envVariable{}
+	scanner := bufio.NewScanner(reader)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+		key := strings.TrimSpace(strings.TrimPrefix(parts[0], "export "))
+		if !dotenvKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", lineNum, key)
+		}
+		value, err := unquote(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", lineNum, err)
+		}
+		variables = append(variables, envVariable{key: key, value: value})
+	}
+	return variables, scanner.Err()
+}
+
+func unquote(value string) (string, error) {
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		return value, nil
+	}
+	quote := value[0]
+	if len(value) < 2 || value[len(value)-1] != quote {
+		return "", fmt.Errorf("unterminated quoted value %s", value)
+	}
+	return value[1 : len(value)-1], nil
+}
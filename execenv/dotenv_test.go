@@ -0,0 +1,137 @@
+package execenv
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/renstrom/dedent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDotenv(t *testing.T) {
+	content := dedent.Dedent(`
+		# a comment
+		FIRST=one
+		export SECOND = two words
+
+		THIRD="quoted # value"
+		FOURTH='single'
+		EMPTY=
+	`)
+	values, err := parseDotenv(strings.NewReader(content))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"FIRST":  "one",
+		"SECOND": "two words",
+		"THIRD":  "quoted # value",
+		"FOURTH": "single",
+		"EMPTY":  "",
+	}, values)
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	var testcases = []struct {
+		content  string
+		expected string
+	}{
+		{content: "FIRST=one\nSECOND", expected: "line 2: expected KEY=VALUE"},
+		{content: "1ST=one", expected: `line 1: invalid variable name "1ST"`},
+		{content: `FIRST="one`, expected: `line 1: unterminated quoted value "one`},
+	}
+	for _, testcase := range testcases {
+		_, err := parseDotenv(strings.NewReader(testcase.content))
+		if assert.Error(t, err, testcase.content) {
+			assert.Contains(t, err.Error(), testcase.expected)
+		}
+	}
+}
+
+func TestNewExecEnvFromConfigWithDotenv(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "dotenv-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	content := []byte("DOTENV_TEST_ID=from-file\nDOTENV_TEST_HOST=from-file\n")
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(tmpDir, ".env"), content, 0644))
+	os.Setenv("DOTENV_TEST_HOST", "from-host")
+	defer os.Unsetenv("DOTENV_TEST_HOST")
+
+	execEnv, err := NewExecEnvFromConfig("{env.DOTENV_TEST_ID}", "", tmpDir, true, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "from-file", execEnv.ExecID)
+
+	value, err := execEnv.Resolve("{env.DOTENV_TEST_HOST}")
+	assert.Nil(t, err)
+	assert.Equal(t, "from-host", value)
+
+	_, err = NewExecEnvFromConfig("{env.DOTENV_TEST_ID}", "", tmpDir, false, nil)
+	assert.Error(t, err)
+}
+
+func TestNewExecEnvFromConfigWithInvalidDotenv(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "dotenv-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(tmpDir, ".env"), []byte("bogus"), 0644))
+
+	_, err = NewExecEnvFromConfig("", "", tmpDir, true, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "line 1: expected KEY=VALUE")
+	}
+}
+
+func TestNewExecEnvFromConfigWithEnvFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "env-file-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(tmpDir, ".env"),
+		[]byte("ENV_FILE_TEST_DOTENV=from-dotenv\nENV_FILE_TEST_ID=from-dotenv\n"), 0644))
+	first := filepath.Join(tmpDir, "first.env")
+	assert.Nil(t, ioutil.WriteFile(first,
+		[]byte("ENV_FILE_TEST_ID=from-first\nENV_FILE_TEST_HOST=from-first\n"), 0644))
+	second := filepath.Join(tmpDir, "second.env")
+	assert.Nil(t, ioutil.WriteFile(second, []byte("ENV_FILE_TEST_ID=from-second\n"), 0644))
+	os.Setenv("ENV_FILE_TEST_HOST", "from-host")
+	defer os.Unsetenv("ENV_FILE_TEST_HOST")
+
+	execEnv, err := NewExecEnvFromConfig(
+		"{env.ENV_FILE_TEST_ID}", "", tmpDir, true, []string{first, second})
+	assert.Nil(t, err)
+	assert.Equal(t, "from-second", execEnv.ExecID)
+
+	value, err := execEnv.Resolve("{env.ENV_FILE_TEST_HOST}")
+	assert.Nil(t, err)
+	assert.Equal(t, "from-first", value)
+
+	value, err = execEnv.Resolve("{env.ENV_FILE_TEST_DOTENV}")
+	assert.Nil(t, err)
+	assert.Equal(t, "from-dotenv", value)
+
+	value, err = execEnv.WithEnv(map[string]string{"ENV_FILE_TEST_ID": "local"}).
+		Resolve("{env.ENV_FILE_TEST_ID}")
+	assert.Nil(t, err)
+	assert.Equal(t, "local", value)
+}
+
+func TestNewExecEnvFromConfigWithInvalidEnvFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "env-file-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	filename := filepath.Join(tmpDir, "ci.env")
+	assert.Nil(t, ioutil.WriteFile(filename, []byte("FIRST=one\nbogus\n"), 0644))
+
+	_, err = NewExecEnvFromConfig("", "", tmpDir, false, []string{filename})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "ci.env")
+		assert.Contains(t, err.Error(), "line 2: expected KEY=VALUE")
+	}
+
+	_, err = NewExecEnvFromConfig("", "", tmpDir, false, []string{filepath.Join(tmpDir, "missing.env")})
+	assert.Error(t, err)
+}
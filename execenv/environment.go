@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/user"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/dnephin/dobi/logging"
@@ -27,7 +31,83 @@ type ExecEnv struct {
 	Project    string
 	tmplCache  map[string]string
 	workingDir string
+	dotenv     map[string]string
+	envFiles   map[string]string
+	localEnv   map[string]string
 	startTime  time.Time
+	captures   *captures
+	resources  *resourceEnv
+}
+
+// resourceEnv are the environment variables set by env resources
+type resourceEnv struct {
+	values map[string]string
+	mu     sync.Mutex
+}
+
+// SetEnv sets environment variables which are used to resolve {env.<name>}
+// when the variable is not set by an env file or the host environment
+func (e *ExecEnv) SetEnv(values map[string]string) {
+	e.resources.mu.Lock()
+	defer e.resources.mu.Unlock()
+	for name, value := range values {
+		e.resources.values[name] = value
+	}
+	// Templates resolved before the variables were set may have used a
+	// default value
+	e.tmplCache = make(map[string]string)
+}
+
+func (e *ExecEnv) valueFromResources(name string) (string, bool) {
+	e.resources.mu.Lock()
+	defer e.resources.mu.Unlock()
+	value, ok := e.resources.values[name]
+	return value, ok
+}
+
+// captures are the values of variables captured from the output of jobs, and
+// the names of the captured variables which were used before they were set
+type captures struct {
+	values  map[string]string
+	missing []string
+	mu      sync.Mutex
+}
+
+// SetCaptured sets the value of the variable {capture.<name>}
+func (e *ExecEnv) SetCaptured(name, value string) {
+	e.captures.mu.Lock()
+	defer e.captures.mu.Unlock()
+	e.captures.values[name] = value
+}
+
+// Captured returns the value of the variable {capture.<name>}, and true if it
+// has been set
+func (e *ExecEnv) Captured(name string) (string, bool) {
+	e.captures.mu.Lock()
+	defer e.captures.mu.Unlock()
+	value, ok := e.captures.values[name]
+	return value, ok
+}
+
+// MissingCaptures returns the names of the captured variables which were used
+// by Resolve before they were set, since the last call to MissingCaptures
+func (e *ExecEnv) MissingCaptures() []string {
+	e.captures.mu.Lock()
+	defer e.captures.mu.Unlock()
+	missing := e.captures.missing
+	e.captures.missing = nil
+	return missing
+}
+
+func (e *ExecEnv) valueFromCapture(name string) (string, error) {
+	e.captures.mu.Lock()
+	defer e.captures.mu.Unlock()
+	value, ok := e.captures.values[name]
+	if !ok {
+		e.captures.missing = append(e.captures.missing, name)
+		return "", fmt.Errorf("Captured variable %q has not been set", name)
+	}
+	return value, nil
 }
 
 // Unique returns a unique id for this execution
@@ -35,6 +115,15 @@ func (e *ExecEnv) Unique() string {
 	return e.Project + "-" + e.ExecID
 }
 
+// WithEnv returns a copy of the ExecEnv where the variables in env take
+// precedence over the host environment
+func (e *ExecEnv) WithEnv(env map[string]string) *ExecEnv {
+	local := *e
+	local.tmplCache = make(map[string]string)
+	local.localEnv = env
+	return &local
+}
+
 // Resolve template variables to a string value and cache the value
 func (e *ExecEnv) Resolve(tmpl string) (string, error) {
 	if val, ok := e.tmplCache[tmpl]; ok {
@@ -83,9 +172,9 @@ func (e *ExecEnv) templateContext(out io.Writer, tag string) (int, error) {
 	prefix, suffix := splitPrefix(tag)
 	switch prefix {
 	case "env":
-		return write(os.Getenv(suffix))
+		return write(e.getenv(suffix))
 	case "git":
-		return valueFromGit(out, suffix, defValue)
+		return valueFromGit(out, e.workingDir, suffix, defValue)
 	case "time":
 		return write(fmtdate.Format(suffix, e.startTime))
 	case "fs":
@@ -94,6 +183,18 @@ func (e *ExecEnv) templateContext(out io.Writer, tag string) (int, error) {
 			return 0, err
 		}
 		return write(val)
+	case "user":
+		val, err := valueFromUser(suffix)
+		if err != nil {
+			return 0, err
+		}
+		return write(val)
+	case "capture":
+		val, err := e.valueFromCapture(suffix)
+		if err != nil {
+			return 0, err
+		}
+		return write(val)
 	}
 
 	switch tag {
@@ -103,11 +204,48 @@ func (e *ExecEnv) templateContext(out io.Writer, tag string) (int, error) {
 		return write(e.Project)
 	case "exec-id":
 		return write(e.ExecID)
+	case "os":
+		return write(runtime.GOOS)
+	case "arch":
+		return write(runtime.GOARCH)
+	case "exe-suffix":
+		// The suffix is empty on most platforms, so it is written directly
+		// instead of requiring a default value
+		return out.Write([]byte(exeSuffix()))
 	default:
 		return 0, fmt.Errorf("Unknown variable %q", tag)
 	}
 }
 
+// exeSuffix returns the file extension of executables on the host platform
+func exeSuffix() string {
+	if runtime.GOOS == "windows" {
+		return ".exe"
+	}
+	return ""
+}
+
+// getenv returns the value of the environment variable name. Variables set by
+// WithEnv take precedence over variables from env files, which take precedence
+// over variables from the host environment, which take precedence over
+// variables set by env resources, which take precedence over variables from the
+// .env file.
+func (e *ExecEnv) getenv(name string) string {
+	if value, ok := e.localEnv[name]; ok {
+		return value
+	}
+	if value, ok := e.envFiles[name]; ok {
+		return value
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	if value, ok := e.valueFromResources(name); ok {
+		return value
+	}
+	return e.dotenv[name]
+}
+
 func valueFromFilesystem(name string, workingdir string) (string, error) {
 	switch name {
 	case "cwd":
@@ -119,7 +257,24 @@ func valueFromFilesystem(name string, workingdir string) (string, error) {
 	}
 }
 
-func valueFromGit(out io.Writer, tag, defValue string) (int, error) {
+func valueFromUser(name string) (string, error) {
+	switch name {
+	case "uid":
+		return strconv.Itoa(os.Getuid()), nil
+	case "gid":
+		return strconv.Itoa(os.Getgid()), nil
+	case "name":
+		current, err := user.Current()
+		if err != nil {
+			return "", fmt.Errorf("Failed resolving variable {user.name}: %s", err)
+		}
+		return current.Username, nil
+	default:
+		return "", fmt.Errorf("Unknown variable \"user.%s\"", name)
+	}
+}
+
+func valueFromGit(out io.Writer, workingDir, tag, defValue string) (int, error) {
 	write := func(value string) (int, error) {
 		return out.Write(bytes.NewBufferString(value).Bytes())
 	}
@@ -133,7 +288,10 @@ func valueFromGit(out io.Writer, tag, defValue string) (int, error) {
 		return write(defValue)
 	}
 
-	repo, err := git.OpenRepository(".")
+	if workingDir == "" {
+		workingDir = "."
+	}
+	repo, err := git.OpenRepository(workingDir)
 	if err != nil {
 		return writeWithError(err)
 	}
@@ -157,6 +315,13 @@ func valueFromGit(out io.Writer, tag, defValue string) (int, error) {
 			return writeWithError(err)
 		}
 		return write(commit.ID.String()[:10])
+	case "tag":
+		tagName, err := git.NewCommand("describe", "--tags", "--exact-match", "HEAD").
+			RunInDir(repo.Path)
+		if err != nil {
+			return writeWithError(fmt.Errorf("HEAD is not tagged: %s", err))
+		}
+		return write(strings.TrimSpace(tagName))
 	default:
 		return 0, fmt.Errorf("Unknown variable \"git.%s\"", tag)
 	}
@@ -181,10 +346,24 @@ func splitPrefix(tag string) (string, string) {
 	}
 }
 
-// NewExecEnvFromConfig returns a new ExecEnv from a Config
-func NewExecEnvFromConfig(execID, project, workingDir string) (*ExecEnv, error) {
+// NewExecEnvFromConfig returns a new ExecEnv from a Config. If autoloadDotenv
+// is true, variables are also loaded from the .env file in workingDir.
+// Variables are also loaded from each of envFiles.
+func NewExecEnvFromConfig(
+	execID, project, workingDir string,
+	autoloadDotenv bool,
+	envFiles []string,
+) (*ExecEnv, error) {
 	env := NewExecEnv(defaultExecID(), getProjectName(project, workingDir), workingDir)
 	var err error
+	if autoloadDotenv {
+		if env.dotenv, err = loadDotenv(workingDir); err != nil {
+			return env, err
+		}
+	}
+	if env.envFiles, err = loadEnvFiles(envFiles); err != nil {
+		return env, err
+	}
 	env.ExecID, err = getExecID(execID, env)
 	return env, err
 }
@@ -197,6 +376,8 @@ func NewExecEnv(execID, project string, workingDir string) *ExecEnv {
 		tmplCache:  make(map[string]string),
 		startTime:  time.Now(),
 		workingDir: workingDir,
+		captures:   &captures{values: make(map[string]string)},
+		resources:  &resourceEnv{values: make(map[string]string)},
 	}
 }
 
@@ -204,9 +385,7 @@ func getProjectName(project, workingDir string) string {
 	if project != "" {
 		return project
 	}
-	project = filepath.Base(workingDir)
-	logging.Log.Warnf("meta.project is not set. Using default %q.", project)
-	return project
+	return filepath.Base(workingDir)
 }
 
 func getExecID(execID string, env *ExecEnv) (string, error) {
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
@@ -35,7 +36,7 @@ func (s *ExecEnvSuite) TestNewExecEnvFromConfigDefault() {
 	defer os.Setenv("USER", os.Getenv("USER"))
 	os.Setenv("USER", "testuser")
 
-	execEnv, err := NewExecEnvFromConfig("", "", s.tmpDir)
+	execEnv, err := NewExecEnvFromConfig("", "", s.tmpDir, false, nil)
 	s.Nil(err)
 	expected := fmt.Sprintf("%s-testuser", filepath.Base(s.tmpDir))
 	s.Equal(expected, execEnv.Unique())
@@ -45,17 +46,45 @@ func (s *ExecEnvSuite) TestNewExecEnvFromConfigWithTemplate() {
 	os.Setenv("EXEC_ID", "Use-This")
 	defer os.Unsetenv("EXEC_ID")
 
-	execEnv, err := NewExecEnvFromConfig("{env.EXEC_ID}", "", s.tmpDir)
+	execEnv, err := NewExecEnvFromConfig("{env.EXEC_ID}", "", s.tmpDir, false, nil)
 	s.Nil(err)
 	s.Equal("Use-This", execEnv.ExecID)
 }
 
 func (s *ExecEnvSuite) TestNewExecEnvFromConfigWithInvalidTemplate() {
-	_, err := NewExecEnvFromConfig("{env.bogus} ", "", s.tmpDir)
+	_, err := NewExecEnvFromConfig("{env.bogus} ", "", s.tmpDir, false, nil)
 	s.Error(err)
 	s.Contains(err.Error(), "A value is required for variable \"env.bogus\"")
 }
 
+func (s *ExecEnvSuite) TestResolveGitTagDefault() {
+	execEnv := NewExecEnv("exec", "project", s.tmpDir)
+	value, err := execEnv.Resolve("{git.tag:dev}")
+	s.Nil(err)
+	s.Equal("dev", value)
+}
+
+func (s *ExecEnvSuite) TestSetEnv() {
+	defer os.Unsetenv("DOBI_TEST_HOST_VAR")
+	os.Setenv("DOBI_TEST_HOST_VAR", "host")
+	execEnv := NewExecEnv("exec", "project", s.tmpDir)
+
+	value, err := execEnv.Resolve("{env.DOBI_TEST_RESOURCE_VAR:default}")
+	s.Nil(err)
+	s.Equal("default", value)
+
+	execEnv.SetEnv(map[string]string{
+		"DOBI_TEST_HOST_VAR":     "resource",
+		"DOBI_TEST_RESOURCE_VAR": "resource",
+	})
+	value, err = execEnv.Resolve("{env.DOBI_TEST_RESOURCE_VAR:default}")
+	s.Nil(err)
+	s.Equal("resource", value)
+	value, err = execEnv.Resolve("{env.DOBI_TEST_HOST_VAR}")
+	s.Nil(err)
+	s.Equal("host", value)
+}
+
 func (s *ExecEnvSuite) TestValidateExecIDEmpty() {
 	output, err := validateExecID("")
 	s.Equal("", output)
@@ -91,6 +120,30 @@ func (s *ExecEnvSuite) TestResolveUnique() {
 	s.Equal(execEnv.tmplCache[tmpl], expected)
 }
 
+func (s *ExecEnvSuite) TestResolvePlatform() {
+	execEnv := NewExecEnv("exec", "project", "cwd")
+	value, err := execEnv.Resolve("dist/app-{os}-{arch}{exe-suffix}")
+
+	s.Nil(err)
+	s.Equal(fmt.Sprintf("dist/app-%s-%s%s", runtime.GOOS, runtime.GOARCH, exeSuffix()), value)
+}
+
+func (s *ExecEnvSuite) TestResolveUser() {
+	execEnv := NewExecEnv("exec", "project", "cwd")
+	value, err := execEnv.Resolve("{user.uid}:{user.gid}")
+
+	s.Nil(err)
+	s.Equal(fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()), value)
+}
+
+func (s *ExecEnvSuite) TestResolveUserUnknown() {
+	execEnv := NewExecEnv("exec", "project", "cwd")
+	_, err := execEnv.Resolve("{user.bogus}")
+
+	s.Error(err)
+	s.Contains(err.Error(), `Unknown variable "user.bogus"`)
+}
+
 func (s *ExecEnvSuite) TestResolveUnknown() {
 	execEnv := NewExecEnv("exec", "project", "cwd")
 	_, err := execEnv.Resolve("{bogus}")
@@ -142,6 +195,26 @@ func (s *ExecEnvSuite) TestResolveTime() {
 	s.Equal(execEnv.tmplCache[tmpl], expected)
 }
 
+func (s *ExecEnvSuite) TestResolveCapture() {
+	execEnv := NewExecEnv("exec", "project", "cwd")
+	execEnv.SetCaptured("build.version", "1.2.3")
+	value, err := execEnv.Resolve("app:{capture.build.version}")
+
+	s.Nil(err)
+	s.Equal("app:1.2.3", value)
+	s.Len(execEnv.MissingCaptures(), 0)
+}
+
+func (s *ExecEnvSuite) TestResolveCaptureNotSet() {
+	execEnv := NewExecEnv("exec", "project", "cwd")
+	_, err := execEnv.Resolve("app:{capture.build.version}")
+
+	s.Error(err)
+	s.Contains(err.Error(), `Captured variable "build.version" has not been set`)
+	s.Equal([]string{"build.version"}, execEnv.MissingCaptures())
+	s.Len(execEnv.MissingCaptures(), 0)
+}
+
 func (s *ExecEnvSuite) TestSplitDefault() {
 	tag := "time.19:01:01:default"
 	value, defVal, hasDefault := splitDefault(tag)
@@ -171,3 +244,18 @@ func TestSplitPrefix(t *testing.T) {
 	assert.Equal(t, prefix, "fo")
 	assert.Equal(t, suffix, "o")
 }
+
+func (s *ExecEnvSuite) TestWithEnv() {
+	defer os.Unsetenv("DOBI_TEST_VALUE")
+	os.Setenv("DOBI_TEST_VALUE", "host")
+	execEnv := NewExecEnv("exec", "project", "cwd")
+
+	value, err := execEnv.WithEnv(map[string]string{"DOBI_TEST_VALUE": "local"}).Resolve(
+		"{env.DOBI_TEST_VALUE}")
+	s.Nil(err)
+	s.Equal("local", value)
+
+	value, err = execEnv.Resolve("{env.DOBI_TEST_VALUE}")
+	s.Nil(err)
+	s.Equal("host", value)
+}
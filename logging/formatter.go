@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/utils/mask"
 )
 
 const (
@@ -24,7 +25,10 @@ type LogRepresenter interface {
 }
 
 // Formatter formats a log entry in a human readable way
-type Formatter struct{}
+type Formatter struct {
+	// Masker redacts sensitive values from the formatted entry
+	Masker *mask.Masker
+}
 
 // Format implements the log.Formatter interface
 func (f *Formatter) Format(entry *log.Entry) ([]byte, error) {
@@ -33,7 +37,7 @@ func (f *Formatter) Format(entry *log.Entry) ([]byte, error) {
 	buff.WriteString(writeData(entry.Data))
 	buff.WriteString(entry.Message)
 	buff.WriteString("\n")
-	return buff.Bytes(), nil
+	return f.Masker.Mask(buff.Bytes()), nil
 }
 
 func withColor(color int, msg string) string {
@@ -0,0 +1,85 @@
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dnephin/dobi/config"
+)
+
+// Artifact is a file or directory declared by a resource
+type Artifact struct {
+	Resource string `json:"resource"`
+	Path     string `json:"path"`
+	Exists   bool   `json:"exists"`
+}
+
+// Artifacts returns the artifacts declared by every resource in the config,
+// sorted by resource name, with variables resolved. Variables are also loaded
+// from each of envFiles. Resources are not run.
+func Artifacts(conf *config.Config, envFiles []string) ([]Artifact, error) {
+	execEnv, err := newExecEnv(conf, envFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := &config.ErrorList{}
+	artifacts := []Artifact{}
+	for _, name := range conf.Sorted() {
+		paths, resolvePath := artifactPaths(conf.Resources[name])
+		if len(paths) == 0 {
+			continue
+		}
+		resolved, err := config.ResolveResource(name, conf.Resources[name], execEnv)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+		paths, _ = artifactPaths(resolved)
+		for _, path := range paths {
+			_, err = os.Stat(resolvePath(conf.WorkingDir, path))
+			artifacts = append(artifacts, Artifact{
+				Resource: name,
+				Path:     path,
+				Exists:   err == nil,
+			})
+		}
+	}
+	if err := errs.ErrorOrNil(); err != nil {
+		return artifacts, fmt.Errorf("Failed to resolve variables:\n%s", err)
+	}
+	return artifacts, nil
+}
+
+// artifactPaths returns the artifacts declared by resource, and a function which
+// returns the host path of an artifact.
+func artifactPaths(resource config.Resource) ([]string, func(string, string) string) {
+	switch conf := resource.(type) {
+	case *config.JobConfig:
+		return conf.ArtifactPaths(), relativeToCwd
+	case *config.ShellConfig:
+		return nonEmpty(conf.Artifact), relativeToCwd
+	case *config.DownloadConfig:
+		return nonEmpty(conf.Dest), relativeToWorkingDir
+	}
+	return nil, nil
+}
+
+func nonEmpty(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return []string{path}
+}
+
+func relativeToCwd(_, path string) string {
+	return path
+}
+
+func relativeToWorkingDir(workingDir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(workingDir, path)
+}
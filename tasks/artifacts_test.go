@@ -0,0 +1,43 @@
+package tasks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArtifacts(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "artifacts-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+	existing := filepath.Join(tmpDir, "existing")
+	assert.Nil(t, ioutil.WriteFile(existing, []byte("ok"), 0644))
+
+	conf := config.NewConfig()
+	conf.Meta.Project = "project"
+	conf.WorkingDir = tmpDir
+	conf.Resources["build"] = &config.JobConfig{Use: "image", Artifact: existing}
+	conf.Resources["image"] = &config.ImageConfig{Image: "example"}
+	conf.Resources["fetch"] = &config.DownloadConfig{Dest: "dist/{project}-tool"}
+
+	artifacts, err := Artifacts(conf, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []Artifact{
+		{Resource: "build", Path: existing, Exists: true},
+		{Resource: "fetch", Path: "dist/project-tool", Exists: false},
+	}, artifacts)
+}
+
+func TestArtifactsUnresolvedVariables(t *testing.T) {
+	conf := config.NewConfig()
+	conf.Meta.Project = "project"
+	conf.Resources["build"] = &config.JobConfig{Use: "image", Artifact: "{bogus}"}
+
+	_, err := Artifacts(conf, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "build")
+}
@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Archive writes a gzipped tar of the files and directories at paths to out.
+// Each file is stored with its path, so that it is restored to the same place
+// by Extract.
+func Archive(paths []string, out io.Writer) error {
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	for _, path := range paths {
+		if err := filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			return addFile(tw, name, info)
+		}); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func addFile(tw *tar.Writer, name string, info os.FileInfo) error {
+	link := ""
+	if info.Mode()&os.ModeSymlink != 0 {
+		var err error
+		if link, err = os.Readlink(name); err != nil {
+			return err
+		}
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.ToSlash(name)
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// Extract restores the files from a gzipped tar created by Archive. Only files
+// at, or under, one of paths are restored.
+func Extract(in io.Reader, paths []string) error {
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+		name := filepath.FromSlash(header.Name)
+		if !underPaths(name, paths) {
+			return fmt.Errorf("unexpected file %q in archive", header.Name)
+		}
+		if err := extractFile(tr, header, name); err != nil {
+			return err
+		}
+	}
+}
+
+func underPaths(name string, paths []string) bool {
+	name = filepath.Clean(name)
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		if name == path || strings.HasPrefix(name, path+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func extractFile(tr *tar.Reader, header *tar.Header, name string) error {
+	mode := os.FileMode(header.Mode).Perm()
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(name, mode)
+	case tar.TypeSymlink:
+		os.Remove(name)
+		return os.Symlink(header.Linkname, name)
+	case tar.TypeReg, tar.TypeRegA:
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(file, tr); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dnephin/dobi/config"
+)
+
+// Backend stores archives of artifacts by key
+type Backend interface {
+	// Get writes the archive for key to out, and returns false if there is no
+	// archive for key
+	Get(key string, out io.Writer) (bool, error)
+	// Put stores the archive for key from in
+	Put(key string, in io.Reader) error
+}
+
+// NewBackend returns the Backend for the url of the resolved cache config
+func NewBackend(conf config.CacheConfig) (Backend, error) {
+	parsed, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, err
+	}
+	switch parsed.Scheme {
+	case "http", "https":
+		return &httpBackend{
+			baseURL: strings.TrimSuffix(conf.URL, "/"),
+			headers: conf.Headers,
+			client:  http.DefaultClient,
+		}, nil
+	case "file":
+		return &dirBackend{dir: parsed.Path}, nil
+	case "":
+		return &dirBackend{dir: conf.URL}, nil
+	default:
+		return nil, fmt.Errorf("unsupported cache url %q", conf.URL)
+	}
+}
+
+// httpBackend reads archives with GET, and stores them with PUT
+type httpBackend struct {
+	baseURL string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (b *httpBackend) request(method, key string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, b.baseURL+"/"+key, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range b.headers {
+		req.Header.Set(name, value)
+	}
+	return b.client.Do(req)
+}
+
+func (b *httpBackend) Get(key string, out io.Writer) (bool, error) {
+	resp, err := b.request("GET", key, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("GET %s: %s", key, resp.Status)
+	}
+	_, err = io.Copy(out, resp.Body)
+	return true, err
+}
+
+func (b *httpBackend) Put(key string, in io.Reader) error {
+	resp, err := b.request("PUT", key, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("PUT %s: %s", key, resp.Status)
+	}
+	return nil
+}
+
+// dirBackend stores archives as files in a directory
+type dirBackend struct {
+	dir string
+}
+
+func (b *dirBackend) Get(key string, out io.Writer) (bool, error) {
+	file, err := os.Open(filepath.Join(b.dir, key))
+	switch {
+	case os.IsNotExist(err):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	defer file.Close()
+	_, err = io.Copy(out, file)
+	return true, err
+}
+
+// Put writes the archive to a temporary file first, so that a partial archive
+// is never read by Get
+func (b *dirBackend) Put(key string, in io.Reader) error {
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(b.dir, ".tmp-"+key)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(b.dir, key))
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveAndExtract(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache-archive-test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	dist := filepath.Join(dir, "dist")
+	assert.Nil(t, os.MkdirAll(filepath.Join(dist, "bin"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dist, "bin", "app"), []byte("binary"), 0755))
+
+	archive := new(bytes.Buffer)
+	assert.Nil(t, Archive([]string{dist}, archive))
+	assert.Nil(t, os.RemoveAll(dist))
+
+	assert.Nil(t, Extract(bytes.NewReader(archive.Bytes()), []string{dist}))
+	content, err := ioutil.ReadFile(filepath.Join(dist, "bin", "app"))
+	assert.Nil(t, err)
+	assert.Equal(t, "binary", string(content))
+
+	err = Extract(bytes.NewReader(archive.Bytes()), []string{filepath.Join(dir, "other")})
+	assert.Error(t, err)
+}
+
+func TestDirBackend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache-dir-test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	backend, err := NewBackend(config.CacheConfig{URL: dir})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	out := new(bytes.Buffer)
+	found, err := backend.Get("build-abc.tar.gz", out)
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	assert.Nil(t, backend.Put("build-abc.tar.gz", bytes.NewBufferString("archive")))
+	found, err = backend.Get("build-abc.tar.gz", out)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "archive", out.String())
+}
+
+func TestHTTPBackend(t *testing.T) {
+	stored := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case "PUT":
+			stored[r.URL.Path], _ = ioutil.ReadAll(r.Body)
+		case "GET":
+			body, ok := stored[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(body)
+		}
+	}))
+	defer server.Close()
+
+	backend, err := NewBackend(config.CacheConfig{
+		URL:     server.URL + "/dobi/",
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	out := new(bytes.Buffer)
+	found, err := backend.Get("build-abc.tar.gz", out)
+	assert.Nil(t, err)
+	assert.False(t, found)
+
+	assert.Nil(t, backend.Put("build-abc.tar.gz", bytes.NewBufferString("archive")))
+	found, err = backend.Get("build-abc.tar.gz", out)
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "archive", out.String())
+	assert.Contains(t, stored, "/dobi/build-abc.tar.gz")
+}
@@ -0,0 +1,150 @@
+package tasks
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/history"
+	"github.com/dnephin/dobi/tasks/iface"
+)
+
+// captures resolves the resources which use captured variables when their task
+// runs, after the jobs which capture the variables, and saves the value
+// captured by each job in the state file. When a job with capture does not run
+// the value saved by an earlier run is used.
+type captures struct {
+	store  *history.Store
+	tasks  *TaskCollection
+	config *config.Config
+	next   func(*context.ExecuteContext, iface.Task) error
+	mu     sync.Mutex
+}
+
+func (c *captures) runTask(ctx *context.ExecuteContext, task iface.Task) error {
+	if err := c.resolve(ctx, task); err != nil {
+		return err
+	}
+	if err := c.next(ctx, task); err != nil {
+		return err
+	}
+	return c.save(ctx, task)
+}
+
+// resolve resolves the variables of a deferred resource. Resolve updates the
+// resource in place, so the task uses the resolved values.
+func (c *captures) resolve(ctx *context.ExecuteContext, task iface.Task) error {
+	name := task.Name().Resource()
+	if !c.tasks.deferred[name] {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resource, err := config.ResolveResource(name, c.config.Resources[name], ctx.Env)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve variables:\n%s", err)
+	}
+	return config.ValidateResolved(name, resource, c.config, ctx.Env)
+}
+
+func (c *captures) save(ctx *context.ExecuteContext, task iface.Task) error {
+	variable := c.variable(task)
+	if variable == "" {
+		return nil
+	}
+
+	key := captureKey(variable)
+	if value, ok := ctx.Env.Captured(variable); ok {
+		if err := c.store.SuccessWithValue(key, value); err != nil {
+			logging.Log.Warnf("Failed to save {capture.%s}: %s", variable, err)
+		}
+		return nil
+	}
+	record, ok := c.store.Get(key)
+	if !ok {
+		return fmt.Errorf(
+			"Job %q did not run, and {capture.%s} was not saved by an earlier run",
+			task.Name().Resource(), variable)
+	}
+	logging.Log.Debugf("Using saved value of {capture.%s}", variable)
+	ctx.Env.SetCaptured(variable, record.Value)
+	return nil
+}
+
+// variable returns the name of the variable captured by the task, or an empty
+// string if the task does not capture a variable
+func (c *captures) variable(task iface.Task) string {
+	resource, _ := c.tasks.Resource(task)
+	conf, ok := resource.(*config.JobConfig)
+	if !ok || task.Name().Action() != "run" {
+		return ""
+	}
+	return conf.Capture
+}
+
+// captureKey returns the name of the record in the state file for the value of
+// a captured variable
+func captureKey(variable string) string {
+	return "capture/" + variable
+}
+
+// checkCaptures returns an error if a captured variable used by the resource is
+// not captured by a job which the resource depends on
+func checkCaptures(conf *config.Config, name string, variables []string) error {
+	for _, variable := range variables {
+		job := capturingJob(conf, variable)
+		switch {
+		case job == "":
+			return fmt.Errorf(
+				"Resource %q uses {capture.%s}, but no job captures %q",
+				name, variable, variable)
+		case !dependsOn(conf, name, job, make(map[string]bool)):
+			return fmt.Errorf(
+				"Resource %q uses {capture.%s}, but does not depend on %q, the job which captures it",
+				name, variable, job)
+		}
+	}
+	return nil
+}
+
+// capturingJob returns the name of the job which captures the variable, or an
+// empty string if no job captures it
+func capturingJob(conf *config.Config, variable string) string {
+	for _, name := range conf.Sorted() {
+		if job, ok := conf.Resources[name].(*config.JobConfig); ok && job.Capture == variable {
+			return name
+		}
+	}
+	return ""
+}
+
+// dependsOn returns true if target is a direct or indirect dependency of the
+// resource name
+func dependsOn(conf *config.Config, name, target string, seen map[string]bool) bool {
+	resource, ok := conf.Resources[name]
+	if !ok || seen[name] {
+		return false
+	}
+	seen[name] = true
+	for _, dep := range resource.Dependencies() {
+		dep = common.ParseTaskName(dep).Resource()
+		if dep == target || dependsOn(conf, dep, target, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCapture returns true if any job in the config sets capture
+func hasCapture(conf *config.Config) bool {
+	for _, resource := range conf.Resources {
+		if job, ok := resource.(*config.JobConfig); ok && job.Capture != "" {
+			return true
+		}
+	}
+	return false
+}
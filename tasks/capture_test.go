@@ -0,0 +1,105 @@
+package tasks
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/history"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/stretchr/testify/suite"
+)
+
+type CapturesSuite struct {
+	suite.Suite
+	dir     string
+	ctx     *context.ExecuteContext
+	store   *history.Store
+	runner  *captures
+	release *config.ImageConfig
+	output  string
+}
+
+func TestCapturesSuite(t *testing.T) {
+	suite.Run(t, new(CapturesSuite))
+}
+
+func (s *CapturesSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "captures-test")
+	s.Require().Nil(err)
+	s.store, err = history.Load(s.dir)
+	s.Require().Nil(err)
+
+	s.ctx = &context.ExecuteContext{Env: execenv.NewExecEnv("exec", "project", s.dir)}
+	s.output = ""
+	s.release = &config.ImageConfig{
+		Image:   "app",
+		Tags:    []string{"{capture.build.version}"},
+		Depends: []string{"version"},
+	}
+	version := &config.JobConfig{Capture: "build.version"}
+	conf := &config.Config{Resources: map[string]config.Resource{
+		"version": version,
+		"release": s.release,
+	}}
+	tasks := newTaskCollection()
+	tasks.addResource(&fakeTask{name: "version"}, version)
+	tasks.addResource(&fakeTask{name: "release"}, s.release)
+	tasks.deferred["release"] = true
+	s.runner = &captures{
+		store:  s.store,
+		tasks:  tasks,
+		config: conf,
+		next: func(ctx *context.ExecuteContext, task iface.Task) error {
+			if task.Name().Resource() == "version" && s.output != "" {
+				ctx.Env.SetCaptured("build.version", s.output)
+			}
+			return nil
+		},
+	}
+}
+
+func (s *CapturesSuite) TearDownTest() {
+	s.Nil(os.RemoveAll(s.dir))
+}
+
+func (s *CapturesSuite) TestRunSavesCapturedValue() {
+	s.output = "1.2.3"
+	s.Nil(s.runner.runTask(s.ctx, &fakeTask{name: "version"}))
+	s.Nil(s.runner.runTask(s.ctx, &fakeTask{name: "release"}))
+	s.Equal([]string{"1.2.3"}, s.release.Tags)
+
+	store, err := history.Load(s.dir)
+	s.Require().Nil(err)
+	record, ok := store.Get("capture/build.version")
+	s.True(ok)
+	s.Equal("1.2.3", record.Value)
+}
+
+func (s *CapturesSuite) TestSkippedJobUsesSavedValue() {
+	s.Require().Nil(s.store.SuccessWithValue("capture/build.version", "1.0.0"))
+	s.Nil(s.runner.runTask(s.ctx, &fakeTask{name: "version"}))
+	s.Nil(s.runner.runTask(s.ctx, &fakeTask{name: "release"}))
+	s.Equal([]string{"1.0.0"}, s.release.Tags)
+}
+
+func (s *CapturesSuite) TestSkippedJobWithoutSavedValue() {
+	err := s.runner.runTask(s.ctx, &fakeTask{name: "version"})
+	if s.Error(err) {
+		s.Contains(err.Error(),
+			`Job "version" did not run, and {capture.build.version} was not saved`)
+	}
+}
+
+func (s *CapturesSuite) TestCheckCaptures() {
+	s.Nil(checkCaptures(s.runner.config, "release", []string{"build.version"}))
+
+	err := checkCaptures(s.runner.config, "release", []string{"bogus"})
+	if s.Error(err) {
+		s.Contains(err.Error(), `no job captures "bogus"`)
+	}
+}
@@ -0,0 +1,33 @@
+package tasks
+
+import (
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+)
+
+// cleanupPolicies skips the remove tasks of resources with a cleanup policy of
+// never
+type cleanupPolicies struct {
+	config *config.Config
+	next   func(*context.ExecuteContext, iface.Task) error
+}
+
+func (c *cleanupPolicies) runTask(ctx *context.ExecuteContext, task iface.Task) error {
+	name := task.Name()
+	if !isRemoveAction(name.Action()) || !c.config.OptionsFor(name.Resource()).Cleanup.Never {
+		return c.next(ctx, task)
+	}
+	logging.Log.Infof("Skipping %q, the cleanup policy is never", name.Name())
+	return nil
+}
+
+// isRemoveAction returns true if the action is the remove action of a resource
+func isRemoveAction(action string) bool {
+	switch action {
+	case "rm", "remove", "down":
+		return true
+	}
+	return false
+}
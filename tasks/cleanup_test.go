@@ -0,0 +1,37 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRemoveTask struct {
+	fakeTask
+}
+
+func (t *fakeRemoveTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "rm")
+}
+
+func TestCleanupPoliciesSkipsRemoveTask(t *testing.T) {
+	conf := config.NewConfig()
+	conf.Options["base"] = &config.ResourceOptions{Cleanup: config.Cleanup{Never: true}}
+
+	ran := []string{}
+	c := &cleanupPolicies{
+		config: conf,
+		next: func(ctx *context.ExecuteContext, task iface.Task) error {
+			ran = append(ran, task.Name().Name())
+			return nil
+		},
+	}
+	assert.Nil(t, c.runTask(nil, &fakeRemoveTask{fakeTask{name: "base"}}))
+	assert.Nil(t, c.runTask(nil, &fakeTask{name: "base"}))
+	assert.Nil(t, c.runTask(nil, &fakeRemoveTask{fakeTask{name: "builder"}}))
+	assert.Equal(t, []string{"base:run", "builder:rm"}, ran)
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	// EngineDocker is the name of the Docker engine
+	EngineDocker = "docker"
+	// EnginePodman is the name of the Podman engine, which is used through
+	// its Docker compatible API
+	EnginePodman = "podman"
+
+	// EngineEnvVar is the environment variable which overrides the engine
+	// from the config
+	EngineEnvVar = "DOBI_ENGINE"
+
+	dockerSocket       = "/var/run/docker.sock"
+	podmanRootSocket   = "/run/podman/podman.sock"
+	podmanSocketSubdir = "podman/podman.sock"
+)
+
+// ResolveEngine returns the name of the engine from the DOBI_ENGINE
+// environment variable, or from the config, or the default engine
+func ResolveEngine(configured string) string {
+	if engine := os.Getenv(EngineEnvVar); engine != "" {
+		return engine
+	}
+	if configured != "" {
+		return configured
+	}
+	return EngineDocker
+}
+
+// SocketPath returns the path to the unix socket of the API of the engine on
+// the host
+func SocketPath(engine string) string {
+	if engine != EnginePodman {
+		return dockerSocket
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" && os.Getuid() != 0 {
+		return filepath.Join(runtimeDir, podmanSocketSubdir)
+	}
+	return podmanRootSocket
+}
+
+// Endpoint returns the address of the API of the engine. The Docker endpoint
+// is read from DOCKER_HOST, and the Podman endpoint from CONTAINER_HOST. When
+// they are not set the unix socket of the engine is used.
+func Endpoint(engine string) string {
+	variable := "DOCKER_HOST"
+	if engine == EnginePodman {
+		variable = "CONTAINER_HOST"
+	}
+	if endpoint := os.Getenv(variable); endpoint != "" {
+		return endpoint
+	}
+	return "unix://" + SocketPath(engine)
+}
+
+// New returns a client for the API of the engine
+func New(engine, apiVersion string) (DockerClient, error) {
+	var client *docker.Client
+	var err error
+	switch engine {
+	case EngineDocker:
+		client, err = docker.NewVersionedClientFromEnv(apiVersion)
+	case EnginePodman:
+		client, err = docker.NewVersionedClient(Endpoint(engine), apiVersion)
+	default:
+		return nil, fmt.Errorf("invalid engine %q, must be one of: %s, %s",
+			engine, EngineDocker, EnginePodman)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveEngine(t *testing.T) {
+	defer os.Setenv(EngineEnvVar, os.Getenv(EngineEnvVar))
+	os.Unsetenv(EngineEnvVar)
+
+	assert.Equal(t, EngineDocker, ResolveEngine(""))
+	assert.Equal(t, EnginePodman, ResolveEngine(EnginePodman))
+
+	os.Setenv(EngineEnvVar, EngineDocker)
+	assert.Equal(t, EngineDocker, ResolveEngine(EnginePodman))
+}
+
+func TestEndpointPodman(t *testing.T) {
+	defer os.Setenv("CONTAINER_HOST", os.Getenv("CONTAINER_HOST"))
+	defer os.Setenv("XDG_RUNTIME_DIR", os.Getenv("XDG_RUNTIME_DIR"))
+	os.Unsetenv("CONTAINER_HOST")
+	os.Unsetenv("XDG_RUNTIME_DIR")
+
+	assert.Equal(t, "unix:///run/podman/podman.sock", Endpoint(EnginePodman))
+
+	os.Setenv("CONTAINER_HOST", "tcp://podman.example.com:8080")
+	assert.Equal(t, "tcp://podman.example.com:8080", Endpoint(EnginePodman))
+}
+
+func TestNewInvalidEngine(t *testing.T) {
+	_, err := New("containerd", "1.23")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `invalid engine "containerd"`)
+	}
+}
@@ -10,6 +10,7 @@ import (
 type DockerClient interface {
 	BuildImage(docker.BuildImageOptions) error
 	InspectImage(string) (*docker.Image, error)
+	ListImages(docker.ListImagesOptions) ([]docker.APIImages, error)
 	PushImage(docker.PushImageOptions, docker.AuthConfiguration) error
 	PullImage(docker.PullImageOptions, docker.AuthConfiguration) error
 	RemoveImage(string) error
@@ -17,8 +18,24 @@ type DockerClient interface {
 
 	AttachToContainerNonBlocking(docker.AttachToContainerOptions) (docker.CloseWaiter, error)
 	CreateContainer(docker.CreateContainerOptions) (*docker.Container, error)
+	DownloadFromContainer(string, docker.DownloadFromContainerOptions) error
+	InspectContainer(string) (*docker.Container, error)
 	KillContainer(docker.KillContainerOptions) error
+	Logs(docker.LogsOptions) error
 	RemoveContainer(docker.RemoveContainerOptions) error
 	StartContainer(string, *docker.HostConfig) error
+	StopContainer(string, uint) error
 	WaitContainer(string) (int, error)
+
+	CreateExec(docker.CreateExecOptions) (*docker.Exec, error)
+	StartExec(string, docker.StartExecOptions) error
+	InspectExec(string) (*docker.ExecInspect, error)
+
+	CreateNetwork(docker.CreateNetworkOptions) (*docker.Network, error)
+	NetworkInfo(string) (*docker.Network, error)
+	RemoveNetwork(string) error
+
+	CreateVolume(docker.CreateVolumeOptions) (*docker.Volume, error)
+	InspectVolume(string) (*docker.Volume, error)
+	RemoveVolume(string) error
 }
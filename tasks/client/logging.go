@@ -0,0 +1,259 @@
+package client
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/utils/mask"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// NewLoggingClient returns a DockerClient which logs each call to client, and
+// the parameters of the call, at debug level. The values of environment
+// variables, build args, and credentials are redacted from the log.
+func NewLoggingClient(client DockerClient) DockerClient {
+	return &loggingClient{client: client}
+}
+
+type loggingClient struct {
+	client DockerClient
+}
+
+func (c *loggingClient) log(method string, params map[string]interface{}) {
+	out, err := json.MarshalIndent(params, "", "  ")
+	if err != nil {
+		logging.Log.Debugf("Docker API call %s: %s", method, err)
+		return
+	}
+	logging.Log.Debugf("Docker API call %s %s", method, out)
+}
+
+// redactEnv returns the names of the environment variables in env, with the
+// values redacted
+func redactEnv(env []string) []string {
+	names := []string{}
+	for _, item := range env {
+		names = append(names, strings.SplitN(item, "=", 2)[0]+"="+mask.Redacted)
+	}
+	return names
+}
+
+// redactAuth returns the auth config without the password
+func redactAuth(auth docker.AuthConfiguration) map[string]string {
+	return map[string]string{
+		"username":      auth.Username,
+		"serveraddress": auth.ServerAddress,
+	}
+}
+
+func (c *loggingClient) BuildImage(opts docker.BuildImageOptions) error {
+	buildArgs := []string{}
+	for _, arg := range opts.BuildArgs {
+		buildArgs = append(buildArgs, arg.Name+"="+mask.Redacted)
+	}
+	c.log("BuildImage", map[string]interface{}{
+		"name":       opts.Name,
+		"dockerfile": opts.Dockerfile,
+		"contextDir": opts.ContextDir,
+		"remote":     opts.Remote,
+		"noCache":    opts.NoCache,
+		"pull":       opts.Pull,
+		"rm":         opts.RmTmpContainer,
+		"forceRm":    opts.ForceRmTmpContainer,
+		"buildArgs":  buildArgs,
+	})
+	return c.client.BuildImage(opts)
+}
+
+func (c *loggingClient) InspectImage(name string) (*docker.Image, error) {
+	c.log("InspectImage", map[string]interface{}{"name": name})
+	return c.client.InspectImage(name)
+}
+
+func (c *loggingClient) ListImages(opts docker.ListImagesOptions) ([]docker.APIImages, error) {
+	c.log("ListImages", map[string]interface{}{"all": opts.All, "filters": opts.Filters})
+	return c.client.ListImages(opts)
+}
+
+func (c *loggingClient) PushImage(opts docker.PushImageOptions, auth docker.AuthConfiguration) error {
+	c.log("PushImage", map[string]interface{}{
+		"name":     opts.Name,
+		"tag":      opts.Tag,
+		"registry": opts.Registry,
+		"auth":     redactAuth(auth),
+	})
+	return c.client.PushImage(opts, auth)
+}
+
+func (c *loggingClient) PullImage(opts docker.PullImageOptions, auth docker.AuthConfiguration) error {
+	c.log("PullImage", map[string]interface{}{
+		"repository": opts.Repository,
+		"tag":        opts.Tag,
+		"registry":   opts.Registry,
+		"auth":       redactAuth(auth),
+	})
+	return c.client.PullImage(opts, auth)
+}
+
+func (c *loggingClient) RemoveImage(name string) error {
+	c.log("RemoveImage", map[string]interface{}{"name": name})
+	return c.client.RemoveImage(name)
+}
+
+func (c *loggingClient) TagImage(name string, opts docker.TagImageOptions) error {
+	c.log("TagImage", map[string]interface{}{
+		"name":  name,
+		"repo":  opts.Repo,
+		"tag":   opts.Tag,
+		"force": opts.Force,
+	})
+	return c.client.TagImage(name, opts)
+}
+
+func (c *loggingClient) AttachToContainerNonBlocking(
+	opts docker.AttachToContainerOptions,
+) (docker.CloseWaiter, error) {
+	c.log("AttachToContainer", map[string]interface{}{
+		"container":   opts.Container,
+		"stdin":       opts.Stdin,
+		"stdout":      opts.Stdout,
+		"stderr":      opts.Stderr,
+		"stream":      opts.Stream,
+		"rawTerminal": opts.RawTerminal,
+	})
+	return c.client.AttachToContainerNonBlocking(opts)
+}
+
+func (c *loggingClient) CreateContainer(opts docker.CreateContainerOptions) (*docker.Container, error) {
+	params := map[string]interface{}{"name": opts.Name}
+	if opts.Config != nil {
+		config := *opts.Config
+		config.Env = redactEnv(config.Env)
+		params["config"] = config
+	}
+	if opts.HostConfig != nil {
+		params["hostConfig"] = opts.HostConfig
+	}
+	c.log("CreateContainer", params)
+	return c.client.CreateContainer(opts)
+}
+
+func (c *loggingClient) DownloadFromContainer(
+	id string,
+	opts docker.DownloadFromContainerOptions,
+) error {
+	c.log("DownloadFromContainer", map[string]interface{}{"id": id, "path": opts.Path})
+	return c.client.DownloadFromContainer(id, opts)
+}
+
+func (c *loggingClient) InspectContainer(id string) (*docker.Container, error) {
+	c.log("InspectContainer", map[string]interface{}{"id": id})
+	return c.client.InspectContainer(id)
+}
+
+func (c *loggingClient) KillContainer(opts docker.KillContainerOptions) error {
+	c.log("KillContainer", map[string]interface{}{"id": opts.ID, "signal": opts.Signal})
+	return c.client.KillContainer(opts)
+}
+
+func (c *loggingClient) Logs(opts docker.LogsOptions) error {
+	c.log("Logs", map[string]interface{}{
+		"container": opts.Container,
+		"follow":    opts.Follow,
+		"stdout":    opts.Stdout,
+		"stderr":    opts.Stderr,
+		"tail":      opts.Tail,
+	})
+	return c.client.Logs(opts)
+}
+
+func (c *loggingClient) RemoveContainer(opts docker.RemoveContainerOptions) error {
+	c.log("RemoveContainer", map[string]interface{}{
+		"id":            opts.ID,
+		"removeVolumes": opts.RemoveVolumes,
+		"force":         opts.Force,
+	})
+	return c.client.RemoveContainer(opts)
+}
+
+func (c *loggingClient) StartContainer(id string, hostConfig *docker.HostConfig) error {
+	c.log("StartContainer", map[string]interface{}{"id": id, "hostConfig": hostConfig})
+	return c.client.StartContainer(id, hostConfig)
+}
+
+func (c *loggingClient) StopContainer(id string, timeout uint) error {
+	c.log("StopContainer", map[string]interface{}{"id": id, "timeout": timeout})
+	return c.client.StopContainer(id, timeout)
+}
+
+func (c *loggingClient) WaitContainer(id string) (int, error) {
+	c.log("WaitContainer", map[string]interface{}{"id": id})
+	return c.client.WaitContainer(id)
+}
+
+func (c *loggingClient) CreateExec(opts docker.CreateExecOptions) (*docker.Exec, error) {
+	c.log("CreateExec", map[string]interface{}{
+		"container":    opts.Container,
+		"cmd":          opts.Cmd,
+		"user":         opts.User,
+		"tty":          opts.Tty,
+		"attachStdin":  opts.AttachStdin,
+		"attachStdout": opts.AttachStdout,
+		"attachStderr": opts.AttachStderr,
+	})
+	return c.client.CreateExec(opts)
+}
+
+func (c *loggingClient) StartExec(id string, opts docker.StartExecOptions) error {
+	c.log("StartExec", map[string]interface{}{
+		"id":          id,
+		"tty":         opts.Tty,
+		"detach":      opts.Detach,
+		"rawTerminal": opts.RawTerminal,
+	})
+	return c.client.StartExec(id, opts)
+}
+
+func (c *loggingClient) InspectExec(id string) (*docker.ExecInspect, error) {
+	c.log("InspectExec", map[string]interface{}{"id": id})
+	return c.client.InspectExec(id)
+}
+
+func (c *loggingClient) CreateNetwork(opts docker.CreateNetworkOptions) (*docker.Network, error) {
+	c.log("CreateNetwork", map[string]interface{}{
+		"name":    opts.Name,
+		"driver":  opts.Driver,
+		"options": opts.Options,
+	})
+	return c.client.CreateNetwork(opts)
+}
+
+func (c *loggingClient) NetworkInfo(id string) (*docker.Network, error) {
+	c.log("NetworkInfo", map[string]interface{}{"id": id})
+	return c.client.NetworkInfo(id)
+}
+
+func (c *loggingClient) RemoveNetwork(id string) error {
+	c.log("RemoveNetwork", map[string]interface{}{"id": id})
+	return c.client.RemoveNetwork(id)
+}
+
+func (c *loggingClient) CreateVolume(opts docker.CreateVolumeOptions) (*docker.Volume, error) {
+	c.log("CreateVolume", map[string]interface{}{
+		"name":       opts.Name,
+		"driver":     opts.Driver,
+		"driverOpts": opts.DriverOpts,
+	})
+	return c.client.CreateVolume(opts)
+}
+
+func (c *loggingClient) InspectVolume(name string) (*docker.Volume, error) {
+	c.log("InspectVolume", map[string]interface{}{"name": name})
+	return c.client.InspectVolume(name)
+}
+
+func (c *loggingClient) RemoveVolume(name string) error {
+	c.log("RemoveVolume", map[string]interface{}{"name": name})
+	return c.client.RemoveVolume(name)
+}
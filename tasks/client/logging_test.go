@@ -0,0 +1,72 @@
+package client
+
+import (
+	"bytes"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/logging"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func captureLog() (*bytes.Buffer, func()) {
+	out, level, formatter := logging.Log.Out, logging.Log.Level, logging.Log.Formatter
+	buff := &bytes.Buffer{}
+	logging.Log.Out = buff
+	logging.Log.Level = log.DebugLevel
+	logging.Log.Formatter = &logging.Formatter{}
+	return buff, func() {
+		logging.Log.Out = out
+		logging.Log.Level = level
+		logging.Log.Formatter = formatter
+	}
+}
+
+func TestLoggingClientCreateContainerRedactsEnv(t *testing.T) {
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+	mockClient := NewMockDockerClient(mock)
+	buff, restore := captureLog()
+	defer restore()
+
+	opts := docker.CreateContainerOptions{
+		Name: "project-exec-test",
+		Config: &docker.Config{
+			Image: "builder",
+			Env:   []string{"TOKEN=secret-token", "EMPTY"},
+		},
+		HostConfig: &docker.HostConfig{Privileged: true},
+	}
+	mockClient.EXPECT().CreateContainer(opts).Return(&docker.Container{ID: "id"}, nil)
+
+	container, err := NewLoggingClient(mockClient).CreateContainer(opts)
+	assert.Nil(t, err)
+	assert.Equal(t, "id", container.ID)
+	assert.Equal(t, []string{"TOKEN=secret-token", "EMPTY"}, opts.Config.Env)
+
+	assert.Contains(t, buff.String(), "Docker API call CreateContainer")
+	assert.Contains(t, buff.String(), `"project-exec-test"`)
+	assert.Contains(t, buff.String(), `"TOKEN=******"`)
+	assert.Contains(t, buff.String(), `"EMPTY=******"`)
+	assert.Contains(t, buff.String(), `"Privileged": true`)
+	assert.NotContains(t, buff.String(), "secret-token")
+}
+
+func TestLoggingClientPushImageRedactsAuth(t *testing.T) {
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+	mockClient := NewMockDockerClient(mock)
+	buff, restore := captureLog()
+	defer restore()
+
+	opts := docker.PushImageOptions{Name: "example/app", Tag: "v1"}
+	auth := docker.AuthConfiguration{Username: "builder", Password: "hunter2"}
+	mockClient.EXPECT().PushImage(opts, auth).Return(nil)
+
+	assert.Nil(t, NewLoggingClient(mockClient).PushImage(opts, auth))
+	assert.Contains(t, buff.String(), "Docker API call PushImage")
+	assert.Contains(t, buff.String(), `"username": "builder"`)
+	assert.NotContains(t, buff.String(), "hunter2")
+}
@@ -49,7 +49,9 @@ func getAction(name string, task string) (action, error) {
 // RunUp starts the Compose project
 func RunUp(ctx *context.ExecuteContext, t *Task) error {
 	t.logger().Info("project up")
-	return t.execCompose(ctx, "up", "-d")
+	return t.withHooks(ctx, func() error {
+		return t.execCompose(ctx, "up", "-d")
+	})
 }
 
 // StopUp stops the project
@@ -12,8 +12,16 @@ import (
 // RunUpAttached starts the Compose project
 func RunUpAttached(ctx *context.ExecuteContext, t *Task) error {
 	t.logger().Info("project up")
+	return t.withHooks(ctx, func() error {
+		return t.runUpAttached(ctx)
+	})
+}
 
-	cmd := t.composeCommand(ctx, "up", "-t", t.config.StopGraceString())
+func (t *Task) runUpAttached(ctx *context.ExecuteContext) error {
+	cmd, err := t.composeCommand(ctx, "up", "-t", t.config.StopGraceString())
+	if err != nil {
+		return err
+	}
 	if err := cmd.Start(); err != nil {
 		return err
 	}
@@ -0,0 +1,55 @@
+package compose
+
+import (
+	"fmt"
+	"os/exec"
+	"sync"
+
+	"github.com/dnephin/dobi/config"
+)
+
+var (
+	// lookPath and hasPlugin are replaced by tests
+	lookPath  = exec.LookPath
+	hasPlugin = func() bool {
+		return exec.Command("docker", "compose", "version").Run() == nil
+	}
+
+	detectOnce sync.Once
+	detected   []string
+	detectErr  error
+)
+
+// composeBinary returns the command used to run Compose. When the binary is
+// detected, the detection is only run once.
+func composeBinary(conf *config.ComposeConfig) ([]string, error) {
+	switch conf.Binary {
+	case config.ComposeBinaryPlugin:
+		return []string{"docker", "compose"}, nil
+	case config.ComposeBinaryStandalone:
+		return []string{"docker-compose"}, nil
+	default:
+		detectOnce.Do(func() {
+			detected, detectErr = detectBinary()
+		})
+		return detected, detectErr
+	}
+}
+
+// detectBinary returns the Compose plugin if it is installed, otherwise
+// docker-compose
+func detectBinary() ([]string, error) {
+	if hasPlugin() {
+		return []string{"docker", "compose"}, nil
+	}
+	if _, err := lookPath("docker-compose"); err != nil {
+		return nil, fmt.Errorf(
+			"Docker Compose is not installed, %q and %q were not found",
+			"docker compose", "docker-compose")
+	}
+	return []string{"docker-compose"}, nil
+}
+
+func isPlugin(binary []string) bool {
+	return len(binary) == 2
+}
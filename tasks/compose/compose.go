@@ -9,6 +9,7 @@ import (
 	log "github.com/Sirupsen/logrus"
 	"github.com/dnephin/dobi/config"
 	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/client"
 	"github.com/dnephin/dobi/tasks/common"
 	"github.com/dnephin/dobi/tasks/context"
 )
@@ -40,13 +41,20 @@ func (t *Task) Repr() string {
 		t.action.name, t.name, strings.Join(t.config.Files, ","))
 }
 
-// Run runs the action
+// Run runs the action, unless the resource is not enabled
 func (t *Task) Run(ctx *context.ExecuteContext) error {
+	if !t.config.IsEnabled() {
+		t.logger().Info("Skipped, not enabled")
+		return nil
+	}
 	return t.action.Run(ctx, t)
 }
 
 // Stop the task
 func (t *Task) Stop(ctx *context.ExecuteContext) error {
+	if !t.config.IsEnabled() {
+		return nil
+	}
 	t.logger().Debug("Stop")
 	return t.action.Stop(ctx, t)
 }
@@ -66,27 +74,55 @@ func StopNothing(ctx *context.ExecuteContext, t *Task) error {
 	return nil
 }
 
-func buildCommandArgs(ctx *context.ExecuteContext, conf *config.ComposeConfig) []string {
-	args := []string{}
+func buildCommandArgs(binary []string, conf *config.ComposeConfig) ([]string, error) {
+	if len(conf.EnvFiles) > 1 && !isPlugin(binary) {
+		return nil, fmt.Errorf("more than one env-file requires the Compose plugin")
+	}
+	args := append([]string{}, binary[1:]...)
 	for _, filename := range conf.Files {
 		args = append(args, "-f", filename)
 	}
-	return append(args, "-p", conf.Project)
+	args = append(args, "-p", conf.Project)
+	if conf.ProjectDirectory != "" {
+		args = append(args, "--project-directory", conf.ProjectDirectory)
+	}
+	for _, profile := range conf.Profiles {
+		args = append(args, "--profile", profile)
+	}
+	for _, filename := range conf.EnvFiles {
+		args = append(args, "--env-file", filename)
+	}
+	return args, nil
 }
 
 func (t *Task) execCompose(ctx *context.ExecuteContext, args ...string) error {
-	if err := t.composeCommand(ctx, args...).Run(); err != nil {
+	cmd, err := t.composeCommand(ctx, args...)
+	if err != nil {
+		return err
+	}
+	if err := cmd.Run(); err != nil {
 		return err
 	}
 	t.logger().Info("Done")
 	return nil
 }
 
-func (t *Task) composeCommand(ctx *context.ExecuteContext, args ...string) *exec.Cmd {
-	args = append(buildCommandArgs(ctx, t.config), args...)
-	cmd := exec.Command("docker-compose", args...)
+func (t *Task) composeCommand(ctx *context.ExecuteContext, args ...string) (*exec.Cmd, error) {
+	binary, err := composeBinary(t.config)
+	if err != nil {
+		return nil, err
+	}
+	baseArgs, err := buildCommandArgs(binary, t.config)
+	if err != nil {
+		return nil, err
+	}
+	args = append(baseArgs, args...)
+	cmd := exec.Command(binary[0], args...)
+	if ctx.Engine == client.EnginePodman {
+		cmd.Env = append(os.Environ(), "DOCKER_HOST="+client.Endpoint(ctx.Engine))
+	}
 	t.logger().Debugf("Args: %s", args)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	return cmd
+	return cmd, nil
 }
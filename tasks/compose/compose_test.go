@@ -0,0 +1,60 @@
+package compose
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildCommandArgs(t *testing.T) {
+	conf := &config.ComposeConfig{
+		Files:            []string{"docker-compose.yml"},
+		Project:          "devenv",
+		ProjectDirectory: "./env",
+		Profiles:         []string{"debug"},
+		EnvFiles:         []string{".env", ".env.local"},
+	}
+	args, err := buildCommandArgs([]string{"docker", "compose"}, conf)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"compose",
+		"-f", "docker-compose.yml",
+		"-p", "devenv",
+		"--project-directory", "./env",
+		"--profile", "debug",
+		"--env-file", ".env",
+		"--env-file", ".env.local",
+	}, args)
+
+	_, err = buildCommandArgs([]string{"docker-compose"}, conf)
+	assert.EqualError(t, err, "more than one env-file requires the Compose plugin")
+}
+
+func TestDetectBinary(t *testing.T) {
+	defer func(plugin func() bool, look func(string) (string, error)) {
+		hasPlugin, lookPath = plugin, look
+	}(hasPlugin, lookPath)
+
+	hasPlugin = func() bool { return true }
+	binary, err := detectBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"docker", "compose"}, binary)
+
+	hasPlugin = func() bool { return false }
+	lookPath = func(string) (string, error) { return "/usr/bin/docker-compose", nil }
+	binary, err = detectBinary()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"docker-compose"}, binary)
+
+	lookPath = func(string) (string, error) { return "", fmt.Errorf("not found") }
+	_, err = detectBinary()
+	assert.Error(t, err)
+}
+
+func TestComposeBinaryFromConfig(t *testing.T) {
+	binary, err := composeBinary(&config.ComposeConfig{Binary: config.ComposeBinaryStandalone})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"docker-compose"}, binary)
+}
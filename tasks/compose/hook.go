@@ -0,0 +1,50 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+)
+
+// withHooks runs the before hook, then start, and then the after hook if start
+// succeeds, or the on-failure hook if it fails. Failures of the after and
+// on-failure hooks are logged, but do not change the result of start.
+func (t *Task) withHooks(ctx *context.ExecuteContext, start func() error) error {
+	if err := t.runHook(ctx, "before", t.config.Before); err != nil {
+		return err
+	}
+	if err := start(); err != nil {
+		if hookErr := t.runHook(ctx, "on-failure", t.config.OnFailure); hookErr != nil {
+			t.logger().Warn(hookErr)
+		}
+		return err
+	}
+	if err := t.runHook(ctx, "after", t.config.After); err != nil {
+		t.logger().Warn(err)
+	}
+	return nil
+}
+
+func (t *Task) runHook(ctx *context.ExecuteContext, hook string, command config.ShlexSlice) error {
+	if command.Empty() {
+		return nil
+	}
+	t.logger().Infof("Running %s hook", hook)
+	cmd := hookCommand(command.Value(), t.config.Project)
+	cmd.Dir = ctx.WorkingDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook %q failed: %s", hook, command.String(), err)
+	}
+	return nil
+}
+
+func hookCommand(args []string, project string) *exec.Cmd {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), "COMPOSE_PROJECT_NAME="+project)
+	return cmd
+}
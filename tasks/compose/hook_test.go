@@ -0,0 +1,54 @@
+package compose
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCommand(t *testing.T, command string) config.ShlexSlice {
+	value := config.ShlexSlice{}
+	assert.Nil(t, value.TransformConfig(reflect.ValueOf(command)))
+	return value
+}
+
+func TestWithHooksBeforeFailureSkipsStart(t *testing.T) {
+	task := NewTask("devenv", &config.ComposeConfig{
+		Project: "devenv",
+		Before:  newCommand(t, "false"),
+	}, action{})
+
+	started := false
+	err := task.withHooks(&context.ExecuteContext{}, func() error {
+		started = true
+		return nil
+	})
+	assert.EqualError(t, err, `before hook "false" failed: exit status 1`)
+	assert.False(t, started)
+}
+
+func TestWithHooksKeepsErrorFromStart(t *testing.T) {
+	task := NewTask("devenv", &config.ComposeConfig{
+		Project:   "devenv",
+		After:     newCommand(t, "false"),
+		OnFailure: newCommand(t, "false"),
+	}, action{})
+
+	err := task.withHooks(&context.ExecuteContext{}, func() error {
+		return errors.New("compose failed")
+	})
+	assert.EqualError(t, err, "compose failed")
+
+	err = task.withHooks(&context.ExecuteContext{}, func() error { return nil })
+	assert.Nil(t, err)
+}
+
+func TestHookCommandSetsProjectName(t *testing.T) {
+	cmd := hookCommand([]string{"docker", "compose", "logs"}, "devenv")
+	assert.Equal(t, []string{"docker", "compose", "logs"}, cmd.Args)
+	assert.Contains(t, cmd.Env, "COMPOSE_PROJECT_NAME=devenv")
+}
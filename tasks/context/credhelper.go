@@ -0,0 +1,115 @@
+package context
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+const (
+	credentialHelperPrefix = "docker-credential-"
+)
+
+// credentialHelpers maps a registry hostname to the name of a credential
+// helper, as defined by the credHelpers and credsStore fields of the docker
+// client config file.
+type credentialHelpers struct {
+	registries map[string]string
+	store      string
+}
+
+// helper returns the name of the credential helper for a registry, or an empty
+// string if there is no helper configured.
+func (c credentialHelpers) helper(registry string) string {
+	if helper, ok := c.registries[registry]; ok {
+		return helper
+	}
+	if helper, ok := c.registries[registryHostname(registry)]; ok {
+		return helper
+	}
+	return c.store
+}
+
+func registryHostname(registry string) string {
+	registry = strings.TrimPrefix(registry, "https://")
+	registry = strings.TrimPrefix(registry, "http://")
+	return strings.SplitN(registry, "/", 2)[0]
+}
+
+type dockerConfigFile struct {
+	CredHelpers map[string]string `json:"credHelpers"`
+	CredsStore  string            `json:"credsStore"`
+}
+
+func dockerConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".docker", "config.json")
+}
+
+// loadCredentialHelpers reads the credential helpers from a docker client
+// config file. A missing file is not an error.
+func loadCredentialHelpers(path string) (credentialHelpers, error) {
+	helpers := credentialHelpers{registries: make(map[string]string)}
+
+	content, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return helpers, nil
+	case err != nil:
+		return helpers, err
+	}
+
+	configFile := dockerConfigFile{}
+	if err := json.Unmarshal(content, &configFile); err != nil {
+		return helpers, fmt.Errorf("failed to parse %q: %s", path, err)
+	}
+	for registry, helper := range configFile.CredHelpers {
+		helpers.registries[registry] = helper
+	}
+	helpers.store = configFile.CredsStore
+	return helpers, nil
+}
+
+type helperCredentials struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+// getCredentialsFromHelper runs a credential helper to get the credentials for
+// a registry. The helper is run each time the credentials are required, because
+// helpers often return short lived tokens.
+func getCredentialsFromHelper(helper, registry string) (docker.AuthConfiguration, error) {
+	auth := docker.AuthConfiguration{}
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	cmd := exec.Command(credentialHelperPrefix+helper, "get")
+	cmd.Stdin = bytes.NewBufferString(registryHostname(registry))
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return auth, fmt.Errorf("credential helper %q failed: %s %s",
+			helper, err, strings.TrimSpace(stdout.String()+stderr.String()))
+	}
+
+	creds := helperCredentials{}
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return auth, fmt.Errorf("credential helper %q returned invalid output: %s",
+			helper, err)
+	}
+
+	auth.ServerAddress = registry
+	auth.Username = creds.Username
+	auth.Password = creds.Secret
+	return auth, nil
+}
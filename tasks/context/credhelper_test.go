@@ -0,0 +1,130 @@
+package context
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/stretchr/testify/suite"
+)
+
+const fakeHelper = `#!/bin/sh
+read registry
+case "$registry" in
+    registry.example.com)
+        echo '{"ServerURL": "registry.example.com", "Username": "AWS", "Secret": "token-1"}'
+        ;;
+    *)
+        echo "credentials not found in native keychain"
+        exit 1
+        ;;
+esac
+`
+
+type CredHelperSuite struct {
+	suite.Suite
+	path    string
+	oldPath string
+}
+
+func TestCredHelperSuite(t *testing.T) {
+	suite.Run(t, new(CredHelperSuite))
+}
+
+func (s *CredHelperSuite) SetupTest() {
+	var err error
+	s.path, err = ioutil.TempDir("", "credhelper-test")
+	s.Require().Nil(err)
+
+	helper := filepath.Join(s.path, credentialHelperPrefix+"fake")
+	s.Require().Nil(ioutil.WriteFile(helper, []byte(fakeHelper), 0755))
+
+	s.oldPath = os.Getenv("PATH")
+	os.Setenv("PATH", s.path+string(os.PathListSeparator)+s.oldPath)
+}
+
+func (s *CredHelperSuite) TearDownTest() {
+	os.Setenv("PATH", s.oldPath)
+	s.Nil(os.RemoveAll(s.path))
+}
+
+func (s *CredHelperSuite) writeConfig(content string) string {
+	path := filepath.Join(s.path, "config.json")
+	s.Require().Nil(ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func (s *CredHelperSuite) TestLoadCredentialHelpers() {
+	path := s.writeConfig(`{
+		"auths": {"https://index.docker.io/v1/": {"auth": "Zm9vOmJhcg=="}},
+		"credHelpers": {"registry.example.com": "fake"}
+	}`)
+	helpers, err := loadCredentialHelpers(path)
+	s.Nil(err)
+	s.Equal("fake", helpers.helper("registry.example.com"))
+	s.Equal("fake", helpers.helper("https://registry.example.com/v2/"))
+	s.Equal("", helpers.helper("other.example.com"))
+}
+
+func (s *CredHelperSuite) TestLoadCredentialHelpersWithStore() {
+	path := s.writeConfig(`{"credsStore": "fake"}`)
+	helpers, err := loadCredentialHelpers(path)
+	s.Nil(err)
+	s.Equal("fake", helpers.helper("other.example.com"))
+}
+
+func (s *CredHelperSuite) TestLoadCredentialHelpersMissingFile() {
+	helpers, err := loadCredentialHelpers(filepath.Join(s.path, "missing.json"))
+	s.Nil(err)
+	s.Equal("", helpers.helper("registry.example.com"))
+}
+
+func (s *CredHelperSuite) TestGetCredentialsFromHelper() {
+	auth, err := getCredentialsFromHelper("fake", "registry.example.com")
+	s.Nil(err)
+	s.Equal("AWS", auth.Username)
+	s.Equal("token-1", auth.Password)
+	s.Equal("registry.example.com", auth.ServerAddress)
+}
+
+func (s *CredHelperSuite) TestGetCredentialsFromHelperFails() {
+	_, err := getCredentialsFromHelper("fake", "other.example.com")
+	s.Error(err)
+	s.Contains(err.Error(), "credentials not found")
+}
+
+func (s *CredHelperSuite) TestGetAuthConfigUsesHelper() {
+	ctx := &ExecuteContext{
+		credHelpers: credentialHelpers{
+			registries: map[string]string{"registry.example.com": "fake"},
+		},
+	}
+	auth := ctx.GetAuthConfig("registry.example.com")
+	s.Equal("token-1", auth.Password)
+}
+
+func (s *CredHelperSuite) TestGetRegistryAuthUsesHelper() {
+	ctx := &ExecuteContext{}
+	auth, err := ctx.GetRegistryAuth(&config.RegistryConfig{
+		Server:           "registry.example.com",
+		CredentialHelper: "fake",
+	})
+	s.Nil(err)
+	s.Equal("AWS", auth.Username)
+	s.Equal("token-1", auth.Password)
+}
+
+func (s *CredHelperSuite) TestGetRegistryAuthWithPassword() {
+	ctx := &ExecuteContext{}
+	auth, err := ctx.GetRegistryAuth(&config.RegistryConfig{
+		Server:   "registry.example.com",
+		Username: "deploy",
+		Password: "secret",
+	})
+	s.Nil(err)
+	s.Equal("deploy", auth.Username)
+	s.Equal("secret", auth.Password)
+	s.Equal("registry.example.com", auth.ServerAddress)
+}
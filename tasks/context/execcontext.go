@@ -1,27 +1,75 @@
 package context
 
 import (
+	"os"
+	"sync"
+
 	"github.com/dnephin/dobi/config"
 	"github.com/dnephin/dobi/execenv"
 	"github.com/dnephin/dobi/logging"
 	"github.com/dnephin/dobi/tasks/client"
+	"github.com/dnephin/dobi/tasks/events"
+	"github.com/dnephin/dobi/utils/mask"
+	"github.com/docker/docker/pkg/jsonmessage"
 	docker "github.com/fsouza/go-dockerclient"
 )
 
 // ExecuteContext contains all the context for task execution
 type ExecuteContext struct {
 	modified    map[string]bool
+	modifiedMu  sync.Mutex
 	Resources   *config.ResourceCollection
 	Client      client.DockerClient
 	authConfigs *docker.AuthConfigurations
+	credHelpers credentialHelpers
 	WorkingDir  string
 	Env         *execenv.ExecEnv
 	Quiet       bool
+	// Limits is the maximum number of tasks of each resource type which may
+	// run concurrently
+	Limits map[string]int
+	// TempDir is the directory used for intermediate files, like build
+	// contexts and downloads
+	TempDir string
+	// EnvPassthrough is a list of host environment variables to set in the
+	// environment of every job
+	EnvPassthrough []string
+	// Masker redacts sensitive values from the output of jobs
+	Masker *mask.Masker
+	// DefaultShell is the shell used to run the command of jobs which do not
+	// set a shell or entrypoint
+	DefaultShell []string
+	// ExecCommand is the command run in the container of a job by the exec
+	// action
+	ExecCommand []string
+	// ImageProgress receives the progress messages from the Docker daemon
+	// when an image is built, pulled, or pushed. When ImageProgress is nil the
+	// progress is displayed on stdout.
+	ImageProgress ImageProgressFunc
+	// Labels are set on every container and image, unless the resource sets
+	// a label with the same name
+	Labels map[string]string
+	// NoRemove keeps the container of every job after it runs
+	NoRemove bool
+	// StaleCheck is the default stale-check of every job and image which does
+	// not set its own
+	StaleCheck string
+	// Events receives a structured event for the output of jobs and shells.
+	// When Events is nil the output is displayed on stdout and stderr.
+	Events *events.Writer
+	// Engine is the name of the container engine used by Client
+	Engine string
 }
 
+// ImageProgressFunc receives a json progress message from the Docker daemon for
+// the image task named task
+type ImageProgressFunc func(task string, message jsonmessage.JSONMessage)
+
 // IsModified returns true if any of the tasks named in names has been modified
 // during this execution
 func (ctx *ExecuteContext) IsModified(names ...string) bool {
+	ctx.modifiedMu.Lock()
+	defer ctx.modifiedMu.Unlock()
 	for _, name := range names {
 		if modified, _ := ctx.modified[name]; modified {
 			return true
@@ -30,13 +78,48 @@ func (ctx *ExecuteContext) IsModified(names ...string) bool {
 	return false
 }
 
+// ComparesContent returns true if files are compared using a checksum of their
+// contents, instead of their modified time, for a resource with staleCheck.
+// The default from meta is used when staleCheck is not set.
+func (ctx *ExecuteContext) ComparesContent(staleCheck string) bool {
+	if staleCheck == "" {
+		staleCheck = ctx.StaleCheck
+	}
+	return staleCheck == "content"
+}
+
 // SetModified sets the task name as modified
 func (ctx *ExecuteContext) SetModified(name string) {
+	ctx.modifiedMu.Lock()
+	defer ctx.modifiedMu.Unlock()
 	ctx.modified[name] = true
 }
 
-// GetAuthConfig returns the auth configuration for the repo
+// Limit returns the maximum number of tasks of resourceType which may run
+// concurrently
+func (ctx *ExecuteContext) Limit(resourceType string) int {
+	if limit := ctx.Limits[resourceType]; limit > 1 {
+		return limit
+	}
+	return 1
+}
+
+// GetAuthConfig returns the auth configuration for the repo. If a credential
+// helper is configured for the registry the helper is used to get the
+// credentials, otherwise the static auth config is used.
 func (ctx *ExecuteContext) GetAuthConfig(repo string) docker.AuthConfiguration {
+	if helper := ctx.credHelpers.helper(repo); helper != "" {
+		auth, err := getCredentialsFromHelper(helper, repo)
+		if err == nil {
+			return auth
+		}
+		logging.Log.Warnf("Failed to get credentials for %q: %s", repo, err)
+	}
+
+	if ctx.authConfigs == nil {
+		logging.Log.Warnf("Missing auth config for %q", repo)
+		return docker.AuthConfiguration{}
+	}
 	auth, ok := ctx.authConfigs.Configs[repo]
 	if !ok {
 		logging.Log.Warnf("Missing auth config for %q", repo)
@@ -44,6 +127,22 @@ func (ctx *ExecuteContext) GetAuthConfig(repo string) docker.AuthConfiguration {
 	return auth
 }
 
+// GetRegistryAuth returns the auth configuration from a registry resource. The
+// credential helper of the registry is run each time the credentials are
+// required.
+func (ctx *ExecuteContext) GetRegistryAuth(
+	registry *config.RegistryConfig,
+) (docker.AuthConfiguration, error) {
+	if registry.CredentialHelper != "" {
+		return getCredentialsFromHelper(registry.CredentialHelper, registry.Server)
+	}
+	return docker.AuthConfiguration{
+		Username:      registry.Username,
+		Password:      registry.Password,
+		ServerAddress: registry.Server,
+	}, nil
+}
+
 // NewExecuteContext craetes a new empty ExecuteContext
 func NewExecuteContext(
 	config *config.Config,
@@ -56,6 +155,10 @@ func NewExecuteContext(
 	if err != nil {
 		logging.Log.Warnf("Failed to load auth config: %s", err)
 	}
+	credHelpers, err := loadCredentialHelpers(dockerConfigPath())
+	if err != nil {
+		logging.Log.Warnf("Failed to load credential helpers: %s", err)
+	}
 
 	return &ExecuteContext{
 		modified:    make(map[string]bool),
@@ -63,7 +166,9 @@ func NewExecuteContext(
 		WorkingDir:  config.WorkingDir,
 		Client:      client,
 		authConfigs: authConfigs,
+		credHelpers: credHelpers,
 		Env:         execEnv,
 		Quiet:       quiet,
+		TempDir:     os.TempDir(),
 	}
 }
@@ -0,0 +1,20 @@
+package download
+
+import (
+	"fmt"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/iface"
+)
+
+// GetTask returns a new task for the action
+func GetTask(name, action string, conf *config.DownloadConfig) (iface.Task, error) {
+	switch action {
+	case "", "fetch":
+		return NewFetchTask(name, conf), nil
+	case "remove", "rm":
+		return NewRemoveTask(name, conf), nil
+	default:
+		return nil, fmt.Errorf("Invalid download action %q for task %q", action, name)
+	}
+}
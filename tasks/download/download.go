@@ -0,0 +1,270 @@
+package download
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+)
+
+// FetchTask is a task which downloads a file to a host path
+type FetchTask struct {
+	name   string
+	config *config.DownloadConfig
+}
+
+// NewFetchTask creates a new FetchTask object
+func NewFetchTask(name string, conf *config.DownloadConfig) *FetchTask {
+	return &FetchTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *FetchTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "fetch")
+}
+
+func (t *FetchTask) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *FetchTask) Repr() string {
+	return fmt.Sprintf("[download:fetch %s] %s", t.name, t.config.Dest)
+}
+
+// Run downloads the file if it doesn't exist, or if the checksum of the
+// existing file doesn't match. When sha256 is not set, and the existing file
+// was downloaded with an ETag or Last-Modified header, a conditional request is
+// made, and the file is only downloaded again if the server reports that it
+// was modified.
+func (t *FetchTask) Run(ctx *context.ExecuteContext) error {
+	dest := destPath(t.config, ctx.WorkingDir)
+	fresh, err := t.isFresh(dest)
+	if err != nil {
+		return err
+	}
+	cachePath := validatorsPath(ctx.WorkingDir, t.config.URL, dest)
+	saved := validators{}
+	if fresh && t.config.SHA256 == "" {
+		saved = loadValidators(cachePath)
+	}
+	if fresh && saved.isZero() {
+		t.logger().Debug("is fresh")
+		return nil
+	}
+
+	modified, err := t.download(ctx, dest, cachePath, saved)
+	switch {
+	case err != nil && !saved.isZero():
+		t.logger().Warnf("Failed to check if the file was modified, using the existing file: %s", err)
+		return nil
+	case err != nil:
+		return err
+	case !modified:
+		t.logger().Debug("is fresh, not modified")
+		return nil
+	}
+	ctx.SetModified(t.name)
+	t.logger().Info("Downloaded")
+	return nil
+}
+
+func (t *FetchTask) isFresh(dest string) (bool, error) {
+	if _, err := os.Stat(dest); err != nil {
+		return false, nil
+	}
+	if t.config.SHA256 == "" {
+		return true, nil
+	}
+	checksum, err := fileChecksum(dest)
+	if err != nil {
+		return false, err
+	}
+	if checksum != t.config.SHA256 {
+		t.logger().Debug("checksum mismatch")
+		return false, nil
+	}
+	return true, nil
+}
+
+// download the file to a temporary file, verify the checksum, and then move it
+// to dest, so that dest is never left with a partial or invalid file. Returns
+// false if the server reports that the file is not modified since the download
+// which saved the validators.
+func (t *FetchTask) download(
+	ctx *context.ExecuteContext,
+	dest string,
+	cachePath string,
+	saved validators,
+) (bool, error) {
+	tmpFile, err := ioutil.TempFile(ctx.TempDir, "dobi-download-")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmpFile.Name())
+
+	result, err := t.fetchWithRetries(tmpFile, saved)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	switch {
+	case err != nil:
+		return false, fmt.Errorf("Failed to download %q: %s", t.config.URL, err)
+	case result.notModified:
+		return false, nil
+	}
+
+	if t.config.SHA256 != "" && result.checksum != t.config.SHA256 {
+		return false, fmt.Errorf("Checksum of %q is %s, expected %s",
+			t.config.URL, result.checksum, t.config.SHA256)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return false, err
+	}
+	if err := moveFile(tmpFile.Name(), dest); err != nil {
+		return false, err
+	}
+
+	result.validators.URL = t.config.URL
+	result.validators.Dest = dest
+	if err := saveValidators(cachePath, result.validators); err != nil {
+		t.logger().Warnf("Failed to save the ETag and Last-Modified headers: %s", err)
+	}
+	return true, nil
+}
+
+// fetchResult is the result of a request for the file
+type fetchResult struct {
+	checksum    string
+	notModified bool
+	validators  validators
+}
+
+// fetchWithRetries downloads the file to out. Failed downloads are retried,
+// with a delay which doubles after each retry.
+func (t *FetchTask) fetchWithRetries(out *os.File, saved validators) (fetchResult, error) {
+	client := &http.Client{Timeout: t.config.Timeout.Duration()}
+	delay := t.config.RetryDelay.Duration()
+
+	for attempt := 0; ; attempt++ {
+		result, err := fetch(client, t.config.URL, saved, out)
+		if err == nil || attempt >= t.config.Retries {
+			return result, err
+		}
+		t.logger().Warnf("Download failed, retrying in %s: %s", delay, err)
+		time.Sleep(delay)
+		delay *= 2
+
+		if err := truncate(out); err != nil {
+			return fetchResult{}, err
+		}
+	}
+}
+
+// truncate removes any partial download from a previous attempt
+func truncate(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := file.Seek(0, 0)
+	return err
+}
+
+// fetch writes the body of the response from url to out, and returns the hex
+// encoded sha256 checksum of the body, and the validators of the response. If
+// saved is not empty the request is conditional.
+func fetch(client *http.Client, url string, saved validators, out io.Writer) (fetchResult, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return fetchResult{}, err
+	}
+	saved.setHeaders(req.Header)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fetchResult{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && !saved.isZero():
+		return fetchResult{notModified: true}, nil
+	case resp.StatusCode != http.StatusOK:
+		return fetchResult{}, fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, hash), resp.Body); err != nil {
+		return fetchResult{}, err
+	}
+	return fetchResult{
+		checksum:   hex.EncodeToString(hash.Sum(nil)),
+		validators: validatorsFromHeader(resp.Header),
+	}, nil
+}
+
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// moveFile renames source to dest. If the rename fails, because the temp
+// directory is on a different device, the file is copied instead.
+func moveFile(source, dest string) error {
+	if err := os.Rename(source, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func destPath(conf *config.DownloadConfig, workingDir string) string {
+	if filepath.IsAbs(conf.Dest) {
+		return conf.Dest
+	}
+	return filepath.Join(workingDir, conf.Dest)
+}
+
+// Dependencies returns the list of dependencies
+func (t *FetchTask) Dependencies() []string {
+	return t.config.Dependencies()
+}
+
+// Stop the task
+func (t *FetchTask) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
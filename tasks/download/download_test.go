@@ -0,0 +1,209 @@
+package download
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	content  = "test"
+	checksum = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+)
+
+type FetchTaskSuite struct {
+	suite.Suite
+	path     string
+	server   *httptest.Server
+	requests int
+	etag     string
+	ctx      *context.ExecuteContext
+}
+
+func TestFetchTaskSuite(t *testing.T) {
+	suite.Run(t, new(FetchTaskSuite))
+}
+
+func (s *FetchTaskSuite) SetupTest() {
+	var err error
+	s.path, err = ioutil.TempDir("", "download-task-test")
+	s.Require().Nil(err)
+
+	s.requests = 0
+	s.etag = `"v1"`
+	s.server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			s.requests++
+			switch {
+			case req.URL.Path == "/missing":
+				http.NotFound(w, req)
+				return
+			case req.URL.Path == "/flaky" && s.requests < 3:
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			case req.URL.Path == "/etag" && req.Header.Get("If-None-Match") == s.etag:
+				w.WriteHeader(http.StatusNotModified)
+				return
+			case req.URL.Path == "/etag":
+				w.Header().Set("ETag", s.etag)
+				fmt.Fprint(w, content+s.etag)
+				return
+			}
+			fmt.Fprint(w, content)
+		}))
+
+	s.ctx = s.newContext()
+}
+
+func (s *FetchTaskSuite) TearDownTest() {
+	s.server.Close()
+	s.Nil(os.RemoveAll(s.path))
+}
+
+func (s *FetchTaskSuite) newTask(path, sha string) *FetchTask {
+	return NewFetchTask("tool", &config.DownloadConfig{
+		URL:    s.server.URL + path,
+		Dest:   filepath.Join("bin", "tool"),
+		SHA256: sha,
+	})
+}
+
+func (s *FetchTaskSuite) readDest() string {
+	raw, err := ioutil.ReadFile(filepath.Join(s.path, "bin", "tool"))
+	s.Require().Nil(err)
+	return string(raw)
+}
+
+func (s *FetchTaskSuite) TestRunDownloadsMissingFile() {
+	s.Nil(s.newTask("/tool", checksum).Run(s.ctx))
+	s.Equal(content, s.readDest())
+	s.True(s.ctx.IsModified("tool"))
+}
+
+func (s *FetchTaskSuite) TestRunSkipsExistingFile() {
+	task := s.newTask("/tool", "")
+	s.Nil(task.Run(s.ctx))
+	s.Nil(task.Run(s.ctx))
+	s.Equal(1, s.requests)
+}
+
+func (s *FetchTaskSuite) TestRunDownloadsOnChecksumMismatch() {
+	dest := filepath.Join(s.path, "bin", "tool")
+	s.Require().Nil(os.MkdirAll(filepath.Dir(dest), 0755))
+	s.Require().Nil(ioutil.WriteFile(dest, []byte("stale"), 0644))
+
+	s.Nil(s.newTask("/tool", checksum).Run(s.ctx))
+	s.Equal(content, s.readDest())
+	s.Equal(1, s.requests)
+}
+
+func (s *FetchTaskSuite) TestRunFailsOnChecksumMismatch() {
+	sha := "0000000000000000000000000000000000000000000000000000000000000000"
+	err := s.newTask("/tool", sha).Run(s.ctx)
+	s.Error(err)
+	s.Contains(err.Error(), "expected "+sha)
+
+	_, err = os.Stat(filepath.Join(s.path, "bin", "tool"))
+	s.True(os.IsNotExist(err))
+}
+
+func (s *FetchTaskSuite) TestRunFailsOnBadStatus() {
+	err := s.newTask("/missing", "").Run(s.ctx)
+	s.Error(err)
+	s.Contains(err.Error(), "404 Not Found")
+	s.False(s.ctx.IsModified("tool"))
+}
+
+func (s *FetchTaskSuite) TestRunRetriesFailedDownload() {
+	task := s.newTask("/flaky", checksum)
+	task.config.Retries = 2
+
+	s.Nil(task.Run(s.ctx))
+	s.Equal(content, s.readDest())
+	s.Equal(3, s.requests)
+}
+
+func (s *FetchTaskSuite) TestRunFailsAfterRetries() {
+	task := s.newTask("/flaky", checksum)
+	task.config.Retries = 1
+
+	err := task.Run(s.ctx)
+	s.Error(err)
+	s.Contains(err.Error(), "503 Service Unavailable")
+	s.Equal(2, s.requests)
+
+	_, err = os.Stat(filepath.Join(s.path, "bin", "tool"))
+	s.True(os.IsNotExist(err))
+}
+
+func (s *FetchTaskSuite) newContext() *context.ExecuteContext {
+	ctx := context.NewExecuteContext(
+		&config.Config{WorkingDir: s.path}, nil, nil, false)
+	ctx.TempDir = s.path
+	return ctx
+}
+
+func (s *FetchTaskSuite) TestRunSkipsFileWhichIsNotModified() {
+	task := s.newTask("/etag", "")
+	s.Nil(task.Run(s.ctx))
+	s.Equal(content+`"v1"`, s.readDest())
+
+	ctx := s.newContext()
+	s.Nil(task.Run(ctx))
+	s.Equal(content+`"v1"`, s.readDest())
+	s.Equal(2, s.requests)
+	s.False(ctx.IsModified("tool"))
+}
+
+func (s *FetchTaskSuite) TestRunDownloadsFileWhichIsModified() {
+	task := s.newTask("/etag", "")
+	s.Nil(task.Run(s.ctx))
+
+	s.etag = `"v2"`
+	ctx := s.newContext()
+	s.Nil(task.Run(ctx))
+	s.Equal(content+`"v2"`, s.readDest())
+	s.Equal(2, s.requests)
+	s.True(ctx.IsModified("tool"))
+}
+
+func (s *FetchTaskSuite) TestRunUsesExistingFileWhenCheckFails() {
+	task := s.newTask("/etag", "")
+	s.Nil(task.Run(s.ctx))
+
+	s.server.Close()
+	ctx := s.newContext()
+	s.Nil(task.Run(ctx))
+	s.Equal(content+`"v1"`, s.readDest())
+	s.False(ctx.IsModified("tool"))
+}
+
+func (s *FetchTaskSuite) TestRunDownloadsMissingFileWithSavedValidators() {
+	task := s.newTask("/etag", "")
+	s.Nil(task.Run(s.ctx))
+	s.Require().Nil(os.Remove(filepath.Join(s.path, "bin", "tool")))
+
+	s.Nil(task.Run(s.newContext()))
+	s.Equal(content+`"v1"`, s.readDest())
+	s.Equal(2, s.requests)
+}
+
+func (s *FetchTaskSuite) TestRemoveTaskRemovesValidators() {
+	task := s.newTask("/etag", "")
+	s.Nil(task.Run(s.ctx))
+	dest := filepath.Join(s.path, "bin", "tool")
+	path := validatorsPath(s.path, task.config.URL, dest)
+	s.Equal(`"v1"`, loadValidators(path).ETag)
+
+	s.Nil(NewRemoveTask("tool", task.config).Run(s.ctx))
+	_, err := os.Stat(path)
+	s.True(os.IsNotExist(err))
+}
@@ -0,0 +1,62 @@
+package download
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+)
+
+// RemoveTask is a task which removes a downloaded file
+type RemoveTask struct {
+	name   string
+	config *config.DownloadConfig
+}
+
+// NewRemoveTask creates a new RemoveTask object
+func NewRemoveTask(name string, conf *config.DownloadConfig) *RemoveTask {
+	return &RemoveTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *RemoveTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "rm")
+}
+
+func (t *RemoveTask) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *RemoveTask) Repr() string {
+	return fmt.Sprintf("[download:rm %s] %s", t.name, t.config.Dest)
+}
+
+// Run removes the downloaded file, and the validators saved by the download
+func (t *RemoveTask) Run(ctx *context.ExecuteContext) error {
+	dest := destPath(t.config, ctx.WorkingDir)
+	err := os.Remove(dest)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := removeValidators(validatorsPath(ctx.WorkingDir, t.config.URL, dest)); err != nil {
+		return err
+	}
+	t.logger().Info("Removed")
+	return nil
+}
+
+// Dependencies returns the list of dependencies. The remove task doesn't depend
+// on anything.
+func (t *RemoveTask) Dependencies() []string {
+	return []string{}
+}
+
+// Stop the task
+func (t *RemoveTask) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
@@ -0,0 +1,96 @@
+package download
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/history"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// validators are the ETag and Last-Modified headers from the last download of
+// a url, which are sent with the next request for the url so that the server
+// can report that the file is not modified
+type validators struct {
+	URL          string `yaml:"url"`
+	Dest         string `yaml:"dest"`
+	ETag         string `yaml:"etag,omitempty"`
+	LastModified string `yaml:"last-modified,omitempty"`
+}
+
+func (v validators) isZero() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+// setHeaders sets the headers of a conditional request
+func (v validators) setHeaders(header http.Header) {
+	if v.ETag != "" {
+		header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		header.Set("If-Modified-Since", v.LastModified)
+	}
+}
+
+func validatorsFromHeader(header http.Header) validators {
+	return validators{
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+	}
+}
+
+// validatorsPath returns the path to the file in the state directory which
+// stores the validators of url downloaded to dest
+func validatorsPath(workingDir, url, dest string) string {
+	hash := sha256.Sum256([]byte(url + "\n" + dest))
+	return filepath.Join(
+		history.StateDir(workingDir), "download", hex.EncodeToString(hash[:])+".yml")
+}
+
+// loadValidators reads the validators from path. A missing or invalid file
+// returns empty validators, so the file is downloaded again.
+func loadValidators(path string) validators {
+	saved := validators{}
+	raw, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return saved
+	case err != nil:
+		logging.Log.Warnf("Failed to read %q: %s", path, err)
+		return saved
+	}
+	if err := yaml.Unmarshal(raw, &saved); err != nil {
+		logging.Log.Warnf("Failed to parse %q: %s", path, err)
+		return validators{}
+	}
+	return saved
+}
+
+// saveValidators writes the validators to path. If the server did not send
+// any validators the file is removed.
+func saveValidators(path string, saved validators) error {
+	if saved.isZero() {
+		return removeValidators(path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	raw, err := yaml.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, raw, 0644)
+}
+
+func removeValidators(path string) error {
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
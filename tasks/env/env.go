@@ -0,0 +1,90 @@
+package env
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+)
+
+// Task sets the variables of an env resource in the execution environment
+type Task struct {
+	name   string
+	config *config.EnvConfig
+}
+
+// NewTask creates a new Task object
+func NewTask(name string, conf *config.EnvConfig) *Task {
+	return &Task{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *Task) Name() common.TaskName {
+	return common.NewTaskName(t.name, "set")
+}
+
+func (t *Task) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *Task) Repr() string {
+	return fmt.Sprintf("[env:set %s]", t.name)
+}
+
+// Run loads the variables and checks that the required variables are set
+func (t *Task) Run(ctx *context.ExecuteContext) error {
+	if err := Load(ctx.WorkingDir, t.config, ctx.Env); err != nil {
+		return err
+	}
+	t.logger().Debug("Variables set")
+	return nil
+}
+
+// Load reads the variables from the files of the env resource, and sets them
+// with the default values in the execution environment. An error is returned
+// if a file can not be read, or if a required variable is not set.
+func Load(workingDir string, conf *config.EnvConfig, env *execenv.ExecEnv) error {
+	values := conf.Defaults()
+	for _, filename := range conf.Files {
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(workingDir, filename)
+		}
+		variables, err := execenv.LoadEnvFile(filename)
+		if err != nil {
+			return err
+		}
+		for _, variable := range variables {
+			parts := strings.SplitN(variable, "=", 2)
+			values[parts[0]] = parts[1]
+		}
+	}
+	env.SetEnv(values)
+
+	missing := []string{}
+	for _, name := range conf.Required() {
+		if _, err := env.Resolve("{env." + name + "}"); err != nil {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("Required variables are not set: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Dependencies returns the list of dependencies
+func (t *Task) Dependencies() []string {
+	return t.config.Dependencies()
+}
+
+// Stop the task
+func (t *Task) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
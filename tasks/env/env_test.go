@@ -0,0 +1,56 @@
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "env-resource-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	content := "DOBI_TEST_STAGE=prod\nDOBI_TEST_TOKEN=secret\n"
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "prod.env"), []byte(content), 0644))
+
+	conf := &config.EnvConfig{
+		Files:     []string{"prod.env"},
+		Variables: []string{"DOBI_TEST_TOKEN", "DOBI_TEST_STAGE=dev", "DOBI_TEST_REGION=us-east-1"},
+	}
+	env := execenv.NewExecEnv("exec", "project", dir)
+	assert.Nil(t, Load(dir, conf, env))
+
+	for variable, expected := range map[string]string{
+		"DOBI_TEST_STAGE":  "prod",
+		"DOBI_TEST_TOKEN":  "secret",
+		"DOBI_TEST_REGION": "us-east-1",
+	} {
+		value, err := env.Resolve("{env." + variable + "}")
+		assert.Nil(t, err)
+		assert.Equal(t, expected, value)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	conf := &config.EnvConfig{Files: []string{"missing.env"}}
+	env := execenv.NewExecEnv("exec", "project", ".")
+	err := Load(".", conf, env)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "missing.env")
+	}
+}
+
+func TestLoadMissingRequiredVariables(t *testing.T) {
+	conf := &config.EnvConfig{
+		Variables: []string{"DOBI_TEST_TOKEN", "DOBI_TEST_USER", "DOBI_TEST_STAGE=dev"},
+	}
+	env := execenv.NewExecEnv("exec", "project", ".")
+	err := Load(".", conf, env)
+	assert.EqualError(t, err,
+		"Required variables are not set: DOBI_TEST_TOKEN, DOBI_TEST_USER")
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Types of events
+const (
+	TypeStart    = "start"
+	TypeOutput   = "output"
+	TypeProgress = "progress"
+	TypeComplete = "complete"
+)
+
+// Status of a complete event
+const (
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Event is a structured record of the execution of a task
+type Event struct {
+	Time time.Time `json:"time"`
+	Type string    `json:"type"`
+	// Task is empty for a progress event
+	Task     string `json:"task,omitempty"`
+	Resource string `json:"resource"`
+	// Stream is stdout or stderr for an output event
+	Stream string `json:"stream,omitempty"`
+	// Data is the output of an output event, or the message of a progress
+	// event
+	Data     string  `json:"data,omitempty"`
+	Status   string  `json:"status,omitempty"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration,omitempty"`
+	// ImageID is the id of the image of an image task
+	ImageID   string   `json:"image-id,omitempty"`
+	Artifacts []string `json:"artifacts,omitempty"`
+}
+
+// Writer writes events as json, one event per line. Writer is safe to use
+// from concurrent tasks.
+type Writer struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	now     func() time.Time
+}
+
+// NewWriter returns a new Writer which writes events to out
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{encoder: json.NewEncoder(out), now: time.Now}
+}
+
+// Emit writes the event. The time of the event is set if it is empty.
+func (w *Writer) Emit(event Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if event.Time.IsZero() {
+		event.Time = w.now()
+	}
+	return w.encoder.Encode(event)
+}
+
+// Output returns an io.Writer which emits an output event for each write to
+// stream by the task
+func (w *Writer) Output(task, resource, stream string) io.Writer {
+	return &outputWriter{events: w, task: task, resource: resource, stream: stream}
+}
+
+type outputWriter struct {
+	events   *Writer
+	task     string
+	resource string
+	stream   string
+}
+
+func (o *outputWriter) Write(p []byte) (int, error) {
+	err := o.events.Emit(Event{
+		Type:     TypeOutput,
+		Task:     o.task,
+		Resource: o.resource,
+		Stream:   o.stream,
+		Data:     string(p),
+	})
+	return len(p), err
+}
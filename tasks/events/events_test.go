@@ -0,0 +1,44 @@
+package events
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestWriter(out *bytes.Buffer) *Writer {
+	w := NewWriter(out)
+	w.now = func() time.Time { return time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC) }
+	return w
+}
+
+func TestWriterEmit(t *testing.T) {
+	out := new(bytes.Buffer)
+	w := newTestWriter(out)
+
+	assert.Nil(t, w.Emit(Event{
+		Type:      TypeComplete,
+		Task:      "test:run",
+		Resource:  "test",
+		Status:    StatusSuccess,
+		Duration:  1.5,
+		Artifacts: []string{"dist/"},
+	}))
+	assert.Equal(t, `{"time":"2017-01-01T00:00:00Z","type":"complete",`+
+		`"task":"test:run","resource":"test","status":"success",`+
+		`"duration":1.5,"artifacts":["dist/"]}`+"\n", out.String())
+}
+
+func TestWriterOutput(t *testing.T) {
+	out := new(bytes.Buffer)
+	w := newTestWriter(out)
+
+	stderr := w.Output("test:run", "test", "stderr")
+	fmt.Fprint(stderr, "failed\n")
+	assert.Equal(t, `{"time":"2017-01-01T00:00:00Z","type":"output",`+
+		`"task":"test:run","resource":"test","stream":"stderr","data":"failed\n"}`+"\n",
+		out.String())
+}
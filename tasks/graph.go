@@ -0,0 +1,165 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dnephin/dobi/config"
+)
+
+// GraphFormats are the formats supported by Graph
+var GraphFormats = []string{"dot", "json", "mermaid"}
+
+// GraphNode is a task in the dependency graph
+type GraphNode struct {
+	Name     string            `json:"name"`
+	Resource string            `json:"resource"`
+	Action   string            `json:"action"`
+	Depends  []string          `json:"depends"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	// Stale is nil when the task can not check if it is stale
+	Stale *bool `json:"stale"`
+}
+
+// Graph writes the dependency graph of the tasks to out in format, which is
+// one of GraphFormats. The graph includes the implicit dependencies of each
+// resource, and each task which can check if it is stale is annotated as stale
+// or fresh. No tasks are run.
+func Graph(options RunOptions, format string, out io.Writer) error {
+	render, ok := map[string]func(io.Writer, []GraphNode) error{
+		"dot":     writeDot,
+		"json":    writeGraphJSON,
+		"mermaid": writeMermaid,
+	}[format]
+	if !ok {
+		return fmt.Errorf("Invalid format %q, must be one of: %s",
+			format, strings.Join(GraphFormats, ", "))
+	}
+
+	options.Tasks = getTaskNames(options)
+	if len(options.Tasks) == 0 {
+		options.Tasks = options.Config.Sorted()
+	}
+	execEnv, err := newExecEnv(options.Config, options.EnvFiles)
+	if err != nil {
+		return err
+	}
+	tasks, err := collectTasks(options, execEnv)
+	if err != nil {
+		return err
+	}
+	ctx, err := newExecuteContext(options, execEnv)
+	if err != nil {
+		return err
+	}
+	return render(out, graphNodes(options.Config, newPlan(ctx, tasks)))
+}
+
+func graphNodes(conf *config.Config, plan Plan) []GraphNode {
+	nodes := []GraphNode{}
+	for _, step := range plan.Tasks {
+		depends := step.Depends
+		if depends == nil {
+			depends = []string{}
+		}
+		nodes = append(nodes, GraphNode{
+			Name:     step.Name,
+			Resource: step.Resource,
+			Action:   step.Action,
+			Depends:  depends,
+			Labels:   conf.OptionsFor(step.Resource).Labels,
+			Stale:    step.Stale,
+		})
+	}
+	return nodes
+}
+
+func staleStatus(node GraphNode) string {
+	switch {
+	case node.Stale == nil:
+		return ""
+	case *node.Stale:
+		return "stale"
+	default:
+		return "fresh"
+	}
+}
+
+func writeDot(out io.Writer, nodes []GraphNode) error {
+	fmt.Fprintln(out, "digraph dobi {")
+	for _, node := range nodes {
+		attrs := []string{}
+		switch staleStatus(node) {
+		case "stale":
+			attrs = append(attrs, `style=filled`, `fillcolor="#f4a582"`)
+		case "fresh":
+			attrs = append(attrs, `style=filled`, `fillcolor="#b8e186"`)
+		}
+		attrs = append(attrs, fmt.Sprintf("label=%q", nodeLabel(node, "\n")))
+		fmt.Fprintf(out, "  %q [%s];\n", node.Name, strings.Join(attrs, ", "))
+	}
+	for _, node := range nodes {
+		for _, dep := range node.Depends {
+			fmt.Fprintf(out, "  %q -> %q;\n", node.Name, dep)
+		}
+	}
+	fmt.Fprintln(out, "}")
+	return nil
+}
+
+func writeMermaid(out io.Writer, nodes []GraphNode) error {
+	ids := make(map[string]string)
+	for i, node := range nodes {
+		ids[node.Name] = fmt.Sprintf("n%d", i)
+	}
+	fmt.Fprintln(out, "graph TD")
+	for _, node := range nodes {
+		label := strings.Replace(nodeLabel(node, "<br>"), `"`, "#quot;", -1)
+		fmt.Fprintf(out, "  %s[\"%s\"]\n", ids[node.Name], label)
+		if status := staleStatus(node); status != "" {
+			fmt.Fprintf(out, "  class %s %s\n", ids[node.Name], status)
+		}
+	}
+	for _, node := range nodes {
+		for _, dep := range node.Depends {
+			fmt.Fprintf(out, "  %s --> %s\n", ids[node.Name], ids[dep])
+		}
+	}
+	fmt.Fprintln(out, "  classDef stale fill:#f4a582")
+	fmt.Fprintln(out, "  classDef fresh fill:#b8e186")
+	return nil
+}
+
+func writeGraphJSON(out io.Writer, nodes []GraphNode) error {
+	raw, err := json.MarshalIndent(nodes, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(raw))
+	return err
+}
+
+// nodeLabel returns the name of the task, followed by its labels and stale
+// status on separate lines
+func nodeLabel(node GraphNode, sep string) string {
+	lines := []string{node.Name}
+	if labels := formatGraphLabels(node.Labels); labels != "" {
+		lines = append(lines, labels)
+	}
+	if status := staleStatus(node); status != "" {
+		lines = append(lines, "("+status+")")
+	}
+	return strings.Join(lines, sep)
+}
+
+func formatGraphLabels(labels map[string]string) string {
+	items := []string{}
+	for key, value := range labels {
+		items = append(items, key+"="+value)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,65 @@
+package tasks
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func graphFixture() []GraphNode {
+	stale := true
+	return []GraphNode{
+		{Name: "base:build", Resource: "base", Action: "build", Depends: []string{},
+			Stale: &stale},
+		{Name: "test:run", Resource: "test", Action: "run",
+			Depends: []string{"base:build"}, Labels: map[string]string{"team": "infra"}},
+	}
+}
+
+func TestGraphNodes(t *testing.T) {
+	conf := config.NewConfig()
+	conf.Options["test"] = &config.ResourceOptions{Labels: map[string]string{"team": "infra"}}
+	fresh := false
+	plan := Plan{Tasks: []PlanTask{
+		{Name: "base:build", Resource: "base", Action: "build", Stale: &fresh},
+		{Name: "test:run", Resource: "test", Action: "run", Depends: []string{"base:build"}},
+	}}
+
+	assert.Equal(t, []GraphNode{
+		{Name: "base:build", Resource: "base", Action: "build", Depends: []string{},
+			Stale: &fresh},
+		{Name: "test:run", Resource: "test", Action: "run",
+			Depends: []string{"base:build"}, Labels: map[string]string{"team": "infra"}},
+	}, graphNodes(conf, plan))
+}
+
+func TestWriteDot(t *testing.T) {
+	out := new(bytes.Buffer)
+	assert.Nil(t, writeDot(out, graphFixture()))
+	assert.Equal(t, `digraph dobi {
+  "base:build" [style=filled, fillcolor="#f4a582", label="base:build\n(stale)"];
+  "test:run" [label="test:run\nteam=infra"];
+  "test:run" -> "base:build";
+}
+`, out.String())
+}
+
+func TestWriteMermaid(t *testing.T) {
+	out := new(bytes.Buffer)
+	assert.Nil(t, writeMermaid(out, graphFixture()))
+	assert.Equal(t, `graph TD
+  n0["base:build<br>(stale)"]
+  class n0 stale
+  n1["test:run<br>team=infra"]
+  n1 --> n0
+  classDef stale fill:#f4a582
+  classDef fresh fill:#b8e186
+`, out.String())
+}
+
+func TestGraphInvalidFormat(t *testing.T) {
+	err := Graph(RunOptions{Config: config.NewConfig()}, "svg", new(bytes.Buffer))
+	assert.EqualError(t, err, `Invalid format "svg", must be one of: dot, json, mermaid`)
+}
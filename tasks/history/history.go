@@ -0,0 +1,135 @@
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	stateDir  = ".dobi"
+	stateFile = "state.yml"
+)
+
+// Record is the state of the last successful run of a task
+type Record struct {
+	InputsHash  string    `yaml:"inputs-hash"`
+	LastSuccess time.Time `yaml:"last-success"`
+	// Value is a value saved by the task, like the output of a job with capture
+	Value string `yaml:"value,omitempty"`
+}
+
+// Store is a collection of Records which is persisted to a state file in the
+// project directory
+type Store struct {
+	path    string
+	records map[string]Record
+	mu      sync.Mutex
+}
+
+// Load reads the Store for the project in workingDir. If the state file does
+// not exist an empty Store is returned.
+func Load(workingDir string) (*Store, error) {
+	store := &Store{
+		path:    StatePath(workingDir),
+		records: make(map[string]Record),
+	}
+
+	raw, err := ioutil.ReadFile(store.path)
+	switch {
+	case os.IsNotExist(err):
+		return store, nil
+	case err != nil:
+		return nil, err
+	}
+	return store, yaml.Unmarshal(raw, &store.records)
+}
+
+// Get returns the Record for the task name
+func (s *Store) Get(name string) (Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[name]
+	return record, ok
+}
+
+// IsUnchanged returns true if the task name has succeeded before with the same
+// inputs hash
+func (s *Store) IsUnchanged(name, hash string) bool {
+	record, ok := s.Get(name)
+	return ok && record.InputsHash == hash
+}
+
+// Success records a successful run of the task name, and saves the Store
+func (s *Store) Success(name, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[name] = Record{InputsHash: hash, LastSuccess: time.Now()}
+	return s.save()
+}
+
+// SuccessWithValue records a successful run of the task name which saved
+// value, and saves the Store
+func (s *Store) SuccessWithValue(name, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[name] = Record{LastSuccess: time.Now(), Value: value}
+	return s.save()
+}
+
+// RemoveResource removes the Records of the tasks of the resource, and saves
+// the Store
+func (s *Store) RemoveResource(resource string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := false
+	for name := range s.records {
+		if strings.HasPrefix(name, resource+":") {
+			delete(s.records, name)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+	return s.save()
+}
+
+func (s *Store) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	raw, err := yaml.Marshal(s.records)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, raw, 0644)
+}
+
+// Remove removes the state file for the project in workingDir
+func Remove(workingDir string) error {
+	err := os.Remove(StatePath(workingDir))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// StatePath returns the path to the state file for the project in workingDir
+func StatePath(workingDir string) string {
+	return filepath.Join(StateDir(workingDir), stateFile)
+}
+
+// StateDir returns the path to the directory which holds the state of the
+// project in workingDir
+func StateDir(workingDir string) string {
+	return filepath.Join(workingDir, stateDir)
+}
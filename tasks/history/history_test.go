@@ -0,0 +1,77 @@
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMissingStateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := Load(dir)
+	assert.Nil(t, err)
+	assert.False(t, store.IsUnchanged("job:run", "abcd"))
+}
+
+func TestSuccessPersistsRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := Load(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Success("job:run", "abcd"))
+
+	store, err = Load(dir)
+	assert.Nil(t, err)
+	assert.True(t, store.IsUnchanged("job:run", "abcd"))
+	assert.False(t, store.IsUnchanged("job:run", "other"))
+
+	record, ok := store.Get("job:run")
+	assert.True(t, ok)
+	assert.False(t, record.LastSuccess.IsZero())
+
+	assert.Nil(t, Remove(dir))
+	assert.Nil(t, Remove(dir))
+	store, err = Load(dir)
+	assert.Nil(t, err)
+	assert.False(t, store.IsUnchanged("job:run", "abcd"))
+}
+
+func TestSuccessWithValuePersistsValue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := Load(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, store.SuccessWithValue("capture/version", "1.2.3"))
+
+	store, err = Load(dir)
+	assert.Nil(t, err)
+	record, ok := store.Get("capture/version")
+	assert.True(t, ok)
+	assert.Equal(t, "1.2.3", record.Value)
+}
+
+func TestRemoveResource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "history-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := Load(dir)
+	assert.Nil(t, err)
+	assert.Nil(t, store.Success("job:run", "abcd"))
+	assert.Nil(t, store.Success("job-two:run", "abcd"))
+	assert.Nil(t, store.RemoveResource("job"))
+
+	store, err = Load(dir)
+	assert.Nil(t, err)
+	assert.False(t, store.IsUnchanged("job:run", "abcd"))
+	assert.True(t, store.IsUnchanged("job-two:run", "abcd"))
+}
@@ -14,3 +14,10 @@ type Task interface {
 	Stop(*context.ExecuteContext) error
 	Dependencies() []string
 }
+
+// StaleTask is implemented by tasks which can check if they are stale without
+// running
+type StaleTask interface {
+	Task
+	IsStale(*context.ExecuteContext) (bool, error)
+}
@@ -1,8 +1,8 @@
 package image
 
 import (
+	"fmt"
 	"io"
-	"os"
 
 	"github.com/dnephin/dobi/tasks/context"
 	"github.com/dnephin/dobi/utils/fs"
@@ -21,13 +21,19 @@ func RunBuild(ctx *context.ExecuteContext, t *Task) error {
 	if err := buildImage(ctx, t); err != nil {
 		return err
 	}
+	// An image for more than one platform is only stored in the build cache
+	if t.config.IsMultiPlatform() {
+		ctx.SetModified(t.name)
+		t.logger().Info("Built")
+		return nil
+	}
 
 	image, err := GetImage(ctx, t.config)
 	if err != nil {
 		return err
 	}
 
-	record := imageModifiedRecord{ImageID: image.ID}
+	record := buildRecord(ctx, t, image)
 	if err := updateImageRecord(recordPath(ctx, t.config), record); err != nil {
 		t.logger().Warnf("Failed to update image record: %s", err)
 	}
@@ -51,7 +57,11 @@ func buildIsStale(ctx *context.ExecuteContext, t *Task) (bool, error) {
 		return true, err
 	}
 
-	mtime, err := fs.LastModified(t.config.Context)
+	if ctx.ComparesContent(t.config.StaleCheck) {
+		return buildIsStaleByContent(ctx, t, image)
+	}
+
+	mtime, err := fs.LastModified(contextPaths(t)...)
 	if err != nil {
 		t.logger().Warnf("Failed to get last modified time of context.")
 		return true, err
@@ -74,11 +84,106 @@ func buildIsStale(ctx *context.ExecuteContext, t *Task) (bool, error) {
 	return false, nil
 }
 
+// buildIsStaleByContent returns true if the image changed, or the checksum of
+// the context changed, since the image was last built
+func buildIsStaleByContent(ctx *context.ExecuteContext, t *Task, image *docker.Image) (bool, error) {
+	record, err := getImageRecord(recordPath(ctx, t.config))
+	if err != nil || record.ContextChecksum == "" {
+		t.logger().Debug("No checksum of the context from a previous build")
+		return true, nil
+	}
+	checksum, err := fs.Checksum(contextPaths(t)...)
+	if err != nil {
+		t.logger().Warnf("Failed to get checksum of context: %s", err)
+		return true, err
+	}
+	if image.ID != record.ImageID || checksum != record.ContextChecksum {
+		t.logger().Debug("Context changed since the last build")
+		return true, nil
+	}
+	return false, nil
+}
+
+// buildRecord returns the image record of a build, which includes the
+// checksum of the context when stale-check is content
+func buildRecord(ctx *context.ExecuteContext, t *Task, image *docker.Image) imageModifiedRecord {
+	record := imageModifiedRecord{ImageID: image.ID}
+	if !ctx.ComparesContent(t.config.StaleCheck) {
+		return record
+	}
+	checksum, err := fs.Checksum(contextPaths(t)...)
+	if err != nil {
+		t.logger().Warnf("Failed to get checksum of context: %s", err)
+	}
+	record.ContextChecksum = checksum
+	return record
+}
+
+// contextPaths returns the build context and the Dockerfile, if the Dockerfile
+// is outside of the context
+func contextPaths(t *Task) []string {
+	paths := []string{t.config.Context}
+	if path := t.config.ExternalDockerfile(); path != "" {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
 func buildImage(ctx *context.ExecuteContext, t *Task) error {
-	if err := Stream(os.Stdout, func(out io.Writer) error {
+	if err := validateBuildPaths(t); err != nil {
+		return err
+	}
+	if t.config.IsDockerfileURL() {
+		remove, err := t.fetchDockerfile()
+		if err != nil {
+			return err
+		}
+		defer remove()
+	}
+
+	buildkit, err := useBuildKit(t)
+	if err != nil {
+		return err
+	}
+	switch buildkit {
+	case true:
+		err = buildImageWithBuildKit(ctx, t)
+	default:
+		err = buildImageWithClient(ctx, t)
+	}
+	if err != nil || t.config.IsMultiPlatform() {
+		return err
+	}
+
+	image, err := GetImage(ctx, t.config)
+	if err != nil {
+		return err
+	}
+	record := buildRecord(ctx, t, image)
+	return updateImageRecord(recordPath(ctx, t.config), record)
+}
+
+func buildImageWithClient(ctx *context.ExecuteContext, t *Task) error {
+	// TODO: set labels when the docker client supports them
+	if len(t.config.Labels) > 0 {
+		return fmt.Errorf(
+			"labels are not supported by the Docker client used by this version "+
+				"of dobi, set buildkit to true or auto to build %q", t.name)
+	}
+	if t.config.ExternalDockerfile() != "" {
+		remove, err := t.copyDockerfile()
+		if err != nil {
+			return err
+		}
+		defer remove()
+	}
+	if len(ctx.Labels) > 0 {
+		t.logger().Warn("meta.labels are only set on images built with BuildKit")
+	}
+	if err := t.stream(ctx, t.output(ctx), func(out io.Writer) error {
 		return ctx.Client.BuildImage(docker.BuildImageOptions{
 			Name:           GetImageName(ctx, t.config),
-			Dockerfile:     t.config.Dockerfile,
+			Dockerfile:     t.dockerfileName(),
 			BuildArgs:      buildArgs(t.config.Args),
 			Pull:           t.config.PullBaseImageOnBuild,
 			RmTmpContainer: true,
@@ -90,13 +195,7 @@ func buildImage(ctx *context.ExecuteContext, t *Task) error {
 	}); err != nil {
 		return err
 	}
-
-	image, err := GetImage(ctx, t.config)
-	if err != nil {
-		return err
-	}
-	record := imageModifiedRecord{ImageID: image.ID}
-	return updateImageRecord(recordPath(ctx, t.config), record)
+	return t.flushOutput()
 }
 
 func buildArgs(args map[string]string) []docker.BuildArg {
@@ -1,11 +1,15 @@
 package image
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/dnephin/dobi/config"
 	"github.com/dnephin/dobi/tasks/client"
 	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/suite"
 )
@@ -47,3 +51,45 @@ func (s *ImageRecordSuite) TestRecordPathEscapesSlash() {
 	path := recordPath(s.ctx, s.config)
 	s.Equal("/dir/.dobi/images/repo name:tag", path)
 }
+
+func (s *ImageRecordSuite) TestBuildIsStaleByContent() {
+	dir, err := ioutil.TempDir("", "image-build-test")
+	s.Require().Nil(err)
+	defer os.RemoveAll(dir)
+	dockerfile := filepath.Join(dir, "Dockerfile")
+	s.Require().Nil(ioutil.WriteFile(dockerfile, []byte("FROM alpine"), 0644))
+
+	s.ctx.WorkingDir = dir
+	s.ctx.StaleCheck = "content"
+	s.config.Context = dir
+	task := &Task{config: s.config}
+	image := &docker.Image{ID: "sha256:abcdef"}
+
+	stale, err := buildIsStaleByContent(s.ctx, task, image)
+	s.Nil(err)
+	s.True(stale)
+
+	record := buildRecord(s.ctx, task, image)
+	s.NotEqual("", record.ContextChecksum)
+	s.Require().Nil(updateImageRecord(recordPath(s.ctx, s.config), record))
+
+	stale, err = buildIsStaleByContent(s.ctx, task, image)
+	s.Nil(err)
+	s.False(stale)
+
+	stale, err = buildIsStaleByContent(s.ctx, task, &docker.Image{ID: "sha256:123456"})
+	s.Nil(err)
+	s.True(stale)
+
+	s.Require().Nil(ioutil.WriteFile(dockerfile, []byte("FROM busybox"), 0644))
+	stale, err = buildIsStaleByContent(s.ctx, task, image)
+	s.Nil(err)
+	s.True(stale)
+}
+
+func (s *ImageRecordSuite) TestBuildRecordWithStaleCheckMtime() {
+	s.ctx.StaleCheck = "content"
+	s.config.StaleCheck = "mtime"
+	record := buildRecord(s.ctx, &Task{config: s.config}, &docker.Image{ID: "sha256:abcdef"})
+	s.Equal(imageModifiedRecord{ImageID: "sha256:abcdef"}, record)
+}
@@ -0,0 +1,194 @@
+package image
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dnephin/dobi/tasks/context"
+)
+
+var (
+	syntaxDirective = regexp.MustCompile(`(?i)^#\s*syntax\s*=`)
+	runWithMount    = regexp.MustCompile(`(?i)^\s*RUN\s+(--\S+\s+)*--mount`)
+)
+
+// requiresBuildKit returns true if the Dockerfile uses syntax which is only
+// supported by BuildKit
+func requiresBuildKit(dockerfile io.Reader) (bool, error) {
+	scanner := bufio.NewScanner(dockerfile)
+	directives := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		if directives && syntaxDirective.MatchString(strings.TrimSpace(line)) {
+			return true, nil
+		}
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			directives = false
+		}
+		if runWithMount.MatchString(line) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+func dockerfilePath(t *Task) string {
+	if path := t.config.ExternalDockerfile(); path != "" && t.dockerfile == "" {
+		return path
+	}
+	return filepath.Join(t.config.Context, t.dockerfileName())
+}
+
+func useBuildKit(t *Task) (bool, error) {
+	file, err := os.Open(dockerfilePath(t))
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	detected, err := requiresBuildKit(file)
+	if err != nil {
+		return false, err
+	}
+	if detected && t.config.Buildkit.IsDisabled() {
+		t.logger().Warn("Dockerfile requires BuildKit, but buildkit is false")
+	}
+	// Labels, named contexts, secrets, and cache options are only supported by
+	// BuildKit builds. meta.labels are not required, so they never select
+	// BuildKit on their own.
+	required := len(t.config.Labels) > 0 || t.config.RequiresBuildKit()
+	return t.config.Buildkit.Enabled(detected || required), nil
+}
+
+// buildImageWithBuildKit builds the image using the docker CLI with BuildKit
+// enabled. The Docker API client does not support BuildKit sessions.
+func buildImageWithBuildKit(ctx *context.ExecuteContext, t *Task) error {
+	binary, err := exec.LookPath("docker")
+	if err != nil {
+		return fmt.Errorf(
+			"the Dockerfile for %q requires BuildKit, which requires the docker CLI: %s",
+			t.name, err)
+	}
+	for _, path := range t.config.ContextPaths() {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("invalid named build context %q: %s", path, err)
+		}
+	}
+
+	return runBuildKit(ctx, t, binary, buildKitArgs(ctx, t))
+}
+
+func runBuildKit(ctx *context.ExecuteContext, t *Task, binary string, args []string) error {
+	cmd := exec.Command(binary, args...)
+	cmd.Env = append(os.Environ(), "DOCKER_BUILDKIT=1")
+	cmd.Stdout = t.output(ctx)
+	cmd.Stderr = t.output(ctx)
+	t.logger().Debugf("BuildKit args: %s", cmd.Args)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("BuildKit build failed: %s", err)
+	}
+	return t.flushOutput()
+}
+
+// pushMultiPlatform builds the image again with buildx, which uses the build
+// cache from the build task, and pushes a manifest list to every tag
+func pushMultiPlatform(ctx *context.ExecuteContext, t *Task) error {
+	binary, err := exec.LookPath("docker")
+	if err != nil {
+		return fmt.Errorf(
+			"pushing %q for more than one platform requires the docker CLI: %s",
+			t.name, err)
+	}
+	args, err := multiPlatformPushArgs(ctx, t)
+	if err != nil {
+		return err
+	}
+	return runBuildKit(ctx, t, binary, args)
+}
+
+// multiPlatformPushArgs returns the buildx args used to build the image, with
+// every tag, and the flag to push the image
+func multiPlatformPushArgs(ctx *context.ExecuteContext, t *Task) ([]string, error) {
+	args := buildKitArgs(ctx, t)
+	buildContext := args[len(args)-1]
+	args = args[:len(args)-1]
+	addTag := func(tag string) error {
+		if tag != GetImageName(ctx, t.config) {
+			args = append(args, "--tag", tag)
+		}
+		return nil
+	}
+	if err := t.ForEachTag(ctx, addTag); err != nil {
+		return nil, err
+	}
+	return append(args, "--push", buildContext), nil
+}
+
+func buildKitArgs(ctx *context.ExecuteContext, t *Task) []string {
+	args := []string{"build"}
+	// Images for more than one platform can only be built by buildx
+	if t.config.IsMultiPlatform() {
+		args = []string{"buildx", "build"}
+	}
+	args = append(args,
+		"--tag", GetImageName(ctx, t.config),
+		"--file", dockerfilePath(t),
+	)
+	if len(t.config.Platforms) > 0 {
+		args = append(args, "--platform", strings.Join(t.config.Platforms, ","))
+	}
+	for _, arg := range buildArgs(t.config.Args) {
+		args = append(args, "--build-arg", arg.Name+"="+arg.Value)
+	}
+	for _, label := range sortedPairs(imageLabels(ctx, t)) {
+		args = append(args, "--label", label)
+	}
+	for _, buildContext := range sortedPairs(t.config.Contexts) {
+		args = append(args, "--build-context", buildContext)
+	}
+	for _, secret := range t.config.Secrets {
+		args = append(args, "--secret", secret)
+	}
+	for _, cache := range t.config.CacheFrom {
+		args = append(args, "--cache-from", cache)
+	}
+	for _, cache := range t.config.CacheTo {
+		args = append(args, "--cache-to", cache)
+	}
+	if t.config.PullBaseImageOnBuild {
+		args = append(args, "--pull")
+	}
+	if ctx.Quiet {
+		args = append(args, "--quiet")
+	}
+	return append(args, t.config.Context)
+}
+
+func sortedPairs(mapping map[string]string) []string {
+	out := []string{}
+	for key, value := range mapping {
+		out = append(out, key+"="+value)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// imageLabels returns the labels from the context, and the labels of the image
+// which take precedence
+func imageLabels(ctx *context.ExecuteContext, t *Task) map[string]string {
+	labels := make(map[string]string, len(ctx.Labels)+len(t.config.Labels))
+	for key, value := range ctx.Labels {
+		labels[key] = value
+	}
+	for key, value := range t.config.Labels {
+		labels[key] = value
+	}
+	return labels
+}
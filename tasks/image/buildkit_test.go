@@ -0,0 +1,164 @@
+package image
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/client"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/golang/mock/gomock"
+	"github.com/renstrom/dedent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequiresBuildKit(t *testing.T) {
+	for _, dockerfile := range []string{
+		`
+		# syntax=docker/dockerfile:1.4
+		FROM alpine
+		`,
+		`
+		# escape=\
+		# syntax = docker/dockerfile:experimental
+		FROM alpine
+		`,
+		`
+		FROM golang
+		RUN --mount=type=cache,target=/root/.cache go build
+		`,
+		`
+		FROM golang
+		run --network=none --mount=type=secret,id=token make
+		`,
+	} {
+		required, err := requiresBuildKit(strings.NewReader(strings.TrimLeft(dedent.Dedent(dockerfile), "\n")))
+		assert.Nil(t, err)
+		assert.True(t, required, dockerfile)
+	}
+}
+
+func TestRequiresBuildKitLegacyDockerfile(t *testing.T) {
+	dockerfile := dedent.Dedent(`
+		FROM alpine
+		# syntax=docker/dockerfile:1.4
+		RUN echo --mount
+		`)
+	required, err := requiresBuildKit(strings.NewReader(dockerfile))
+	assert.Nil(t, err)
+	assert.False(t, required)
+}
+
+func TestSortedPairs(t *testing.T) {
+	labels := map[string]string{"b": "2", "a": "1"}
+	assert.Equal(t, []string{"a=1", "b=2"}, sortedPairs(labels))
+}
+
+func TestImageLabels(t *testing.T) {
+	ctx := &context.ExecuteContext{
+		Labels: map[string]string{"pipeline": "42", "team": "platform"},
+	}
+	task := &Task{config: &config.ImageConfig{
+		Labels: map[string]string{"team": "backend"},
+	}}
+	expected := map[string]string{"pipeline": "42", "team": "backend"}
+	assert.Equal(t, expected, imageLabels(ctx, task))
+}
+
+func TestUseBuildKitWithImageLabels(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buildkit-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0644))
+
+	imageConfig := &config.ImageConfig{Context: dir, Dockerfile: "Dockerfile"}
+	buildkit, err := useBuildKit(NewTask("app", imageConfig, action{}))
+	assert.Nil(t, err)
+	assert.False(t, buildkit)
+
+	imageConfig.Labels = map[string]string{"team": "backend"}
+	buildkit, err = useBuildKit(NewTask("app", imageConfig, action{}))
+	assert.Nil(t, err)
+	assert.True(t, buildkit)
+}
+
+func TestBuildImageWithMetaLabelsUsesClient(t *testing.T) {
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+	dir, err := ioutil.TempDir("", "buildkit-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM alpine\n"), 0644))
+
+	for _, value := range []string{"auto", "false"} {
+		mockClient := client.NewMockDockerClient(mock)
+		ctx := &context.ExecuteContext{
+			Client: mockClient,
+			Env:    execenv.NewExecEnv("exec", "project", dir),
+			Labels: map[string]string{"pipeline": "42"},
+		}
+		imageConfig := &config.ImageConfig{
+			Image:      "example/app",
+			Context:    dir,
+			Dockerfile: "Dockerfile",
+		}
+		assert.Nil(t, imageConfig.Buildkit.TransformConfig(reflect.ValueOf(value)))
+		buildErr := fmt.Errorf("built with the client")
+		mockClient.EXPECT().BuildImage(gomock.Any()).Return(buildErr)
+
+		err := buildImage(ctx, NewTask("app", imageConfig, action{}))
+		assert.Equal(t, buildErr, err, value)
+	}
+}
+
+func TestBuildKitArgsSecretsAndCache(t *testing.T) {
+	ctx := &context.ExecuteContext{}
+	task := &Task{name: "app", config: &config.ImageConfig{
+		Image:      "example/app",
+		Context:    ".",
+		Dockerfile: "Dockerfile",
+		Tags:       []string{"latest"},
+		Secrets:    []string{"id=token,env=GITHUB_TOKEN"},
+		CacheFrom:  []string{"type=registry,ref=example/app:cache"},
+		CacheTo:    []string{"type=inline"},
+	}}
+	expected := []string{
+		"build",
+		"--tag", "example/app:latest",
+		"--file", "Dockerfile",
+		"--secret", "id=token,env=GITHUB_TOKEN",
+		"--cache-from", "type=registry,ref=example/app:cache",
+		"--cache-to", "type=inline",
+		".",
+	}
+	assert.Equal(t, expected, buildKitArgs(ctx, task))
+}
+
+func TestMultiPlatformPushArgs(t *testing.T) {
+	ctx := &context.ExecuteContext{}
+	task := &Task{name: "app", config: &config.ImageConfig{
+		Image:      "example/app",
+		Context:    ".",
+		Dockerfile: "Dockerfile",
+		Tags:       []string{"1.2.3", "latest"},
+		Platforms:  []string{"linux/amd64", "linux/arm64"},
+	}}
+	expected := []string{
+		"buildx", "build",
+		"--tag", "example/app:1.2.3",
+		"--file", "Dockerfile",
+		"--platform", "linux/amd64,linux/arm64",
+		"--tag", "example/app:latest",
+		"--push",
+		".",
+	}
+	args, err := multiPlatformPushArgs(ctx, task)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, args)
+}
@@ -0,0 +1,118 @@
+package image
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// dockerfileName returns the name of the Dockerfile relative to the context
+func (t *Task) dockerfileName() string {
+	if t.dockerfile != "" {
+		return t.dockerfile
+	}
+	return t.config.Dockerfile
+}
+
+// fetchDockerfile downloads the Dockerfile url into the build context, so that
+// it is sent to the daemon with the rest of the context. It returns a function
+// which removes the downloaded file.
+func (t *Task) fetchDockerfile() (func(), error) {
+	url := t.config.Dockerfile
+	remove, err := t.writeDockerfile(func(out io.Writer) error {
+		return fetchURL(url, out)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to download Dockerfile from %q: %s", url, err)
+	}
+	t.logger().Debugf("Downloaded Dockerfile from %q", url)
+	return remove, nil
+}
+
+// copyDockerfile copies a Dockerfile from outside of the build context into the
+// context, because the Docker API only reads the Dockerfile from the context.
+// It returns a function which removes the copy.
+func (t *Task) copyDockerfile() (func(), error) {
+	path := t.config.ExternalDockerfile()
+	source, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	remove, err := t.writeDockerfile(func(out io.Writer) error {
+		_, err := io.Copy(out, source)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to copy Dockerfile %q into the context: %s", path, err)
+	}
+	t.logger().Debugf("Copied Dockerfile %q into the context", path)
+	return remove, nil
+}
+
+// writeDockerfile creates a temporary Dockerfile in the build context using
+// write, and returns a function which removes the file
+func (t *Task) writeDockerfile(write func(io.Writer) error) (func(), error) {
+	file, err := ioutil.TempFile(t.config.Context, ".dobi-dockerfile-")
+	if err != nil {
+		return nil, err
+	}
+	remove := func() {
+		t.dockerfile = ""
+		if err := os.Remove(file.Name()); err != nil {
+			t.logger().Warnf("Failed to remove temporary Dockerfile: %s", err)
+		}
+	}
+
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		remove()
+		return nil, err
+	}
+	t.dockerfile = filepath.Base(file.Name())
+	return remove, nil
+}
+
+// validateBuildPaths checks that the build context is a directory, and that
+// the Dockerfile is a file
+func validateBuildPaths(t *Task) error {
+	info, err := os.Stat(t.config.Context)
+	switch {
+	case err != nil:
+		return fmt.Errorf("Invalid build context: %s", err)
+	case !info.IsDir():
+		return fmt.Errorf("Invalid build context %q: not a directory", t.config.Context)
+	case t.config.IsDockerfileURL():
+		return nil
+	}
+
+	info, err = os.Stat(dockerfilePath(t))
+	switch {
+	case err != nil:
+		return fmt.Errorf("Invalid Dockerfile: %s", err)
+	case info.IsDir():
+		return fmt.Errorf("Invalid Dockerfile %q: is a directory", dockerfilePath(t))
+	}
+	return nil
+}
+
+func fetchURL(url string, out io.Writer) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
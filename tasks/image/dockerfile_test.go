@@ -0,0 +1,123 @@
+package image
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDockerfileServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != "/Dockerfile" {
+				http.NotFound(w, req)
+				return
+			}
+			fmt.Fprint(w, "FROM alpine\n")
+		}))
+}
+
+func TestFetchDockerfile(t *testing.T) {
+	server := newDockerfileServer()
+	defer server.Close()
+	context, err := ioutil.TempDir("", "dockerfile-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(context)
+
+	task := NewTask("base", &config.ImageConfig{
+		Context:    context,
+		Dockerfile: server.URL + "/Dockerfile",
+	}, action{})
+	remove, err := task.fetchDockerfile()
+	assert.Nil(t, err)
+
+	path := filepath.Join(context, task.dockerfileName())
+	assert.Equal(t, path, dockerfilePath(task))
+	raw, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "FROM alpine\n", string(raw))
+
+	remove()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, server.URL+"/Dockerfile", task.dockerfileName())
+}
+
+func TestFetchDockerfileFailure(t *testing.T) {
+	server := newDockerfileServer()
+	defer server.Close()
+	context, err := ioutil.TempDir("", "dockerfile-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(context)
+
+	task := NewTask("base", &config.ImageConfig{
+		Context:    context,
+		Dockerfile: server.URL + "/missing",
+	}, action{})
+	_, err = task.fetchDockerfile()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Failed to download Dockerfile")
+	assert.Contains(t, err.Error(), "404 Not Found")
+
+	files, err := ioutil.ReadDir(context)
+	assert.Nil(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestCopyExternalDockerfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dockerfile-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	context := filepath.Join(dir, "app")
+	assert.Nil(t, os.Mkdir(context, 0755))
+	external := filepath.Join(dir, "Dockerfile")
+	assert.Nil(t, ioutil.WriteFile(external, []byte("FROM alpine\n"), 0644))
+
+	task := NewTask("base", &config.ImageConfig{
+		Context:    context,
+		Dockerfile: "../Dockerfile",
+	}, action{})
+	assert.Nil(t, validateBuildPaths(task))
+	assert.Equal(t, external, dockerfilePath(task))
+
+	remove, err := task.copyDockerfile()
+	assert.Nil(t, err)
+	path := filepath.Join(context, task.dockerfileName())
+	assert.Equal(t, path, dockerfilePath(task))
+	raw, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "FROM alpine\n", string(raw))
+
+	remove()
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, external, dockerfilePath(task))
+}
+
+func TestValidateBuildPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dockerfile-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	task := NewTask("base", &config.ImageConfig{
+		Context:    filepath.Join(dir, "missing"),
+		Dockerfile: "Dockerfile",
+	}, action{})
+	err = validateBuildPaths(task)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Invalid build context")
+	}
+
+	task.config.Context = dir
+	err = validateBuildPaths(task)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Invalid Dockerfile")
+	}
+}
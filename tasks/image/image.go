@@ -1,14 +1,17 @@
 package image
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/dnephin/dobi/config"
 	"github.com/dnephin/dobi/logging"
 	"github.com/dnephin/dobi/tasks/common"
 	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/utils/prefix"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/term"
 )
@@ -18,6 +21,9 @@ type Task struct {
 	name   string
 	config *config.ImageConfig
 	action action
+	out    *prefix.Writer
+	// dockerfile is the name of a Dockerfile downloaded into the context
+	dockerfile string
 }
 
 // NewTask creates a new Task object
@@ -44,6 +50,15 @@ func (t *Task) Run(ctx *context.ExecuteContext) error {
 	return t.action.Run(ctx, t)
 }
 
+// IsStale returns true if the image needs to be built. Actions other than build
+// are always considered stale.
+func (t *Task) IsStale(ctx *context.ExecuteContext) (bool, error) {
+	if t.action.name != "build" {
+		return true, nil
+	}
+	return buildIsStale(ctx, t)
+}
+
 // Stop the task
 func (t *Task) Stop(ctx *context.ExecuteContext) error {
 	return nil
@@ -72,24 +87,90 @@ func (t *Task) ForEachTag(ctx *context.ExecuteContext, each func(string) error)
 	return nil
 }
 
+// output returns the writer used for the output of the task. When images are
+// built in parallel the output is prefixed with the task name.
+func (t *Task) output(ctx *context.ExecuteContext) io.Writer {
+	if ctx.Limit("image") <= 1 {
+		return os.Stdout
+	}
+	if t.out == nil {
+		t.out = prefix.NewWriter(os.Stdout, fmt.Sprintf("[%s] ", t.name))
+	}
+	return t.out
+}
+
+func (t *Task) flushOutput() error {
+	if t.out == nil {
+		return nil
+	}
+	return t.out.Flush()
+}
+
+// stream the json output from streamer. If the context has an ImageProgress
+// function each message is sent to the function, otherwise the output is
+// displayed on out.
+func (t *Task) stream(
+	ctx *context.ExecuteContext,
+	out io.Writer,
+	streamer func(out io.Writer) error,
+) error {
+	if ctx.ImageProgress == nil {
+		return Stream(out, streamer)
+	}
+	return streamMessages(func(in io.Reader) error {
+		return decodeMessages(in, func(message jsonmessage.JSONMessage) {
+			ctx.ImageProgress(t.name, message)
+		})
+	}, streamer)
+}
+
+// decodeMessages decodes each json message from in and calls progress with the
+// message. An error message from the daemon is returned as an error.
+func decodeMessages(in io.Reader, progress func(jsonmessage.JSONMessage)) error {
+	decoder := json.NewDecoder(in)
+	for {
+		var message jsonmessage.JSONMessage
+		switch err := decoder.Decode(&message); {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+		progress(message)
+		if message.Error != nil {
+			return message.Error
+		}
+	}
+}
+
 // Stream json output to a terminal
 func Stream(out io.Writer, streamer func(out io.Writer) error) error {
 	outFd, isTTY := term.GetFdInfo(out)
+	return streamMessages(func(in io.Reader) error {
+		return jsonmessage.DisplayJSONMessagesStream(in, out, outFd, isTTY, nil)
+	}, streamer)
+}
+
+// streamMessages pipes the output of streamer to display
+func streamMessages(display func(in io.Reader) error, streamer func(out io.Writer) error) error {
 	rpipe, wpipe := io.Pipe()
 	defer rpipe.Close()
 
 	errChan := make(chan error)
 
 	go func() {
-		err := jsonmessage.DisplayJSONMessagesStream(rpipe, out, outFd, isTTY, nil)
+		err := display(rpipe)
+		// Unblock the streamer if display returns before the end of the stream
+		rpipe.CloseWithError(err)
 		errChan <- err
 	}()
 
 	err := streamer(wpipe)
 	wpipe.Close()
-	if err != nil {
-		<-errChan
-		return err
+	// An error from display, like an error message from the daemon, is more
+	// specific than the error from the streamer when the pipe is closed early
+	if displayErr := <-errChan; displayErr != nil {
+		return displayErr
 	}
-	return <-errChan
+	return err
 }
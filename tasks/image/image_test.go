@@ -0,0 +1,64 @@
+package image
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamToImageProgress(t *testing.T) {
+	messages := []jsonmessage.JSONMessage{}
+	ctx := &context.ExecuteContext{
+		ImageProgress: func(task string, message jsonmessage.JSONMessage) {
+			assert.Equal(t, "builder", task)
+			messages = append(messages, message)
+		},
+	}
+	task := &Task{name: "builder", config: &config.ImageConfig{}}
+	out := &bytes.Buffer{}
+
+	err := task.stream(ctx, out, func(out io.Writer) error {
+		_, err := io.WriteString(out,
+			`{"stream":"Step 1/2 : FROM alpine\n"}`+
+				`{"status":"Downloading","id":"abc","progressDetail":{"current":1,"total":2}}`)
+		return err
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "", out.String())
+	assert.Equal(t, []jsonmessage.JSONMessage{
+		{Stream: "Step 1/2 : FROM alpine\n"},
+		{
+			Status:   "Downloading",
+			ID:       "abc",
+			Progress: &jsonmessage.JSONProgress{Current: 1, Total: 2},
+		},
+	}, messages)
+}
+
+func TestStreamToImageProgressWithError(t *testing.T) {
+	count := 0
+	ctx := &context.ExecuteContext{
+		ImageProgress: func(task string, message jsonmessage.JSONMessage) {
+			count++
+		},
+	}
+	task := &Task{name: "builder", config: &config.ImageConfig{}}
+
+	err := task.stream(ctx, &bytes.Buffer{}, func(out io.Writer) error {
+		if _, err := io.WriteString(out,
+			`{"errorDetail":{"message":"build failed"},"error":"build failed"}`); err != nil {
+			return err
+		}
+		_, err := io.WriteString(out, `{"stream":"never read"}`)
+		return err
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "build failed", err.Error())
+	}
+	assert.Equal(t, 1, count)
+}
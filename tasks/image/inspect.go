@@ -2,6 +2,7 @@ package image
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/dnephin/dobi/config"
 	"github.com/dnephin/dobi/tasks/context"
@@ -30,7 +31,16 @@ func GetCanonicalTag(ctx *context.ExecuteContext, conf *config.ImageConfig) stri
 	return ctx.Env.Unique()
 }
 
+// parseRepo returns the registry hostname from an image name. Images without a
+// registry hostname use the default registry.
 func parseRepo(image string) (string, error) {
-	// TODO: how is this supposed to work?
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 1 {
+		return defaultRepo, nil
+	}
+	hostname := parts[0]
+	if strings.ContainsAny(hostname, ".:") || hostname == "localhost" {
+		return hostname, nil
+	}
 	return defaultRepo, nil
 }
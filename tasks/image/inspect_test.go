@@ -0,0 +1,24 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRepo(t *testing.T) {
+	for _, item := range []struct {
+		image    string
+		expected string
+	}{
+		{"alpine", defaultRepo},
+		{"dnephin/dobi", defaultRepo},
+		{"localhost/dobi", "localhost"},
+		{"localhost:5000/dobi", "localhost:5000"},
+		{"123.dkr.ecr.us-east-1.amazonaws.com/repo/name", "123.dkr.ecr.us-east-1.amazonaws.com"},
+	} {
+		repo, err := parseRepo(item.image)
+		assert.Nil(t, err)
+		assert.Equal(t, item.expected, repo)
+	}
+}
@@ -0,0 +1,135 @@
+package image
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/utils/prefix"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+var fromInstruction = regexp.MustCompile(`(?i)^\s*FROM\s+(--\S+\s+)*(\S+)(\s+AS\s+(\S+))?`)
+
+// PrePull is an image which is pulled before any tasks run
+type PrePull struct {
+	// Image is the name of the image which is pulled
+	Image string
+	// Required is false for the base image of a build. The build can continue
+	// without it, because the image may be built by another task, or pulled by
+	// the build.
+	Required bool
+	run      func(ctx *context.ExecuteContext, out io.Writer) error
+}
+
+// Run pulls the image. When images are pulled in parallel the output is
+// prefixed with the name of the image.
+func (p PrePull) Run(ctx *context.ExecuteContext, parallel bool) error {
+	if !parallel {
+		return p.run(ctx, os.Stdout)
+	}
+	out := prefix.NewWriter(os.Stdout, fmt.Sprintf("[%s] ", p.Image))
+	err := p.run(ctx, out)
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// PrePulls returns the images which are pulled by the task. A pull task
+// returns the image if a pull is required, and a build task returns the base
+// images from the Dockerfile.
+func PrePulls(ctx *context.ExecuteContext, t *Task) ([]PrePull, error) {
+	switch t.action.name {
+	case "pull":
+		return pullPrePulls(ctx, t)
+	case "build":
+		return buildPrePulls(t)
+	}
+	return nil, nil
+}
+
+func pullPrePulls(ctx *context.ExecuteContext, t *Task) ([]PrePull, error) {
+	record, err := getImageRecord(recordPath(ctx, t.config))
+	if err != nil {
+		t.logger().Warnf("Failed to get image record: %s", err)
+	}
+	if !t.config.Pull.Required(record.LastPull) {
+		return nil, nil
+	}
+	return []PrePull{{
+		Image:    t.config.Image,
+		Required: true,
+		run: func(ctx *context.ExecuteContext, out io.Writer) error {
+			return runPull(ctx, t, out)
+		},
+	}}, nil
+}
+
+func buildPrePulls(t *Task) ([]PrePull, error) {
+	if t.config.IsDockerfileURL() {
+		return nil, nil
+	}
+	file, err := os.Open(dockerfilePath(t))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	images, err := baseImages(file)
+	if err != nil {
+		return nil, err
+	}
+	pulls := []PrePull{}
+	for _, image := range images {
+		reference := image
+		pulls = append(pulls, PrePull{
+			Image: reference,
+			run: func(ctx *context.ExecuteContext, out io.Writer) error {
+				registry, err := parseRepo(reference)
+				if err != nil {
+					return err
+				}
+				return pullReference(ctx, t, registry, reference, out)
+			},
+		})
+	}
+	return pulls, nil
+}
+
+// baseImages returns the images used by the FROM instructions of a
+// Dockerfile, with a tag. Build stages, scratch, and images which use build
+// args are not included.
+func baseImages(dockerfile io.Reader) ([]string, error) {
+	images := []string{}
+	stages := map[string]bool{"scratch": true}
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(dockerfile)
+	for scanner.Scan() {
+		match := fromInstruction.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		image := match[2]
+		switch {
+		case stages[strings.ToLower(image)], strings.Contains(image, "$"):
+		default:
+			if repo, tag := docker.ParseRepositoryTag(image); tag == "" {
+				image = repo + ":latest"
+			}
+			if !seen[image] {
+				seen[image] = true
+				images = append(images, image)
+			}
+		}
+		if match[4] != "" {
+			stages[strings.ToLower(match[4])] = true
+		}
+	}
+	return images, scanner.Err()
+}
@@ -0,0 +1,26 @@
+package image
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/renstrom/dedent"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseImages(t *testing.T) {
+	dockerfile := dedent.Dedent(`
+		ARG VERSION=3.19
+		FROM golang:1.8 AS builder
+		RUN go build
+		from --platform=linux/amd64 alpine:${VERSION}
+		FROM builder AS test
+		FROM scratch
+		FROM example.com:5000/tools
+		FROM golang:1.8
+		COPY --from=builder /go/bin/app /app
+		`)
+	images, err := baseImages(strings.NewReader(dockerfile))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"golang:1.8", "example.com:5000/tools:latest"}, images)
+}
@@ -1,8 +1,10 @@
 package image
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/dnephin/dobi/tasks/context"
@@ -11,6 +13,10 @@ import (
 
 // RunPull builds or pulls an image if it is out of date
 func RunPull(ctx *context.ExecuteContext, t *Task) error {
+	return runPull(ctx, t, os.Stdout)
+}
+
+func runPull(ctx *context.ExecuteContext, t *Task, out io.Writer) error {
 	record, err := getImageRecord(recordPath(ctx, t.config))
 	if err != nil {
 		t.logger().Warnf("Failed to get image record: %s", err)
@@ -21,13 +27,21 @@ func RunPull(ctx *context.ExecuteContext, t *Task) error {
 	}
 
 	pullTag := func(tag string) error {
-		return pullImage(ctx, t, tag)
+		return pullImage(ctx, t, tag, out)
 	}
 	if err := t.ForEachTag(ctx, pullTag); err != nil {
 		return err
 	}
 
+	digest, err := verifyDigest(ctx, t, record.Digest)
+	if err != nil {
+		return err
+	}
+
 	record = imageModifiedRecord{LastPull: now()}
+	if t.config.PinDigest {
+		record.Digest = digest
+	}
 	if err := updateImageRecord(recordPath(ctx, t.config), record); err != nil {
 		t.logger().Warnf("Failed to update image record: %s", err)
 	}
@@ -37,25 +51,84 @@ func RunPull(ctx *context.ExecuteContext, t *Task) error {
 	return nil
 }
 
+// verifyDigest returns the digest of the pulled image. An error is returned if
+// the digest is different from the digest of the config, or from the recorded
+// digest when the digest is pinned.
+func verifyDigest(ctx *context.ExecuteContext, t *Task, recorded string) (string, error) {
+	expected := t.config.Digest
+	if t.config.PinDigest {
+		expected = recorded
+	}
+	if expected == "" && !t.config.PinDigest {
+		return "", nil
+	}
+
+	image, err := ctx.Client.InspectImage(GetImageName(ctx, t.config))
+	if err != nil {
+		return "", fmt.Errorf("Failed to inspect image: %s", err)
+	}
+	digest := repoDigest(image.RepoDigests, t.config.Image)
+	switch {
+	case digest == "":
+		return "", fmt.Errorf("Image %s does not have a digest from a registry",
+			t.config.Image)
+	case expected != "" && digest != expected:
+		return "", fmt.Errorf("Image %s has digest %s, expected %s",
+			t.config.Image, digest, expected)
+	}
+	return digest, nil
+}
+
+// repoDigest returns the digest from the repo digest of the repository. If
+// the image has a single repo digest, which may use a different form of the
+// repository name, its digest is returned.
+func repoDigest(repoDigests []string, repository string) string {
+	for _, repoDigest := range repoDigests {
+		parts := strings.SplitN(repoDigest, "@", 2)
+		if len(parts) == 2 && parts[0] == repository {
+			return parts[1]
+		}
+	}
+	if len(repoDigests) == 1 {
+		if parts := strings.SplitN(repoDigests[0], "@", 2); len(parts) == 2 {
+			return parts[1]
+		}
+	}
+	return ""
+}
+
 func now() *time.Time {
 	now := time.Now()
 	return &now
 }
 
-func pullImage(ctx *context.ExecuteContext, t *Task, imageTag string) error {
+func pullImage(ctx *context.ExecuteContext, t *Task, imageTag string, out io.Writer) error {
 	registry, err := parseRepo(t.config.Image)
 	if err != nil {
 		return err
 	}
+	return pullReference(ctx, t, registry, imageTag, out)
+}
 
+func pullReference(
+	ctx *context.ExecuteContext,
+	t *Task,
+	registry string,
+	imageTag string,
+	out io.Writer,
+) error {
+	auth, err := getAuthConfig(ctx, t, registry)
+	if err != nil {
+		return err
+	}
 	repo, tag := docker.ParseRepositoryTag(imageTag)
-	return Stream(os.Stdout, func(out io.Writer) error {
+	return t.stream(ctx, out, func(out io.Writer) error {
 		return ctx.Client.PullImage(docker.PullImageOptions{
 			Repository:    repo,
 			Tag:           tag,
 			OutputStream:  out,
 			RawJSONStream: true,
 			// TODO: timeout
-		}, ctx.GetAuthConfig(registry))
+		}, auth)
 	})
 }
@@ -0,0 +1,71 @@
+package image
+
+import (
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/client"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	digestA = "sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	digestB = "sha256:bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+)
+
+func newDigestTask(t *testing.T, conf *config.ImageConfig, digest string) (*context.ExecuteContext, *Task, *gomock.Controller) {
+	mock := gomock.NewController(t)
+	mockClient := client.NewMockDockerClient(mock)
+	mockClient.EXPECT().InspectImage("example/app:v1").Return(&docker.Image{
+		RepoDigests: []string{"example/app@" + digest},
+	}, nil)
+	conf.Image = "example/app"
+	conf.Tags = []string{"v1"}
+	return &context.ExecuteContext{Client: mockClient}, &Task{name: "app", config: conf}, mock
+}
+
+func TestVerifyDigestMatches(t *testing.T) {
+	ctx, task, mock := newDigestTask(t, &config.ImageConfig{Digest: digestA}, digestA)
+	defer mock.Finish()
+
+	digest, err := verifyDigest(ctx, task, "")
+	assert.Nil(t, err)
+	assert.Equal(t, digestA, digest)
+}
+
+func TestVerifyDigestDoesNotMatch(t *testing.T) {
+	ctx, task, mock := newDigestTask(t, &config.ImageConfig{Digest: digestA}, digestB)
+	defer mock.Finish()
+
+	_, err := verifyDigest(ctx, task, "")
+	assert.EqualError(t, err,
+		"Image example/app has digest "+digestB+", expected "+digestA)
+}
+
+func TestVerifyDigestPinned(t *testing.T) {
+	ctx, task, mock := newDigestTask(t, &config.ImageConfig{PinDigest: true}, digestB)
+	defer mock.Finish()
+
+	digest, err := verifyDigest(ctx, task, "")
+	assert.Nil(t, err)
+	assert.Equal(t, digestB, digest)
+}
+
+func TestVerifyDigestPinnedChanged(t *testing.T) {
+	ctx, task, mock := newDigestTask(t, &config.ImageConfig{PinDigest: true}, digestB)
+	defer mock.Finish()
+
+	_, err := verifyDigest(ctx, task, digestA)
+	assert.EqualError(t, err,
+		"Image example/app has digest "+digestB+", expected "+digestA)
+}
+
+func TestRepoDigest(t *testing.T) {
+	digests := []string{"example/app@" + digestA, "mirror/app@" + digestB}
+	assert.Equal(t, digestB, repoDigest(digests, "mirror/app"))
+	assert.Equal(t, "", repoDigest(digests, "other/app"))
+	assert.Equal(t, digestA, repoDigest(digests[:1], "docker.io/example/app"))
+}
@@ -1,6 +1,7 @@
 package image
 
 import (
+	"fmt"
 	"io"
 	"os"
 
@@ -10,6 +11,14 @@ import (
 
 // RunPush pushes an image to the registry
 func RunPush(ctx *context.ExecuteContext, t *Task) error {
+	if t.config.IsMultiPlatform() {
+		if err := pushMultiPlatform(ctx, t); err != nil {
+			return err
+		}
+		ctx.SetModified(t.name)
+		t.logger().Info("Pushed")
+		return nil
+	}
 	pushTag := func(tag string) error {
 		return pushImage(ctx, t, tag)
 	}
@@ -27,12 +36,40 @@ func pushImage(ctx *context.ExecuteContext, t *Task, tag string) error {
 		return err
 	}
 
-	return Stream(os.Stdout, func(out io.Writer) error {
+	auth, err := getAuthConfig(ctx, t, repo)
+	if err != nil {
+		return err
+	}
+
+	return t.stream(ctx, os.Stdout, func(out io.Writer) error {
 		return ctx.Client.PushImage(docker.PushImageOptions{
 			Name:          tag,
 			OutputStream:  out,
 			RawJSONStream: true,
 			// TODO: timeout
-		}, ctx.GetAuthConfig(repo))
+		}, auth)
 	})
 }
+
+// getAuthConfig returns the credentials used to pull or push the image. When
+// the image sets auth the credentials of the registry resource are used,
+// otherwise the credentials for repo from the docker client config are used.
+func getAuthConfig(
+	ctx *context.ExecuteContext,
+	t *Task,
+	repo string,
+) (docker.AuthConfiguration, error) {
+	if t.config.Auth == "" {
+		return ctx.GetAuthConfig(repo), nil
+	}
+	registry := ctx.Resources.Registry(t.config.Auth)
+	if registry == nil {
+		return docker.AuthConfiguration{}, fmt.Errorf(
+			"%s is not a registry resource", t.config.Auth)
+	}
+	auth, err := ctx.GetRegistryAuth(registry)
+	if err != nil {
+		return auth, fmt.Errorf("Failed to get credentials for %q: %s", registry.Server, err)
+	}
+	return auth, nil
+}
@@ -21,6 +21,12 @@ type imageModifiedRecord struct {
 	ImageID  string
 	LastPull *time.Time  `yaml:",omitempty"`
 	Info     os.FileInfo `yaml:",omitempty"`
+	// ContextChecksum is the checksum of the build context, which is only
+	// recorded when stale-check is content
+	ContextChecksum string `yaml:",omitempty"`
+	// Digest is the digest of the pulled image, which is only recorded when
+	// pin-digest is enabled
+	Digest string `yaml:",omitempty"`
 }
 
 func updateImageRecord(path string, record imageModifiedRecord) error {
@@ -1,10 +1,17 @@
 package image
 
 import (
+	"fmt"
+	"os"
+	"sort"
+
 	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
 )
 
-// RunRemove builds or pulls an image if it is out of date
+// RunRemove removes the tags of the image. When the cleanup policy of the
+// image sets keep-tags, every tag of the repository is removed except for the
+// most recently created tags.
 func RunRemove(ctx *context.ExecuteContext, t *Task) error {
 	removeTag := func(tag string) error {
 		if err := ctx.Client.RemoveImage(tag); err != nil {
@@ -13,10 +20,65 @@ func RunRemove(ctx *context.ExecuteContext, t *Task) error {
 		return nil
 	}
 
-	if err := t.ForEachTag(ctx, removeTag); err != nil {
+	if keep := ctx.Resources.Cleanup(t.name).KeepTags; keep > 0 {
+		images, err := ctx.Client.ListImages(docker.ListImagesOptions{
+			Filters: map[string][]string{"reference": {t.config.Image}},
+		})
+		if err != nil {
+			return fmt.Errorf("Failed to list the tags of %q: %s", t.config.Image, err)
+		}
+		for _, tag := range oldTags(images, t.config.Image, keep) {
+			removeTag(tag)
+		}
+	} else if err := t.ForEachTag(ctx, removeTag); err != nil {
 		return err
 	}
+	if t.config.PinDigest {
+		if err := os.Remove(recordPath(ctx, t.config)); err != nil && !os.IsNotExist(err) {
+			t.logger().Warnf("failed to remove the recorded digest: %s", err)
+		}
+	}
 	ctx.SetModified(t.name)
 	t.logger().Info("Removed")
 	return nil
 }
+
+type imageTag struct {
+	name    string
+	created int64
+}
+
+// byNewest sorts tags by the created time of the image, newest first, and then
+// by name
+type byNewest []imageTag
+
+func (t byNewest) Len() int      { return len(t) }
+func (t byNewest) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+func (t byNewest) Less(i, j int) bool {
+	if t[i].created != t[j].created {
+		return t[i].created > t[j].created
+	}
+	return t[i].name < t[j].name
+}
+
+// oldTags returns the tags of the repository from images, except for the keep
+// tags of the most recently created images
+func oldTags(images []docker.APIImages, repository string, keep int) []string {
+	tags := []imageTag{}
+	for _, image := range images {
+		for _, tag := range image.RepoTags {
+			if repo, _ := docker.ParseRepositoryTag(tag); repo == repository {
+				tags = append(tags, imageTag{name: tag, created: image.Created})
+			}
+		}
+	}
+	sort.Sort(byNewest(tags))
+
+	old := []string{}
+	for i, tag := range tags {
+		if i >= keep {
+			old = append(old, tag.name)
+		}
+	}
+	return old
+}
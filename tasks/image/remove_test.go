@@ -0,0 +1,21 @@
+package image
+
+import (
+	"testing"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOldTags(t *testing.T) {
+	images := []docker.APIImages{
+		{RepoTags: []string{"example/app:v1", "other/app:v1"}, Created: 100},
+		{RepoTags: []string{"example/app:v3", "example/app:latest"}, Created: 300},
+		{RepoTags: []string{"example/app:v2"}, Created: 200},
+		{RepoTags: []string{"localhost:5000/example/app:v4"}, Created: 400},
+	}
+	assert.Equal(t,
+		[]string{"example/app:v2", "example/app:v1"},
+		oldTags(images, "example/app", 2))
+	assert.Equal(t, []string{}, oldTags(images, "example/app", 4))
+}
@@ -9,6 +9,10 @@ import (
 
 // RunTag builds or pulls an image if it is out of date
 func RunTag(ctx *context.ExecuteContext, t *Task) error {
+	if t.config.IsMultiPlatform() {
+		t.logger().Info("Tags are added when the image is pushed")
+		return nil
+	}
 	tag := func(tag string) error {
 		return tagImage(ctx, t, tag)
 	}
@@ -0,0 +1,226 @@
+package tasks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/history"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/dnephin/dobi/tasks/image"
+	"github.com/dnephin/dobi/tasks/job"
+	"github.com/dnephin/dobi/tasks/mount"
+	"github.com/dnephin/dobi/tasks/shell"
+	"github.com/dnephin/dobi/utils/fs"
+)
+
+// incremental runs tasks, skipping any job, shell, or image build which succeeded in a
+// previous run when the inputs of the task are unchanged, and none of its
+// dependencies were modified in this run.
+type incremental struct {
+	store *history.Store
+	tasks *TaskCollection
+	// explain receives an explanation of each cache hit or miss, if it is not
+	// nil
+	explain io.Writer
+}
+
+func (i *incremental) runTask(ctx *context.ExecuteContext, task iface.Task) error {
+	resource, ok := i.tasks.Resource(task)
+	if !ok || !isIncremental(task) {
+		return runTask(ctx, task)
+	}
+
+	name := task.Name().Name()
+	hash, err := inputsHash(ctx, resource)
+	if err != nil {
+		logging.Log.Warnf("Failed to hash inputs of %q: %s", name, err)
+		i.explainf("%s: miss, failed to hash inputs: %s\n", name, err)
+		return runTask(ctx, task)
+	}
+	hit, reason := i.cacheStatus(ctx, task, hash)
+	i.explainStatus(name, hash, hit, reason)
+	if hit {
+		logging.Log.Infof("Skipping %q, %s", name, reason)
+		return nil
+	}
+
+	if err := runTask(ctx, task); err != nil {
+		return err
+	}
+
+	// The hash is computed again because running the task may have created or
+	// modified its artifact
+	if hash, err = inputsHash(ctx, resource); err != nil {
+		logging.Log.Warnf("Failed to hash inputs of %q: %s", name, err)
+		return nil
+	}
+	if err := i.store.Success(name, hash); err != nil {
+		logging.Log.Warnf("Failed to save state of %q: %s", name, err)
+	}
+	return nil
+}
+
+// cacheStatus returns true if the task can be skipped, and the reason the task
+// can or can not be skipped
+func (i *incremental) cacheStatus(
+	ctx *context.ExecuteContext,
+	task iface.Task,
+	hash string,
+) (bool, string) {
+	record, ok := i.store.Get(task.Name().Name())
+	switch {
+	case ctx.IsModified(task.Dependencies()...):
+		return false, "a dependency was modified in this run"
+	case !ok:
+		return false, "no previous success"
+	case record.InputsHash != hash:
+		return false, "inputs changed since the last success"
+	default:
+		return true, fmt.Sprintf("unchanged since the last success at %s",
+			record.LastSuccess.Format("2006-01-02 15:04:05"))
+	}
+}
+
+func (i *incremental) explainStatus(name, hash string, hit bool, reason string) {
+	status := "miss"
+	if hit {
+		status = "hit"
+	}
+	stored := "(none)"
+	if record, ok := i.store.Get(name); ok {
+		stored = record.InputsHash
+	}
+	i.explainf("%s: %s, %s\n    inputs hash: %s\n    stored hash: %s\n",
+		name, status, reason, hash, stored)
+}
+
+func (i *incremental) explainf(format string, args ...interface{}) {
+	if i.explain != nil {
+		fmt.Fprintf(i.explain, format, args...)
+	}
+}
+
+// isIncremental returns true if the task can be skipped by incremental runs
+func isIncremental(task iface.Task) bool {
+	switch task.(type) {
+	case *job.Task, *shell.Task:
+		return true
+	case *image.Task:
+		return task.Name().Action() == "build"
+	default:
+		return false
+	}
+}
+
+// inputsHash returns a hash of the resolved config of the resource, and the
+// modified time, or the checksum when stale-check is content, of all the files
+// used by the resource
+func inputsHash(ctx *context.ExecuteContext, resource config.Resource) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%#v\n", resource)
+
+	for _, item := range job.PassthroughEnv(ctx.EnvPassthrough) {
+		fmt.Fprintln(hash, item)
+	}
+	writeFile := writeLastModified
+	if comparesContent(ctx, resource) {
+		writeFile = writeChecksum
+	}
+	for _, path := range inputFiles(ctx, resource) {
+		if err := writeFile(hash, path); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func inputFiles(ctx *context.ExecuteContext, resource config.Resource) []string {
+	files := sourceFiles(ctx, resource)
+	switch conf := resource.(type) {
+	case *config.JobConfig:
+		return append(files, conf.ArtifactPaths()...)
+	case *config.ShellConfig:
+		if conf.Artifact != "" {
+			return append(files, conf.Artifact)
+		}
+	}
+	return files
+}
+
+// sourceFiles returns the files used by the resource, not including its
+// artifacts
+func sourceFiles(ctx *context.ExecuteContext, resource config.Resource) []string {
+	switch conf := resource.(type) {
+	case *config.JobConfig:
+		files := append([]string{}, conf.Sources...)
+		for _, artifact := range conf.Artifacts.Paths() {
+			files = append(files, conf.Artifacts.Sources(artifact)...)
+		}
+		if len(files) == 0 {
+			ctx.Resources.EachMount(conf.Mounts, func(_ string, mountConf *config.MountConfig) {
+				if mountConf.Volume == "" {
+					files = append(files, mount.AbsBindPath(mountConf, ctx.WorkingDir))
+				}
+			})
+		}
+		for _, filename := range conf.EnvFile {
+			files = append(files, relativeToWorkingDir(ctx.WorkingDir, filename))
+		}
+		return files
+	case *config.ShellConfig:
+		return append([]string{}, conf.Sources...)
+	case *config.ImageConfig:
+		if conf.Context != "" {
+			files := append([]string{conf.Context}, conf.ContextPaths()...)
+			if path := conf.ExternalDockerfile(); path != "" {
+				files = append(files, path)
+			}
+			return files
+		}
+	}
+	return nil
+}
+
+func writeLastModified(out io.Writer, path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		_, err := fmt.Fprintf(out, "%s missing\n", path)
+		return err
+	}
+	modified, err := fs.LastModified(path)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s %d\n", path, modified.UnixNano())
+	return err
+}
+
+func writeChecksum(out io.Writer, path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		_, err := fmt.Fprintf(out, "%s missing\n", path)
+		return err
+	}
+	checksum, err := fs.Checksum(path)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "%s %s\n", path, checksum)
+	return err
+}
+
+// comparesContent returns true if the files of the resource are compared by
+// the checksum of their contents
+func comparesContent(ctx *context.ExecuteContext, resource config.Resource) bool {
+	switch conf := resource.(type) {
+	case *config.JobConfig:
+		return ctx.ComparesContent(conf.StaleCheck)
+	case *config.ImageConfig:
+		return ctx.ComparesContent(conf.StaleCheck)
+	}
+	return false
+}
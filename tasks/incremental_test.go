@@ -0,0 +1,135 @@
+package tasks
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/history"
+	"github.com/dnephin/dobi/tasks/job"
+	"github.com/stretchr/testify/suite"
+)
+
+type IncrementalSuite struct {
+	suite.Suite
+	dir  string
+	ctx  *context.ExecuteContext
+	conf *config.JobConfig
+}
+
+func TestIncrementalSuite(t *testing.T) {
+	suite.Run(t, new(IncrementalSuite))
+}
+
+func (s *IncrementalSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "incremental-test")
+	s.Require().Nil(err)
+
+	s.ctx = context.NewExecuteContext(
+		&config.Config{WorkingDir: s.dir}, nil, nil, false)
+	s.conf = &config.JobConfig{
+		Use:     "builder",
+		Sources: []string{filepath.Join(s.dir, "source")},
+	}
+	s.Require().Nil(ioutil.WriteFile(s.conf.Sources[0], []byte("one"), 0644))
+}
+
+func (s *IncrementalSuite) TearDownTest() {
+	s.Nil(os.RemoveAll(s.dir))
+}
+
+func (s *IncrementalSuite) TestInputsHashChangesWithConfig() {
+	before, err := inputsHash(s.ctx, s.conf)
+	s.Require().Nil(err)
+
+	s.conf.Env = []string{"FOO=bar"}
+	after, err := inputsHash(s.ctx, s.conf)
+	s.Require().Nil(err)
+	s.NotEqual(before, after)
+}
+
+func (s *IncrementalSuite) TestInputsHashChangesWithSources() {
+	before, err := inputsHash(s.ctx, s.conf)
+	s.Require().Nil(err)
+
+	modified := time.Now().Add(time.Minute)
+	s.Require().Nil(os.Chtimes(s.conf.Sources[0], modified, modified))
+	after, err := inputsHash(s.ctx, s.conf)
+	s.Require().Nil(err)
+	s.NotEqual(before, after)
+}
+
+func (s *IncrementalSuite) TestInputsHashMissingArtifact() {
+	s.conf.Artifact = filepath.Join(s.dir, "artifact")
+	before, err := inputsHash(s.ctx, s.conf)
+	s.Require().Nil(err)
+
+	s.Require().Nil(ioutil.WriteFile(s.conf.Artifact, []byte("out"), 0644))
+	after, err := inputsHash(s.ctx, s.conf)
+	s.Require().Nil(err)
+	s.NotEqual(before, after)
+}
+
+func (s *IncrementalSuite) TestRunTaskSkipsUnchangedTask() {
+	task := job.NewTask("test", s.conf)
+	tasks := newTaskCollection()
+	tasks.add(task)
+	tasks.addResource(task, s.conf)
+
+	store, err := history.Load(s.dir)
+	s.Require().Nil(err)
+	hash, err := inputsHash(s.ctx, s.conf)
+	s.Require().Nil(err)
+	s.Require().Nil(store.Success("test:run", hash))
+
+	// The task would fail to run without a docker client
+	runner := &incremental{store: store, tasks: tasks}
+	s.Nil(runner.runTask(s.ctx, task))
+}
+
+func (s *IncrementalSuite) TestRunTaskExplainsCacheHit() {
+	task := job.NewTask("test", s.conf)
+	tasks := newTaskCollection()
+	tasks.add(task)
+	tasks.addResource(task, s.conf)
+
+	store, err := history.Load(s.dir)
+	s.Require().Nil(err)
+	hash, err := inputsHash(s.ctx, s.conf)
+	s.Require().Nil(err)
+	s.Require().Nil(store.Success("test:run", hash))
+
+	out := &bytes.Buffer{}
+	runner := &incremental{store: store, tasks: tasks, explain: out}
+	s.Nil(runner.runTask(s.ctx, task))
+	s.Contains(out.String(), "test:run: hit, unchanged since the last success at")
+	s.Contains(out.String(), "inputs hash: "+hash)
+	s.Contains(out.String(), "stored hash: "+hash)
+}
+
+func (s *IncrementalSuite) TestCacheStatusMiss() {
+	task := job.NewTask("test", s.conf)
+	store, err := history.Load(s.dir)
+	s.Require().Nil(err)
+	runner := &incremental{store: store, tasks: newTaskCollection()}
+
+	hit, reason := runner.cacheStatus(s.ctx, task, "abcd")
+	s.False(hit)
+	s.Equal("no previous success", reason)
+
+	s.Require().Nil(store.Success("test:run", "other"))
+	hit, reason = runner.cacheStatus(s.ctx, task, "abcd")
+	s.False(hit)
+	s.Equal("inputs changed since the last success", reason)
+
+	s.ctx.SetModified("builder")
+	hit, reason = runner.cacheStatus(s.ctx, task, "other")
+	s.False(hit)
+	s.Equal("a dependency was modified in this run", reason)
+}
@@ -14,6 +14,10 @@ func GetTask(name, action string, conf *config.JobConfig) (iface.Task, error) {
 		return NewTask(name, conf), nil
 	case "remove", "rm":
 		return NewRemoveTask(name, conf), nil
+	case "stop":
+		return NewStopTask(name, conf), nil
+	case "exec":
+		return NewExecTask(name, conf), nil
 	default:
 		return nil, fmt.Errorf("Invalid run action %q for task %q", name, action)
 	}
@@ -0,0 +1,170 @@
+package job
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// copyArtifact copies the artifact-from-container path from the container to
+// the artifact on the host
+func (t *Task) copyArtifact(ctx *context.ExecuteContext, containerID string) error {
+	source := t.config.ArtifactFromContainer
+	t.logger().Infof("Copying %s from the container to %s", source, t.config.Artifact)
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(ctx.Client.DownloadFromContainer(
+			containerID,
+			docker.DownloadFromContainerOptions{Path: source, OutputStream: writer}))
+	}()
+	defer reader.Close()
+
+	if err := extractArtifact(reader, t.config.Artifact); err != nil {
+		return fmt.Errorf("Failed to copy %s from the container: %s", source, err)
+	}
+	return nil
+}
+
+// extractArtifact extracts a tar archive of a single file or directory to the
+// dest path. The first element of each path in the archive, which is the name
+// of the file or directory that was archived, is replaced by dest.
+func extractArtifact(archive io.Reader, dest string) error {
+	reader := tar.NewReader(archive)
+	for {
+		header, err := reader.Next()
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+
+		name := path.Clean(header.Name)
+		if name == ".." || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			return fmt.Errorf("invalid path %q in archive", header.Name)
+		}
+		target := dest
+		if parts := strings.SplitN(name, "/", 2); len(parts) == 2 {
+			target = filepath.Join(dest, filepath.FromSlash(parts[1]))
+		}
+
+		if err := extractEntry(reader, header, dest, target); err != nil {
+			return err
+		}
+	}
+}
+
+// extractEntry writes an entry of the archive to target. Entries are never
+// written through a symlink, and a symlink must link to a path in dest, so
+// that an archive can not write files outside of dest.
+func extractEntry(reader io.Reader, header *tar.Header, dest, target string) error {
+	if err := checkNoSymlinks(dest, target); err != nil {
+		return err
+	}
+	if err := removeSymlink(target); err != nil {
+		return err
+	}
+
+	mode := header.FileInfo().Mode()
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, mode.Perm()|0700)
+	case tar.TypeReg, tar.TypeRegA:
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(file, reader); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	case tar.TypeSymlink:
+		if err := checkLink(dest, target, header.Linkname); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return os.Symlink(header.Linkname, target)
+	default:
+		return nil
+	}
+}
+
+// checkLink returns an error if the symlink at target links to an absolute
+// path, or to a path which is not in dest
+func checkLink(dest, target, linkname string) error {
+	if path.IsAbs(linkname) || filepath.IsAbs(linkname) {
+		return fmt.Errorf("invalid symlink %q to an absolute path %q", target, linkname)
+	}
+	resolved := filepath.Join(filepath.Dir(target), filepath.FromSlash(linkname))
+	if !isWithin(dest, resolved) {
+		return fmt.Errorf("invalid symlink %q to %q, which is outside of %q",
+			target, linkname, dest)
+	}
+	return nil
+}
+
+// checkNoSymlinks returns an error if any of the directories between dest and
+// target is a symlink
+func checkNoSymlinks(dest, target string) error {
+	dir := filepath.Dir(target)
+	rel, err := filepath.Rel(dest, dir)
+	switch {
+	case err != nil:
+		return err
+	case rel == "." || !isWithin(dest, dir):
+		// target is dest, or a file directly in dest
+		return nil
+	}
+	current := dest
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		switch {
+		case os.IsNotExist(err):
+			return nil
+		case err != nil:
+			return err
+		case info.Mode()&os.ModeSymlink != 0:
+			return fmt.Errorf("refusing to write %q through the symlink %q", target, current)
+		}
+	}
+	return nil
+}
+
+// removeSymlink removes target if it is a symlink, so that the entry replaces
+// the symlink instead of being written to the path it links to
+func removeSymlink(target string) error {
+	info, err := os.Lstat(target)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	case info.Mode()&os.ModeSymlink != 0:
+		return os.Remove(target)
+	}
+	return nil
+}
+
+// isWithin returns true if name is dest, or a path in dest
+func isWithin(dest, name string) bool {
+	rel, err := filepath.Rel(dest, name)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,175 @@
+package job
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tarEntry struct {
+	name    string
+	content string
+	dir     bool
+	link    string
+}
+
+func buildArchive(t *testing.T, entries ...tarEntry) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	writer := tar.NewWriter(buf)
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+			Typeflag: tar.TypeReg,
+		}
+		switch {
+		case entry.dir:
+			header.Mode = 0755
+			header.Typeflag = tar.TypeDir
+		case entry.link != "":
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = entry.link
+		}
+		assert.Nil(t, writer.WriteHeader(header))
+		_, err := writer.Write([]byte(entry.content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, writer.Close())
+	return buf
+}
+
+func TestExtractArtifactFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "copy-artifact-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	dest := filepath.Join(dir, "dist", "app")
+	archive := buildArchive(t, tarEntry{name: "app-linux", content: "binary"})
+	assert.Nil(t, extractArtifact(archive, dest))
+
+	content, err := ioutil.ReadFile(dest)
+	assert.Nil(t, err)
+	assert.Equal(t, "binary", string(content))
+}
+
+func TestExtractArtifactDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "copy-artifact-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	dest := filepath.Join(dir, "dist")
+	archive := buildArchive(t,
+		tarEntry{name: "out/", dir: true},
+		tarEntry{name: "out/app", content: "binary"},
+		tarEntry{name: "out/docs/", dir: true},
+		tarEntry{name: "out/docs/index.html", content: "docs"})
+	assert.Nil(t, extractArtifact(archive, dest))
+
+	content, err := ioutil.ReadFile(filepath.Join(dest, "app"))
+	assert.Nil(t, err)
+	assert.Equal(t, "binary", string(content))
+	content, err = ioutil.ReadFile(filepath.Join(dest, "docs", "index.html"))
+	assert.Nil(t, err)
+	assert.Equal(t, "docs", string(content))
+}
+
+func TestExtractArtifactInvalidPath(t *testing.T) {
+	archive := buildArchive(t, tarEntry{name: "../escape", content: "data"})
+	err := extractArtifact(archive, "dist")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `invalid path "../escape" in archive`)
+	}
+}
+
+func TestExtractArtifactSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "copy-artifact-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	dest := filepath.Join(dir, "dist")
+	archive := buildArchive(t,
+		tarEntry{name: "out/", dir: true},
+		tarEntry{name: "out/app-1.0", content: "binary"},
+		tarEntry{name: "out/app", link: "app-1.0"})
+	assert.Nil(t, extractArtifact(archive, dest))
+
+	content, err := ioutil.ReadFile(filepath.Join(dest, "app"))
+	assert.Nil(t, err)
+	assert.Equal(t, "binary", string(content))
+}
+
+func TestExtractArtifactMaliciousArchive(t *testing.T) {
+	var testcases = []struct {
+		doc      string
+		entries  []tarEntry
+		expected string
+	}{
+		{
+			doc:      "absolute symlink",
+			entries:  []tarEntry{{name: "out/passwd", link: "/etc/passwd"}},
+			expected: "to an absolute path",
+		},
+		{
+			doc:      "symlink outside of dest",
+			entries:  []tarEntry{{name: "out/escape", link: "../../outside"}},
+			expected: "which is outside of",
+		},
+		{
+			doc: "write through a symlink in the archive",
+			entries: []tarEntry{
+				{name: "out/", dir: true},
+				{name: "out/sub", link: "."},
+				{name: "out/sub/file", content: "data"},
+			},
+			expected: "through the symlink",
+		},
+	}
+	for _, testcase := range testcases {
+		dir, err := ioutil.TempDir("", "copy-artifact-test")
+		assert.Nil(t, err)
+		defer os.RemoveAll(dir)
+
+		err = extractArtifact(buildArchive(t, testcase.entries...), filepath.Join(dir, "dist"))
+		if assert.Error(t, err, testcase.doc) {
+			assert.Contains(t, err.Error(), testcase.expected, testcase.doc)
+		}
+	}
+}
+
+func TestExtractArtifactDoesNotWriteThroughExistingSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "copy-artifact-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	outside := filepath.Join(dir, "outside")
+	assert.Nil(t, os.MkdirAll(outside, 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(outside, "app"), []byte("original"), 0644))
+	dest := filepath.Join(dir, "dist")
+	assert.Nil(t, os.MkdirAll(dest, 0755))
+	assert.Nil(t, os.Symlink(outside, filepath.Join(dest, "lib")))
+	assert.Nil(t, os.Symlink(filepath.Join(outside, "app"), filepath.Join(dest, "app")))
+
+	archive := buildArchive(t, tarEntry{name: "out/lib/evil", content: "data"})
+	err = extractArtifact(archive, dest)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "through the symlink")
+	}
+	_, err = os.Stat(filepath.Join(outside, "evil"))
+	assert.True(t, os.IsNotExist(err))
+
+	// A symlink at the path of an entry is replaced, not written through
+	archive = buildArchive(t, tarEntry{name: "out/app", content: "binary"})
+	assert.Nil(t, extractArtifact(archive, dest))
+	content, err := ioutil.ReadFile(filepath.Join(outside, "app"))
+	assert.Nil(t, err)
+	assert.Equal(t, "original", string(content))
+	content, err = ioutil.ReadFile(filepath.Join(dest, "app"))
+	assert.Nil(t, err)
+	assert.Equal(t, "binary", string(content))
+}
@@ -0,0 +1,94 @@
+package job
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/context"
+)
+
+// Environment returns the environment variables for the container. Variables
+// from env files follow passthrough variables, and variables from the job
+// config are last, so they take precedence over other sources.
+func (t *Task) Environment(ctx *context.ExecuteContext) ([]string, error) {
+	env := PassthroughEnv(ctx.EnvPassthrough)
+	for _, filename := range t.config.EnvFile {
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(ctx.WorkingDir, filename)
+		}
+		fileEnv, err := execenv.LoadEnvFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to load env-file: %s", err)
+		}
+		env = append(env, fileEnv...)
+	}
+	return append(env, t.config.Env...), nil
+}
+
+// EffectiveEnvironment returns env with only the last value of each variable,
+// in the order each variable first appears
+func EffectiveEnvironment(env []string) []string {
+	values := make(map[string]string)
+	names := []string{}
+	for _, item := range env {
+		name := strings.SplitN(item, "=", 2)[0]
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = item
+	}
+
+	effective := []string{}
+	for _, name := range names {
+		effective = append(effective, values[name])
+	}
+	return effective
+}
+
+// PassthroughEnv returns key=value pairs for each of the names which are set
+// in the host environment. A name which ends with * is a prefix, which
+// matches every host variable that starts with the prefix, in sorted order.
+// Unset variables are skipped.
+func PassthroughEnv(names []string) []string {
+	env := []string{}
+	for _, name := range names {
+		if strings.HasSuffix(name, "*") {
+			env = append(env, prefixEnv(strings.TrimSuffix(name, "*"))...)
+			continue
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+func prefixEnv(prefix string) []string {
+	env := []string{}
+	for _, item := range os.Environ() {
+		if strings.HasPrefix(item, prefix) {
+			env = append(env, item)
+		}
+	}
+	sort.Strings(env)
+	return env
+}
+
+var passthroughRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\*?$`)
+
+// ValidatePassthrough validates that each name is the name of a variable, or a
+// prefix of a name followed by *
+func ValidatePassthrough(names []string) error {
+	for _, name := range names {
+		if !passthroughRegex.MatchString(name) {
+			return fmt.Errorf(
+				"%q must be a variable name, or a prefix of a name followed by *", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package job
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPassthroughEnvSkipsMissing(t *testing.T) {
+	defer os.Unsetenv("DOBI_TEST_ONE")
+	os.Setenv("DOBI_TEST_ONE", "one")
+	os.Unsetenv("DOBI_TEST_MISSING")
+
+	env := PassthroughEnv([]string{"DOBI_TEST_ONE", "DOBI_TEST_MISSING"})
+	assert.Equal(t, []string{"DOBI_TEST_ONE=one"}, env)
+}
+
+func TestPassthroughEnvWithPrefix(t *testing.T) {
+	defer os.Unsetenv("DOBI_CI_TWO")
+	defer os.Unsetenv("DOBI_CI_ONE")
+	os.Setenv("DOBI_CI_TWO", "two")
+	os.Setenv("DOBI_CI_ONE", "one")
+
+	env := PassthroughEnv([]string{"DOBI_CI_*"})
+	assert.Equal(t, []string{"DOBI_CI_ONE=one", "DOBI_CI_TWO=two"}, env)
+}
+
+func TestValidatePassthrough(t *testing.T) {
+	assert.Nil(t, ValidatePassthrough([]string{"HOME", "CI_*"}))
+
+	err := ValidatePassthrough([]string{"*"})
+	assert.EqualError(t, err, `"*" must be a variable name, or a prefix of a name followed by *`)
+	assert.Error(t, ValidatePassthrough([]string{"CI_*_ID"}))
+}
+
+func TestEnvironmentConfigTakesPrecedence(t *testing.T) {
+	defer os.Unsetenv("DOBI_TEST_ONE")
+	os.Setenv("DOBI_TEST_ONE", "host")
+
+	task := NewTask("job", &config.JobConfig{Env: []string{"DOBI_TEST_ONE=config"}})
+	ctx := &context.ExecuteContext{EnvPassthrough: []string{"DOBI_TEST_ONE"}}
+	env, err := task.Environment(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"DOBI_TEST_ONE=host", "DOBI_TEST_ONE=config"}, env)
+}
+
+func TestEnvironmentWithEnvFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "env-file-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+	content := "# comment\n\nONE=file\nTWO=file\n"
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(tmpDir, "job.env"), []byte(content), 0644))
+
+	task := NewTask("job", &config.JobConfig{
+		EnvFile: []string{"job.env"},
+		Env:     []string{"TWO=config"},
+	})
+	env, err := task.Environment(&context.ExecuteContext{WorkingDir: tmpDir})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ONE=file", "TWO=file", "TWO=config"}, env)
+	assert.Equal(t, []string{"ONE=file", "TWO=config"}, EffectiveEnvironment(env))
+}
+
+func TestEnvironmentWithMissingEnvFile(t *testing.T) {
+	task := NewTask("job", &config.JobConfig{EnvFile: []string{"missing.env"}})
+	_, err := task.Environment(&context.ExecuteContext{WorkingDir: "/dir"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Failed to load env-file: open /dir/missing.env")
+	}
+}
+
+func TestEffectiveEnvironment(t *testing.T) {
+	env := []string{"ONE=host", "TWO=2", "ONE=config", "THREE"}
+	assert.Equal(t,
+		[]string{"ONE=config", "TWO=2", "THREE"},
+		EffectiveEnvironment(env))
+}
@@ -0,0 +1,120 @@
+package job
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/docker/docker/pkg/term"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// ExecTask is a task which runs a command in the container of a job which is
+// already running, for example a long running job started by another dobi
+// process.
+type ExecTask struct {
+	name   string
+	config *config.JobConfig
+}
+
+// NewExecTask creates a new ExecTask object
+func NewExecTask(name string, conf *config.JobConfig) *ExecTask {
+	return &ExecTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *ExecTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "exec")
+}
+
+func (t *ExecTask) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *ExecTask) Repr() string {
+	return fmt.Sprintf("[job:exec %v] %v", t.name, t.config.Use)
+}
+
+// Run runs the command from --command in the running container of the job
+func (t *ExecTask) Run(ctx *context.ExecuteContext) error {
+	if len(ctx.ExecCommand) == 0 {
+		return fmt.Errorf("the exec action requires a command, set one with --command")
+	}
+	name := ContainerName(ctx, t.name)
+	container, err := ctx.Client.InspectContainer(name)
+	switch err.(type) {
+	case *docker.NoSuchContainer:
+		return fmt.Errorf("Container %q is not running", name)
+	case nil:
+	default:
+		return fmt.Errorf("Failed to inspect container %q: %s", name, err)
+	}
+	if !container.State.Running {
+		return fmt.Errorf("Container %q is not running", name)
+	}
+
+	interactive := t.config.Interactive
+	exec, err := ctx.Client.CreateExec(docker.CreateExecOptions{
+		Container:    container.ID,
+		Cmd:          ctx.ExecCommand,
+		AttachStdin:  interactive,
+		AttachStdout: true,
+		AttachStderr: true,
+		Tty:          interactive,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to create exec in container %q: %s", name, err)
+	}
+
+	if interactive {
+		inFd, _ := term.GetFdInfo(os.Stdin)
+		state, err := term.SetRawTerminal(inFd)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := term.RestoreTerminal(inFd, state); err != nil {
+				t.logger().Warnf("Failed to restore fd %v: %s", inFd, err)
+			}
+		}()
+	}
+
+	stdout, stderr, flush := NewTask(t.name, t.config).outputStreams(ctx)
+	defer flush()
+	if err := ctx.Client.StartExec(exec.ID, docker.StartExecOptions{
+		Tty:          interactive,
+		RawTerminal:  interactive,
+		InputStream:  ioutil.NopCloser(os.Stdin),
+		OutputStream: stdout,
+		ErrorStream:  stderr,
+	}); err != nil {
+		return fmt.Errorf("Failed to run exec in container %q: %s", name, err)
+	}
+
+	inspect, err := ctx.Client.InspectExec(exec.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to inspect exec in container %q: %s", name, err)
+	}
+	if inspect.ExitCode != 0 {
+		return &exitError{status: inspect.ExitCode}
+	}
+	t.logger().Info("Done")
+	return nil
+}
+
+// Dependencies returns the list of dependencies. The exec task doesn't depend
+// on anything, because the container must already be running.
+func (t *ExecTask) Dependencies() []string {
+	return []string{}
+}
+
+// Stop the task
+func (t *ExecTask) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
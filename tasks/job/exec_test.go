@@ -0,0 +1,98 @@
+package job
+
+import (
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/client"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExecTaskSuite struct {
+	suite.Suite
+	mock      *gomock.Controller
+	client    *client.MockDockerClient
+	ctx       *context.ExecuteContext
+	task      *ExecTask
+	container string
+}
+
+func TestExecTaskSuite(t *testing.T) {
+	suite.Run(t, new(ExecTaskSuite))
+}
+
+func (s *ExecTaskSuite) SetupTest() {
+	s.mock = gomock.NewController(s.T())
+	s.client = client.NewMockDockerClient(s.mock)
+	s.ctx = &context.ExecuteContext{
+		Client:      s.client,
+		Env:         execenv.NewExecEnv("exec", "project", "/dir"),
+		ExecCommand: []string{"psql", "-c", "select 1"},
+	}
+	s.task = NewExecTask("db", &config.JobConfig{Use: "postgres"})
+	s.container = ContainerName(s.ctx, "db")
+}
+
+func (s *ExecTaskSuite) TearDownTest() {
+	s.mock.Finish()
+}
+
+func (s *ExecTaskSuite) expectRunning(running bool) {
+	s.client.EXPECT().InspectContainer(s.container).Return(&docker.Container{
+		ID:    "container-id",
+		State: docker.State{Running: running},
+	}, nil)
+}
+
+func (s *ExecTaskSuite) TestRunExecutesCommand() {
+	s.expectRunning(true)
+	s.client.EXPECT().CreateExec(docker.CreateExecOptions{
+		Container:    "container-id",
+		Cmd:          []string{"psql", "-c", "select 1"},
+		AttachStdout: true,
+		AttachStderr: true,
+	}).Return(&docker.Exec{ID: "exec-id"}, nil)
+	s.client.EXPECT().StartExec("exec-id", gomock.Any()).Return(nil)
+	s.client.EXPECT().InspectExec("exec-id").Return(&docker.ExecInspect{ExitCode: 0}, nil)
+	s.Nil(s.task.Run(s.ctx))
+}
+
+func (s *ExecTaskSuite) TestRunCommandFailed() {
+	s.expectRunning(true)
+	s.client.EXPECT().CreateExec(gomock.Any()).Return(&docker.Exec{ID: "exec-id"}, nil)
+	s.client.EXPECT().StartExec("exec-id", gomock.Any()).Return(nil)
+	s.client.EXPECT().InspectExec("exec-id").Return(&docker.ExecInspect{ExitCode: 2}, nil)
+	err := s.task.Run(s.ctx)
+	if s.Error(err) {
+		s.Contains(err.Error(), "Exited with non-zero status code 2")
+	}
+}
+
+func (s *ExecTaskSuite) TestRunContainerNotRunning() {
+	s.expectRunning(false)
+	err := s.task.Run(s.ctx)
+	if s.Error(err) {
+		s.Contains(err.Error(), "is not running")
+	}
+}
+
+func (s *ExecTaskSuite) TestRunNoContainer() {
+	s.client.EXPECT().InspectContainer(s.container).Return(
+		nil, &docker.NoSuchContainer{ID: s.container})
+	err := s.task.Run(s.ctx)
+	if s.Error(err) {
+		s.Contains(err.Error(), "is not running")
+	}
+}
+
+func (s *ExecTaskSuite) TestRunNoCommand() {
+	s.ctx.ExecCommand = nil
+	err := s.task.Run(s.ctx)
+	if s.Error(err) {
+		s.Contains(err.Error(), "the exec action requires a command")
+	}
+}
@@ -0,0 +1,123 @@
+package job
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+const containerIDVariable = "{job.container-id}"
+
+// runHook runs the before or after hook of the job in a new container, which
+// is created like the container for the command of the job, but is not
+// interactive and does not publish any ports. The container is always
+// removed.
+func (t *Task) runHook(ctx *context.ExecuteContext, hook string, command config.ShlexSlice) error {
+	if command.Empty() {
+		return nil
+	}
+	t.logger().Infof("Running %s hook", hook)
+	if err := t.runHookContainer(ctx, hook, command); err != nil {
+		return fmt.Errorf("%s hook %q failed: %s", hook, command.String(), err)
+	}
+	return nil
+}
+
+func (t *Task) runHookContainer(
+	ctx *context.ExecuteContext,
+	hook string,
+	command config.ShlexSlice,
+) error {
+	name := ContainerName(ctx, t.name) + "-" + hook
+	opts, err := t.hookCreateOptions(ctx, name, command)
+	if err != nil {
+		return err
+	}
+	if opts.Config.Image, err = imageID(ctx.Client, opts.Config.Image); err != nil {
+		return err
+	}
+	// Remove the container left by a previous run which was interrupted
+	RemoveContainer(t.logger(), ctx.Client, name, false)
+	container, err := ctx.Client.CreateContainer(opts)
+	if err != nil {
+		return fmt.Errorf("Failed creating container %q: %s", name, err)
+	}
+	defer RemoveContainer(t.logger(), ctx.Client, container.ID, true)
+
+	stdout, stderr, flush := t.outputStreams(ctx)
+	defer flush()
+	waiter, err := ctx.Client.AttachToContainerNonBlocking(docker.AttachToContainerOptions{
+		Container:    container.ID,
+		OutputStream: stdout,
+		ErrorStream:  stderr,
+		Stream:       true,
+		Stdout:       true,
+		Stderr:       true,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed attaching to container %q: %s", name, err)
+	}
+	if err := ctx.Client.StartContainer(container.ID, nil); err != nil {
+		return fmt.Errorf("Failed starting container %q: %s", name, err)
+	}
+	if err := t.wait(ctx.Client, container.ID); err != nil {
+		return err
+	}
+	waiter.Wait()
+	return nil
+}
+
+func (t *Task) hookCreateOptions(
+	ctx *context.ExecuteContext,
+	name string,
+	command config.ShlexSlice,
+) (docker.CreateContainerOptions, error) {
+	opts, err := t.createOptions(ctx, name)
+	if err != nil {
+		return opts, err
+	}
+	opts.Config.Entrypoint, opts.Config.Cmd = t.commandFor(ctx, command)
+	opts.Config.OpenStdin = false
+	opts.Config.Tty = false
+	opts.Config.AttachStdin = false
+	opts.Config.StdinOnce = false
+	opts.Config.MacAddress = ""
+	opts.Config.ExposedPorts = nil
+	opts.HostConfig.PortBindings = nil
+	return opts, nil
+}
+
+// runFailureHook runs the OnFailure command for the job. Errors from the hook
+// are logged and returned, but should not replace the error from the job.
+func (t *Task) runFailureHook(ctx *context.ExecuteContext, containerID string) error {
+	if t.config.OnFailure.Empty() {
+		return nil
+	}
+	t.logger().Info("Running on-failure hook")
+
+	cmd := failureHookCommand(t.config.OnFailure.Value(), containerID)
+	cmd.Dir = ctx.WorkingDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		err = fmt.Errorf("on-failure hook %q failed: %s", t.config.OnFailure.String(), err)
+		t.logger().Warn(err)
+		return err
+	}
+	return nil
+}
+
+func failureHookCommand(args []string, containerID string) *exec.Cmd {
+	resolved := []string{}
+	for _, arg := range args {
+		resolved = append(resolved, strings.Replace(arg, containerIDVariable, containerID, -1))
+	}
+	cmd := exec.Command(resolved[0], resolved[1:]...)
+	cmd.Env = append(os.Environ(), "DOBI_CONTAINER_ID="+containerID)
+	return cmd
+}
@@ -0,0 +1,82 @@
+package job
+
+import (
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/client"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailureHookCommandReplacesContainerID(t *testing.T) {
+	cmd := failureHookCommand(
+		[]string{"docker", "logs", "{job.container-id}", "--tail=10"}, "abcdef")
+
+	assert.Equal(t, []string{"docker", "logs", "abcdef", "--tail=10"}, cmd.Args)
+	assert.Contains(t, cmd.Env, "DOBI_CONTAINER_ID=abcdef")
+}
+
+func TestHookCreateOptions(t *testing.T) {
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n" +
+			"job=test:\n  use: builder\n  interactive: true\n  ports: ['8080:80']\n" +
+			"  command: ./serve\n  shell: bash\n  before: ./migrate --all\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, nil, execenv.NewExecEnv("exec", "project", "."), false)
+	task := NewTask("test", conf.Resources["test"].(*config.JobConfig))
+
+	opts, err := task.hookCreateOptions(ctx, "test-before", task.config.Before)
+	assert.Nil(t, err)
+	assert.Equal(t, "test-before", opts.Name)
+	assert.Equal(t, []string{"bash", "-c"}, opts.Config.Entrypoint)
+	assert.Equal(t, []string{"./migrate --all"}, opts.Config.Cmd)
+	assert.False(t, opts.Config.Tty)
+	assert.False(t, opts.Config.OpenStdin)
+	assert.Nil(t, opts.Config.ExposedPorts)
+	assert.Nil(t, opts.HostConfig.PortBindings)
+}
+
+func TestRunHookFailure(t *testing.T) {
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+	mockClient := client.NewMockDockerClient(mock)
+
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n" +
+			"job=test:\n  use: builder\n  after: ./notify\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, mockClient, execenv.NewExecEnv("exec", "project", "."), false)
+	task := NewTask("test", conf.Resources["test"].(*config.JobConfig))
+
+	mockClient.EXPECT().InspectImage("builder:project-exec").Return(
+		&docker.Image{ID: "sha256:builder"}, nil)
+	mockClient.EXPECT().RemoveContainer(gomock.Any()).Do(
+		func(opts docker.RemoveContainerOptions) {
+			assert.Equal(t, "project-exec-test-after", opts.ID)
+		}).Return(&docker.NoSuchContainer{ID: "project-exec-test-after"})
+	mockClient.EXPECT().CreateContainer(gomock.Any()).Do(
+		func(opts docker.CreateContainerOptions) {
+			assert.Equal(t, "sha256:builder", opts.Config.Image)
+			assert.Equal(t, []string{"./notify"}, opts.Config.Cmd)
+		}).Return(&docker.Container{ID: "hook-id"}, nil)
+	mockClient.EXPECT().AttachToContainerNonBlocking(gomock.Any()).Return(closeWaiter{}, nil)
+	mockClient.EXPECT().StartContainer("hook-id", nil).Return(nil)
+	mockClient.EXPECT().WaitContainer("hook-id").Return(2, nil)
+	mockClient.EXPECT().RemoveContainer(gomock.Any()).Do(
+		func(opts docker.RemoveContainerOptions) {
+			assert.Equal(t, "hook-id", opts.ID)
+		}).Return(nil)
+
+	err = task.runHook(ctx, "after", task.config.After)
+	assert.EqualError(t, err, `after hook "./notify" failed: `+(&exitError{status: 2}).Error())
+}
@@ -0,0 +1,148 @@
+package job
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/image"
+	"github.com/dnephin/dobi/utils/fs"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const jobRecordDir = ".dobi/jobs"
+
+// jobRecord is the checksum of the inputs of a job, recorded after the job
+// runs, which is used to determine if the job is stale when stale-check is
+// content
+type jobRecord struct {
+	Checksum string
+	// Command is a checksum of the entrypoint and command of the container, so
+	// that the job is stale when the command changes
+	Command string
+	ImageID string `yaml:",omitempty"`
+}
+
+func updateJobRecord(path string, record jobRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	bytes, err := yaml.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, bytes, 0644)
+}
+
+func getJobRecord(path string) (jobRecord, error) {
+	record := jobRecord{}
+	recordBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return record, err
+	}
+	return record, yaml.Unmarshal(recordBytes, &record)
+}
+
+func jobRecordPath(workdir string, name string) string {
+	return filepath.Join(workdir, jobRecordDir, strings.Replace(name, "/", " ", -1))
+}
+
+// recordsContent returns true if the job has artifacts which are compared to
+// their sources using a checksum of their contents
+func (t *Task) recordsContent(ctx *context.ExecuteContext) bool {
+	return ctx.ComparesContent(t.config.StaleCheck) && len(t.config.ArtifactPaths()) != 0
+}
+
+// sourcePaths returns the files used to create the artifacts of the job, and
+// true if the paths are the mounts of the job, because no sources are set
+func (t *Task) sourcePaths(ctx *context.ExecuteContext) ([]string, bool) {
+	paths := append([]string{}, t.config.Sources...)
+	for _, artifact := range t.config.Artifacts.Paths() {
+		paths = append(paths, t.config.Artifacts.Sources(artifact)...)
+	}
+	if len(paths) != 0 {
+		return paths, false
+	}
+	ctx.Resources.EachMount(t.config.Mounts, func(name string, mount *config.MountConfig) {
+		if mount.Volume == "" {
+			paths = append(paths, mount.Bind)
+		}
+	})
+	return paths, true
+}
+
+// newJobRecord returns a record of the current checksum of the sources and
+// command of the job, and the id of the image when the mounts are used as the
+// sources
+func (t *Task) newJobRecord(ctx *context.ExecuteContext) (jobRecord, error) {
+	paths, isMounts := t.sourcePaths(ctx)
+	checksum, err := fs.Checksum(paths...)
+	if err != nil {
+		return jobRecord{}, err
+	}
+	record := jobRecord{Checksum: checksum, Command: t.commandChecksum(ctx)}
+	if isMounts {
+		imageName := ctx.Resources.Image(t.config.Use)
+		img, err := image.GetImage(ctx, imageName)
+		if err != nil {
+			return record, err
+		}
+		record.ImageID = img.ID
+	}
+	return record, nil
+}
+
+// commandChecksum returns a checksum of the entrypoint and command of the
+// container
+func (t *Task) commandChecksum(ctx *context.ExecuteContext) string {
+	entrypoint, command := t.Command(ctx)
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%q\n%q\n", entrypoint, command)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// isStaleByContent returns true if an artifact does not exist, or if the
+// checksum of the sources or command has changed since the job last ran
+func (t *Task) isStaleByContent(ctx *context.ExecuteContext) (bool, error) {
+	for _, artifact := range t.config.ArtifactPaths() {
+		if _, err := os.Stat(artifact); err != nil {
+			t.logger().Debugf("artifact %s does not exist", artifact)
+			return true, nil
+		}
+	}
+
+	previous, err := getJobRecord(jobRecordPath(ctx.WorkingDir, t.name))
+	if err != nil {
+		t.logger().Debugf("Failed to get job record: %s", err)
+		return true, nil
+	}
+	current, err := t.newJobRecord(ctx)
+	if err != nil {
+		t.logger().Warnf("Failed to get checksum of sources: %s", err)
+		return true, err
+	}
+	if current != previous {
+		t.logger().Debug("sources or command changed since the last run")
+		return true, nil
+	}
+	return false, nil
+}
+
+// recordContent saves the checksum of the sources of the job after it runs
+func (t *Task) recordContent(ctx *context.ExecuteContext) {
+	record, err := t.newJobRecord(ctx)
+	if err != nil {
+		t.logger().Warnf("Failed to get checksum of sources: %s", err)
+		return
+	}
+	if err := updateJobRecord(jobRecordPath(ctx.WorkingDir, t.name), record); err != nil {
+		t.logger().Warnf("Failed to update job record: %s", err)
+	}
+}
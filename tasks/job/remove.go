@@ -3,6 +3,7 @@ package job
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/dnephin/dobi/config"
@@ -34,16 +35,16 @@ func (t *RemoveTask) logger() *log.Entry {
 
 // Repr formats the task for logging
 func (t *RemoveTask) Repr() string {
-	return fmt.Sprintf("[job:rm %v] %v", t.name, t.config.Artifact)
+	return fmt.Sprintf("[job:rm %v] %v", t.name, strings.Join(t.config.ArtifactPaths(), ", "))
 }
 
 // Run creates the host path if it doesn't already exist
 func (t *RemoveTask) Run(ctx *context.ExecuteContext) error {
 	RemoveContainer(t.logger(), ctx.Client, ContainerName(ctx, t.name), false)
 
-	if t.config.Artifact != "" {
-		if err := os.RemoveAll(t.config.Artifact); err != nil {
-			t.logger().Warnf("failed to remove artifact %s: %s", t.config.Artifact, err)
+	for _, artifact := range t.config.ArtifactPaths() {
+		if err := os.RemoveAll(artifact); err != nil {
+			t.logger().Warnf("failed to remove artifact %s: %s", artifact, err)
 		}
 	}
 
@@ -3,9 +3,11 @@ package job
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -18,6 +20,8 @@ import (
 	"github.com/dnephin/dobi/tasks/image"
 	"github.com/dnephin/dobi/tasks/mount"
 	"github.com/dnephin/dobi/utils/fs"
+	"github.com/dnephin/dobi/utils/mask"
+	"github.com/dnephin/dobi/utils/prefix"
 	dopts "github.com/docker/docker/opts"
 	"github.com/docker/docker/pkg/term"
 	docker "github.com/fsouza/go-dockerclient"
@@ -28,6 +32,15 @@ import (
 type Task struct {
 	name   string
 	config *config.JobConfig
+	// service is the id of the container which is left running after the
+	// wait-for condition of the job is met
+	service string
+	// detach leaves the container running once it starts, even if the job
+	// does not have a wait-for condition
+	detach bool
+	// retryContainer is the id of the container of a failed attempt, which is
+	// started again by the next attempt when retry-reuse-container is set
+	retryContainer string
 }
 
 // NewTask creates a new Task object
@@ -35,6 +48,12 @@ func NewTask(name string, conf *config.JobConfig) *Task {
 	return &Task{name: name, config: conf}
 }
 
+// NewServiceTask creates a new Task which leaves the container running once
+// it is ready, until the task is stopped
+func NewServiceTask(name string, conf *config.JobConfig) *Task {
+	return &Task{name: name, config: conf, detach: true}
+}
+
 // Name returns the name of the task
 func (t *Task) Name() common.TaskName {
 	return common.NewTaskName(t.name, "run")
@@ -51,11 +70,15 @@ func (t *Task) Repr() string {
 	if !t.config.Command.Empty() {
 		buff.WriteString(" " + t.config.Command.String())
 	}
-	if !t.config.Command.Empty() && t.config.Artifact != "" {
+	artifacts := t.config.ArtifactPaths()
+	if !t.config.Command.Empty() && len(artifacts) != 0 {
 		buff.WriteString(" ->")
 	}
-	if t.config.Artifact != "" {
-		buff.WriteString(" " + t.config.Artifact)
+	if len(artifacts) != 0 {
+		buff.WriteString(" " + strings.Join(artifacts, ", "))
+	}
+	if t.detach {
+		return fmt.Sprintf("[service:start %v]%v", t.name, buff.String())
 	}
 	return fmt.Sprintf("[job:run %v]%v", t.name, buff.String())
 }
@@ -70,25 +93,149 @@ func (t *Task) Run(ctx *context.ExecuteContext) error {
 	t.logger().Debug("is stale")
 
 	t.logger().Info("Start")
-	err = t.runContainer(ctx)
+	if err := t.runHook(ctx, "before", t.config.Before); err != nil {
+		return err
+	}
+	err = t.runWithRetries(ctx, t.runContainer)
 	if err != nil {
 		return err
 	}
+	if err := t.runHook(ctx, "after", t.config.After); err != nil {
+		t.logger().Warn(err)
+	}
 	ctx.SetModified(t.name)
+	if t.recordsContent(ctx) {
+		t.recordContent(ctx)
+	}
 	t.logger().Info("Done")
 	return nil
 }
 
+// runWithRetries calls run, and calls it again each time the command of the
+// container exits with a non-zero status or times out, up to the number of
+// retries. A container kept by a failed attempt, for retry-reuse-container,
+// is removed once there are no more attempts.
+func (t *Task) runWithRetries(
+	ctx *context.ExecuteContext,
+	run func(*context.ExecuteContext) error,
+) error {
+	defer t.removeRetryContainer(ctx)
+
+	attempts := t.config.Retries + 1
+	for attempt := 1; ; attempt++ {
+		if attempts > 1 {
+			t.logger().Infof("Attempt %d of %d", attempt, attempts)
+		}
+		err := t.runWithTimeout(ctx, run)
+		if _, ok := err.(*timeoutError); ok {
+			// The container was removed when it was stopped
+			t.retryContainer = ""
+		}
+		if !isRetryable(err) {
+			return err
+		}
+		if attempt >= attempts {
+			if attempts > 1 {
+				return fmt.Errorf("Attempt %d of %d failed: %s", attempt, attempts, err)
+			}
+			return err
+		}
+		t.logger().Warnf("Attempt %d failed, retrying in %s: %s",
+			attempt, t.config.RetryDelay.Duration(), err)
+		time.Sleep(t.config.RetryDelay.Duration())
+	}
+}
+
+// runWithTimeout calls run, and stops the container of the job if run does not
+// return before the timeout of the job. The container is removed before
+// runWithTimeout returns, so that the next attempt can create a new one.
+func (t *Task) runWithTimeout(
+	ctx *context.ExecuteContext,
+	run func(*context.ExecuteContext) error,
+) error {
+	limit := t.config.Timeout.Duration()
+	if limit == 0 {
+		return run(ctx)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- run(ctx)
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(limit):
+		t.logger().Warnf("Timed out after %s, stopping the container", limit)
+		if err := NewStopTask(t.name, t.config).Run(ctx); err != nil {
+			return fmt.Errorf("Timed out after %s: %s", limit, err)
+		}
+		<-result
+		return &timeoutError{timeout: limit}
+	}
+}
+
+// removeRetryContainer removes the container kept by a failed attempt, unless
+// the container of the job is kept
+func (t *Task) removeRetryContainer(ctx *context.ExecuteContext) {
+	if t.retryContainer == "" {
+		return
+	}
+	if !keepContainer(ctx, t.name) {
+		RemoveContainer(t.logger(), ctx.Client, t.retryContainer, true)
+	}
+	t.retryContainer = ""
+}
+
+func isRetryable(err error) bool {
+	switch err.(type) {
+	case *exitError, *timeoutError:
+		return true
+	}
+	return false
+}
+
+// exitError is the error returned when the command of a container exits with a
+// non-zero status
+type exitError struct {
+	status int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("Exited with non-zero status code %d", e.status)
+}
+
+// timeoutError is the error returned when the command of a container runs for
+// longer than the timeout of the job
+type timeoutError struct {
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("Timed out after %s", e.timeout)
+}
+
+// IsStale returns true if the job needs to run
+func (t *Task) IsStale(ctx *context.ExecuteContext) (bool, error) {
+	return t.isStale(ctx)
+}
+
 func (t *Task) isStale(ctx *context.ExecuteContext) (bool, error) {
 	if ctx.IsModified(t.config.Dependencies()...) {
 		return true, nil
 	}
 
+	if t.recordsContent(ctx) {
+		return t.isStaleByContent(ctx)
+	}
+	if !t.config.Artifacts.Empty() {
+		return t.isAnyArtifactStale()
+	}
 	if t.config.Artifact == "" {
 		return true, nil
 	}
 
-	artifactLastModified, err := t.artifactLastModified()
+	artifactLastModified, err := artifactLastModified(t.config.Artifact)
 	if err != nil {
 		t.logger().Warnf("Failed to get artifact last modified: %s", err)
 		return true, err
@@ -129,19 +276,42 @@ func (t *Task) isStale(ctx *context.ExecuteContext) (bool, error) {
 	return false, nil
 }
 
-func (t *Task) artifactLastModified() (time.Time, error) {
+// isAnyArtifactStale returns true if any of the artifacts is older than its own
+// sources
+func (t *Task) isAnyArtifactStale() (bool, error) {
+	for _, artifact := range t.config.Artifacts.Paths() {
+		artifactLastModified, err := artifactLastModified(artifact)
+		if err != nil {
+			t.logger().Warnf("Failed to get artifact last modified: %s", err)
+			return true, err
+		}
+		sourcesLastModified, err := fs.LastModified(t.config.Artifacts.Sources(artifact)...)
+		if err != nil {
+			return true, err
+		}
+		if artifactLastModified.Before(sourcesLastModified) {
+			t.logger().Debugf("artifact %s older than its sources", artifact)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func artifactLastModified(artifact string) (time.Time, error) {
 	// File or directory doesn't exist
-	if _, err := os.Stat(t.config.Artifact); err != nil {
+	if _, err := os.Stat(artifact); err != nil {
 		return time.Time{}, nil
 	}
-	return fs.LastModified(t.config.Artifact)
+	return fs.LastModified(artifact)
 }
 
 // TODO: support a .mountignore file used to ignore mtime of files
 func (t *Task) mountsLastModified(ctx *context.ExecuteContext) (time.Time, error) {
 	mountPaths := []string{}
 	ctx.Resources.EachMount(t.config.Mounts, func(name string, mount *config.MountConfig) {
-		mountPaths = append(mountPaths, mount.Bind)
+		if mount.Volume == "" {
+			mountPaths = append(mountPaths, mount.Bind)
+		}
 	})
 	return fs.LastModified(mountPaths...)
 }
@@ -149,27 +319,59 @@ func (t *Task) mountsLastModified(ctx *context.ExecuteContext) (time.Time, error
 func (t *Task) bindMounts(ctx *context.ExecuteContext) []string {
 	binds := []string{}
 	ctx.Resources.EachMount(t.config.Mounts, func(name string, config *config.MountConfig) {
+		if config.Volume != "" {
+			volume := ctx.Resources.Volume(config.Volume)
+			binds = append(binds, mount.AsVolumeBind(config, volume))
+			return
+		}
 		binds = append(binds, mount.AsBind(config, ctx.WorkingDir))
 	})
 	return binds
 }
 
-func (t *Task) runContainer(ctx *context.ExecuteContext) error {
+func (t *Task) runContainer(ctx *context.ExecuteContext) (err error) {
 	interactive := t.config.Interactive
 	name := ContainerName(ctx, t.name)
-	container, err := ctx.Client.CreateContainer(t.createOptions(ctx, name))
+	keep := keepContainer(ctx, t.name)
+	container, err := t.attemptContainer(ctx, name, keep)
 	if err != nil {
-		return fmt.Errorf("Failed creating container %q: %s", name, err)
+		return err
 	}
 
-	chanSig := t.forwardSignals(ctx.Client, container.ID)
-	defer signal.Stop(chanSig)
-	defer RemoveContainer(t.logger(), ctx.Client, container.ID, true)
+	if t.config.ForwardSignals.Value() {
+		chanSig := t.forwardSignals(ctx.Client, container.ID)
+		defer stopForwardingSignals(chanSig)
+	}
+	defer func() {
+		if _, exited := err.(*exitError); exited && t.config.RetryReuseContainer {
+			// Keep the container so that the next attempt can start it again
+			t.retryContainer = container.ID
+			return
+		}
+		switch {
+		case ctx.NoRemove:
+			t.logger().Infof("Kept container %s", container.ID)
+		case !keep && t.service == "":
+			RemoveContainer(t.logger(), ctx.Client, container.ID, true)
+		}
+	}()
+	defer func() {
+		if err != nil {
+			t.runFailureHook(ctx, container.ID)
+		}
+	}()
+
+	stdout, stderr, flush := t.outputStreams(ctx)
+	defer flush()
+	captured := &bytes.Buffer{}
+	if t.config.Capture != "" {
+		stdout = io.MultiWriter(captured, stdout)
+	}
 
-	_, err = ctx.Client.AttachToContainerNonBlocking(docker.AttachToContainerOptions{
+	waiter, err := ctx.Client.AttachToContainerNonBlocking(docker.AttachToContainerOptions{
 		Container:    container.ID,
-		OutputStream: os.Stdout,
-		ErrorStream:  os.Stderr,
+		OutputStream: stdout,
+		ErrorStream:  stderr,
 		InputStream:  ioutil.NopCloser(os.Stdin),
 		Stream:       true,
 		Stdin:        t.config.Interactive,
@@ -198,19 +400,160 @@ func (t *Task) runContainer(ctx *context.ExecuteContext) error {
 		return fmt.Errorf("Failed starting container %q: %s", name, err)
 	}
 
-	return t.wait(ctx.Client, container.ID)
+	if !t.config.WaitFor.IsZero() || t.detach {
+		if !t.config.WaitFor.IsZero() {
+			if err := t.waitFor(ctx, container.ID); err != nil {
+				return err
+			}
+		}
+		t.service = container.ID
+		return nil
+	}
+	if err := t.wait(ctx.Client, container.ID); err != nil {
+		return err
+	}
+	if t.config.ArtifactFromContainer != "" {
+		if err := t.copyArtifact(ctx, container.ID); err != nil {
+			return err
+		}
+	}
+	if t.config.Capture != "" {
+		// Wait for the attached streams to finish copying the output
+		waiter.Wait()
+		ctx.Env.SetCaptured(t.config.Capture, strings.TrimSpace(captured.String()))
+	}
+	return nil
+}
+
+// attemptContainer returns the container to run. The container kept by a
+// failed attempt is returned when there is one, otherwise a new container is
+// created.
+func (t *Task) attemptContainer(
+	ctx *context.ExecuteContext,
+	name string,
+	keep bool,
+) (*docker.Container, error) {
+	if t.retryContainer != "" {
+		container := &docker.Container{ID: t.retryContainer}
+		t.retryContainer = ""
+		t.logger().Infof("Starting container %s again", container.ID)
+		return container, nil
+	}
+
+	opts, err := t.createOptions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Config.Image, err = imageID(ctx.Client, opts.Config.Image); err != nil {
+		return nil, err
+	}
+	if keep {
+		// Remove the container kept from a previous run
+		RemoveContainer(t.logger(), ctx.Client, name, false)
+	}
+	container, err := ctx.Client.CreateContainer(opts)
+	if err != nil {
+		return nil, fmt.Errorf("Failed creating container %q: %s", name, err)
+	}
+	return container, nil
+}
+
+// keepContainer returns true if the container of the job should not be removed
+// when it exits
+func keepContainer(ctx *context.ExecuteContext, name string) bool {
+	return ctx.NoRemove || ctx.Resources.KeepContainer(name)
+}
+
+// imageID returns the id of the image which the image name refers to. The
+// container is created from the image id, so that it uses the same image even
+// if the image name is tagged again before the container is created.
+func imageID(client client.DockerClient, imageName string) (string, error) {
+	image, err := client.InspectImage(imageName)
+	if err != nil {
+		return "", fmt.Errorf("Failed to inspect image %q: %s", imageName, err)
+	}
+	return image.ID, nil
+}
+
+type flusher interface {
+	Flush() error
+}
+
+// outputStreams returns the writers for the stdout and stderr of the container,
+// and a function which flushes any buffered output. When jobs run concurrently
+// the output of non-interactive jobs is prefixed with the task name, unless the
+// context has Events, which receive the output instead. When a mask is
+// configured the output of non-interactive jobs is masked.
+func (t *Task) outputStreams(ctx *context.ExecuteContext) (io.Writer, io.Writer, func()) {
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	if t.config.Interactive {
+		return stdout, stderr, func() {}
+	}
+
+	flushers := []flusher{}
+	switch {
+	case ctx.Events != nil:
+		name := t.Name()
+		stdout = ctx.Events.Output(name.Name(), name.Resource(), "stdout")
+		stderr = ctx.Events.Output(name.Name(), name.Resource(), "stderr")
+	case ctx.Limit("job") > 1:
+		label := fmt.Sprintf("[%s] ", t.name)
+		outPrefix, errPrefix := prefix.NewWriter(stdout, label), prefix.NewWriter(stderr, label)
+		stdout, stderr = outPrefix, errPrefix
+		flushers = append(flushers, outPrefix, errPrefix)
+	}
+	if ctx.Masker != nil {
+		outMask, errMask := mask.NewWriter(stdout, ctx.Masker), mask.NewWriter(stderr, ctx.Masker)
+		stdout, stderr = outMask, errMask
+		flushers = append([]flusher{outMask, errMask}, flushers...)
+	}
+	return stdout, stderr, func() {
+		for _, writer := range flushers {
+			writer.Flush()
+		}
+	}
+}
+
+// Command returns the entrypoint and command of the container. If the job, or
+// meta.default-shell, sets a shell the command is run as "<shell> -c <command>".
+func (t *Task) Command(ctx *context.ExecuteContext) ([]string, []string) {
+	return t.commandFor(ctx, t.config.Command)
+}
+
+// commandFor returns the entrypoint and command of a container which runs
+// command, using the entrypoint or shell of the job
+func (t *Task) commandFor(ctx *context.ExecuteContext, command config.ShlexSlice) ([]string, []string) {
+	shell := t.config.Shell.Value()
+	if len(shell) == 0 && t.config.Entrypoint.Empty() {
+		shell = ctx.DefaultShell
+	}
+	if len(shell) == 0 || command.Empty() {
+		return t.config.Entrypoint.Value(), command.Value()
+	}
+	entrypoint := append(append([]string{}, shell...), "-c")
+	return entrypoint, []string{command.String()}
 }
 
-func (t *Task) createOptions(ctx *context.ExecuteContext, name string) docker.CreateContainerOptions {
+func (t *Task) createOptions(ctx *context.ExecuteContext, name string) (docker.CreateContainerOptions, error) {
 	interactive := t.config.Interactive
 
 	imageName := image.GetImageName(ctx, ctx.Resources.Image(t.config.Use))
 	t.logger().Debugf("Image name %q", imageName)
+	entrypoint, command := t.Command(ctx)
+	env, err := t.Environment(ctx)
+	if err != nil {
+		return docker.CreateContainerOptions{}, err
+	}
+	exposedPorts, portBindings, err := publishedPorts(t.config.Ports)
+	if err != nil {
+		return docker.CreateContainerOptions{}, err
+	}
+	cpuPeriod, cpuQuota := t.config.CPUQuota()
 	// TODO: only set Tty if running in a tty
 	opts := docker.CreateContainerOptions{
 		Name: name,
 		Config: &docker.Config{
-			Cmd:          t.config.Command.Value(),
+			Cmd:          command,
 			Image:        imageName,
 			OpenStdin:    interactive,
 			Tty:          interactive,
@@ -218,39 +561,135 @@ func (t *Task) createOptions(ctx *context.ExecuteContext, name string) docker.Cr
 			StdinOnce:    interactive,
 			AttachStderr: true,
 			AttachStdout: true,
-			Env:          t.config.Env,
-			Entrypoint:   t.config.Entrypoint.Value(),
+			Env:          env,
+			Entrypoint:   entrypoint,
 			WorkingDir:   t.config.WorkingDir,
+			User:         t.config.User,
+			Labels:       ctx.Labels,
+			StopSignal:   t.config.StopSignal,
+			MacAddress:   t.config.MacAddress,
+			ExposedPorts: exposedPorts,
 		},
 		HostConfig: &docker.HostConfig{
-			Binds:       t.bindMounts(ctx),
-			Privileged:  t.config.Privileged,
-			NetworkMode: t.config.NetMode,
+			Binds:          t.bindMounts(ctx),
+			Privileged:     t.config.Privileged,
+			CapAdd:         t.config.CapAdd,
+			CapDrop:        t.config.CapDrop,
+			GroupAdd:       t.config.Groups,
+			NetworkMode:    t.networkMode(ctx),
+			PortBindings:   portBindings,
+			OOMKillDisable: t.config.OOMKillDisable,
+			OomScoreAdj:    t.config.OOMScoreAdj,
+			PidsLimit:      int64(t.config.PidsLimit),
+			Memory:         t.config.MemoryBytes(),
+			CPUPeriod:      cpuPeriod,
+			CPUQuota:       cpuQuota,
+			Ulimits:        ulimits(t.config),
+			VolumesFrom:    t.volumesFrom(ctx),
 		},
 	}
-	opts = provideDocker(opts)
-	return opts
+	opts = provideDocker(ctx.Engine, opts)
+	if t.config.ProvideSSHAgent {
+		if opts, err = provideSSHAgent(opts); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
 }
 
-func provideDocker(opts docker.CreateContainerOptions) docker.CreateContainerOptions {
-	dockerHostEnv := os.Getenv("DOCKER_HOST")
-	switch {
-	case dockerHostEnv != "":
-		opts.Config.Env = append(opts.Config.Env, "DOCKER_HOST="+dockerHostEnv)
+func ulimits(conf *config.JobConfig) []docker.ULimit {
+	values := []docker.ULimit{}
+	for _, ulimit := range conf.UlimitValues() {
+		values = append(values, docker.ULimit{
+			Name: ulimit.Name,
+			Soft: ulimit.Soft,
+			Hard: ulimit.Hard,
+		})
+	}
+	return values
+}
+
+// volumesFrom returns the names of the containers of the jobs in volumes-from
+func (t *Task) volumesFrom(ctx *context.ExecuteContext) []string {
+	containers := []string{}
+	for _, name := range t.config.VolumesFrom {
+		containers = append(containers, ContainerName(ctx, name))
+	}
+	return containers
+}
+
+func (t *Task) networkMode(ctx *context.ExecuteContext) string {
+	if name := t.config.NetModeContainer(); name != "" {
+		return "container:" + ContainerName(ctx, name)
+	}
+	return t.config.NetMode
+}
+
+// publishedPorts returns the exposed ports and port bindings of the container
+// for a list of port mappings
+func publishedPorts(
+	ports []string,
+) (map[docker.Port]struct{}, map[docker.Port][]docker.PortBinding, error) {
+	if len(ports) == 0 {
+		return nil, nil, nil
+	}
+	exposed := make(map[docker.Port]struct{})
+	bindings := make(map[docker.Port][]docker.PortBinding)
+	for _, spec := range ports {
+		port, err := config.ParsePort(spec)
+		if err != nil {
+			return nil, nil, err
+		}
+		containerPort := docker.Port(port.ContainerPort + "/" + port.Protocol)
+		exposed[containerPort] = struct{}{}
+		bindings[containerPort] = append(bindings[containerPort], docker.PortBinding{
+			HostIP:   port.HostIP,
+			HostPort: port.HostPort,
+		})
+	}
+	return exposed, bindings, nil
+}
+
+// provideDocker exposes the API of the engine to the container. The socket of
+// a Podman engine is mounted at the path of the docker socket, so that a docker
+// client in the container uses it.
+func provideDocker(engine string, opts docker.CreateContainerOptions) docker.CreateContainerOptions {
+	hostVariable := "DOCKER_HOST"
+	if engine == client.EnginePodman {
+		hostVariable = "CONTAINER_HOST"
+	}
+	switch endpoint := os.Getenv(hostVariable); {
+	case endpoint != "":
+		opts.Config.Env = append(opts.Config.Env, "DOCKER_HOST="+endpoint)
 	default:
 		path := dopts.DefaultUnixSocket
-		opts.HostConfig.Binds = append(opts.HostConfig.Binds, path+":"+path)
+		opts.HostConfig.Binds = append(opts.HostConfig.Binds,
+			client.SocketPath(engine)+":"+path)
 	}
 	return opts
 }
 
+func provideSSHAgent(opts docker.CreateContainerOptions) (docker.CreateContainerOptions, error) {
+	path := os.Getenv("SSH_AUTH_SOCK")
+	if path == "" {
+		return opts, fmt.Errorf(
+			"provide-ssh-agent requires an SSH agent, but $SSH_AUTH_SOCK is not set")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return opts, fmt.Errorf("SSH agent socket %q is not available: %s", path, err)
+	}
+	opts.Config.Env = append(opts.Config.Env, "SSH_AUTH_SOCK="+path)
+	opts.HostConfig.Binds = append(opts.HostConfig.Binds, path+":"+path)
+	return opts, nil
+}
+
 func (t *Task) wait(client client.DockerClient, containerID string) error {
 	status, err := client.WaitContainer(containerID)
 	if err != nil {
 		return fmt.Errorf("Failed to wait on container exit: %s", err)
 	}
 	if status != 0 {
-		return fmt.Errorf("Exited with non-zero status code %d", status)
+		return &exitError{status: status}
 	}
 	return nil
 }
@@ -270,6 +709,7 @@ func (t *Task) forwardSignals(client client.DockerClient, containerID string) ch
 				"Failed to convert signal from %T", sig)
 			return
 		}
+		intSig = forwardedSignal(t.config, intSig)
 
 		if err := client.KillContainer(docker.KillContainerOptions{
 			ID:     containerID,
@@ -288,12 +728,35 @@ func (t *Task) forwardSignals(client client.DockerClient, containerID string) ch
 	return chanSig
 }
 
+// stopForwardingSignals stops sending signals to chanSig, and closes it so that
+// the goroutine started by forwardSignals returns
+func stopForwardingSignals(chanSig chan<- os.Signal) {
+	signal.Stop(chanSig)
+	close(chanSig)
+}
+
 // Dependencies returns the list of dependencies
 func (t *Task) Dependencies() []string {
 	return t.config.Dependencies()
 }
 
-// Stop the task
+// Stop the task. The container left running by a job with wait-for is sent the
+// stop signal, killed if it does not exit before the stop timeout, and then
+// removed.
 func (t *Task) Stop(ctx *context.ExecuteContext) error {
+	if t.service == "" {
+		return nil
+	}
+	t.logger().Info("Stopping")
+	err := ctx.Client.StopContainer(t.service, stopTimeout(t.config))
+	switch err.(type) {
+	case nil, *docker.ContainerNotRunning:
+	default:
+		t.logger().Warnf("Failed to stop container: %s", err)
+	}
+	if !keepContainer(ctx, t.name) {
+		RemoveContainer(t.logger(), ctx.Client, t.service, true)
+	}
+	t.service = ""
 	return nil
 }
@@ -0,0 +1,544 @@
+package job
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/client"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCreateOptions() docker.CreateContainerOptions {
+	return docker.CreateContainerOptions{
+		Config:     &docker.Config{},
+		HostConfig: &docker.HostConfig{},
+	}
+}
+
+func TestProvideDockerPodman(t *testing.T) {
+	defer os.Setenv("CONTAINER_HOST", os.Getenv("CONTAINER_HOST"))
+	defer os.Setenv("XDG_RUNTIME_DIR", os.Getenv("XDG_RUNTIME_DIR"))
+	os.Unsetenv("CONTAINER_HOST")
+	os.Unsetenv("XDG_RUNTIME_DIR")
+
+	opts := provideDocker(client.EnginePodman, newCreateOptions())
+	assert.Equal(t, []string{"/run/podman/podman.sock:/var/run/docker.sock"},
+		opts.HostConfig.Binds)
+
+	os.Setenv("CONTAINER_HOST", "tcp://podman.example.com:8080")
+	opts = provideDocker(client.EnginePodman, newCreateOptions())
+	assert.Equal(t, []string{"DOCKER_HOST=tcp://podman.example.com:8080"}, opts.Config.Env)
+}
+
+func TestProvideSSHAgent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssh-agent-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "agent.sock")
+	assert.Nil(t, ioutil.WriteFile(socket, []byte{}, 0600))
+
+	defer os.Setenv("SSH_AUTH_SOCK", os.Getenv("SSH_AUTH_SOCK"))
+	os.Setenv("SSH_AUTH_SOCK", socket)
+
+	opts, err := provideSSHAgent(newCreateOptions())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"SSH_AUTH_SOCK=" + socket}, opts.Config.Env)
+	assert.Equal(t, []string{socket + ":" + socket}, opts.HostConfig.Binds)
+}
+
+func TestProvideSSHAgentMissingSocket(t *testing.T) {
+	defer os.Setenv("SSH_AUTH_SOCK", os.Getenv("SSH_AUTH_SOCK"))
+	os.Unsetenv("SSH_AUTH_SOCK")
+
+	_, err := provideSSHAgent(newCreateOptions())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "$SSH_AUTH_SOCK is not set")
+}
+
+func TestCreateOptionsPorts(t *testing.T) {
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n" +
+			"job=test:\n  use: builder\n  ports: ['8080:80', '127.0.0.1::53/udp', '9000:80']\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, nil, execenv.NewExecEnv("exec", "project", "."), false)
+	task := NewTask("test", conf.Resources["test"].(*config.JobConfig))
+
+	opts, err := task.createOptions(ctx, "test")
+	assert.Nil(t, err)
+	assert.Equal(t, map[docker.Port]struct{}{"80/tcp": {}, "53/udp": {}}, opts.Config.ExposedPorts)
+	assert.Equal(t, map[docker.Port][]docker.PortBinding{
+		"80/tcp": {{HostPort: "8080"}, {HostPort: "9000"}},
+		"53/udp": {{HostIP: "127.0.0.1"}},
+	}, opts.HostConfig.PortBindings)
+}
+
+func loadJob(t *testing.T, fields string) *config.JobConfig {
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n  pull: once\n" +
+			"job=test:\n  use: builder\n" + fields))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return conf.Resources["test"].(*config.JobConfig)
+}
+
+func TestCommandWithShell(t *testing.T) {
+	task := NewTask("test", loadJob(t, "  command: echo $HOME && ls\n  shell: bash -e\n"))
+	entrypoint, command := task.Command(&context.ExecuteContext{DefaultShell: []string{"sh"}})
+	assert.Equal(t, []string{"bash", "-e", "-c"}, entrypoint)
+	assert.Equal(t, []string{"echo $HOME && ls"}, command)
+}
+
+func TestCommandWithDefaultShell(t *testing.T) {
+	task := NewTask("test", loadJob(t, "  command: echo ok\n"))
+	entrypoint, command := task.Command(&context.ExecuteContext{DefaultShell: []string{"sh"}})
+	assert.Equal(t, []string{"sh", "-c"}, entrypoint)
+	assert.Equal(t, []string{"echo ok"}, command)
+
+	task = NewTask("test", loadJob(t, "  command: echo ok\n  entrypoint: /init\n"))
+	entrypoint, command = task.Command(&context.ExecuteContext{DefaultShell: []string{"sh"}})
+	assert.Equal(t, []string{"/init"}, entrypoint)
+	assert.Equal(t, []string{"echo", "ok"}, command)
+}
+
+func TestIsStaleWithStaleCheckContent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "job-stale-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	source, artifact := filepath.Join(dir, "source"), filepath.Join(dir, "artifact")
+	assert.Nil(t, ioutil.WriteFile(source, []byte("src"), 0644))
+	ctx := context.NewExecuteContext(config.NewConfig(), nil, nil, false)
+	ctx.WorkingDir = dir
+	task := NewTask("test", &config.JobConfig{
+		Use:        "builder",
+		Artifact:   artifact,
+		Sources:    []string{source},
+		StaleCheck: "content",
+	})
+
+	stale, err := task.isStale(ctx)
+	assert.Nil(t, err)
+	assert.True(t, stale)
+
+	assert.Nil(t, ioutil.WriteFile(artifact, []byte("out"), 0644))
+	task.recordContent(ctx)
+
+	// A source which is newer than the artifact, but has the same content
+	newer := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(source, newer, newer))
+	stale, err = task.isStale(ctx)
+	assert.Nil(t, err)
+	assert.False(t, stale)
+
+	assert.Nil(t, ioutil.WriteFile(source, []byte("changed"), 0644))
+	stale, err = task.isStale(ctx)
+	assert.Nil(t, err)
+	assert.True(t, stale)
+}
+
+func TestIsStaleWithStaleCheckContentCommandChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "job-stale-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	source, artifact := filepath.Join(dir, "source"), filepath.Join(dir, "artifact")
+	assert.Nil(t, ioutil.WriteFile(source, []byte("src"), 0644))
+	assert.Nil(t, ioutil.WriteFile(artifact, []byte("out"), 0644))
+	ctx := context.NewExecuteContext(config.NewConfig(), nil, nil, false)
+	ctx.WorkingDir = dir
+	newTask := func(command string) *Task {
+		conf := loadJob(t, "  stale-check: content\n  command: "+command+"\n")
+		conf.Artifact = artifact
+		conf.Sources = []string{source}
+		return NewTask("test", conf)
+	}
+	newTask("make build").recordContent(ctx)
+
+	stale, err := newTask("make build").isStale(ctx)
+	assert.Nil(t, err)
+	assert.False(t, stale)
+
+	stale, err = newTask("make build-all").isStale(ctx)
+	assert.Nil(t, err)
+	assert.True(t, stale)
+}
+
+func TestVolumesFrom(t *testing.T) {
+	task := NewTask("test", &config.JobConfig{VolumesFrom: []string{"build"}})
+	ctx := &context.ExecuteContext{Env: execenv.NewExecEnv("exec", "project", ".")}
+	assert.Equal(t, []string{"project-exec-build"}, task.volumesFrom(ctx))
+}
+
+func TestCreateOptionsCapabilities(t *testing.T) {
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n" +
+			"job=test:\n  use: builder\n  cap-add: [NET_ADMIN]\n  cap-drop: [MKNOD]\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, nil, execenv.NewExecEnv("exec", "project", "."), false)
+	task := NewTask("test", conf.Resources["test"].(*config.JobConfig))
+
+	opts, err := task.createOptions(ctx, "test")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"NET_ADMIN"}, opts.HostConfig.CapAdd)
+	assert.Equal(t, []string{"MKNOD"}, opts.HostConfig.CapDrop)
+}
+
+func TestCreateOptionsGroups(t *testing.T) {
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n" +
+			"job=test:\n  use: builder\n  groups: [docker, '999']\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, nil, execenv.NewExecEnv("exec", "project", "."), false)
+	task := NewTask("test", conf.Resources["test"].(*config.JobConfig))
+
+	opts, err := task.createOptions(ctx, "test")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"docker", "999"}, opts.HostConfig.GroupAdd)
+}
+
+func TestCreateOptionsMacAddress(t *testing.T) {
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n" +
+			"job=test:\n  use: builder\n  mac-address: '02:42:ac:11:00:02'\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, nil, execenv.NewExecEnv("exec", "project", "."), false)
+	task := NewTask("test", conf.Resources["test"].(*config.JobConfig))
+
+	opts, err := task.createOptions(ctx, "test")
+	assert.Nil(t, err)
+	assert.Equal(t, "02:42:ac:11:00:02", opts.Config.MacAddress)
+}
+
+func TestIsStaleUsesResolvedPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "job-stale-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	defer os.Unsetenv("DOBI_TEST_DIR")
+	os.Setenv("DOBI_TEST_DIR", dir)
+
+	source, artifact := filepath.Join(dir, "source"), filepath.Join(dir, "artifact")
+	assert.Nil(t, ioutil.WriteFile(source, []byte("src"), 0644))
+	assert.Nil(t, ioutil.WriteFile(artifact, []byte("out"), 0644))
+	older := time.Now().Add(-time.Minute)
+	assert.Nil(t, os.Chtimes(source, older, older))
+
+	resource, err := config.ResolveResource("test", &config.JobConfig{
+		Use:      "builder",
+		Artifact: "{env.DOBI_TEST_DIR}/artifact",
+		Sources:  []string{"{env.DOBI_TEST_DIR}/source"},
+	}, execenv.NewExecEnv("exec", "project", dir))
+	assert.Nil(t, err)
+	ctx := context.NewExecuteContext(config.NewConfig(), nil, nil, false)
+	task := NewTask("test", resource.(*config.JobConfig))
+
+	stale, err := task.isStale(ctx)
+	assert.Nil(t, err)
+	assert.False(t, stale)
+
+	newer := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(source, newer, newer))
+	stale, err = task.isStale(ctx)
+	assert.Nil(t, err)
+	assert.True(t, stale)
+}
+
+func TestIsStaleWithArtifactSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "job-stale-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"app.go", "app", "docs.md", "docs"} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+	older := time.Now().Add(-time.Minute)
+	assert.Nil(t, os.Chtimes(filepath.Join(dir, "app.go"), older, older))
+	assert.Nil(t, os.Chtimes(filepath.Join(dir, "docs.md"), older, older))
+
+	job := &config.JobConfig{Use: "builder"}
+	assert.Nil(t, job.Artifacts.TransformConfig(reflect.ValueOf(map[interface{}]interface{}{
+		filepath.Join(dir, "app"):  []interface{}{filepath.Join(dir, "app.go")},
+		filepath.Join(dir, "docs"): []interface{}{filepath.Join(dir, "docs.md")},
+	})))
+	ctx := context.NewExecuteContext(config.NewConfig(), nil, nil, false)
+	task := NewTask("test", job)
+
+	stale, err := task.isStale(ctx)
+	assert.Nil(t, err)
+	assert.False(t, stale)
+
+	// The docs source is newer than the app artifact, but is not one of its
+	// sources
+	newer := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(filepath.Join(dir, "docs.md"), newer, newer))
+	assert.Nil(t, os.Chtimes(filepath.Join(dir, "docs"), newer, newer))
+	stale, err = task.isStale(ctx)
+	assert.Nil(t, err)
+	assert.False(t, stale)
+
+	assert.Nil(t, os.Chtimes(filepath.Join(dir, "app.go"), newer, newer))
+	stale, err = task.isStale(ctx)
+	assert.Nil(t, err)
+	assert.True(t, stale)
+}
+
+func newAttempts(errs ...error) (func(*context.ExecuteContext) error, *int) {
+	count := 0
+	return func(ctx *context.ExecuteContext) error {
+		err := errs[count]
+		count++
+		return err
+	}, &count
+}
+
+func TestRunWithRetriesSucceedsOnSecondAttempt(t *testing.T) {
+	task := NewTask("test", &config.JobConfig{Retries: 2})
+	run, count := newAttempts(&exitError{status: 1}, nil)
+
+	assert.Nil(t, task.runWithRetries(&context.ExecuteContext{}, run))
+	assert.Equal(t, 2, *count)
+}
+
+func TestRunWithRetriesExhaustsRetries(t *testing.T) {
+	task := NewTask("test", &config.JobConfig{Retries: 2})
+	run, count := newAttempts(
+		&exitError{status: 1}, &exitError{status: 2}, &exitError{status: 3})
+
+	err := task.runWithRetries(&context.ExecuteContext{}, run)
+	assert.EqualError(t, err,
+		"Attempt 3 of 3 failed: Exited with non-zero status code 3")
+	assert.Equal(t, 3, *count)
+}
+
+func TestRunWithRetriesDoesNotRetryOtherErrors(t *testing.T) {
+	task := NewTask("test", &config.JobConfig{Retries: 2})
+	run, count := newAttempts(fmt.Errorf("Failed creating container"))
+
+	err := task.runWithRetries(&context.ExecuteContext{}, run)
+	assert.Error(t, err)
+	assert.Equal(t, 1, *count)
+}
+
+func TestRunWithRetriesRetriesTimedOutAttempt(t *testing.T) {
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+	mockClient := client.NewMockDockerClient(mock)
+
+	conf := &config.JobConfig{Retries: 1}
+	assert.Nil(t, conf.Timeout.TransformConfig(reflect.ValueOf("10ms")))
+	task := NewTask("test", conf)
+	ctx := &context.ExecuteContext{
+		Client: mockClient,
+		Env:    execenv.NewExecEnv("exec", "project", "."),
+	}
+
+	stopped := make(chan struct{})
+	count := 0
+	run := func(ctx *context.ExecuteContext) error {
+		count++
+		if count == 1 {
+			<-stopped
+			return &exitError{status: 137}
+		}
+		return nil
+	}
+	name := ContainerName(ctx, "test")
+	mockClient.EXPECT().StopContainer(name, uint(10)).Do(
+		func(string, uint) { close(stopped) }).Return(nil)
+	mockClient.EXPECT().RemoveContainer(gomock.Any()).Return(nil)
+
+	assert.Nil(t, task.runWithRetries(ctx, run))
+	assert.Equal(t, 2, count)
+}
+
+func TestRunWithRetriesReuseContainer(t *testing.T) {
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+	mockClient := client.NewMockDockerClient(mock)
+
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n" +
+			"job=test:\n  use: builder\n  retries: 2\n  retry-reuse-container: true\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, mockClient, execenv.NewExecEnv("exec", "project", "."), false)
+	task := NewTask("test", conf.Resources["test"].(*config.JobConfig))
+
+	// The container is created once, started for each attempt, and removed
+	// after the last attempt
+	mockClient.EXPECT().InspectImage("builder:project-exec").Return(
+		&docker.Image{ID: "sha256:builder"}, nil)
+	mockClient.EXPECT().CreateContainer(gomock.Any()).Return(
+		&docker.Container{ID: "container-id"}, nil).Times(1)
+	mockClient.EXPECT().AttachToContainerNonBlocking(gomock.Any()).Return(
+		closeWaiter{}, nil).Times(3)
+	mockClient.EXPECT().StartContainer("container-id", nil).Return(nil).Times(3)
+	gomock.InOrder(
+		mockClient.EXPECT().WaitContainer("container-id").Return(1, nil),
+		mockClient.EXPECT().WaitContainer("container-id").Return(2, nil),
+		mockClient.EXPECT().WaitContainer("container-id").Return(3, nil),
+	)
+	mockClient.EXPECT().RemoveContainer(gomock.Any()).Do(
+		func(opts docker.RemoveContainerOptions) {
+			assert.Equal(t, "container-id", opts.ID)
+		}).Return(nil).Times(1)
+
+	err = task.runWithRetries(ctx, task.runContainer)
+	assert.EqualError(t, err,
+		"Attempt 3 of 3 failed: Exited with non-zero status code 3")
+	assert.Equal(t, "", task.retryContainer)
+}
+
+func TestRunWithTimeoutWithinTimeout(t *testing.T) {
+	conf := &config.JobConfig{}
+	assert.Nil(t, conf.Timeout.TransformConfig(reflect.ValueOf("1s")))
+	task := NewTask("test", conf)
+	run, count := newAttempts(nil)
+
+	assert.Nil(t, task.runWithTimeout(&context.ExecuteContext{}, run))
+	assert.Equal(t, 1, *count)
+}
+
+type closeWaiter struct{}
+
+func (w closeWaiter) Close() error {
+	return nil
+}
+
+func (w closeWaiter) Wait() error {
+	return nil
+}
+
+func TestRunContainerCapturesStdout(t *testing.T) {
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+	mockClient := client.NewMockDockerClient(mock)
+
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n" +
+			"job=version:\n  use: builder\n  capture: build.version\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, mockClient, execenv.NewExecEnv("exec", "project", "."), false)
+	task := NewTask("version", conf.Resources["version"].(*config.JobConfig))
+
+	mockClient.EXPECT().InspectImage("builder:project-exec").Return(
+		&docker.Image{ID: "sha256:builder"}, nil)
+	mockClient.EXPECT().CreateContainer(gomock.Any()).Return(
+		&docker.Container{ID: "container-id"}, nil)
+	mockClient.EXPECT().AttachToContainerNonBlocking(gomock.Any()).Do(
+		func(opts docker.AttachToContainerOptions) {
+			opts.OutputStream.Write([]byte("1.2.3\n"))
+		}).Return(closeWaiter{}, nil)
+	mockClient.EXPECT().StartContainer("container-id", nil).Return(nil)
+	mockClient.EXPECT().WaitContainer("container-id").Return(0, nil)
+	mockClient.EXPECT().RemoveContainer(gomock.Any()).Return(nil)
+
+	assert.Nil(t, task.runContainer(ctx))
+	value, ok := ctx.Env.Captured("build.version")
+	assert.True(t, ok)
+	assert.Equal(t, "1.2.3", value)
+}
+
+func TestRunContainerCreatesContainerFromImageID(t *testing.T) {
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+	mockClient := client.NewMockDockerClient(mock)
+
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n  tags: [latest]\n" +
+			"job=test:\n  use: builder\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, mockClient, execenv.NewExecEnv("exec", "project", "."), false)
+	task := NewTask("test", conf.Resources["test"].(*config.JobConfig))
+
+	// The image is looked up once, so a new image tagged as builder:latest by
+	// another process before the container is created is not used
+	mockClient.EXPECT().InspectImage("builder:latest").Return(
+		&docker.Image{ID: "sha256:first"}, nil).Times(1)
+	mockClient.EXPECT().CreateContainer(gomock.Any()).Do(
+		func(opts docker.CreateContainerOptions) {
+			assert.Equal(t, "sha256:first", opts.Config.Image)
+		}).Return(&docker.Container{ID: "container-id"}, nil)
+	mockClient.EXPECT().AttachToContainerNonBlocking(gomock.Any()).Return(closeWaiter{}, nil)
+	mockClient.EXPECT().StartContainer("container-id", nil).Return(nil)
+	mockClient.EXPECT().WaitContainer("container-id").Return(0, nil)
+	mockClient.EXPECT().RemoveContainer(gomock.Any()).Return(nil)
+
+	assert.Nil(t, task.runContainer(ctx))
+}
+
+func TestRunContainerNoRemoveKeepsContainer(t *testing.T) {
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+	mockClient := client.NewMockDockerClient(mock)
+
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n" +
+			"job=test:\n  use: builder\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, mockClient, execenv.NewExecEnv("exec", "project", "."), false)
+	ctx.NoRemove = true
+	task := NewTask("test", conf.Resources["test"].(*config.JobConfig))
+
+	mockClient.EXPECT().InspectImage("builder:project-exec").Return(
+		&docker.Image{ID: "sha256:builder"}, nil)
+	// Only the container kept by a previous run is removed
+	mockClient.EXPECT().RemoveContainer(gomock.Any()).Do(
+		func(opts docker.RemoveContainerOptions) {
+			assert.Equal(t, "project-exec-test", opts.ID)
+		}).Return(&docker.NoSuchContainer{ID: "project-exec-test"}).Times(1)
+	mockClient.EXPECT().CreateContainer(gomock.Any()).Return(
+		&docker.Container{ID: "container-id"}, nil)
+	mockClient.EXPECT().AttachToContainerNonBlocking(gomock.Any()).Return(closeWaiter{}, nil)
+	mockClient.EXPECT().StartContainer("container-id", nil).Return(nil)
+	mockClient.EXPECT().WaitContainer("container-id").Return(0, nil)
+
+	assert.Nil(t, task.runContainer(ctx))
+}
+
+func TestRecordsContentWithMetaStaleCheck(t *testing.T) {
+	ctx := context.NewExecuteContext(config.NewConfig(), nil, nil, false)
+	ctx.StaleCheck = "content"
+
+	task := NewTask("test", &config.JobConfig{Artifact: "dist/app"})
+	assert.True(t, task.recordsContent(ctx))
+
+	task = NewTask("test", &config.JobConfig{Artifact: "dist/app", StaleCheck: "mtime"})
+	assert.False(t, task.recordsContent(ctx))
+
+	task = NewTask("test", &config.JobConfig{})
+	assert.False(t, task.recordsContent(ctx))
+}
@@ -0,0 +1,117 @@
+package job
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// defaultStopTimeout is the time to wait for the container to stop before it
+// is killed, when the job does not set stop-timeout
+const defaultStopTimeout = 10 * time.Second
+
+// stopTimeout returns the number of seconds to wait for the container of the
+// job to stop before it is killed
+func stopTimeout(conf *config.JobConfig) uint {
+	timeout := conf.StopTimeout.Duration()
+	if timeout == 0 {
+		timeout = defaultStopTimeout
+	}
+	// Round up, so that a timeout of less than a second is not 0
+	return uint((timeout + time.Second - 1) / time.Second)
+}
+
+// signalNames are the signals which may be used by name when a stop-signal
+// is forwarded to the container. Other signals may be used by number.
+var signalNames = map[string]syscall.Signal{
+	"ABRT": syscall.SIGABRT,
+	"ALRM": syscall.SIGALRM,
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"KILL": syscall.SIGKILL,
+	"QUIT": syscall.SIGQUIT,
+	"TERM": syscall.SIGTERM,
+}
+
+// forwardedSignal returns the signal sent to the container of the job when
+// dobi receives sig. SIGTERM is replaced by the stop-signal of the job, so
+// that the container is stopped the same way as by the stop action.
+func forwardedSignal(conf *config.JobConfig, sig syscall.Signal) syscall.Signal {
+	if sig != syscall.SIGTERM || conf.StopSignal == "" {
+		return sig
+	}
+	if number, err := strconv.Atoi(conf.StopSignal); err == nil {
+		return syscall.Signal(number)
+	}
+	if stopSignal, ok := signalNames[strings.TrimPrefix(conf.StopSignal, "SIG")]; ok {
+		return stopSignal
+	}
+	return sig
+}
+
+// StopTask is a task which stops and removes the container of a job which is
+// still running, for example a long running job started by another dobi
+// process.
+type StopTask struct {
+	name   string
+	config *config.JobConfig
+}
+
+// NewStopTask creates a new StopTask object
+func NewStopTask(name string, conf *config.JobConfig) *StopTask {
+	return &StopTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *StopTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "stop")
+}
+
+func (t *StopTask) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *StopTask) Repr() string {
+	return fmt.Sprintf("[job:stop %v] %v", t.name, t.config.Use)
+}
+
+// Run stops the container and removes it
+func (t *StopTask) Run(ctx *context.ExecuteContext) error {
+	name := ContainerName(ctx, t.name)
+	err := ctx.Client.StopContainer(name, stopTimeout(t.config))
+	switch err.(type) {
+	case *docker.NoSuchContainer:
+		t.logger().Info("No container to stop")
+		return nil
+	case *docker.ContainerNotRunning:
+		t.logger().Debug("Container is not running")
+	case nil:
+	default:
+		return fmt.Errorf("Failed to stop container %q: %s", name, err)
+	}
+
+	RemoveContainer(t.logger(), ctx.Client, name, true)
+	t.logger().Info("Stopped")
+	return nil
+}
+
+// Dependencies returns the list of dependencies. The stop task doesn't depend
+// on anything.
+func (t *StopTask) Dependencies() []string {
+	return []string{}
+}
+
+// Stop the task
+func (t *StopTask) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
@@ -0,0 +1,149 @@
+package job
+
+import (
+	"fmt"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/client"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type StopTaskSuite struct {
+	suite.Suite
+	mock      *gomock.Controller
+	client    *client.MockDockerClient
+	ctx       *context.ExecuteContext
+	task      *StopTask
+	container string
+}
+
+func TestStopTaskSuite(t *testing.T) {
+	suite.Run(t, new(StopTaskSuite))
+}
+
+func (s *StopTaskSuite) SetupTest() {
+	s.mock = gomock.NewController(s.T())
+	s.client = client.NewMockDockerClient(s.mock)
+	s.ctx = &context.ExecuteContext{
+		Client: s.client,
+		Env:    execenv.NewExecEnv("exec", "project", "/dir"),
+	}
+	s.task = NewStopTask("db", &config.JobConfig{Use: "postgres"})
+	s.container = ContainerName(s.ctx, "db")
+}
+
+func (s *StopTaskSuite) TearDownTest() {
+	s.mock.Finish()
+}
+
+func (s *StopTaskSuite) TestRunStopsAndRemovesContainer() {
+	s.client.EXPECT().StopContainer(s.container, uint(10)).Return(nil)
+	s.client.EXPECT().RemoveContainer(docker.RemoveContainerOptions{
+		ID:            s.container,
+		RemoveVolumes: true,
+	}).Return(nil)
+	s.Nil(s.task.Run(s.ctx))
+}
+
+func (s *StopTaskSuite) TestRunNoContainer() {
+	s.client.EXPECT().StopContainer(s.container, uint(10)).Return(
+		&docker.NoSuchContainer{ID: s.container})
+	s.Nil(s.task.Run(s.ctx))
+}
+
+func (s *StopTaskSuite) TestRunStopFailed() {
+	s.client.EXPECT().StopContainer(s.container, uint(10)).Return(
+		fmt.Errorf("daemon error"))
+	err := s.task.Run(s.ctx)
+	s.Error(err)
+	s.Contains(err.Error(), "daemon error")
+}
+
+func (s *StopTaskSuite) TestRunWithStopTimeout() {
+	conf, err := config.LoadFromBytes([]byte(
+		"image=postgres:\n  image: postgres\n" +
+			"job=db:\n  use: postgres\n  stop-timeout: 1500ms\n"))
+	s.Require().Nil(err)
+	task := NewStopTask("db", conf.Resources["db"].(*config.JobConfig))
+
+	s.client.EXPECT().StopContainer(s.container, uint(2)).Return(nil)
+	s.client.EXPECT().RemoveContainer(gomock.Any()).Return(nil)
+	s.Nil(task.Run(s.ctx))
+}
+
+func TestForwardedSignal(t *testing.T) {
+	for _, testcase := range []struct {
+		stopSignal string
+		received   syscall.Signal
+		expected   syscall.Signal
+	}{
+		{stopSignal: "", received: syscall.SIGTERM, expected: syscall.SIGTERM},
+		{stopSignal: "SIGQUIT", received: syscall.SIGTERM, expected: syscall.SIGQUIT},
+		{stopSignal: "HUP", received: syscall.SIGTERM, expected: syscall.SIGHUP},
+		{stopSignal: "10", received: syscall.SIGTERM, expected: syscall.Signal(10)},
+		{stopSignal: "SIGQUIT", received: syscall.SIGINT, expected: syscall.SIGINT},
+		{stopSignal: "SIGWINCH", received: syscall.SIGTERM, expected: syscall.SIGTERM},
+	} {
+		conf := &config.JobConfig{StopSignal: testcase.stopSignal}
+		assert.Equal(t, testcase.expected, forwardedSignal(conf, testcase.received),
+			"stop signal %q", testcase.stopSignal)
+	}
+}
+
+func TestForwardSignalsSendsStopSignal(t *testing.T) {
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+	mockClient := client.NewMockDockerClient(mock)
+	task := NewTask("db", &config.JobConfig{Use: "postgres", StopSignal: "SIGINT"})
+
+	received := make(chan struct{})
+	gomock.InOrder(
+		mockClient.EXPECT().KillContainer(docker.KillContainerOptions{
+			ID:     "container-id",
+			Signal: docker.Signal(syscall.SIGINT),
+		}).Return(nil),
+		mockClient.EXPECT().KillContainer(docker.KillContainerOptions{
+			ID:     "container-id",
+			Signal: docker.Signal(syscall.SIGINT),
+		}).Do(func(docker.KillContainerOptions) { close(received) }).Return(nil),
+	)
+
+	chanSig := task.forwardSignals(mockClient, "container-id")
+	defer stopForwardingSignals(chanSig)
+	chanSig <- syscall.SIGTERM
+	chanSig <- syscall.SIGINT
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal was not forwarded to the container")
+	}
+}
+
+func TestStopForwardingSignalsEndsGoroutine(t *testing.T) {
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+	mockClient := client.NewMockDockerClient(mock)
+	task := NewTask("db", &config.JobConfig{Use: "postgres"})
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		stopForwardingSignals(task.forwardSignals(mockClient, "container-id"))
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("forwarding goroutines did not return: %d running, %d before",
+				runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
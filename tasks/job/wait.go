@@ -0,0 +1,92 @@
+package job
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/wait"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// waitInterval is the time between checks of a wait-for condition
+var waitInterval = time.Second
+
+// waitFor checks the wait-for condition of the job until it is met. If the
+// container exits, or the condition is not met before the timeout, the
+// container is stopped and an error is returned.
+func (t *Task) waitFor(ctx *context.ExecuteContext, containerID string) error {
+	condition := &t.config.WaitFor
+	deadline := time.Now().Add(condition.Timeout())
+	t.logger().Infof("Waiting for %s", condition)
+
+	for {
+		err := t.checkCondition(ctx, containerID)
+		if err == nil {
+			t.logger().Infof("%s is ready", condition)
+			return nil
+		}
+		t.logger().Debugf("%s is not ready: %s", condition, err)
+
+		container, inspectErr := ctx.Client.InspectContainer(containerID)
+		switch {
+		case inspectErr != nil:
+			return fmt.Errorf("Failed to inspect container: %s", inspectErr)
+		case !container.State.Running:
+			return fmt.Errorf("Container exited with status %d before %s was ready",
+				container.State.ExitCode, condition)
+		case time.Now().After(deadline):
+			t.stopContainer(ctx, containerID)
+			return fmt.Errorf("Timed out after %s waiting for %s: %s",
+				condition.Timeout(), condition, err)
+		}
+		time.Sleep(waitInterval)
+	}
+}
+
+// checkCondition returns nil if the wait-for condition of the job is met
+func (t *Task) checkCondition(ctx *context.ExecuteContext, containerID string) error {
+	condition := t.config.WaitFor
+	switch {
+	case condition.TCP != "":
+		return wait.CheckTCP(condition.Address(), waitInterval)
+	case condition.HTTP != "":
+		return wait.CheckHTTP(condition.HTTP, waitInterval)
+	}
+
+	exec, err := ctx.Client.CreateExec(docker.CreateExecOptions{
+		Container:    containerID,
+		Cmd:          condition.Exec.Value(),
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to create exec: %s", err)
+	}
+	if err := ctx.Client.StartExec(exec.ID, docker.StartExecOptions{
+		OutputStream: ioutil.Discard,
+		ErrorStream:  ioutil.Discard,
+	}); err != nil {
+		return fmt.Errorf("Failed to run exec: %s", err)
+	}
+	inspect, err := ctx.Client.InspectExec(exec.ID)
+	if err != nil {
+		return fmt.Errorf("Failed to inspect exec: %s", err)
+	}
+	if inspect.ExitCode != 0 {
+		return &exitError{status: inspect.ExitCode}
+	}
+	return nil
+}
+
+// stopContainer kills the container and waits for it to exit
+func (t *Task) stopContainer(ctx *context.ExecuteContext, containerID string) {
+	if err := ctx.Client.KillContainer(docker.KillContainerOptions{ID: containerID}); err != nil {
+		t.logger().Warnf("Failed to kill container: %s", err)
+		return
+	}
+	if _, err := ctx.Client.WaitContainer(containerID); err != nil {
+		t.logger().Warnf("Failed to wait on container exit: %s", err)
+	}
+}
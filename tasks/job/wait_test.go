@@ -0,0 +1,149 @@
+package job
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/client"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+)
+
+type WaitForSuite struct {
+	suite.Suite
+	mock     *gomock.Controller
+	client   *client.MockDockerClient
+	ctx      *context.ExecuteContext
+	interval time.Duration
+}
+
+func TestWaitForSuite(t *testing.T) {
+	suite.Run(t, new(WaitForSuite))
+}
+
+func (s *WaitForSuite) SetupTest() {
+	s.mock = gomock.NewController(s.T())
+	s.client = client.NewMockDockerClient(s.mock)
+	s.ctx = &context.ExecuteContext{
+		Client: s.client,
+		Env:    execenv.NewExecEnv("exec", "project", "/dir"),
+	}
+	s.interval = waitInterval
+	waitInterval = 10 * time.Millisecond
+}
+
+func (s *WaitForSuite) TearDownTest() {
+	waitInterval = s.interval
+	s.mock.Finish()
+}
+
+func (s *WaitForSuite) newTask(waitFor string) *Task {
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n" +
+			"job=db:\n  use: builder\n  wait-for: " + waitFor + "\n"))
+	s.Require().Nil(err)
+	return NewTask("db", conf.Resources["db"].(*config.JobConfig))
+}
+
+func (s *WaitForSuite) expectRunning(running bool) {
+	s.client.EXPECT().InspectContainer("container-id").Return(&docker.Container{
+		State: docker.State{Running: running, ExitCode: 3},
+	}, nil).AnyTimes()
+}
+
+func (s *WaitForSuite) TestWaitForTCPReady() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().Nil(err)
+	defer listener.Close()
+
+	task := s.newTask("{tcp: '" + listener.Addr().String() + "'}")
+	s.Nil(task.waitFor(s.ctx, "container-id"))
+}
+
+func (s *WaitForSuite) TestWaitForTCPTimesOut() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	s.Require().Nil(err)
+	addr := listener.Addr().String()
+	listener.Close()
+
+	task := s.newTask("{tcp: '" + addr + "', timeout: 50ms}")
+	s.expectRunning(true)
+	s.client.EXPECT().KillContainer(docker.KillContainerOptions{ID: "container-id"}).Return(nil)
+	s.client.EXPECT().WaitContainer("container-id").Return(137, nil)
+
+	err = task.waitFor(s.ctx, "container-id")
+	if s.Error(err) {
+		s.Contains(err.Error(), "Timed out after 50ms waiting for tcp "+addr)
+	}
+}
+
+func (s *WaitForSuite) TestWaitForHTTPReady() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	task := s.newTask("{http: '" + server.URL + "/healthz'}")
+	s.Nil(task.waitFor(s.ctx, "container-id"))
+}
+
+func (s *WaitForSuite) TestWaitForExecReadyOnSecondCheck() {
+	task := s.newTask("{exec: 'pg_isready -q'}")
+	s.expectRunning(true)
+	s.client.EXPECT().CreateExec(docker.CreateExecOptions{
+		Container:    "container-id",
+		Cmd:          []string{"pg_isready", "-q"},
+		AttachStdout: true,
+		AttachStderr: true,
+	}).Return(&docker.Exec{ID: "exec-id"}, nil).Times(2)
+	s.client.EXPECT().StartExec("exec-id", gomock.Any()).Return(nil).Times(2)
+	gomock.InOrder(
+		s.client.EXPECT().InspectExec("exec-id").Return(&docker.ExecInspect{ExitCode: 1}, nil),
+		s.client.EXPECT().InspectExec("exec-id").Return(&docker.ExecInspect{ExitCode: 0}, nil),
+	)
+	s.Nil(task.waitFor(s.ctx, "container-id"))
+}
+
+func (s *WaitForSuite) TestWaitForContainerExited() {
+	task := s.newTask("{exec: 'pg_isready'}")
+	s.expectRunning(false)
+	s.client.EXPECT().CreateExec(gomock.Any()).Return(nil, fmt.Errorf("container not running"))
+
+	err := task.waitFor(s.ctx, "container-id")
+	if s.Error(err) {
+		s.Contains(err.Error(), "Container exited with status 3 before exec pg_isready was ready")
+	}
+}
+
+func (s *WaitForSuite) TestStopServiceWithStopTimeout() {
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n" +
+			"job=db:\n  use: builder\n  wait-for: {tcp: 'localhost:5432'}\n" +
+			"  stop-signal: SIGINT\n  stop-timeout: 30s\n"))
+	s.Require().Nil(err)
+	ctx := context.NewExecuteContext(conf, s.client, s.ctx.Env, false)
+	task := NewTask("db", conf.Resources["db"].(*config.JobConfig))
+	task.service = "container-id"
+
+	opts, err := task.createOptions(ctx, "db")
+	s.Require().Nil(err)
+	s.Equal("SIGINT", opts.Config.StopSignal)
+
+	gomock.InOrder(
+		s.client.EXPECT().StopContainer("container-id", uint(30)).Return(nil),
+		s.client.EXPECT().RemoveContainer(docker.RemoveContainerOptions{
+			ID:            "container-id",
+			RemoveVolumes: true,
+		}).Return(nil),
+	)
+	s.Nil(task.Stop(ctx))
+	s.Equal("", task.service)
+}
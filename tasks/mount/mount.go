@@ -37,9 +37,10 @@ func (t *CreateTask) Repr() string {
 	return fmt.Sprintf("[mount:create %s] %s (%#o)", t.name, t.config.Bind, t.config.Mode)
 }
 
-// Run creates the host path if it doesn't already exist
+// Run creates the host path if it doesn't already exist. Volumes are created by
+// the volume resource, so there is nothing to create for a mount of a volume.
 func (t *CreateTask) Run(ctx *context.ExecuteContext) error {
-	if t.exists(ctx) {
+	if t.config.Volume != "" || t.exists(ctx) {
 		t.logger().Debug("is fresh")
 		return nil
 	}
@@ -9,13 +9,20 @@ import (
 
 // AsBind returns a MountConfig formatted as a bind mount string
 func AsBind(c *config.MountConfig, workingDir string) string {
-	var mode string
+	return fmt.Sprintf("%s:%s:%s", AbsBindPath(c, workingDir), c.Path, mode(c))
+}
+
+// AsVolumeBind returns a MountConfig of a volume resource formatted as a bind
+// mount string
+func AsVolumeBind(c *config.MountConfig, volume *config.VolumeConfig) string {
+	return fmt.Sprintf("%s:%s:%s", volume.Name, c.Path, mode(c))
+}
+
+func mode(c *config.MountConfig) string {
 	if c.ReadOnly {
-		mode = "ro"
-	} else {
-		mode = "rw"
+		return "ro"
 	}
-	return fmt.Sprintf("%s:%s:%s", AbsBindPath(c, workingDir), c.Path, mode)
+	return "rw"
 }
 
 // AbsBindPath returns the MountConfig.Bind as an absolute path
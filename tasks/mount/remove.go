@@ -37,7 +37,9 @@ func (t *RemoveTask) Repr() string {
 
 // Run does nothing
 func (t *RemoveTask) Run(ctx *context.ExecuteContext) error {
-	t.logger().Warn("Bind mounts are not removable")
+	if t.config.Volume == "" {
+		t.logger().Warn("Bind mounts are not removable")
+	}
 	return nil
 }
 
@@ -0,0 +1,20 @@
+package network
+
+import (
+	"fmt"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/iface"
+)
+
+// GetTask returns a new task for the action
+func GetTask(name, action string, conf *config.NetworkConfig) (iface.Task, error) {
+	switch action {
+	case "", "create":
+		return NewCreateTask(name, conf), nil
+	case "remove", "rm":
+		return NewRemoveTask(name, conf), nil
+	default:
+		return nil, fmt.Errorf("Invalid network action %q for task %q", action, name)
+	}
+}
@@ -0,0 +1,83 @@
+package network
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// CreateTask is a task which creates a Docker network
+type CreateTask struct {
+	name   string
+	config *config.NetworkConfig
+}
+
+// NewCreateTask creates a new CreateTask object
+func NewCreateTask(name string, conf *config.NetworkConfig) *CreateTask {
+	return &CreateTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *CreateTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "create")
+}
+
+func (t *CreateTask) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *CreateTask) Repr() string {
+	return fmt.Sprintf("[network:create %s] %s", t.name, t.config.Name)
+}
+
+// Run creates the network if it doesn't exist
+func (t *CreateTask) Run(ctx *context.ExecuteContext) error {
+	_, err := ctx.Client.NetworkInfo(t.config.Name)
+	switch err.(type) {
+	case nil:
+		t.logger().Debug("Network exists")
+		return nil
+	case *docker.NoSuchNetwork:
+	default:
+		return fmt.Errorf("Failed to inspect network %q: %s", t.config.Name, err)
+	}
+
+	if _, err := ctx.Client.CreateNetwork(docker.CreateNetworkOptions{
+		Name:           t.config.Name,
+		CheckDuplicate: true,
+		Driver:         t.config.Driver,
+		Options:        networkOptions(t.config.Options),
+	}); err != nil {
+		return fmt.Errorf("Failed to create network %q: %s", t.config.Name, err)
+	}
+	ctx.SetModified(t.name)
+	t.logger().Info("Created")
+	return nil
+}
+
+func networkOptions(options map[string]string) map[string]interface{} {
+	if len(options) == 0 {
+		return nil
+	}
+	values := make(map[string]interface{}, len(options))
+	for key, value := range options {
+		values[key] = value
+	}
+	return values
+}
+
+// Dependencies returns the list of dependencies
+func (t *CreateTask) Dependencies() []string {
+	return t.config.Dependencies()
+}
+
+// Stop the task
+func (t *CreateTask) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
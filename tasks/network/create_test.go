@@ -0,0 +1,82 @@
+package network
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/client"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+)
+
+type NetworkTaskSuite struct {
+	suite.Suite
+	mock   *gomock.Controller
+	client *client.MockDockerClient
+	ctx    *context.ExecuteContext
+	config *config.NetworkConfig
+}
+
+func TestNetworkTaskSuite(t *testing.T) {
+	suite.Run(t, new(NetworkTaskSuite))
+}
+
+func (s *NetworkTaskSuite) SetupTest() {
+	s.mock = gomock.NewController(s.T())
+	s.client = client.NewMockDockerClient(s.mock)
+	s.ctx = context.NewExecuteContext(config.NewConfig(), s.client, nil, false)
+	s.config = &config.NetworkConfig{
+		Name:    "backend-net",
+		Driver:  "bridge",
+		Options: map[string]string{"com.docker.network.bridge.enable_icc": "true"},
+	}
+}
+
+func (s *NetworkTaskSuite) TearDownTest() {
+	s.mock.Finish()
+}
+
+func (s *NetworkTaskSuite) TestCreateWhenMissing() {
+	s.client.EXPECT().NetworkInfo("backend-net").Return(
+		nil, &docker.NoSuchNetwork{ID: "backend-net"})
+	s.client.EXPECT().CreateNetwork(docker.CreateNetworkOptions{
+		Name:           "backend-net",
+		CheckDuplicate: true,
+		Driver:         "bridge",
+		Options: map[string]interface{}{
+			"com.docker.network.bridge.enable_icc": "true",
+		},
+	}).Return(&docker.Network{}, nil)
+
+	s.Nil(NewCreateTask("backend", s.config).Run(s.ctx))
+	s.True(s.ctx.IsModified("backend"))
+}
+
+func (s *NetworkTaskSuite) TestCreateWhenExists() {
+	s.client.EXPECT().NetworkInfo("backend-net").Return(&docker.Network{}, nil)
+
+	s.Nil(NewCreateTask("backend", s.config).Run(s.ctx))
+	s.False(s.ctx.IsModified("backend"))
+}
+
+func (s *NetworkTaskSuite) TestCreateInspectFails() {
+	s.client.EXPECT().NetworkInfo("backend-net").Return(nil, fmt.Errorf("oops"))
+
+	err := NewCreateTask("backend", s.config).Run(s.ctx)
+	s.Error(err)
+	s.Contains(err.Error(), `Failed to inspect network "backend-net": oops`)
+}
+
+func (s *NetworkTaskSuite) TestRemove() {
+	s.client.EXPECT().RemoveNetwork("backend-net").Return(nil)
+	s.Nil(NewRemoveTask("backend", s.config).Run(s.ctx))
+}
+
+func (s *NetworkTaskSuite) TestRemoveWhenMissing() {
+	s.client.EXPECT().RemoveNetwork("backend-net").Return(
+		&docker.NoSuchNetwork{ID: "backend-net"})
+	s.Nil(NewRemoveTask("backend", s.config).Run(s.ctx))
+}
@@ -0,0 +1,61 @@
+package network
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// RemoveTask is a task which removes a Docker network
+type RemoveTask struct {
+	name   string
+	config *config.NetworkConfig
+}
+
+// NewRemoveTask creates a new RemoveTask object
+func NewRemoveTask(name string, conf *config.NetworkConfig) *RemoveTask {
+	return &RemoveTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *RemoveTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "rm")
+}
+
+func (t *RemoveTask) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *RemoveTask) Repr() string {
+	return fmt.Sprintf("[network:rm %s] %s", t.name, t.config.Name)
+}
+
+// Run removes the network
+func (t *RemoveTask) Run(ctx *context.ExecuteContext) error {
+	switch err := ctx.Client.RemoveNetwork(t.config.Name); err.(type) {
+	case nil:
+		t.logger().Info("Removed")
+	case *docker.NoSuchNetwork:
+		t.logger().Debug("Network does not exist")
+	default:
+		return fmt.Errorf("Failed to remove network %q: %s", t.config.Name, err)
+	}
+	return nil
+}
+
+// Dependencies returns the list of dependencies. The remove task doesn't depend
+// on anything.
+func (t *RemoveTask) Dependencies() []string {
+	return []string{}
+}
+
+// Stop the task
+func (t *RemoveTask) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
@@ -0,0 +1,61 @@
+package tasks
+
+import (
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/history"
+	"github.com/dnephin/dobi/tasks/iface"
+)
+
+// runOnce skips the run task of a job with run-once when the job has already
+// succeeded in the same context. The context is the project and exec-id of the
+// run, from {unique}. Runs are recorded in the state file.
+type runOnce struct {
+	store *history.Store
+	tasks *TaskCollection
+	force bool
+	next  func(*context.ExecuteContext, iface.Task) error
+}
+
+func (r *runOnce) runTask(ctx *context.ExecuteContext, task iface.Task) error {
+	if !r.isRunOnce(task) {
+		return r.next(ctx, task)
+	}
+
+	key := runOnceKey(ctx, task)
+	if _, ok := r.store.Get(key); ok && !r.force {
+		logging.Log.Infof("Skipping %q, it has already run for %q",
+			task.Name().Name(), ctx.Env.Unique())
+		return nil
+	}
+	if err := r.next(ctx, task); err != nil {
+		return err
+	}
+	if err := r.store.Success(key, ""); err != nil {
+		logging.Log.Warnf("Failed to save state of %q: %s", task.Name().Name(), err)
+	}
+	return nil
+}
+
+func (r *runOnce) isRunOnce(task iface.Task) bool {
+	resource, _ := r.tasks.Resource(task)
+	conf, ok := resource.(*config.JobConfig)
+	return ok && conf.RunOnce && task.Name().Action() == "run"
+}
+
+// runOnceKey returns the name of the record in the state file for the task in
+// the context of this run
+func runOnceKey(ctx *context.ExecuteContext, task iface.Task) string {
+	return "run-once/" + task.Name().Name() + "/" + ctx.Env.Unique()
+}
+
+// hasRunOnce returns true if any job in the config sets run-once
+func hasRunOnce(conf *config.Config) bool {
+	for _, resource := range conf.Resources {
+		if job, ok := resource.(*config.JobConfig); ok && job.RunOnce {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package tasks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/history"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/stretchr/testify/suite"
+)
+
+type RunOnceSuite struct {
+	suite.Suite
+	dir    string
+	ctx    *context.ExecuteContext
+	runner *runOnce
+	ran    []string
+	err    error
+}
+
+func TestRunOnceSuite(t *testing.T) {
+	suite.Run(t, new(RunOnceSuite))
+}
+
+func (s *RunOnceSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "run-once-test")
+	s.Require().Nil(err)
+	store, err := history.Load(s.dir)
+	s.Require().Nil(err)
+
+	s.ctx = &context.ExecuteContext{Env: execenv.NewExecEnv("alice", "webapp", s.dir)}
+	s.ran = nil
+	s.err = nil
+	tasks := newTaskCollection()
+	tasks.addResource(&fakeTask{name: "seed"}, &config.JobConfig{RunOnce: true})
+	tasks.addResource(&fakeTask{name: "test"}, &config.JobConfig{})
+	s.runner = &runOnce{
+		store: store,
+		tasks: tasks,
+		next: func(ctx *context.ExecuteContext, task iface.Task) error {
+			s.ran = append(s.ran, task.Name().Resource())
+			return s.err
+		},
+	}
+}
+
+func (s *RunOnceSuite) TearDownTest() {
+	s.Nil(os.RemoveAll(s.dir))
+}
+
+func (s *RunOnceSuite) TestSkipsJobWhichHasRun() {
+	for i := 0; i < 2; i++ {
+		s.Nil(s.runner.runTask(s.ctx, &fakeTask{name: "seed"}))
+		s.Nil(s.runner.runTask(s.ctx, &fakeTask{name: "test"}))
+	}
+	s.Equal([]string{"seed", "test", "test"}, s.ran)
+
+	store, err := history.Load(s.dir)
+	s.Require().Nil(err)
+	_, ok := store.Get("run-once/seed:run/webapp-alice")
+	s.True(ok)
+}
+
+func (s *RunOnceSuite) TestRunsAgainInAnotherContext() {
+	s.Nil(s.runner.runTask(s.ctx, &fakeTask{name: "seed"}))
+	s.ctx.Env = execenv.NewExecEnv("bob", "webapp", s.dir)
+	s.Nil(s.runner.runTask(s.ctx, &fakeTask{name: "seed"}))
+	s.Equal([]string{"seed", "seed"}, s.ran)
+}
+
+func (s *RunOnceSuite) TestForce() {
+	s.runner.force = true
+	s.Nil(s.runner.runTask(s.ctx, &fakeTask{name: "seed"}))
+	s.Nil(s.runner.runTask(s.ctx, &fakeTask{name: "seed"}))
+	s.Equal([]string{"seed", "seed"}, s.ran)
+}
+
+func (s *RunOnceSuite) TestFailureIsNotRecorded() {
+	s.err = fmt.Errorf("failed")
+	s.Error(s.runner.runTask(s.ctx, &fakeTask{name: "seed"}))
+	s.err = nil
+	s.Nil(s.runner.runTask(s.ctx, &fakeTask{name: "seed"}))
+	s.Equal([]string{"seed", "seed"}, s.ran)
+}
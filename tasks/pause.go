@@ -0,0 +1,45 @@
+package tasks
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+)
+
+// pauser runs tasks, and prompts the user to continue after the tasks of any
+// resource which sets pause. When stdin is not a terminal the pause is
+// skipped, or is an error if failOnPause is set.
+type pauser struct {
+	config      *config.Config
+	interactive bool
+	failOnPause bool
+	in          *bufio.Reader
+	out         io.Writer
+	next        func(*context.ExecuteContext, iface.Task) error
+}
+
+func (p *pauser) runTask(ctx *context.ExecuteContext, task iface.Task) error {
+	if err := p.next(ctx, task); err != nil {
+		return err
+	}
+	if !p.config.OptionsFor(task.Name().Resource()).Pause {
+		return nil
+	}
+
+	switch {
+	case p.interactive:
+		fmt.Fprintf(p.out, "Paused after %q, press Enter to continue ", task.Name())
+		_, err := p.in.ReadString('\n')
+		return err
+	case p.failOnPause:
+		return fmt.Errorf("Task %q pauses, but stdin is not a terminal", task.Name())
+	default:
+		logging.Log.Debugf("Not pausing after %q, stdin is not a terminal", task.Name())
+		return nil
+	}
+}
@@ -0,0 +1,53 @@
+package tasks
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPauser(input string) (*pauser, *bytes.Buffer, *[]string) {
+	conf := config.NewConfig()
+	conf.Options["paused"] = &config.ResourceOptions{Pause: true}
+	out := &bytes.Buffer{}
+	ran := &[]string{}
+	return &pauser{
+		config: conf,
+		in:     bufio.NewReader(strings.NewReader(input)),
+		out:    out,
+		next: func(ctx *context.ExecuteContext, task iface.Task) error {
+			*ran = append(*ran, task.Name().Resource())
+			return nil
+		},
+	}, out, ran
+}
+
+func TestPauserPromptsAfterPausedResource(t *testing.T) {
+	p, out, ran := newPauser("\n")
+	p.interactive = true
+
+	assert.Nil(t, p.runTask(nil, &fakeTask{name: "other"}))
+	assert.Equal(t, "", out.String())
+
+	assert.Nil(t, p.runTask(nil, &fakeTask{name: "paused"}))
+	assert.Equal(t, "Paused after \"paused:run\", press Enter to continue ", out.String())
+	assert.Equal(t, []string{"other", "paused"}, *ran)
+}
+
+func TestPauserNonInteractive(t *testing.T) {
+	p, out, _ := newPauser("")
+
+	assert.Nil(t, p.runTask(nil, &fakeTask{name: "paused"}))
+	assert.Equal(t, "", out.String())
+
+	p.failOnPause = true
+	err := p.runTask(nil, &fakeTask{name: "paused"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "stdin is not a terminal")
+}
@@ -0,0 +1,114 @@
+package tasks
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/dnephin/dobi/tasks/image"
+	"github.com/dnephin/dobi/tasks/job"
+)
+
+// Plan is the execution plan of a run, with the tasks in the order they are
+// scheduled
+type Plan struct {
+	Project string     `json:"project"`
+	ExecID  string     `json:"exec-id"`
+	Tasks   []PlanTask `json:"tasks"`
+}
+
+// PlanTask is a task in the execution plan, with variables resolved
+type PlanTask struct {
+	Name        string   `json:"name"`
+	Resource    string   `json:"resource"`
+	Action      string   `json:"action"`
+	Description string   `json:"description"`
+	Depends     []string `json:"depends,omitempty"`
+	Image       string   `json:"image,omitempty"`
+	Entrypoint  []string `json:"entrypoint,omitempty"`
+	Command     []string `json:"command,omitempty"`
+	// Stale is nil when the task can not check if it is stale
+	Stale      *bool  `json:"stale"`
+	StaleError string `json:"stale-error,omitempty"`
+}
+
+// newPlan returns the execution plan of tasks. A task is stale if it is stale
+// itself, or if it depends on a task which is stale. A task which fails to
+// check if it is stale is recorded as stale, with the error.
+func newPlan(ctx *context.ExecuteContext, tasks *TaskCollection) Plan {
+	plan := Plan{Project: ctx.Env.Project, ExecID: ctx.Env.ExecID, Tasks: []PlanTask{}}
+	stale := make(map[string]bool)
+
+	for _, task := range tasks.All() {
+		step := PlanTask{
+			Name:        task.Name().Name(),
+			Resource:    task.Name().Resource(),
+			Action:      task.Name().Action(),
+			Description: ctx.Masker.MaskString(task.Repr()),
+		}
+		for _, dep := range tasks.Dependencies(task) {
+			step.Depends = append(step.Depends, dep.Name())
+		}
+		resource, _ := tasks.Resource(task)
+		planResource(ctx, task, resource, &step)
+
+		if _, canCheck := task.(iface.StaleTask); canCheck {
+			isStale, err := taskIsStale(ctx, tasks, task, stale)
+			if err != nil {
+				isStale = true
+				step.StaleError = err.Error()
+			}
+			stale[step.Name] = isStale
+			step.Stale = &isStale
+		}
+		plan.Tasks = append(plan.Tasks, step)
+	}
+	return plan
+}
+
+// planResource sets the image and command of the step from the task and the
+// resolved resource
+func planResource(
+	ctx *context.ExecuteContext,
+	task iface.Task,
+	resource config.Resource,
+	step *PlanTask,
+) {
+	switch conf := resource.(type) {
+	case *config.JobConfig:
+		if imageConf := ctx.Resources.Image(conf.Use); imageConf != nil {
+			step.Image = image.GetImageName(ctx, imageConf)
+		}
+		if jobTask, ok := task.(*job.Task); ok {
+			step.Entrypoint, step.Command = jobTask.Command(ctx)
+		}
+	case *config.ImageConfig:
+		step.Image = image.GetImageName(ctx, conf)
+	case *config.ShellConfig:
+		step.Command = conf.Command.Value()
+	}
+	step.Entrypoint = maskAll(ctx, step.Entrypoint)
+	step.Command = maskAll(ctx, step.Command)
+}
+
+func maskAll(ctx *context.ExecuteContext, values []string) []string {
+	if values == nil {
+		return nil
+	}
+	masked := []string{}
+	for _, value := range values {
+		masked = append(masked, ctx.Masker.MaskString(value))
+	}
+	return masked
+}
+
+// writePlan writes the execution plan of tasks to filename as json
+func writePlan(ctx *context.ExecuteContext, tasks *TaskCollection, filename string) error {
+	out, err := json.MarshalIndent(newPlan(ctx, tasks), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, append(out, '\n'), 0644)
+}
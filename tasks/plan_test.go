@@ -0,0 +1,84 @@
+package tasks
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/job"
+	"github.com/dnephin/dobi/utils/mask"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritePlan(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "plan-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	conf, err := config.LoadFromBytes([]byte(
+		"image=base:\n  image: example\n" +
+			"job=test:\n  use: base\n  command: 'echo secret'\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, nil, execenv.NewExecEnv("exec", "project", tmpDir), false)
+	ctx.Masker, err = mask.New([]string{"secret"})
+	assert.Nil(t, err)
+
+	tasks := newTaskCollection()
+	image := &fakeStaleTask{fakeTask: fakeTask{name: "base"}}
+	tasks.add(image)
+	tasks.addName("base", image.Name())
+	jobTask := job.NewTask("test", conf.Resources["test"].(*config.JobConfig))
+	tasks.add(jobTask)
+	tasks.addName("test", jobTask.Name())
+	tasks.addResource(jobTask, conf.Resources["test"])
+	alias := &fakeTask{name: "all", deps: []string{"test"}}
+	tasks.add(alias)
+	tasks.addName("all", alias.Name())
+
+	filename := filepath.Join(tmpDir, "plan.json")
+	assert.Nil(t, writePlan(ctx, tasks, filename))
+	raw, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	plan := Plan{}
+	assert.Nil(t, json.Unmarshal(raw, &plan))
+
+	fresh, stale := false, true
+	assert.Equal(t, Plan{
+		Project: "project",
+		ExecID:  "exec",
+		Tasks: []PlanTask{
+			{
+				Name:        "base:run",
+				Resource:    "base",
+				Action:      "run",
+				Description: "base",
+				Stale:       &fresh,
+			},
+			{
+				Name:        "test:run",
+				Resource:    "test",
+				Action:      "run",
+				Description: "[job:run test] echo ******",
+				Depends:     []string{"base:run"},
+				Image:       "example:project-exec",
+				Command:     []string{"echo", "******"},
+				Stale:       &stale,
+			},
+			{
+				Name:        "all:run",
+				Resource:    "all",
+				Action:      "run",
+				Description: "all",
+				Depends:     []string{"test:run"},
+			},
+		},
+	}, plan)
+}
@@ -0,0 +1,100 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/image"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// prePull pulls the images used by the image tasks, up to limit at a time,
+// before any tasks run. A base image which fails to pull is logged as a
+// warning, because the build can continue without it. Any other failure is
+// returned as an error after all the pulls are done.
+func prePull(ctx *context.ExecuteContext, tasks *TaskCollection, limit int) error {
+	pulls := collectPrePulls(ctx, tasks)
+	if len(pulls) == 0 {
+		return nil
+	}
+	logging.Log.Infof("Pulling %d images, %d at a time", len(pulls), limit)
+
+	errs := make([]error, len(pulls))
+	sem := make(chan struct{}, limit)
+	wg := sync.WaitGroup{}
+	for i, pull := range pulls {
+		wg.Add(1)
+		go func(i int, pull image.PrePull) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			errs[i] = pull.Run(ctx, limit > 1)
+		}(i, pull)
+	}
+	wg.Wait()
+
+	failed := []string{}
+	for i, err := range errs {
+		switch {
+		case err == nil:
+		case !pulls[i].Required:
+			logging.Log.Warnf("Failed to pull base image %s: %s", pulls[i].Image, err)
+		default:
+			failed = append(failed, fmt.Sprintf("%s: %s", pulls[i].Image, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("Failed to pull images:\n  %s", strings.Join(failed, "\n  "))
+	}
+	return nil
+}
+
+// collectPrePulls returns the images pulled by each image task, in the order
+// of the tasks. Each image is pulled once, and a base image which is built or
+// pulled by one of the tasks is not pulled as a base image.
+func collectPrePulls(ctx *context.ExecuteContext, tasks *TaskCollection) []image.PrePull {
+	images := taskImages(tasks)
+	seen := map[string]bool{}
+	pulls := []image.PrePull{}
+	for _, task := range tasks.All() {
+		imageTask, ok := task.(*image.Task)
+		if !ok {
+			continue
+		}
+		taskPulls, err := image.PrePulls(ctx, imageTask)
+		if err != nil {
+			logging.Log.Warnf("Failed to find the images pulled by %s: %s", task.Name(), err)
+			continue
+		}
+		for _, pull := range taskPulls {
+			repo, _ := docker.ParseRepositoryTag(pull.Image)
+			if seen[pull.Image] || (!pull.Required && images[repo]) {
+				continue
+			}
+			seen[pull.Image] = true
+			pulls = append(pulls, pull)
+		}
+	}
+	return pulls
+}
+
+// taskImages returns the names of the images built or pulled by the tasks
+func taskImages(tasks *TaskCollection) map[string]bool {
+	images := map[string]bool{}
+	for _, task := range tasks.All() {
+		resource, _ := tasks.Resource(task)
+		conf, ok := resource.(*config.ImageConfig)
+		if !ok {
+			continue
+		}
+		switch task.Name().Action() {
+		case "build", "pull":
+			images[conf.Image] = true
+		}
+	}
+	return images
+}
@@ -0,0 +1,54 @@
+package tasks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectPrePulls(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "prepull-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(tmpDir)
+	err = ioutil.WriteFile(filepath.Join(tmpDir, "Dockerfile"),
+		[]byte("FROM example/base:1.0\nFROM alpine\nFROM postgres:9.6\n"), 0644)
+	assert.Nil(t, err)
+
+	conf, err := config.LoadFromBytes([]byte(fmt.Sprintf(
+		"image=base:\n  image: example/base\n  context: %[1]s\n  dockerfile: Dockerfile\n"+
+			"image=app:\n  image: example/app\n  context: %[1]s\n  dockerfile: Dockerfile\n"+
+			"image=db:\n  image: postgres\n  pull: always\n  tags: ['9.6']\n",
+		tmpDir)))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, nil, execenv.NewExecEnv("exec", "project", tmpDir), false)
+
+	tasks := newTaskCollection()
+	for _, name := range []string{"db", "base", "app"} {
+		resource := conf.Resources[name].(*config.ImageConfig)
+		task, err := image.GetTask(name, "", resource)
+		assert.Nil(t, err)
+		tasks.add(task)
+		tasks.addResource(task, resource)
+	}
+
+	pulls := collectPrePulls(ctx, tasks)
+	images := []string{}
+	required := []bool{}
+	for _, pull := range pulls {
+		images = append(images, pull.Image)
+		required = append(required, pull.Required)
+	}
+	assert.Equal(t, []string{"postgres", "alpine:latest"}, images)
+	assert.Equal(t, []bool{true, false}, required)
+}
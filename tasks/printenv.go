@@ -0,0 +1,48 @@
+package tasks
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/job"
+)
+
+// PrintEnv prints the environment which would be set in the container of the
+// job resource name, without running the job. Any value which matches a mask
+// pattern is redacted.
+func PrintEnv(options RunOptions, name string, out io.Writer) error {
+	name = common.ParseTaskName(name).Resource()
+	resource, ok := options.Config.Resources[name]
+	if !ok {
+		return fmt.Errorf("Resource %q does not exist", name)
+	}
+	if _, ok := resource.(*config.JobConfig); !ok {
+		return fmt.Errorf("%q is not a job resource", name)
+	}
+
+	execEnv, err := newExecEnv(options.Config, options.EnvFiles)
+	if err != nil {
+		return err
+	}
+	resolved, err := config.ResolveResource(name, resource, execEnv)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve variables in %q:\n%s", name, err)
+	}
+
+	ctx := &context.ExecuteContext{
+		EnvPassthrough: options.EnvPassthrough,
+		WorkingDir:     options.Config.WorkingDir,
+	}
+	task := job.NewTask(name, resolved.(*config.JobConfig))
+	env, err := task.Environment(ctx)
+	if err != nil {
+		return err
+	}
+	for _, item := range job.EffectiveEnvironment(env) {
+		fmt.Fprintln(out, options.Masker.MaskString(item))
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package tasks
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/utils/mask"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintEnv(t *testing.T) {
+	defer os.Unsetenv("DOBI_TEST_TOKEN")
+	os.Setenv("DOBI_TEST_TOKEN", "secret-1234")
+
+	conf := config.NewConfig()
+	conf.Meta.Project = "project"
+	conf.Resources["builder"] = &config.JobConfig{
+		Use: "image",
+		Env: []string{"MODE={env.DOBI_TEST_MODE:debug}", "DOBI_TEST_TOKEN=override"},
+	}
+	masker, err := mask.New([]string{"secret-[0-9]+"})
+	assert.Nil(t, err)
+
+	out := &bytes.Buffer{}
+	err = PrintEnv(RunOptions{
+		Config:         conf,
+		EnvPassthrough: []string{"DOBI_TEST_TOKEN"},
+		Masker:         masker,
+	}, "builder:run", out)
+	assert.Nil(t, err)
+	assert.Equal(t, "DOBI_TEST_TOKEN=override\nMODE=debug\n", out.String())
+}
+
+func TestPrintEnvRedactsMaskedValues(t *testing.T) {
+	defer os.Unsetenv("DOBI_TEST_TOKEN")
+	os.Setenv("DOBI_TEST_TOKEN", "secret-1234")
+
+	conf := config.NewConfig()
+	conf.Meta.Project = "project"
+	conf.Resources["builder"] = &config.JobConfig{Use: "image"}
+	masker, err := mask.New([]string{"secret-[0-9]+"})
+	assert.Nil(t, err)
+
+	out := &bytes.Buffer{}
+	err = PrintEnv(RunOptions{
+		Config:         conf,
+		EnvPassthrough: []string{"DOBI_TEST_TOKEN"},
+		Masker:         masker,
+	}, "builder", out)
+	assert.Nil(t, err)
+	assert.Equal(t, "DOBI_TEST_TOKEN=******\n", out.String())
+}
+
+func TestPrintEnvNotAJob(t *testing.T) {
+	conf := config.NewConfig()
+	conf.Resources["tools"] = &config.AliasConfig{}
+
+	err := PrintEnv(RunOptions{Config: conf}, "tools", &bytes.Buffer{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `"tools" is not a job resource`)
+	}
+}
@@ -0,0 +1,18 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/iface"
+)
+
+// GetTask returns a new task for the action
+func GetTask(name, action string, conf *config.RegistryConfig) (iface.Task, error) {
+	switch action {
+	case "", "login":
+		return NewLoginTask(name, conf), nil
+	default:
+		return nil, fmt.Errorf("Invalid registry action %q for task %q", action, name)
+	}
+}
@@ -0,0 +1,61 @@
+package registry
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+)
+
+// LoginTask is a task which gets the credentials for a registry, so that tasks
+// which depend on the registry fail early when the credentials are not
+// available
+type LoginTask struct {
+	name   string
+	config *config.RegistryConfig
+}
+
+// NewLoginTask creates a new LoginTask object
+func NewLoginTask(name string, conf *config.RegistryConfig) *LoginTask {
+	return &LoginTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *LoginTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "login")
+}
+
+func (t *LoginTask) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *LoginTask) Repr() string {
+	return fmt.Sprintf("[registry:login %s] %s", t.name, t.config.Server)
+}
+
+// Run gets the credentials for the registry
+func (t *LoginTask) Run(ctx *context.ExecuteContext) error {
+	auth, err := ctx.GetRegistryAuth(t.config)
+	if err != nil {
+		return fmt.Errorf("Failed to get credentials for %q: %s", t.config.Server, err)
+	}
+	if auth.Username == "" || auth.Password == "" {
+		return fmt.Errorf("Missing credentials for %q", t.config.Server)
+	}
+	t.logger().Debug("Credentials available")
+	return nil
+}
+
+// Dependencies returns the list of dependencies
+func (t *LoginTask) Dependencies() []string {
+	return t.config.Dependencies()
+}
+
+// Stop the task
+func (t *LoginTask) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
@@ -0,0 +1,32 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginTaskRun(t *testing.T) {
+	task := NewLoginTask("private", &config.RegistryConfig{
+		Server:   "registry.example.com",
+		Username: "deploy",
+		Password: "secret",
+	})
+	assert.Nil(t, task.Run(&context.ExecuteContext{}))
+}
+
+func TestLoginTaskRunMissingPassword(t *testing.T) {
+	task := NewLoginTask("private", &config.RegistryConfig{
+		Server:   "registry.example.com",
+		Username: "deploy",
+	})
+	err := task.Run(&context.ExecuteContext{})
+	assert.EqualError(t, err, `Missing credentials for "registry.example.com"`)
+}
+
+func TestGetTaskInvalidAction(t *testing.T) {
+	_, err := GetTask("private", "push", &config.RegistryConfig{})
+	assert.Error(t, err)
+}
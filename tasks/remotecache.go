@@ -0,0 +1,163 @@
+package tasks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/cache"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/dnephin/dobi/tasks/job"
+	"github.com/dnephin/dobi/utils/fs"
+)
+
+// remoteCache restores the artifacts of a stale job from the remote cache
+// instead of running the job. When the artifacts are not in the cache the job
+// runs, and its artifacts are stored in the cache.
+type remoteCache struct {
+	backend  cache.Backend
+	readOnly bool
+	tasks    *TaskCollection
+	next     func(*context.ExecuteContext, iface.Task) error
+}
+
+func newRemoteCache(
+	ctx *context.ExecuteContext,
+	conf *config.Config,
+	tasks *TaskCollection,
+	next func(*context.ExecuteContext, iface.Task) error,
+) (*remoteCache, error) {
+	resolved, err := conf.Meta.Cache.Resolve(ctx.Env)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := cache.NewBackend(resolved)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteCache{
+		backend:  backend,
+		readOnly: resolved.ReadOnly,
+		tasks:    tasks,
+		next:     next,
+	}, nil
+}
+
+func (c *remoteCache) runTask(ctx *context.ExecuteContext, task iface.Task) error {
+	conf, ok := c.cachedJob(task)
+	if !ok {
+		return c.next(ctx, task)
+	}
+	if stale, err := task.(iface.StaleTask).IsStale(ctx); err == nil && !stale {
+		return c.next(ctx, task)
+	}
+
+	name := task.Name().Resource()
+	key, err := cacheKey(ctx, name, conf)
+	if err != nil {
+		logging.Log.Warnf("Failed to hash inputs of %q: %s", name, err)
+		return c.next(ctx, task)
+	}
+
+	restored, err := c.restore(ctx, key, conf.ArtifactPaths())
+	switch {
+	case err != nil:
+		logging.Log.Warnf("Failed to restore artifacts of %q from the cache: %s", name, err)
+	case restored:
+		logging.Log.Infof("Skipping %q, restored its artifacts from the cache", task.Name())
+		ctx.SetModified(name)
+		return nil
+	}
+
+	if err := c.next(ctx, task); err != nil {
+		return err
+	}
+	if c.readOnly {
+		return nil
+	}
+	if err := c.store(key, conf.ArtifactPaths()); err != nil {
+		logging.Log.Warnf("Failed to store artifacts of %q in the cache: %s", name, err)
+	}
+	return nil
+}
+
+// cachedJob returns the resolved config of task if it is the run task of a job
+// with artifacts
+func (c *remoteCache) cachedJob(task iface.Task) (*config.JobConfig, bool) {
+	if _, ok := task.(*job.Task); !ok || task.Name().Action() != "run" {
+		return nil, false
+	}
+	resource, _ := c.tasks.Resource(task)
+	conf, ok := resource.(*config.JobConfig)
+	if !ok || len(conf.ArtifactPaths()) == 0 || c.tasks.deferred[task.Name().Resource()] {
+		return nil, false
+	}
+	return conf, true
+}
+
+// restore downloads the archive to a temporary file first, so that a failed
+// download does not leave partial artifacts
+func (c *remoteCache) restore(
+	ctx *context.ExecuteContext,
+	key string,
+	paths []string,
+) (bool, error) {
+	tmp, err := ioutil.TempFile(ctx.TempDir, "dobi-cache-")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	found, err := c.backend.Get(key, tmp)
+	if !found || err != nil {
+		return false, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	return true, cache.Extract(tmp, paths)
+}
+
+func (c *remoteCache) store(key string, paths []string) error {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(cache.Archive(paths, writer))
+	}()
+	err := c.backend.Put(key, reader)
+	reader.Close()
+	return err
+}
+
+// cacheKey returns the key of the archive of the artifacts of the job. The key
+// is a checksum of the resolved config of the job, and the contents of its
+// sources, so that the key is the same on every machine with the same sources.
+func cacheKey(ctx *context.ExecuteContext, name string, conf *config.JobConfig) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%#v\n", conf)
+	for _, path := range sourceFiles(ctx, conf) {
+		// Paths are relative to the project, which may be in a different
+		// directory on each machine
+		relPath := path
+		if rel, err := filepath.Rel(ctx.WorkingDir, path); err == nil && filepath.IsAbs(path) {
+			relPath = rel
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			fmt.Fprintf(hash, "%s missing\n", relPath)
+			continue
+		}
+		checksum, err := fs.Checksum(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s %s\n", relPath, checksum)
+	}
+	return name + "-" + hex.EncodeToString(hash.Sum(nil)) + ".tar.gz", nil
+}
@@ -0,0 +1,128 @@
+package tasks
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/client"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/dnephin/dobi/tasks/job"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBackend struct {
+	archives map[string][]byte
+}
+
+func (b *fakeBackend) Get(key string, out io.Writer) (bool, error) {
+	archive, ok := b.archives[key]
+	if !ok {
+		return false, nil
+	}
+	_, err := out.Write(archive)
+	return true, err
+}
+
+func (b *fakeBackend) Put(key string, in io.Reader) error {
+	archive, err := ioutil.ReadAll(in)
+	b.archives[key] = archive
+	return err
+}
+
+// newRemoteCacheContext returns a context with a builder image, which is
+// inspected to check if the job is stale
+func newRemoteCacheContext(t *testing.T, mock *gomock.Controller, dir string) *context.ExecuteContext {
+	conf, err := config.LoadFromBytes([]byte(
+		"image=builder:\n  image: builder\n  tags: [latest]\n"))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	conf.WorkingDir = dir
+
+	mockClient := client.NewMockDockerClient(mock)
+	mockClient.EXPECT().InspectImage("builder:latest").Return(
+		&docker.Image{ID: "sha256:builder", Created: time.Now()}, nil).AnyTimes()
+	return context.NewExecuteContext(
+		conf, mockClient, execenv.NewExecEnv("exec", "project", dir), false)
+}
+
+func TestRemoteCacheStoresAndRestoresArtifacts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote-cache-test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+
+	artifact := filepath.Join(dir, "app")
+	jobConf := &config.JobConfig{Use: "builder", Artifact: artifact}
+	ctx := newRemoteCacheContext(t, mock, dir)
+
+	tasks := newTaskCollection()
+	task := job.NewTask("build", jobConf)
+	tasks.add(task)
+	tasks.addResource(task, jobConf)
+
+	runs := 0
+	backend := &fakeBackend{archives: map[string][]byte{}}
+	c := &remoteCache{
+		backend: backend,
+		tasks:   tasks,
+		next: func(ctx *context.ExecuteContext, task iface.Task) error {
+			runs++
+			return ioutil.WriteFile(artifact, []byte("binary"), 0644)
+		},
+	}
+
+	assert.Nil(t, c.runTask(ctx, task))
+	assert.Equal(t, 1, runs)
+	assert.Len(t, backend.archives, 1)
+
+	assert.Nil(t, os.Remove(artifact))
+	assert.Nil(t, c.runTask(ctx, task))
+	assert.Equal(t, 1, runs)
+	content, err := ioutil.ReadFile(artifact)
+	assert.Nil(t, err)
+	assert.Equal(t, "binary", string(content))
+}
+
+func TestRemoteCacheReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "remote-cache-test")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+
+	jobConf := &config.JobConfig{Use: "builder", Artifact: filepath.Join(dir, "app")}
+	ctx := newRemoteCacheContext(t, mock, dir)
+	tasks := newTaskCollection()
+	task := job.NewTask("build", jobConf)
+	tasks.add(task)
+	tasks.addResource(task, jobConf)
+
+	backend := &fakeBackend{archives: map[string][]byte{}}
+	c := &remoteCache{
+		backend:  backend,
+		readOnly: true,
+		tasks:    tasks,
+		next: func(ctx *context.ExecuteContext, task iface.Task) error {
+			return nil
+		},
+	}
+	assert.Nil(t, c.runTask(ctx, task))
+	assert.Len(t, backend.archives, 0)
+}
@@ -0,0 +1,79 @@
+package tasks
+
+import (
+	"time"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/events"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/dnephin/dobi/tasks/image"
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// reporter emits an event when each task starts, and when it completes with
+// the status and duration of the task, the id of the image of an image task,
+// and the artifacts of the resource
+type reporter struct {
+	events *events.Writer
+	tasks  *TaskCollection
+	next   func(*context.ExecuteContext, iface.Task) error
+}
+
+func (r *reporter) runTask(ctx *context.ExecuteContext, task iface.Task) error {
+	name := task.Name()
+	if r.tasks.Skipped(task) {
+		r.emit(events.Event{
+			Type:     events.TypeComplete,
+			Task:     name.Name(),
+			Resource: name.Resource(),
+			Status:   events.StatusSkipped,
+		})
+		return r.next(ctx, task)
+	}
+
+	start := time.Now()
+	r.emit(events.Event{Type: events.TypeStart, Task: name.Name(), Resource: name.Resource()})
+	err := r.next(ctx, task)
+
+	complete := events.Event{
+		Type:     events.TypeComplete,
+		Task:     name.Name(),
+		Resource: name.Resource(),
+		Status:   events.StatusSuccess,
+		Duration: time.Since(start).Seconds(),
+	}
+	if err != nil {
+		complete.Status = events.StatusFailed
+		complete.Error = ctx.Masker.MaskString(err.Error())
+	}
+	resource, _ := r.tasks.Resource(task)
+	if conf, ok := resource.(*config.ImageConfig); ok && err == nil && name.Action() != "remove" {
+		if img, err := image.GetImage(ctx, conf); err == nil {
+			complete.ImageID = img.ID
+		}
+	}
+	complete.Artifacts, _ = artifactPaths(resource)
+	r.emit(complete)
+	return err
+}
+
+func (r *reporter) emit(event events.Event) {
+	if err := r.events.Emit(event); err != nil {
+		logging.Log.Warnf("Failed to write event for %q: %s", event.Task, err)
+	}
+}
+
+// imageProgressEvents returns an ImageProgressFunc which emits a progress event
+// for each message from the Docker daemon. The task of the message is the name
+// of the image resource.
+func imageProgressEvents(writer *events.Writer) context.ImageProgressFunc {
+	return func(task string, message jsonmessage.JSONMessage) {
+		data := message.Status
+		if message.Stream != "" {
+			data = message.Stream
+		}
+		writer.Emit(events.Event{Type: events.TypeProgress, Resource: task, Data: data})
+	}
+}
@@ -0,0 +1,57 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/events"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeEvents(t *testing.T, out *bytes.Buffer) []events.Event {
+	decoded := []events.Event{}
+	decoder := json.NewDecoder(out)
+	for decoder.More() {
+		event := events.Event{}
+		assert.Nil(t, decoder.Decode(&event))
+		decoded = append(decoded, event)
+	}
+	return decoded
+}
+
+func TestReporterRunTask(t *testing.T) {
+	out := new(bytes.Buffer)
+	tasks := newTaskCollection()
+	task := &fakeTask{name: "test", err: fmt.Errorf("exit 1")}
+	tasks.addResource(task, &config.JobConfig{Artifact: "dist/"})
+	tasks.skipped["push"] = true
+
+	r := &reporter{
+		events: events.NewWriter(out),
+		tasks:  tasks,
+		next: func(ctx *context.ExecuteContext, task iface.Task) error {
+			return task.Run(ctx)
+		},
+	}
+	ctx := &context.ExecuteContext{}
+	assert.EqualError(t, r.runTask(ctx, task), "exit 1")
+	assert.Nil(t, r.runTask(ctx, &fakeTask{name: "push"}))
+
+	decoded := decodeEvents(t, out)
+	if !assert.Len(t, decoded, 3) {
+		return
+	}
+	assert.Equal(t, events.TypeStart, decoded[0].Type)
+	assert.Equal(t, "test:run", decoded[0].Task)
+	assert.Equal(t, events.TypeComplete, decoded[1].Type)
+	assert.Equal(t, events.StatusFailed, decoded[1].Status)
+	assert.Equal(t, "exit 1", decoded[1].Error)
+	assert.Equal(t, []string{"dist/"}, decoded[1].Artifacts)
+	assert.Equal(t, events.StatusSkipped, decoded[2].Status)
+	assert.Equal(t, "push", decoded[2].Resource)
+}
@@ -0,0 +1,82 @@
+package tasks
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+	shlex "github.com/kballard/go-shellquote"
+)
+
+// requirements runs the require commands of a resource before the first task
+// of the resource runs. If a command fails the task is not run.
+type requirements struct {
+	config     *config.Config
+	runCommand func(dir string, args []string) ([]byte, error)
+	next       func(*context.ExecuteContext, iface.Task) error
+
+	mu      sync.Mutex
+	checked map[string]error
+}
+
+func newRequirements(conf *config.Config, next func(*context.ExecuteContext, iface.Task) error) *requirements {
+	return &requirements{
+		config:     conf,
+		runCommand: runRequireCommand,
+		next:       next,
+		checked:    make(map[string]error),
+	}
+}
+
+func (r *requirements) runTask(ctx *context.ExecuteContext, task iface.Task) error {
+	if err := r.check(task.Name().Resource()); err != nil {
+		return err
+	}
+	return r.next(ctx, task)
+}
+
+// check runs the require commands of the resource, once for each resource
+func (r *requirements) check(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err, ok := r.checked[name]; ok {
+		return err
+	}
+	var err error
+	for _, command := range r.config.OptionsFor(name).Require {
+		if err = r.require(name, command); err != nil {
+			break
+		}
+	}
+	r.checked[name] = err
+	return err
+}
+
+func (r *requirements) require(name, command string) error {
+	logging.Log.Debugf("Checking requirement %q of %q", command, name)
+	args, err := shlex.Split(command)
+	if err != nil {
+		return fmt.Errorf("Failed to parse requirement %q of %q: %s", command, name, err)
+	}
+	out, err := r.runCommand(r.config.WorkingDir, args)
+	if err != nil {
+		msg := fmt.Sprintf("Requirement %q of %q failed: %s", command, name, err)
+		if output := strings.TrimSpace(string(out)); output != "" {
+			msg += "\n" + output
+		}
+		return fmt.Errorf("%s", msg)
+	}
+	return nil
+}
+
+func runRequireCommand(dir string, args []string) ([]byte, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
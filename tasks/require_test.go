@@ -0,0 +1,57 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRequirements(failing string) (*requirements, *[]string, *[]string) {
+	conf := config.NewConfig()
+	conf.Options["deploy"] = &config.ResourceOptions{
+		Require: []string{"ping -c 1 vpn.internal", "test -f credentials"},
+	}
+	commands := &[]string{}
+	ran := &[]string{}
+	r := newRequirements(conf, func(ctx *context.ExecuteContext, task iface.Task) error {
+		*ran = append(*ran, task.Name().Name())
+		return nil
+	})
+	r.runCommand = func(dir string, args []string) ([]byte, error) {
+		command := strings.Join(args, " ")
+		*commands = append(*commands, command)
+		if command == failing {
+			return []byte("unknown host\n"), fmt.Errorf("exit status 1")
+		}
+		return nil, nil
+	}
+	return r, commands, ran
+}
+
+func TestRequirementsRunOncePerResource(t *testing.T) {
+	r, commands, ran := newTestRequirements("")
+
+	assert.Nil(t, r.runTask(nil, &fakeTask{name: "other"}))
+	assert.Nil(t, r.runTask(nil, &fakeTask{name: "deploy"}))
+	assert.Nil(t, r.runTask(nil, &fakeTask{name: "deploy"}))
+	assert.Equal(t, []string{"ping -c 1 vpn.internal", "test -f credentials"}, *commands)
+	assert.Equal(t, []string{"other:run", "deploy:run", "deploy:run"}, *ran)
+}
+
+func TestRequirementsFailed(t *testing.T) {
+	r, commands, ran := newTestRequirements("ping -c 1 vpn.internal")
+
+	err := r.runTask(nil, &fakeTask{name: "deploy"})
+	if assert.Error(t, err) {
+		assert.Equal(t,
+			"Requirement \"ping -c 1 vpn.internal\" of \"deploy\" failed: exit status 1\nunknown host",
+			err.Error())
+	}
+	assert.Equal(t, []string{"ping -c 1 vpn.internal"}, *commands)
+	assert.Len(t, *ran, 0)
+}
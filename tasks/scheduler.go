@@ -0,0 +1,159 @@
+package tasks
+
+import (
+	"fmt"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/dnephin/dobi/tasks/image"
+	"github.com/dnephin/dobi/tasks/job"
+	"github.com/dnephin/dobi/tasks/shell"
+)
+
+// scheduler runs the tasks of a TaskCollection in dependency order. Tasks which
+// can run concurrently (see parallelType) are started as soon as all of their
+// dependencies are complete, up to the limit for their resource type. All other
+// tasks run exclusively, in the order of the collection.
+type scheduler struct {
+	ctx          *context.ExecuteContext
+	tasks        *TaskCollection
+	parallelType func(iface.Task) string
+	runTask      func(*context.ExecuteContext, iface.Task) error
+
+	done      map[string]bool
+	running   map[string]iface.Task
+	exclusive bool
+	results   chan taskResult
+}
+
+type taskResult struct {
+	task iface.Task
+	err  error
+}
+
+func newScheduler(ctx *context.ExecuteContext, tasks *TaskCollection) *scheduler {
+	return &scheduler{
+		ctx:   ctx,
+		tasks: tasks,
+		parallelType: func(task iface.Task) string {
+			return parallelType(tasks, task)
+		},
+		runTask: runTask,
+		done:    make(map[string]bool),
+		running: make(map[string]iface.Task),
+		results: make(chan taskResult),
+	}
+}
+
+// parallelTypes are the resource types which can run concurrently
+var parallelTypes = []string{"image", "job", "shell"}
+
+// parallelType returns the resource type used to limit the concurrency of the
+// task, or an empty string if the task must run exclusively. Image builds,
+// jobs which are not interactive, and shell commands may run concurrently.
+func parallelType(tasks *TaskCollection, task iface.Task) string {
+	switch task.(type) {
+	case *image.Task:
+		if task.Name().Action() == "build" {
+			return "image"
+		}
+	case *job.Task:
+		resource, _ := tasks.Resource(task)
+		if conf, ok := resource.(*config.JobConfig); ok && !conf.Interactive {
+			return "job"
+		}
+	case *shell.Task:
+		return "shell"
+	}
+	return ""
+}
+
+func (s *scheduler) run() error {
+	pending := s.tasks.All()
+	var firstErr error
+
+	for len(pending) > 0 || len(s.running) > 0 {
+		if firstErr == nil {
+			pending = s.startReady(pending)
+		}
+		if len(s.running) == 0 {
+			if firstErr == nil && len(pending) > 0 {
+				return fmt.Errorf("Failed to schedule task %q", pending[0].Name())
+			}
+			break
+		}
+
+		result := <-s.results
+		name := result.task.Name().Name()
+		delete(s.running, name)
+		s.done[name] = true
+		s.exclusive = false
+		if result.err != nil && firstErr == nil {
+			firstErr = result.err
+		}
+	}
+	return firstErr
+}
+
+// startReady starts every pending task which is ready and returns the tasks
+// which are still pending. An exclusive task which is ready but can not start
+// blocks all the tasks after it, so that exclusive tasks run in order.
+func (s *scheduler) startReady(pending []iface.Task) []iface.Task {
+	remaining := []iface.Task{}
+	blocked := false
+
+	for _, task := range pending {
+		switch {
+		case blocked || !s.isReady(task):
+		case s.parallel(task) && s.canStartParallel(task):
+			s.start(task)
+			continue
+		case !s.parallel(task) && len(s.running) == 0:
+			s.exclusive = true
+			s.start(task)
+			continue
+		case !s.parallel(task):
+			blocked = true
+		}
+		remaining = append(remaining, task)
+	}
+	return remaining
+}
+
+func (s *scheduler) parallel(task iface.Task) bool {
+	resourceType := s.parallelType(task)
+	return resourceType != "" && s.ctx.Limit(resourceType) > 1
+}
+
+// canStartParallel returns true if fewer tasks of the same resource type as
+// task are running than the limit for the type
+func (s *scheduler) canStartParallel(task iface.Task) bool {
+	if s.exclusive {
+		return false
+	}
+	resourceType := s.parallelType(task)
+	count := 0
+	for _, running := range s.running {
+		if s.parallelType(running) == resourceType {
+			count++
+		}
+	}
+	return count < s.ctx.Limit(resourceType)
+}
+
+func (s *scheduler) isReady(task iface.Task) bool {
+	for _, dep := range s.tasks.Dependencies(task) {
+		if !s.done[dep.Name()] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *scheduler) start(task iface.Task) {
+	s.running[task.Name().Name()] = task
+	go func() {
+		s.results <- taskResult{task: task, err: s.runTask(s.ctx, task)}
+	}()
+}
@@ -0,0 +1,215 @@
+package tasks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeTask struct {
+	name    string
+	deps    []string
+	err     error
+	stopped *[]string
+}
+
+func (t *fakeTask) Repr() string {
+	return t.name
+}
+
+func (t *fakeTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "run")
+}
+
+func (t *fakeTask) Run(ctx *context.ExecuteContext) error {
+	return t.err
+}
+
+func (t *fakeTask) Stop(ctx *context.ExecuteContext) error {
+	if t.stopped != nil {
+		*t.stopped = append(*t.stopped, t.name)
+	}
+	return nil
+}
+
+func (t *fakeTask) Dependencies() []string {
+	return t.deps
+}
+
+type SchedulerSuite struct {
+	suite.Suite
+	tasks  *TaskCollection
+	events []string
+	mu     sync.Mutex
+}
+
+func TestSchedulerSuite(t *testing.T) {
+	suite.Run(t, new(SchedulerSuite))
+}
+
+func (s *SchedulerSuite) SetupTest() {
+	s.tasks = newTaskCollection()
+	s.events = []string{}
+}
+
+func (s *SchedulerSuite) add(task *fakeTask) {
+	s.tasks.add(task)
+	s.tasks.addName(task.name, task.Name())
+}
+
+func (s *SchedulerSuite) record(event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *SchedulerSuite) newScheduler(limit int) *scheduler {
+	return s.newSchedulerWithLimits(map[string]int{"image": limit})
+}
+
+func (s *SchedulerSuite) newSchedulerWithLimits(limits map[string]int) *scheduler {
+	sched := newScheduler(&context.ExecuteContext{Limits: limits}, s.tasks)
+	sched.parallelType = func(task iface.Task) string {
+		name := task.Name().Resource()
+		switch {
+		case strings.HasPrefix(name, "image"):
+			return "image"
+		case strings.HasPrefix(name, "job"):
+			return "job"
+		}
+		return ""
+	}
+	sched.runTask = func(ctx *context.ExecuteContext, task iface.Task) error {
+		s.record("start " + task.Name().Resource())
+		time.Sleep(10 * time.Millisecond)
+		s.record("end " + task.Name().Resource())
+		return task.Run(ctx)
+	}
+	return sched
+}
+
+func (s *SchedulerSuite) TestRunSequential() {
+	s.add(&fakeTask{name: "image-a"})
+	s.add(&fakeTask{name: "job-a", deps: []string{"image-a"}})
+	s.add(&fakeTask{name: "image-b"})
+
+	s.Nil(s.newScheduler(1).run())
+	s.Equal([]string{
+		"start image-a", "end image-a",
+		"start job-a", "end job-a",
+		"start image-b", "end image-b",
+	}, s.events)
+}
+
+func (s *SchedulerSuite) TestRunParallelImages() {
+	s.add(&fakeTask{name: "image-a"})
+	s.add(&fakeTask{name: "image-b"})
+	s.add(&fakeTask{name: "job-a", deps: []string{"image-a", "image-b"}})
+
+	s.Nil(s.newScheduler(2).run())
+	s.Len(s.events, 6)
+	s.Equal([]string{"start image-a", "start image-b"}, sortedPair(s.events[:2]))
+	s.Equal([]string{"start job-a", "end job-a"}, s.events[4:])
+}
+
+func (s *SchedulerSuite) TestRunParallelImagesRespectsLimit() {
+	s.add(&fakeTask{name: "image-a"})
+	s.add(&fakeTask{name: "image-b"})
+	s.add(&fakeTask{name: "image-c"})
+
+	s.Nil(s.newScheduler(2).run())
+	s.Len(s.events, 6)
+	s.Contains(s.events[3:5], "start image-c")
+	s.Contains(s.events[2], "end")
+}
+
+func (s *SchedulerSuite) TestRunParallelImagesRespectsDependencies() {
+	s.add(&fakeTask{name: "image-a"})
+	s.add(&fakeTask{name: "image-b", deps: []string{"image-a"}})
+
+	s.Nil(s.newScheduler(4).run())
+	s.Equal([]string{
+		"start image-a", "end image-a",
+		"start image-b", "end image-b",
+	}, s.events)
+}
+
+func (s *SchedulerSuite) TestRunStopsOnError() {
+	s.add(&fakeTask{name: "image-a", err: fmt.Errorf("failed")})
+	s.add(&fakeTask{name: "job-a", deps: []string{"image-a"}})
+
+	err := s.newScheduler(2).run()
+	s.Error(err)
+	s.Equal([]string{"start image-a", "end image-a"}, s.events)
+}
+
+func (s *SchedulerSuite) TestRunParallelRespectsLimitPerType() {
+	s.add(&fakeTask{name: "image-a"})
+	s.add(&fakeTask{name: "image-b"})
+	s.add(&fakeTask{name: "image-c"})
+	s.add(&fakeTask{name: "job-a"})
+	s.add(&fakeTask{name: "job-b"})
+
+	s.Nil(s.newSchedulerWithLimits(map[string]int{"image": 2, "job": 3}).run())
+	s.Len(s.events, 10)
+	started := append([]string{}, s.events[:4]...)
+	sort.Strings(started)
+	s.Equal([]string{
+		"start image-a", "start image-b", "start job-a", "start job-b",
+	}, started)
+}
+
+func (s *SchedulerSuite) TestRunExclusiveTaskWaitsForParallelTasks() {
+	s.add(&fakeTask{name: "job-a"})
+	s.add(&fakeTask{name: "mount-a"})
+	s.add(&fakeTask{name: "job-b"})
+
+	s.Nil(s.newSchedulerWithLimits(map[string]int{"job": 2}).run())
+	s.Equal([]string{
+		"start job-a", "end job-a",
+		"start mount-a", "end mount-a",
+		"start job-b", "end job-b",
+	}, s.events)
+}
+
+func sortedPair(pair []string) []string {
+	if pair[0] > pair[1] {
+		return []string{pair[1], pair[0]}
+	}
+	return pair
+}
+
+func (s *SchedulerSuite) TestRunFanOutFanIn() {
+	s.add(&fakeTask{name: "image-base"})
+	s.add(&fakeTask{name: "job-a", deps: []string{"image-base"}})
+	s.add(&fakeTask{name: "job-b", deps: []string{"image-base"}})
+	s.add(&fakeTask{name: "job-c", deps: []string{"image-base"}})
+	s.add(&fakeTask{name: "image-release", deps: []string{"job-a", "job-b", "job-c"}})
+
+	s.Nil(s.newSchedulerWithLimits(map[string]int{"image": 2, "job": 3}).run())
+	s.Len(s.events, 10)
+	s.Equal([]string{"start image-base", "end image-base"}, s.events[:2])
+	started := append([]string{}, s.events[2:5]...)
+	sort.Strings(started)
+	s.Equal([]string{"start job-a", "start job-b", "start job-c"}, started)
+	s.Equal([]string{"start image-release", "end image-release"}, s.events[8:])
+}
+
+func (s *SchedulerSuite) TestRunErrorCancelsPendingTasks() {
+	s.add(&fakeTask{name: "job-a", err: fmt.Errorf("failed")})
+	s.add(&fakeTask{name: "job-b"})
+	s.add(&fakeTask{name: "image-a", deps: []string{"job-b"}})
+
+	err := s.newSchedulerWithLimits(map[string]int{"image": 2, "job": 2}).run()
+	s.Error(err)
+	s.Len(s.events, 4)
+	s.NotContains(s.events, "start image-a")
+}
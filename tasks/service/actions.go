@@ -0,0 +1,24 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/iface"
+)
+
+// GetTask returns a new task for the action
+func GetTask(name, action string, conf *config.ServiceConfig) (iface.Task, error) {
+	switch action {
+	case "", "start":
+		return NewStartTask(name, conf), nil
+	case "stop":
+		return NewStopTask(name, conf), nil
+	case "logs":
+		return NewLogsTask(name, conf), nil
+	case "remove", "rm":
+		return NewRemoveTask(name, conf), nil
+	default:
+		return nil, fmt.Errorf("Invalid service action %q for task %q", action, name)
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/job"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// LogsTask is a task which prints the logs of the container of a service
+type LogsTask struct {
+	name   string
+	config *config.ServiceConfig
+}
+
+// NewLogsTask creates a new LogsTask object
+func NewLogsTask(name string, conf *config.ServiceConfig) *LogsTask {
+	return &LogsTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *LogsTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "logs")
+}
+
+// Repr formats the task for logging
+func (t *LogsTask) Repr() string {
+	return fmt.Sprintf("[service:logs %s] %s", t.name, t.config.Use)
+}
+
+// Run prints the logs of the container
+func (t *LogsTask) Run(ctx *context.ExecuteContext) error {
+	name := job.ContainerName(ctx, t.name)
+	err := ctx.Client.Logs(docker.LogsOptions{
+		Container:    name,
+		OutputStream: os.Stdout,
+		ErrorStream:  os.Stderr,
+		Stdout:       true,
+		Stderr:       true,
+		Tail:         "all",
+	})
+	switch err.(type) {
+	case nil:
+		return nil
+	case *docker.NoSuchContainer:
+		return fmt.Errorf("Service %q is not running", t.name)
+	default:
+		return fmt.Errorf("Failed to get the logs of container %q: %s", name, err)
+	}
+}
+
+// Dependencies returns the list of dependencies. The logs task doesn't depend
+// on anything.
+func (t *LogsTask) Dependencies() []string {
+	return []string{}
+}
+
+// Stop the task
+func (t *LogsTask) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/job"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// RemoveTask is a task which removes the container of a service
+type RemoveTask struct {
+	name   string
+	config *config.ServiceConfig
+}
+
+// NewRemoveTask creates a new RemoveTask object
+func NewRemoveTask(name string, conf *config.ServiceConfig) *RemoveTask {
+	return &RemoveTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *RemoveTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "rm")
+}
+
+func (t *RemoveTask) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *RemoveTask) Repr() string {
+	return fmt.Sprintf("[service:rm %s] %s", t.name, t.config.Use)
+}
+
+// Run removes the container, and kills it if it is running
+func (t *RemoveTask) Run(ctx *context.ExecuteContext) error {
+	name := job.ContainerName(ctx, t.name)
+	err := ctx.Client.RemoveContainer(docker.RemoveContainerOptions{
+		ID:            name,
+		RemoveVolumes: true,
+		Force:         true,
+	})
+	switch err.(type) {
+	case nil, *docker.NoSuchContainer:
+	default:
+		return fmt.Errorf("Failed to remove container %q: %s", name, err)
+	}
+	t.logger().Info("Removed")
+	return nil
+}
+
+// Dependencies returns the list of dependencies. The remove task doesn't depend
+// on anything.
+func (t *RemoveTask) Dependencies() []string {
+	return []string{}
+}
+
+// Stop the task
+func (t *RemoveTask) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
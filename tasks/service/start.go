@@ -0,0 +1,55 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/job"
+)
+
+// StartTask is a task which starts the container of a service, and waits for
+// it to be ready. The container is stopped and removed when the task is
+// stopped.
+type StartTask struct {
+	name   string
+	config *config.ServiceConfig
+	job    *job.Task
+}
+
+// NewStartTask creates a new StartTask object
+func NewStartTask(name string, conf *config.ServiceConfig) *StartTask {
+	return &StartTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *StartTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "start")
+}
+
+// Repr formats the task for logging
+func (t *StartTask) Repr() string {
+	return fmt.Sprintf("[service:start %s] %s", t.name, t.config.Use)
+}
+
+// Run starts the container of the service
+func (t *StartTask) Run(ctx *context.ExecuteContext) error {
+	// The job is created when the task runs, because the config of the
+	// service may be resolved after the task is created
+	t.job = job.NewServiceTask(t.name, t.config.Job())
+	return t.job.Run(ctx)
+}
+
+// Dependencies returns the list of dependencies
+func (t *StartTask) Dependencies() []string {
+	return t.config.Dependencies()
+}
+
+// Stop stops and removes the container of the service, if it was started
+func (t *StartTask) Stop(ctx *context.ExecuteContext) error {
+	if t.job == nil {
+		return nil
+	}
+	return t.job.Stop(ctx)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/client"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type closeWaiter struct{}
+
+func (w closeWaiter) Close() error {
+	return nil
+}
+
+func (w closeWaiter) Wait() error {
+	return nil
+}
+
+func TestStartTaskLeavesContainerRunningUntilStopped(t *testing.T) {
+	mock := gomock.NewController(t)
+	defer mock.Finish()
+	mockClient := client.NewMockDockerClient(mock)
+
+	conf, err := config.LoadFromBytes([]byte(
+		"image=postgres:\n  image: postgres\n" +
+			"service=db:\n  use: postgres\n  ports: ['5432:5432']\n  stop-timeout: 30s\n"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := context.NewExecuteContext(
+		conf, mockClient, execenv.NewExecEnv("exec", "project", "."), false)
+	task := NewStartTask("db", conf.Resources["db"].(*config.ServiceConfig))
+	assert.Equal(t, "db:start", task.Name().Name())
+
+	mockClient.EXPECT().InspectImage("postgres:project-exec").Return(
+		&docker.Image{ID: "sha256:postgres"}, nil)
+	mockClient.EXPECT().CreateContainer(gomock.Any()).Do(
+		func(opts docker.CreateContainerOptions) {
+			assert.Equal(t, "project-exec-db", opts.Name)
+			assert.Len(t, opts.HostConfig.PortBindings, 1)
+		}).Return(&docker.Container{ID: "container-id"}, nil)
+	mockClient.EXPECT().AttachToContainerNonBlocking(gomock.Any()).Return(closeWaiter{}, nil)
+	mockClient.EXPECT().StartContainer("container-id", nil).Return(nil)
+	assert.Nil(t, task.Run(ctx))
+
+	gomock.InOrder(
+		mockClient.EXPECT().StopContainer("container-id", uint(30)).Return(nil),
+		mockClient.EXPECT().RemoveContainer(docker.RemoveContainerOptions{
+			ID:            "container-id",
+			RemoveVolumes: true,
+		}).Return(nil),
+	)
+	assert.Nil(t, task.Stop(ctx))
+}
+
+func TestStopTaskNotStarted(t *testing.T) {
+	task := NewStartTask("db", &config.ServiceConfig{Use: "postgres"})
+	assert.Nil(t, task.Stop(&context.ExecuteContext{}))
+}
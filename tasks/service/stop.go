@@ -0,0 +1,48 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/job"
+)
+
+// StopTask is a task which stops and removes the container of a service which
+// is still running, for example a service started by another dobi process
+type StopTask struct {
+	name   string
+	config *config.ServiceConfig
+}
+
+// NewStopTask creates a new StopTask object
+func NewStopTask(name string, conf *config.ServiceConfig) *StopTask {
+	return &StopTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *StopTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "stop")
+}
+
+// Repr formats the task for logging
+func (t *StopTask) Repr() string {
+	return fmt.Sprintf("[service:stop %s] %s", t.name, t.config.Use)
+}
+
+// Run stops the container and removes it
+func (t *StopTask) Run(ctx *context.ExecuteContext) error {
+	return job.NewStopTask(t.name, t.config.Job()).Run(ctx)
+}
+
+// Dependencies returns the list of dependencies. The stop task doesn't depend
+// on anything.
+func (t *StopTask) Dependencies() []string {
+	return []string{}
+}
+
+// Stop the task
+func (t *StopTask) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
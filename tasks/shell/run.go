@@ -0,0 +1,172 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/utils/fs"
+	"github.com/dnephin/dobi/utils/mask"
+	"github.com/dnephin/dobi/utils/prefix"
+)
+
+// Task is a task which runs a command on the host
+type Task struct {
+	name   string
+	config *config.ShellConfig
+}
+
+// NewTask creates a new Task object
+func NewTask(name string, conf *config.ShellConfig) *Task {
+	return &Task{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *Task) Name() common.TaskName {
+	return common.NewTaskName(t.name, "run")
+}
+
+func (t *Task) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *Task) Repr() string {
+	buff := &bytes.Buffer{}
+	buff.WriteString(" " + t.config.Command.String())
+	if t.config.Artifact != "" {
+		buff.WriteString(" -> " + t.config.Artifact)
+	}
+	return fmt.Sprintf("[shell:run %v]%v", t.name, buff.String())
+}
+
+// Run runs the command if the artifact is stale
+func (t *Task) Run(ctx *context.ExecuteContext) error {
+	stale, err := t.IsStale(ctx)
+	if !stale || err != nil {
+		t.logger().Info("is fresh")
+		return err
+	}
+	t.logger().Debug("is stale")
+
+	t.logger().Info("Start")
+	if err := t.runCommand(ctx); err != nil {
+		return err
+	}
+	ctx.SetModified(t.name)
+	t.logger().Info("Done")
+	return nil
+}
+
+// IsStale returns true if the command needs to run
+func (t *Task) IsStale(ctx *context.ExecuteContext) (bool, error) {
+	if ctx.IsModified(t.config.Dependencies()...) {
+		return true, nil
+	}
+	if t.config.Artifact == "" {
+		return true, nil
+	}
+
+	// File or directory doesn't exist
+	if _, err := os.Stat(t.config.Artifact); err != nil {
+		return true, nil
+	}
+	artifactLastModified, err := fs.LastModified(t.config.Artifact)
+	if err != nil {
+		t.logger().Warnf("Failed to get artifact last modified: %s", err)
+		return true, err
+	}
+	if len(t.config.Sources) == 0 {
+		return false, nil
+	}
+	sourcesLastModified, err := fs.LastModified(t.config.Sources...)
+	if err != nil {
+		return true, err
+	}
+	if artifactLastModified.Before(sourcesLastModified) {
+		t.logger().Debug("artifact older than sources")
+		return true, nil
+	}
+	return false, nil
+}
+
+func (t *Task) runCommand(ctx *context.ExecuteContext) error {
+	args := t.config.Command.Value()
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = workingDir(ctx.WorkingDir, t.config.WorkingDir)
+	cmd.Env = append(os.Environ(), t.config.Env...)
+	// Concurrent commands can not share stdin
+	if ctx.Limit("shell") <= 1 {
+		cmd.Stdin = os.Stdin
+	}
+	var flush func()
+	cmd.Stdout, cmd.Stderr, flush = t.outputStreams(ctx, os.Stdout, os.Stderr)
+	defer flush()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("Failed to run %q: %s", t.config.Command.String(), err)
+	}
+	return nil
+}
+
+type flusher interface {
+	Flush() error
+}
+
+// outputStreams returns the writers for the stdout and stderr of the command,
+// and a function which flushes any buffered output. When shell tasks run
+// concurrently the output is prefixed with the task name, unless the context
+// has Events, which receive the output instead. When a mask is configured the
+// output is masked.
+func (t *Task) outputStreams(
+	ctx *context.ExecuteContext,
+	stdout io.Writer,
+	stderr io.Writer,
+) (io.Writer, io.Writer, func()) {
+	flushers := []flusher{}
+	switch {
+	case ctx.Events != nil:
+		name := t.Name()
+		stdout = ctx.Events.Output(name.Name(), name.Resource(), "stdout")
+		stderr = ctx.Events.Output(name.Name(), name.Resource(), "stderr")
+	case ctx.Limit("shell") > 1:
+		label := fmt.Sprintf("[%s] ", t.name)
+		outPrefix, errPrefix := prefix.NewWriter(stdout, label), prefix.NewWriter(stderr, label)
+		stdout, stderr = outPrefix, errPrefix
+		flushers = append(flushers, outPrefix, errPrefix)
+	}
+	if ctx.Masker != nil {
+		outMask, errMask := mask.NewWriter(stdout, ctx.Masker), mask.NewWriter(stderr, ctx.Masker)
+		stdout, stderr = outMask, errMask
+		flushers = append([]flusher{outMask, errMask}, flushers...)
+	}
+	return stdout, stderr, func() {
+		for _, writer := range flushers {
+			writer.Flush()
+		}
+	}
+}
+
+func workingDir(projectDir, dir string) string {
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(projectDir, dir)
+}
+
+// Dependencies returns the list of dependencies
+func (t *Task) Dependencies() []string {
+	return t.config.Dependencies()
+}
+
+// Stop the task
+func (t *Task) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
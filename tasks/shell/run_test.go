@@ -0,0 +1,122 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/events"
+	"github.com/stretchr/testify/suite"
+)
+
+type ShellTaskSuite struct {
+	suite.Suite
+	dir string
+	ctx *context.ExecuteContext
+}
+
+func TestShellTaskSuite(t *testing.T) {
+	suite.Run(t, new(ShellTaskSuite))
+}
+
+func (s *ShellTaskSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "shell-task-test")
+	s.Require().Nil(err)
+	s.ctx = context.NewExecuteContext(
+		&config.Config{WorkingDir: s.dir}, nil, nil, false)
+}
+
+func (s *ShellTaskSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *ShellTaskSuite) newConfig(command string) *config.ShellConfig {
+	conf, err := config.LoadFromBytes([]byte("shell=generate:\n  command: " + command + "\n"))
+	s.Require().Nil(err)
+	return conf.Resources["generate"].(*config.ShellConfig)
+}
+
+func (s *ShellTaskSuite) TestRunCreatesArtifact() {
+	conf := s.newConfig(`"sh -c 'echo $GREETING > out.txt'"`)
+	conf.Env = []string{"GREETING=hello"}
+	conf.Artifact = filepath.Join(s.dir, "out.txt")
+
+	s.Nil(NewTask("generate", conf).Run(s.ctx))
+	s.True(s.ctx.IsModified("generate"))
+	content, err := ioutil.ReadFile(conf.Artifact)
+	s.Nil(err)
+	s.Equal("hello\n", string(content))
+}
+
+func (s *ShellTaskSuite) TestRunFails() {
+	conf := s.newConfig(`"false"`)
+
+	err := NewTask("generate", conf).Run(s.ctx)
+	s.Error(err)
+	s.Contains(err.Error(), `Failed to run "false"`)
+}
+
+func (s *ShellTaskSuite) TestIsStale() {
+	conf := s.newConfig(`"true"`)
+	conf.Artifact = filepath.Join(s.dir, "artifact")
+	source := filepath.Join(s.dir, "source")
+	conf.Sources = []string{source}
+	task := NewTask("generate", conf)
+
+	stale, err := task.IsStale(s.ctx)
+	s.Nil(err)
+	s.True(stale, "artifact is missing")
+
+	s.Require().Nil(ioutil.WriteFile(source, []byte("a"), 0644))
+	s.Require().Nil(ioutil.WriteFile(conf.Artifact, []byte("b"), 0644))
+	old := time.Now().Add(-time.Hour)
+	s.Require().Nil(os.Chtimes(source, old, old))
+
+	stale, err = task.IsStale(s.ctx)
+	s.Nil(err)
+	s.False(stale)
+
+	s.Require().Nil(os.Chtimes(source, time.Now().Add(time.Hour), time.Now().Add(time.Hour)))
+	stale, err = task.IsStale(s.ctx)
+	s.Nil(err)
+	s.True(stale, "artifact is older than sources")
+}
+
+func (s *ShellTaskSuite) TestOutputStreamsPrefixedWhenParallel() {
+	task := NewTask("generate", s.newConfig(`"true"`))
+	out := new(bytes.Buffer)
+
+	s.ctx.Limits = map[string]int{"shell": 2}
+	stdout, _, flush := task.outputStreams(s.ctx, out, out)
+	fmt.Fprint(stdout, "one\ntwo")
+	flush()
+	s.Equal("[generate] one\n[generate] two\n", out.String())
+
+	out.Reset()
+	s.ctx.Limits = nil
+	stdout, _, flush = task.outputStreams(s.ctx, out, out)
+	fmt.Fprint(stdout, "one\n")
+	flush()
+	s.Equal("one\n", out.String())
+}
+
+func (s *ShellTaskSuite) TestOutputStreamsToEvents() {
+	task := NewTask("generate", s.newConfig(`"true"`))
+	out, eventOut := new(bytes.Buffer), new(bytes.Buffer)
+
+	s.ctx.Limits = map[string]int{"shell": 2}
+	s.ctx.Events = events.NewWriter(eventOut)
+	_, stderr, flush := task.outputStreams(s.ctx, out, out)
+	fmt.Fprint(stderr, "failed\n")
+	flush()
+	s.Equal("", out.String())
+	s.Contains(eventOut.String(),
+		`"type":"output","task":"generate:run","resource":"generate","stream":"stderr","data":"failed\n"`)
+}
@@ -0,0 +1,85 @@
+package tasks
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+)
+
+// filterStale returns a TaskCollection with only the tasks which are stale, or
+// which depend on a task which is stale. Tasks which can not check if they are
+// stale are always included. Skipped tasks are never stale.
+func filterStale(ctx *context.ExecuteContext, tasks *TaskCollection) (*TaskCollection, error) {
+	stale := make(map[string]bool)
+	filtered := newTaskCollection()
+
+	for _, task := range tasks.All() {
+		isStale, err := taskIsStale(ctx, tasks, task, stale)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to check if %q is stale: %s", task.Name(), err)
+		}
+		_, canCheck := task.(iface.StaleTask)
+		if !isStale && canCheck {
+			continue
+		}
+		stale[task.Name().Name()] = isStale
+		filtered.skipped[task.Name().Resource()] = tasks.Skipped(task)
+		filtered.add(task)
+		if resource, ok := tasks.Resource(task); ok {
+			filtered.addResource(task, resource)
+		}
+	}
+
+	for name, taskname := range tasks.names {
+		if filtered.contains(taskname) {
+			filtered.addName(name, taskname)
+		}
+	}
+	return filtered, nil
+}
+
+func taskIsStale(
+	ctx *context.ExecuteContext,
+	tasks *TaskCollection,
+	task iface.Task,
+	stale map[string]bool,
+) (bool, error) {
+	if tasks.Skipped(task) {
+		return false, nil
+	}
+	for _, dep := range tasks.Dependencies(task) {
+		if stale[dep.Name()] {
+			return true, nil
+		}
+	}
+	staleTask, ok := task.(iface.StaleTask)
+	if !ok {
+		return false, nil
+	}
+	return staleTask.IsStale(ctx)
+}
+
+// listStale prints the name of each resource which has a stale task, one per
+// line, and returns an error if any resource is stale. Tasks which can not
+// check if they are stale are ignored.
+func listStale(ctx *context.ExecuteContext, out io.Writer, tasks *TaskCollection) error {
+	filtered, err := filterStale(ctx, tasks)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool)
+	for _, task := range filtered.All() {
+		name := task.Name().Resource()
+		if _, canCheck := task.(iface.StaleTask); !canCheck || seen[name] {
+			continue
+		}
+		seen[name] = true
+		fmt.Fprintln(out, name)
+	}
+	if len(seen) > 0 {
+		return fmt.Errorf("Found %d stale resources", len(seen))
+	}
+	return nil
+}
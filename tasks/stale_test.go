@@ -0,0 +1,87 @@
+package tasks
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStaleTask struct {
+	fakeTask
+	stale bool
+}
+
+func (t *fakeStaleTask) IsStale(ctx *context.ExecuteContext) (bool, error) {
+	return t.stale, nil
+}
+
+func TestFilterStale(t *testing.T) {
+	tasks := newTaskCollection()
+	for _, task := range []*fakeStaleTask{
+		{fakeTask: fakeTask{name: "fresh-image"}},
+		{fakeTask: fakeTask{name: "stale-image"}, stale: true},
+		{fakeTask: fakeTask{name: "fresh-job", deps: []string{"fresh-image"}}},
+		{fakeTask: fakeTask{name: "dependent-job", deps: []string{"stale-image"}}},
+	} {
+		tasks.add(task)
+		tasks.addName(task.name, task.Name())
+	}
+	alias := &fakeTask{name: "alias", deps: []string{"fresh-job", "dependent-job"}}
+	tasks.add(alias)
+	tasks.addName(alias.name, alias.Name())
+
+	filtered, err := filterStale(&context.ExecuteContext{}, tasks)
+	assert.Nil(t, err)
+
+	names := []string{}
+	for _, task := range filtered.All() {
+		names = append(names, task.Name().Resource())
+	}
+	assert.Equal(t, []string{"stale-image", "dependent-job", "alias"}, names)
+	assert.Len(t, filtered.Dependencies(alias), 1)
+}
+
+func TestPrintDryRun(t *testing.T) {
+	tasks := newTaskCollection()
+	tasks.add(&fakeTask{name: "one"})
+	tasks.add(&fakeTask{name: "two"})
+
+	out := &bytes.Buffer{}
+	printDryRun(out, tasks)
+	assert.Equal(t, "Tasks which would run:\n  one\n  two\n", out.String())
+}
+
+func TestListStale(t *testing.T) {
+	tasks := newTaskCollection()
+	for _, task := range []*fakeStaleTask{
+		{fakeTask: fakeTask{name: "fresh-image"}},
+		{fakeTask: fakeTask{name: "stale-image"}, stale: true},
+		{fakeTask: fakeTask{name: "dependent-job", deps: []string{"stale-image"}}},
+	} {
+		tasks.add(task)
+		tasks.addName(task.name, task.Name())
+	}
+	alias := &fakeTask{name: "alias", deps: []string{"dependent-job"}}
+	tasks.add(alias)
+	tasks.addName(alias.name, alias.Name())
+
+	out := &bytes.Buffer{}
+	err := listStale(&context.ExecuteContext{}, out, tasks)
+	if assert.Error(t, err) {
+		assert.Equal(t, "Found 2 stale resources", err.Error())
+	}
+	assert.Equal(t, "stale-image\ndependent-job\n", out.String())
+}
+
+func TestListStaleNoneStale(t *testing.T) {
+	tasks := newTaskCollection()
+	task := &fakeStaleTask{fakeTask: fakeTask{name: "fresh-image"}}
+	tasks.add(task)
+	tasks.addName(task.name, task.Name())
+
+	out := &bytes.Buffer{}
+	assert.Nil(t, listStale(&context.ExecuteContext{}, out, tasks))
+	assert.Equal(t, "", out.String())
+}
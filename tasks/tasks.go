@@ -1,7 +1,10 @@
 package tasks
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
 
@@ -14,22 +17,70 @@ import (
 	"github.com/dnephin/dobi/tasks/common"
 	"github.com/dnephin/dobi/tasks/compose"
 	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/download"
+	"github.com/dnephin/dobi/tasks/env"
+	"github.com/dnephin/dobi/tasks/events"
+	"github.com/dnephin/dobi/tasks/history"
 	"github.com/dnephin/dobi/tasks/iface"
 	"github.com/dnephin/dobi/tasks/image"
 	"github.com/dnephin/dobi/tasks/job"
 	"github.com/dnephin/dobi/tasks/mount"
+	"github.com/dnephin/dobi/tasks/network"
+	"github.com/dnephin/dobi/tasks/registry"
+	"github.com/dnephin/dobi/tasks/service"
+	"github.com/dnephin/dobi/tasks/shell"
+	"github.com/dnephin/dobi/tasks/volume"
+	"github.com/dnephin/dobi/tasks/wait"
+	"github.com/dnephin/dobi/utils/mask"
 	"github.com/dnephin/dobi/utils/stack"
+	"github.com/docker/docker/pkg/term"
 )
 
 // TaskCollection is a collection of Task objects
 type TaskCollection struct {
 	tasks []iface.Task
+	// names maps a task name, as it appears in config, to the name of the
+	// task that was created for it
+	names map[string]common.TaskName
+	// resources maps a task name to the resolved resource of the task
+	resources map[string]config.Resource
+	// deferred is the set of resources which use captured variables, and are
+	// resolved when their task runs
+	deferred map[string]bool
+	// skipped is the set of resources with a when condition which is false
+	skipped map[string]bool
 }
 
 func (c *TaskCollection) add(task iface.Task) {
 	c.tasks = append(c.tasks, task)
 }
 
+func (c *TaskCollection) addResource(task iface.Task, resource config.Resource) {
+	c.resources[task.Name().Name()] = resource
+}
+
+// Resource returns the resolved resource of the task
+func (c *TaskCollection) Resource(task iface.Task) (config.Resource, bool) {
+	resource, ok := c.resources[task.Name().Name()]
+	return resource, ok
+}
+
+func (c *TaskCollection) addName(name string, taskname common.TaskName) {
+	c.names[name] = taskname
+}
+
+// Dependencies returns the names of the tasks which are direct dependencies
+// of task
+func (c *TaskCollection) Dependencies(task iface.Task) []common.TaskName {
+	deps := []common.TaskName{}
+	for _, dep := range task.Dependencies() {
+		if taskname, ok := c.names[dep]; ok {
+			deps = append(deps, taskname)
+		}
+	}
+	return deps
+}
+
 func (c *TaskCollection) contains(name common.TaskName) bool {
 	for _, task := range c.tasks {
 		if task.Name().Name() == name.Name() {
@@ -39,6 +90,11 @@ func (c *TaskCollection) contains(name common.TaskName) bool {
 	return false
 }
 
+// Skipped returns true if the when condition of the resource of task is false
+func (c *TaskCollection) Skipped(task iface.Task) bool {
+	return c.skipped[task.Name().Resource()]
+}
+
 // All returns all the tasks in the dependency order
 func (c *TaskCollection) All() []iface.Task {
 	return c.tasks
@@ -54,41 +110,94 @@ func (c *TaskCollection) Reversed() []iface.Task {
 }
 
 func newTaskCollection() *TaskCollection {
-	return &TaskCollection{}
+	return &TaskCollection{
+		names:     make(map[string]common.TaskName),
+		resources: make(map[string]config.Resource),
+		deferred:  make(map[string]bool),
+		skipped:   make(map[string]bool),
+	}
 }
 
+// collectTasks returns the tasks and all their dependencies. Errors from
+// resolving variables are collected from every task, so they can all be
+// reported together.
 func collectTasks(options RunOptions, execEnv *execenv.ExecEnv) (*TaskCollection, error) {
-	return collect(options, &collectionState{
-		newTaskCollection(),
-		stack.NewStringStack(),
-		newResourceResolver(execEnv),
-	})
+	state := &collectionState{
+		tasks:         newTaskCollection(),
+		taskStack:     stack.NewStringStack(),
+		resolver:      newResourceResolver(execEnv),
+		resolveErrors: &config.ErrorList{},
+		unresolved:    make(map[string]bool),
+		loadedEnv:     make(map[string]bool),
+	}
+	tasks, err := collect(options, state)
+	if err != nil {
+		return nil, err
+	}
+	if err := state.resolveErrors.ErrorOrNil(); err != nil {
+		return nil, fmt.Errorf("Failed to resolve variables:\n%s", err)
+	}
+	return tasks, nil
 }
 
 type collectionState struct {
-	tasks     *TaskCollection
-	taskStack *stack.StringStack
-	resolver  *ResourceResolver
+	tasks         *TaskCollection
+	taskStack     *stack.StringStack
+	resolver      *ResourceResolver
+	resolveErrors *config.ErrorList
+	unresolved    map[string]bool
+	loadedEnv     map[string]bool
 }
 
 func collect(options RunOptions, state *collectionState) (*TaskCollection, error) {
-	for _, taskname := range options.Tasks {
-		taskname := common.ParseTaskName(taskname)
+	for _, rawname := range options.Tasks {
+		taskname := common.ParseTaskName(rawname)
 		name := taskname.Resource()
 		resource, ok := options.Config.Resources[name]
 		if !ok {
 			return nil, fmt.Errorf("Resource %q does not exist", name)
 		}
 
+		if err := loadEnvDependencies(options.Config, resource, state); err != nil {
+			return nil, err
+		}
 		resource, err := state.resolver.Resolve(name, resource)
+		var missing []string
 		if err != nil {
-			return nil, err
+			missing = state.resolver.execEnv.MissingCaptures()
+		}
+		switch {
+		case len(missing) > 0:
+			// The resource uses variables captured by jobs, and is resolved
+			// after the jobs run
+			if err := checkCaptures(options.Config, name, missing); err != nil {
+				return nil, err
+			}
+			state.tasks.deferred[name] = true
+		case err != nil:
+			if !state.unresolved[name] {
+				state.resolveErrors.Add(err)
+				state.unresolved[name] = true
+			}
+		default:
+			if err := config.ValidateResolved(
+				name, resource, options.Config, state.resolver.execEnv); err != nil {
+				return nil, err
+			}
 		}
 
+		run, err := config.EvalWhen(
+			options.Config.OptionsFor(name).When, state.resolver.execEnv)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to evaluate when of %q: %s", name, err)
+		}
+		state.tasks.skipped[name] = !run
+
 		task, err := buildTaskFromResource(name, taskname.Action(), resource)
 		if err != nil {
 			return nil, err
 		}
+		state.tasks.addName(rawname, task.Name())
 
 		if state.tasks.contains(task.Name()) {
 			logging.Log.Debugf("%q already in task list, skipping", task.Name())
@@ -102,15 +211,67 @@ func collect(options RunOptions, state *collectionState) (*TaskCollection, error
 		state.taskStack.Push(task.Name().Name())
 
 		options.Tasks = task.Dependencies()
+		if state.unresolved[name] || state.tasks.deferred[name] {
+			options.Tasks = existingResources(options.Config, options.Tasks)
+		}
+		if state.tasks.skipped[name] {
+			// The dependencies of a skipped resource are only collected if
+			// another resource depends on them
+			options.Tasks = nil
+		}
 		if _, err := collect(options, state); err != nil {
 			return nil, err
 		}
 		state.tasks.add(task)
+		state.tasks.addResource(task, resource)
 		state.taskStack.Pop()
 	}
 	return state.tasks, nil
 }
 
+// loadEnvDependencies sets the variables of the env resources which the
+// resource depends on, so that the variables are set when the resource is
+// resolved
+func loadEnvDependencies(
+	conf *config.Config,
+	resource config.Resource,
+	state *collectionState,
+) error {
+	for _, dep := range resource.Dependencies() {
+		name := common.ParseTaskName(dep).Resource()
+		envConf, ok := conf.Resources[name].(*config.EnvConfig)
+		if !ok || state.loadedEnv[name] {
+			continue
+		}
+		state.loadedEnv[name] = true
+		if err := loadEnvDependencies(conf, envConf, state); err != nil {
+			return err
+		}
+		resolved, err := state.resolver.Resolve(name, envConf)
+		if err != nil {
+			return err
+		}
+		err = env.Load(conf.WorkingDir, resolved.(*config.EnvConfig), state.resolver.execEnv)
+		if err != nil {
+			return fmt.Errorf("Failed to load env %q: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// existingResources returns the task names which refer to a resource in the
+// config. The dependencies of a resource which failed to resolve may still
+// contain variables.
+func existingResources(conf *config.Config, names []string) []string {
+	existing := []string{}
+	for _, name := range names {
+		if _, ok := conf.Resources[common.ParseTaskName(name).Resource()]; ok {
+			existing = append(existing, name)
+		}
+	}
+	return existing
+}
+
 // ResourceResolver is used to resolve variables in a resource config, and cache
 // the result of the resolution
 type ResourceResolver struct {
@@ -125,7 +286,7 @@ func (r *ResourceResolver) Resolve(name string, res config.Resource) (config.Res
 	if ok {
 		return resolved, nil
 	}
-	resolved, err = res.Resolve(r.execEnv)
+	resolved, err = config.ResolveResource(name, res, r.execEnv)
 	if err == nil {
 		r.cache[name] = resolved
 	}
@@ -149,13 +310,36 @@ func buildTaskFromResource(name, action string, resource config.Resource) (iface
 		return alias.GetTask(name, action, conf)
 	case *config.ComposeConfig:
 		return compose.GetTask(name, action, conf)
+	case *config.DownloadConfig:
+		return download.GetTask(name, action, conf)
+	case *config.NetworkConfig:
+		return network.GetTask(name, action, conf)
+	case *config.VolumeConfig:
+		return volume.GetTask(name, action, conf)
+	case *config.ShellConfig:
+		return shell.GetTask(name, action, conf)
+	case *config.ServiceConfig:
+		return service.GetTask(name, action, conf)
+	case *config.RegistryConfig:
+		return registry.GetTask(name, action, conf)
+	case *config.WaitConfig:
+		return wait.GetTask(name, action, conf)
+	case *config.EnvConfig:
+		return env.GetTask(name, action, conf)
 	default:
 		panic(fmt.Sprintf("Unexpected config type %T", conf))
 	}
 
 }
 
-func executeTasks(ctx *context.ExecuteContext, tasks *TaskCollection) error {
+// executeTasks runs the tasks, and then stops them one at a time in the reverse
+// of their dependency order, so that a task is stopped before the tasks it
+// depends on
+func executeTasks(
+	ctx *context.ExecuteContext,
+	tasks *TaskCollection,
+	run func(*context.ExecuteContext, iface.Task) error,
+) error {
 	defer func() {
 		logging.Log.Debug("stopping tasks")
 		for _, task := range tasks.Reversed() {
@@ -166,21 +350,36 @@ func executeTasks(ctx *context.ExecuteContext, tasks *TaskCollection) error {
 	}()
 
 	logging.Log.Debug("executing tasks")
-	for _, task := range tasks.All() {
-		start := time.Now()
-		logging.Log.WithFields(log.Fields{
-			"time": start,
-			"task": task,
-		}).Debug("Start")
+	sched := newScheduler(ctx, tasks)
+	sched.runTask = run
+	return sched.run()
+}
 
-		if err := task.Run(ctx); err != nil {
-			return fmt.Errorf("Failed to execute task %q: %s", task.Name(), err)
+func printDryRun(out io.Writer, tasks *TaskCollection) {
+	fmt.Fprintln(out, "Tasks which would run:")
+	for _, task := range tasks.All() {
+		if tasks.Skipped(task) {
+			fmt.Fprintf(out, "  %s (skipped)\n", task.Repr())
+			continue
 		}
-		logging.Log.WithFields(log.Fields{
-			"elapsed": time.Since(start),
-			"task":    task,
-		}).Debug("Complete")
+		fmt.Fprintf(out, "  %s\n", task.Repr())
+	}
+}
+
+func runTask(ctx *context.ExecuteContext, task iface.Task) error {
+	start := time.Now()
+	logging.Log.WithFields(log.Fields{
+		"time": start,
+		"task": task,
+	}).Debug("Start")
+
+	if err := task.Run(ctx); err != nil {
+		return fmt.Errorf("Failed to execute task %q: %s", task.Name(), err)
 	}
+	logging.Log.WithFields(log.Fields{
+		"elapsed": time.Since(start),
+		"task":    task,
+	}).Debug("Complete")
 	return nil
 }
 
@@ -190,6 +389,115 @@ type RunOptions struct {
 	Config *config.Config
 	Tasks  []string
 	Quiet  bool
+	// ParallelImages is the maximum number of independent image builds to
+	// run concurrently
+	ParallelImages int
+	// Parallel is the maximum number of independent tasks of each resource
+	// type to run concurrently
+	Parallel int
+	// ParallelPull, when set, is the number of images to pull concurrently
+	// before any tasks run
+	ParallelPull int
+	// TempDir is the directory used for intermediate files. Defaults to the
+	// system temp directory.
+	TempDir string
+	// EnvPassthrough is a list of host environment variables which are added
+	// to the environment of every job
+	EnvPassthrough []string
+	// EnvFiles are files of variables which are used to resolve {env.NAME}
+	// variables. Variables from a later file take precedence.
+	EnvFiles []string
+	// Masker redacts sensitive values from the output of jobs
+	Masker *mask.Masker
+	// SinceLastSuccess skips jobs and image builds which succeeded in a previous
+	// run, when their inputs are unchanged
+	SinceLastSuccess bool
+	// ExplainCache prints the inputs hash and stored hash of each task skipped
+	// or run by SinceLastSuccess, and the reason
+	ExplainCache bool
+	// FailOnPause returns an error when a resource pauses and stdin is not a
+	// terminal, instead of continuing without a pause
+	FailOnPause bool
+	// OnlyStale runs only the tasks which are stale, or depend on a stale task
+	OnlyStale bool
+	// DryRun prints the tasks which would run, without running them
+	DryRun bool
+	// PlanFile is the path of a file where the execution plan is written as
+	// json, before any tasks run
+	PlanFile string
+	// ExecCommand is the command run in the container of a job by the exec
+	// action
+	ExecCommand []string
+	// ListStale prints the name of each stale resource, without running any
+	// tasks, and returns an error if any resource is stale
+	ListStale bool
+	// Force runs jobs with run-once, even if they have already run
+	Force bool
+	// Interactive, when set, overrides the interactive setting of every job
+	Interactive *bool
+	// ResourceTimeout is the maximum time to run the task of any resource which
+	// does not set its own timeout. Defaults to meta.default-timeout.
+	ResourceTimeout time.Duration
+	// NoRemove keeps the container of every job after it runs
+	NoRemove bool
+	// SkipInitialRun is used by Watch to wait for a change to the watched
+	// files before the tasks run for the first time
+	SkipInitialRun bool
+	// ImageProgress receives the progress messages from the Docker daemon
+	// when an image is built, pulled, or pushed, instead of displaying them
+	// on stdout
+	ImageProgress context.ImageProgressFunc
+	// Events, when set, receives a structured event when each task starts and
+	// completes, for the output of jobs and shells, and for the progress of
+	// images, unless ImageProgress is set
+	Events *events.Writer
+}
+
+// resourceTimeout returns the ResourceTimeout, or meta.default-timeout if it
+// is not set
+func resourceTimeout(options RunOptions) time.Duration {
+	if options.ResourceTimeout != 0 {
+		return options.ResourceTimeout
+	}
+	return options.Config.Meta.DefaultTimeout.Duration()
+}
+
+// overrideInteractive sets the interactive setting of every job resource
+func overrideInteractive(conf *config.Config, interactive bool) {
+	for _, resource := range conf.Resources {
+		if job, ok := resource.(*config.JobConfig); ok {
+			job.Interactive = interactive
+		}
+	}
+}
+
+// concurrencyLimits returns the limits from meta.limits, with the limit for
+// every type overridden by Parallel, and the limit for images overridden by
+// ParallelImages
+func concurrencyLimits(options RunOptions) map[string]int {
+	limits := make(map[string]int)
+	for resourceType, limit := range options.Config.Meta.Limits {
+		limits[resourceType] = limit
+	}
+	if options.Parallel > 1 {
+		for _, resourceType := range parallelTypes {
+			limits[resourceType] = options.Parallel
+		}
+	}
+	if options.ParallelImages > 1 {
+		limits["image"] = options.ParallelImages
+	}
+	return limits
+}
+
+func newExecEnv(conf *config.Config, envFiles []string) (*execenv.ExecEnv, error) {
+	return execenv.NewExecEnvFromConfig(
+		conf.Meta.ExecID,
+		conf.Meta.Project,
+		conf.WorkingDir,
+		conf.Meta.AutoloadDotenv,
+		envFiles,
+	)
 }
 
 func getTaskNames(options RunOptions) []string {
@@ -204,18 +512,50 @@ func getTaskNames(options RunOptions) []string {
 	return options.Tasks
 }
 
+// newExecuteContext returns the ExecuteContext used to run the tasks
+func newExecuteContext(
+	options RunOptions,
+	execEnv *execenv.ExecEnv,
+) (*context.ExecuteContext, error) {
+	ctx := context.NewExecuteContext(
+		options.Config,
+		options.Client,
+		execEnv,
+		options.Quiet)
+	ctx.Limits = concurrencyLimits(options)
+	ctx.EnvPassthrough = options.EnvPassthrough
+	ctx.Masker = options.Masker
+	ctx.DefaultShell = options.Config.Meta.DefaultShell.Value()
+	ctx.ExecCommand = options.ExecCommand
+	ctx.ImageProgress = options.ImageProgress
+	ctx.NoRemove = options.NoRemove
+	ctx.StaleCheck = options.Config.Meta.StaleCheck
+	ctx.Events = options.Events
+	ctx.Engine = client.ResolveEngine(options.Config.Meta.Engine)
+	if options.Events != nil && options.ImageProgress == nil {
+		ctx.ImageProgress = imageProgressEvents(options.Events)
+	}
+	var err error
+	if ctx.Labels, err = options.Config.Meta.ResolveLabels(execEnv); err != nil {
+		return nil, err
+	}
+	if options.TempDir != "" {
+		ctx.TempDir = options.TempDir
+	}
+	return ctx, nil
+}
+
 // Run one or more tasks
 func Run(options RunOptions) error {
 	options.Tasks = getTaskNames(options)
 	if len(options.Tasks) == 0 {
 		return fmt.Errorf("No task to run, and no default task defined.")
 	}
+	if options.Interactive != nil {
+		overrideInteractive(options.Config, *options.Interactive)
+	}
 
-	execEnv, err := execenv.NewExecEnvFromConfig(
-		options.Config.Meta.ExecID,
-		options.Config.Meta.Project,
-		options.Config.WorkingDir,
-	)
+	execEnv, err := newExecEnv(options.Config, options.EnvFiles)
 	if err != nil {
 		return err
 	}
@@ -225,10 +565,79 @@ func Run(options RunOptions) error {
 		return err
 	}
 
-	ctx := context.NewExecuteContext(
-		options.Config,
-		options.Client,
-		execEnv,
-		options.Quiet)
-	return executeTasks(ctx, tasks)
+	ctx, err := newExecuteContext(options, execEnv)
+	if err != nil {
+		return err
+	}
+
+	if options.ListStale {
+		return listStale(ctx, os.Stdout, tasks)
+	}
+	if options.OnlyStale {
+		if tasks, err = filterStale(ctx, tasks); err != nil {
+			return err
+		}
+	}
+	if options.PlanFile != "" {
+		if err := writePlan(ctx, tasks, options.PlanFile); err != nil {
+			return fmt.Errorf("Failed to write plan file %q: %s", options.PlanFile, err)
+		}
+	}
+	if options.DryRun {
+		printDryRun(os.Stdout, tasks)
+		return nil
+	}
+	if options.ParallelPull > 0 {
+		if err := prePull(ctx, tasks, options.ParallelPull); err != nil {
+			return err
+		}
+	}
+
+	var store *history.Store
+	if options.SinceLastSuccess || hasRunOnce(options.Config) || hasCapture(options.Config) {
+		if store, err = history.Load(ctx.WorkingDir); err != nil {
+			return fmt.Errorf("Failed to load state: %s", err)
+		}
+	}
+
+	run := runTask
+	if options.SinceLastSuccess {
+		runner := &incremental{store: store, tasks: tasks}
+		if options.ExplainCache {
+			runner.explain = os.Stdout
+		}
+		run = runner.runTask
+	}
+	if !options.Config.Meta.Cache.IsZero() {
+		cached, err := newRemoteCache(ctx, options.Config, tasks, run)
+		if err != nil {
+			return fmt.Errorf("Failed to configure the cache: %s", err)
+		}
+		run = cached.runTask
+	}
+	if limit := resourceTimeout(options); limit != 0 {
+		run = (&timeouts{defaultTimeout: limit, tasks: tasks, next: run}).runTask
+	}
+	run = newRequirements(options.Config, run).runTask
+	if store != nil {
+		run = (&runOnce{store: store, tasks: tasks, force: options.Force, next: run}).runTask
+	}
+	if hasCapture(options.Config) {
+		run = (&captures{store: store, tasks: tasks, config: options.Config, next: run}).runTask
+	}
+	_, interactive := term.GetFdInfo(os.Stdin)
+	run = (&pauser{
+		config:      options.Config,
+		interactive: interactive,
+		failOnPause: options.FailOnPause,
+		in:          bufio.NewReader(os.Stdin),
+		out:         os.Stdout,
+		next:        run,
+	}).runTask
+	run = (&cleanupPolicies{config: options.Config, next: run}).runTask
+	run = (&conditions{config: options.Config, tasks: tasks, next: run}).runTask
+	if options.Events != nil {
+		run = (&reporter{events: options.Events, tasks: tasks, next: run}).runTask
+	}
+	return executeTasks(ctx, tasks, run)
 }
@@ -1,9 +1,13 @@
 package tasks
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/execenv"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,7 +24,8 @@ func TestCollectTasksErrorsOnCyclicDependencies(t *testing.T) {
 		},
 		Tasks: []string{"one"},
 	}
-	tasks, err := collectTasks(runOptions, nil)
+	env := execenv.NewExecEnv("exec", "project", ".")
+	tasks, err := collectTasks(runOptions, env)
 	assert.Nil(t, tasks)
 	assert.Error(t, err)
 	assert.Contains(t,
@@ -37,7 +42,196 @@ func TestCollectTasksDoesNotErrorOnDuplicateTask(t *testing.T) {
 		},
 		Tasks: []string{"one", "two"},
 	}
-	tasks, err := collectTasks(runOptions, nil)
+	env := execenv.NewExecEnv("exec", "project", ".")
+	tasks, err := collectTasks(runOptions, env)
 	assert.Nil(t, err)
 	assert.Equal(t, 2, len(tasks.All()))
 }
+
+func TestCollectTasksReportsAllUnresolvedVariables(t *testing.T) {
+	runOptions := RunOptions{
+		Config: &config.Config{
+			Resources: map[string]config.Resource{
+				"builder": &config.ImageConfig{Tags: []string{"{env.DOBI_TEST_TAG}"}},
+				"test": &config.JobConfig{
+					Use: "builder",
+					Env: []string{"TOKEN={env.DOBI_TEST_TOKEN}"},
+				},
+			},
+		},
+		Tasks: []string{"test"},
+	}
+	env := execenv.NewExecEnv("exec", "project", ".")
+	tasks, err := collectTasks(runOptions, env)
+	assert.Nil(t, tasks)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			`Error at test.env: A value is required for variable "env.DOBI_TEST_TOKEN"`)
+		assert.Contains(t, err.Error(),
+			`Error at builder.tags: A value is required for variable "env.DOBI_TEST_TAG"`)
+	}
+}
+
+func TestCollectTasksLoadsEnvBeforeResolvingDependents(t *testing.T) {
+	runOptions := RunOptions{
+		Config: &config.Config{
+			Resources: map[string]config.Resource{
+				"builder": &config.ImageConfig{Image: "builder"},
+				"settings": &config.EnvConfig{
+					Variables: []string{"DOBI_TEST_REGION=us-east-1"},
+				},
+				"deploy": &config.JobConfig{
+					Use:     "builder",
+					Env:     []string{"REGION={env.DOBI_TEST_REGION}"},
+					Depends: []string{"settings"},
+				},
+			},
+		},
+		Tasks: []string{"deploy"},
+	}
+	env := execenv.NewExecEnv("exec", "project", ".")
+	tasks, err := collectTasks(runOptions, env)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 3, len(tasks.All()))
+	}
+	region, err := env.Resolve("{env.DOBI_TEST_REGION}")
+	assert.Nil(t, err)
+	assert.Equal(t, "us-east-1", region)
+}
+
+func TestCollectTasksErrorsOnMissingRequiredEnv(t *testing.T) {
+	runOptions := RunOptions{
+		Config: &config.Config{
+			Resources: map[string]config.Resource{
+				"settings": &config.EnvConfig{Variables: []string{"DOBI_TEST_TOKEN"}},
+				"deploy":   &config.AliasConfig{Tasks: []string{"settings"}},
+			},
+		},
+		Tasks: []string{"deploy"},
+	}
+	env := execenv.NewExecEnv("exec", "project", ".")
+	_, err := collectTasks(runOptions, env)
+	assert.EqualError(t, err,
+		`Failed to load env "settings": Required variables are not set: DOBI_TEST_TOKEN`)
+}
+
+func TestCollectTasksDefersResourcesWhichUseCaptures(t *testing.T) {
+	runOptions := RunOptions{
+		Config: &config.Config{
+			Resources: map[string]config.Resource{
+				"builder": &config.ImageConfig{Image: "builder"},
+				"version": &config.JobConfig{Use: "builder", Capture: "build.version"},
+				"release": &config.ImageConfig{
+					Image:   "app",
+					Tags:    []string{"{capture.build.version}"},
+					Depends: []string{"version"},
+				},
+			},
+		},
+		Tasks: []string{"release"},
+	}
+	env := execenv.NewExecEnv("exec", "project", ".")
+	tasks, err := collectTasks(runOptions, env)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 3, len(tasks.All()))
+		assert.Equal(t, map[string]bool{"release": true}, tasks.deferred)
+	}
+}
+
+func TestCollectTasksErrorsOnCaptureFromJobNotInDependencies(t *testing.T) {
+	runOptions := RunOptions{
+		Config: &config.Config{
+			Resources: map[string]config.Resource{
+				"builder": &config.ImageConfig{Image: "builder"},
+				"version": &config.JobConfig{Use: "builder", Capture: "build.version"},
+				"release": &config.ImageConfig{
+					Image: "app",
+					Tags:  []string{"{capture.build.version}"},
+				},
+			},
+		},
+		Tasks: []string{"release"},
+	}
+	env := execenv.NewExecEnv("exec", "project", ".")
+	tasks, err := collectTasks(runOptions, env)
+	assert.Nil(t, tasks)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(),
+			`Resource "release" uses {capture.build.version}, but does not depend on "version"`)
+	}
+}
+
+func TestOverrideInteractive(t *testing.T) {
+	conf := config.NewConfig()
+	conf.Resources["shell"] = &config.JobConfig{Use: "builder", Interactive: true}
+	conf.Resources["test"] = &config.JobConfig{Use: "builder"}
+	conf.Resources["builder"] = &config.ImageConfig{Image: "builder"}
+
+	overrideInteractive(conf, false)
+	assert.False(t, conf.Resources["shell"].(*config.JobConfig).Interactive)
+	assert.False(t, conf.Resources["test"].(*config.JobConfig).Interactive)
+
+	overrideInteractive(conf, true)
+	assert.True(t, conf.Resources["shell"].(*config.JobConfig).Interactive)
+	assert.True(t, conf.Resources["test"].(*config.JobConfig).Interactive)
+}
+
+func TestConcurrencyLimits(t *testing.T) {
+	conf := config.NewConfig()
+	conf.Meta.Limits = map[string]int{"image": 2, "job": 8}
+
+	options := RunOptions{Config: conf}
+	assert.Equal(t, map[string]int{"image": 2, "job": 8}, concurrencyLimits(options))
+
+	options.Parallel = 4
+	assert.Equal(t, map[string]int{"image": 4, "job": 4, "shell": 4}, concurrencyLimits(options))
+
+	options.ParallelImages = 6
+	assert.Equal(t, map[string]int{"image": 6, "job": 4, "shell": 4}, concurrencyLimits(options))
+}
+
+func TestExecuteTasksStopsInReverseDependencyOrder(t *testing.T) {
+	for _, runErr := range []error{nil, fmt.Errorf("failed")} {
+		stopped := []string{}
+		tasks := newTaskCollection()
+		for _, task := range []*fakeTask{
+			{name: "db", stopped: &stopped},
+			{name: "cache", stopped: &stopped},
+			{name: "app", deps: []string{"db", "cache"}, stopped: &stopped},
+			{name: "test", deps: []string{"app"}, err: runErr, stopped: &stopped},
+		} {
+			tasks.add(task)
+			tasks.addName(task.name, task.Name())
+		}
+
+		run := func(ctx *context.ExecuteContext, task iface.Task) error {
+			return task.Run(ctx)
+		}
+		err := executeTasks(&context.ExecuteContext{}, tasks, run)
+		assert.Equal(t, runErr, err)
+		assert.Equal(t, []string{"test", "app", "cache", "db"}, stopped)
+	}
+}
+
+func TestCollectTasksSkipsResourcesWhenFalse(t *testing.T) {
+	runOptions := RunOptions{
+		Config: &config.Config{
+			Resources: map[string]config.Resource{
+				"builder": &config.ImageConfig{Image: "builder"},
+				"app":     &config.ImageConfig{Image: "app", Depends: []string{"builder"}},
+				"release": &config.AliasConfig{Tasks: []string{"app:push"}},
+			},
+			Options: map[string]*config.ResourceOptions{
+				"app": {When: "{env.DOBI_TEST_BRANCH:dev} == main"},
+			},
+		},
+		Tasks: []string{"release"},
+	}
+	env := execenv.NewExecEnv("exec", "project", ".")
+	tasks, err := collectTasks(runOptions, env)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 2, len(tasks.All()))
+		assert.True(t, tasks.Skipped(tasks.All()[0]))
+		assert.False(t, tasks.Skipped(tasks.All()[1]))
+	}
+}
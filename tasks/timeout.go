@@ -0,0 +1,73 @@
+package tasks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/dnephin/dobi/tasks/job"
+)
+
+// timeouts runs tasks, and fails any task which runs for longer than the
+// default timeout. Resources which set their own timeout are not limited by
+// the default timeout. When a job times out its container is stopped. Tasks of
+// other resources can not be interrupted, so a task which times out fails once
+// it returns.
+type timeouts struct {
+	defaultTimeout time.Duration
+	tasks          *TaskCollection
+	next           func(*context.ExecuteContext, iface.Task) error
+}
+
+func (t *timeouts) runTask(ctx *context.ExecuteContext, task iface.Task) error {
+	resource, _ := t.tasks.Resource(task)
+	limit := t.timeout(resource)
+	if limit == 0 {
+		return t.next(ctx, task)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- t.next(ctx, task)
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(limit):
+		logging.Log.Warnf("Task %q timed out after %s, stopping it", task.Name(), limit)
+		if err := stopTimedOut(ctx, task, resource); err != nil {
+			return fmt.Errorf("Task %q timed out after %s: failed to stop: %s",
+				task.Name(), limit, err)
+		}
+		<-result
+		return fmt.Errorf("Task %q timed out after %s", task.Name(), limit)
+	}
+}
+
+// timeout returns the timeout for the tasks of resource, or 0 if the tasks
+// have no timeout
+func (t *timeouts) timeout(resource config.Resource) time.Duration {
+	switch conf := resource.(type) {
+	case *config.DownloadConfig:
+		if conf.Timeout.Duration() != 0 {
+			return 0
+		}
+	case *config.JobConfig:
+		if conf.Timeout.Duration() != 0 {
+			return 0
+		}
+	}
+	return t.defaultTimeout
+}
+
+func stopTimedOut(ctx *context.ExecuteContext, task iface.Task, resource config.Resource) error {
+	if conf, ok := resource.(*config.JobConfig); ok {
+		if _, ok := task.(*job.Task); ok {
+			return job.NewStopTask(task.Name().Resource(), conf).Run(ctx)
+		}
+	}
+	return task.Stop(ctx)
+}
@@ -0,0 +1,86 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTimeouts(limit time.Duration, delay time.Duration) *timeouts {
+	return &timeouts{
+		defaultTimeout: limit,
+		tasks:          newTaskCollection(),
+		next: func(ctx *context.ExecuteContext, task iface.Task) error {
+			time.Sleep(delay)
+			return task.Run(ctx)
+		},
+	}
+}
+
+func TestTimeoutsRunTaskWithinTimeout(t *testing.T) {
+	runner := newTimeouts(time.Second, 0)
+	assert.Nil(t, runner.runTask(nil, &fakeTask{name: "job"}))
+}
+
+func TestTimeoutsRunTaskTimedOut(t *testing.T) {
+	runner := newTimeouts(10*time.Millisecond, 50*time.Millisecond)
+	err := runner.runTask(nil, &fakeTask{name: "job"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `Task "job:run" timed out after 10ms`)
+	}
+}
+
+func TestTimeoutsRunTaskTimedOutWaitsForTask(t *testing.T) {
+	runner := newTimeouts(10*time.Millisecond, 0)
+	returned := false
+	runner.next = func(ctx *context.ExecuteContext, task iface.Task) error {
+		time.Sleep(50 * time.Millisecond)
+		returned = true
+		return nil
+	}
+
+	err := runner.runTask(nil, &fakeTask{name: "job"})
+	assert.Error(t, err)
+	assert.True(t, returned)
+}
+
+func TestTimeoutsResourceWithOwnTimeout(t *testing.T) {
+	runner := newTimeouts(10*time.Millisecond, 50*time.Millisecond)
+	download := &config.DownloadConfig{}
+	assert.Equal(t, 10*time.Millisecond, runner.timeout(download))
+
+	conf, err := config.LoadFromBytes([]byte(`
+download=fetch:
+  url: https://example.com/file
+  dest: file
+  timeout: 1m
+image=builder:
+  image: builder
+job=test:
+  use: builder
+  timeout: 5m
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), runner.timeout(conf.Resources["fetch"]))
+	assert.Equal(t, time.Duration(0), runner.timeout(conf.Resources["test"]))
+}
+
+func TestResourceTimeout(t *testing.T) {
+	options := RunOptions{Config: config.NewConfig()}
+	assert.Equal(t, time.Duration(0), resourceTimeout(options))
+
+	conf, err := config.LoadFromBytes([]byte(`
+meta:
+  default-timeout: 30m
+`))
+	assert.Nil(t, err)
+	options.Config = conf
+	assert.Equal(t, 30*time.Minute, resourceTimeout(options))
+
+	options.ResourceTimeout = time.Minute
+	assert.Equal(t, time.Minute, resourceTimeout(options))
+}
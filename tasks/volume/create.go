@@ -0,0 +1,75 @@
+package volume
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// CreateTask is a task which creates a Docker volume
+type CreateTask struct {
+	name   string
+	config *config.VolumeConfig
+}
+
+// NewCreateTask creates a new CreateTask object
+func NewCreateTask(name string, conf *config.VolumeConfig) *CreateTask {
+	return &CreateTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *CreateTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "create")
+}
+
+func (t *CreateTask) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *CreateTask) Repr() string {
+	return fmt.Sprintf("[volume:create %s] %s", t.name, t.config.Name)
+}
+
+// Run creates the volume if it doesn't exist
+func (t *CreateTask) Run(ctx *context.ExecuteContext) error {
+	switch _, err := ctx.Client.InspectVolume(t.config.Name); err {
+	case nil:
+		t.logger().Debug("Volume exists")
+		return nil
+	case docker.ErrNoSuchVolume:
+	default:
+		return fmt.Errorf("Failed to inspect volume %q: %s", t.config.Name, err)
+	}
+
+	// TODO: set labels when the docker client supports them
+	if len(t.config.Labels) > 0 {
+		return fmt.Errorf(
+			"labels are not supported by the Docker client used by this version of dobi")
+	}
+	if _, err := ctx.Client.CreateVolume(docker.CreateVolumeOptions{
+		Name:       t.config.Name,
+		Driver:     t.config.Driver,
+		DriverOpts: t.config.DriverOpts,
+	}); err != nil {
+		return fmt.Errorf("Failed to create volume %q: %s", t.config.Name, err)
+	}
+	ctx.SetModified(t.name)
+	t.logger().Info("Created")
+	return nil
+}
+
+// Dependencies returns the list of dependencies
+func (t *CreateTask) Dependencies() []string {
+	return t.config.Dependencies()
+}
+
+// Stop the task
+func (t *CreateTask) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
@@ -0,0 +1,86 @@
+package volume
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/client"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/suite"
+)
+
+type VolumeTaskSuite struct {
+	suite.Suite
+	mock   *gomock.Controller
+	client *client.MockDockerClient
+	ctx    *context.ExecuteContext
+	config *config.VolumeConfig
+}
+
+func TestVolumeTaskSuite(t *testing.T) {
+	suite.Run(t, new(VolumeTaskSuite))
+}
+
+func (s *VolumeTaskSuite) SetupTest() {
+	s.mock = gomock.NewController(s.T())
+	s.client = client.NewMockDockerClient(s.mock)
+	s.ctx = context.NewExecuteContext(config.NewConfig(), s.client, nil, false)
+	s.config = &config.VolumeConfig{
+		Name:       "project-cache",
+		Driver:     "local",
+		DriverOpts: map[string]string{"type": "tmpfs"},
+	}
+}
+
+func (s *VolumeTaskSuite) TearDownTest() {
+	s.mock.Finish()
+}
+
+func (s *VolumeTaskSuite) TestCreateWhenMissing() {
+	s.client.EXPECT().InspectVolume("project-cache").Return(nil, docker.ErrNoSuchVolume)
+	s.client.EXPECT().CreateVolume(docker.CreateVolumeOptions{
+		Name:       "project-cache",
+		Driver:     "local",
+		DriverOpts: map[string]string{"type": "tmpfs"},
+	}).Return(&docker.Volume{}, nil)
+
+	s.Nil(NewCreateTask("cache", s.config).Run(s.ctx))
+	s.True(s.ctx.IsModified("cache"))
+}
+
+func (s *VolumeTaskSuite) TestCreateWhenExists() {
+	s.client.EXPECT().InspectVolume("project-cache").Return(&docker.Volume{}, nil)
+
+	s.Nil(NewCreateTask("cache", s.config).Run(s.ctx))
+	s.False(s.ctx.IsModified("cache"))
+}
+
+func (s *VolumeTaskSuite) TestCreateInspectFails() {
+	s.client.EXPECT().InspectVolume("project-cache").Return(nil, fmt.Errorf("oops"))
+
+	err := NewCreateTask("cache", s.config).Run(s.ctx)
+	s.Error(err)
+	s.Contains(err.Error(), `Failed to inspect volume "project-cache": oops`)
+}
+
+func (s *VolumeTaskSuite) TestCreateWithLabels() {
+	s.config.Labels = map[string]string{"team": "backend"}
+	s.client.EXPECT().InspectVolume("project-cache").Return(nil, docker.ErrNoSuchVolume)
+
+	err := NewCreateTask("cache", s.config).Run(s.ctx)
+	s.Error(err)
+	s.Contains(err.Error(), "labels are not supported")
+}
+
+func (s *VolumeTaskSuite) TestRemove() {
+	s.client.EXPECT().RemoveVolume("project-cache").Return(nil)
+	s.Nil(NewRemoveTask("cache", s.config).Run(s.ctx))
+}
+
+func (s *VolumeTaskSuite) TestRemoveWhenMissing() {
+	s.client.EXPECT().RemoveVolume("project-cache").Return(docker.ErrNoSuchVolume)
+	s.Nil(NewRemoveTask("cache", s.config).Run(s.ctx))
+}
@@ -0,0 +1,61 @@
+package volume
+
+import (
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// RemoveTask is a task which removes a Docker volume
+type RemoveTask struct {
+	name   string
+	config *config.VolumeConfig
+}
+
+// NewRemoveTask creates a new RemoveTask object
+func NewRemoveTask(name string, conf *config.VolumeConfig) *RemoveTask {
+	return &RemoveTask{name: name, config: conf}
+}
+
+// Name returns the name of the task
+func (t *RemoveTask) Name() common.TaskName {
+	return common.NewTaskName(t.name, "rm")
+}
+
+func (t *RemoveTask) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *RemoveTask) Repr() string {
+	return fmt.Sprintf("[volume:rm %s] %s", t.name, t.config.Name)
+}
+
+// Run removes the volume
+func (t *RemoveTask) Run(ctx *context.ExecuteContext) error {
+	switch err := ctx.Client.RemoveVolume(t.config.Name); err {
+	case nil:
+		t.logger().Info("Removed")
+	case docker.ErrNoSuchVolume:
+		t.logger().Debug("Volume does not exist")
+	default:
+		return fmt.Errorf("Failed to remove volume %q: %s", t.config.Name, err)
+	}
+	return nil
+}
+
+// Dependencies returns the list of dependencies. The remove task doesn't depend
+// on anything.
+func (t *RemoveTask) Dependencies() []string {
+	return []string{}
+}
+
+// Stop the task
+func (t *RemoveTask) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
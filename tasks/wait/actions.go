@@ -0,0 +1,18 @@
+package wait
+
+import (
+	"fmt"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/iface"
+)
+
+// GetTask returns a new task for the action
+func GetTask(name, action string, conf *config.WaitConfig) (iface.Task, error) {
+	switch action {
+	case "", "wait":
+		return NewTask(name, conf), nil
+	default:
+		return nil, fmt.Errorf("Invalid wait action %q for task %q", action, name)
+	}
+}
@@ -0,0 +1,31 @@
+package wait
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// CheckTCP returns nil if a connection to the address succeeds
+func CheckTCP(address string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// CheckHTTP returns nil if a request to the url returns a 2xx or 3xx status
+func CheckHTTP(url string, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("request returned status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package wait
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/common"
+	"github.com/dnephin/dobi/tasks/context"
+)
+
+// Task is a task which waits for a condition to be met
+type Task struct {
+	name       string
+	config     *config.WaitConfig
+	runCommand func(dir string, args []string) ([]byte, error)
+}
+
+// NewTask creates a new Task object
+func NewTask(name string, conf *config.WaitConfig) *Task {
+	return &Task{name: name, config: conf, runCommand: runCommand}
+}
+
+// Name returns the name of the task
+func (t *Task) Name() common.TaskName {
+	return common.NewTaskName(t.name, "wait")
+}
+
+func (t *Task) logger() *log.Entry {
+	return logging.Log.WithFields(log.Fields{"task": t})
+}
+
+// Repr formats the task for logging
+func (t *Task) Repr() string {
+	return fmt.Sprintf("[wait %s] %s", t.name, t.config.WaitFor())
+}
+
+// Run checks the condition until it is met, or until the timeout
+func (t *Task) Run(ctx *context.ExecuteContext) error {
+	condition := t.config.WaitFor()
+	deadline := time.Now().Add(condition.Timeout())
+	t.logger().Infof("Waiting for %s", condition)
+
+	for {
+		err := t.check(ctx, condition)
+		if err == nil {
+			t.logger().Infof("%s is ready", condition)
+			return nil
+		}
+		t.logger().Debugf("%s is not ready: %s", condition, err)
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("Timed out after %s waiting for %s: %s",
+				condition.Timeout(), condition, err)
+		}
+		time.Sleep(t.config.CheckInterval())
+	}
+}
+
+// check returns nil if the condition is met
+func (t *Task) check(ctx *context.ExecuteContext, condition *config.WaitFor) error {
+	interval := t.config.CheckInterval()
+	switch {
+	case condition.TCP != "":
+		return CheckTCP(condition.Address(), interval)
+	case condition.HTTP != "":
+		return CheckHTTP(condition.HTTP, interval)
+	}
+	out, err := t.runCommand(ctx.WorkingDir, condition.Exec.Value())
+	if err != nil {
+		if output := strings.TrimSpace(string(out)); output != "" {
+			return fmt.Errorf("%s: %s", err, output)
+		}
+		return err
+	}
+	return nil
+}
+
+func runCommand(dir string, args []string) ([]byte, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
+
+// Dependencies returns the list of dependencies
+func (t *Task) Dependencies() []string {
+	return t.config.Dependencies()
+}
+
+// Stop the task
+func (t *Task) Stop(ctx *context.ExecuteContext) error {
+	return nil
+}
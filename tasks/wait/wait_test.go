@@ -0,0 +1,62 @@
+package wait
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTask(t *testing.T, values string) *Task {
+	conf, err := config.LoadFromBytes([]byte("wait=ready:\n" + values))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return NewTask("ready", conf.Resources["ready"].(*config.WaitConfig))
+}
+
+func TestTaskRunTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer listener.Close()
+
+	task := newTestTask(t, "  tcp: tcp://"+listener.Addr().String()+"\n")
+	assert.Nil(t, task.Run(&context.ExecuteContext{}))
+}
+
+func TestTaskRunHTTPTimesOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	task := newTestTask(t, "  http: "+server.URL+"\n  timeout: 100ms\n  interval: 50ms\n")
+	err := task.Run(&context.ExecuteContext{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Timed out after 100ms waiting for http "+server.URL)
+		assert.Contains(t, err.Error(), "request returned status 503 Service Unavailable")
+	}
+}
+
+func TestTaskRunExecReadyOnSecondCheck(t *testing.T) {
+	task := newTestTask(t, "  exec: pg_isready -h localhost\n  interval: 10ms\n")
+	calls := 0
+	task.runCommand = func(dir string, args []string) ([]byte, error) {
+		assert.Equal(t, "/project", dir)
+		assert.Equal(t, []string{"pg_isready", "-h", "localhost"}, args)
+		calls++
+		if calls == 1 {
+			return []byte("no response"), fmt.Errorf("exit status 2")
+		}
+		return nil, nil
+	}
+	assert.Nil(t, task.Run(&context.ExecuteContext{WorkingDir: "/project"}))
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,127 @@
+package tasks
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/utils/fs"
+)
+
+const (
+	// watchInterval is how often the watched files are checked for changes
+	watchInterval = 500 * time.Millisecond
+	// watchDebounce is how long the files must be unchanged, after a change,
+	// before the tasks run again
+	watchDebounce = 300 * time.Millisecond
+)
+
+// Watch runs the tasks, and runs them again each time one of the files used by
+// the tasks changes. A failed run is logged, and the files are watched again.
+// Jobs with an artifact, and image builds, only run again if they are stale.
+// When options.SkipInitialRun is set the tasks only run after the first change.
+func Watch(options RunOptions) error {
+	w := &watcher{interval: watchInterval, debounce: watchDebounce}
+	return w.watch(options, Run)
+}
+
+func (w *watcher) watch(options RunOptions, run func(RunOptions) error) error {
+	initial := !options.SkipInitialRun
+	for {
+		if initial {
+			if err := run(options); err != nil {
+				logging.Log.Error(err)
+			}
+		}
+		initial = true
+		paths, err := watchedPaths(options)
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return fmt.Errorf("No files to watch, the tasks do not use any sources, " +
+				"mounts, or build contexts")
+		}
+		logging.Log.Infof("Watching %d paths for changes", len(paths))
+		w.waitForChange(paths)
+	}
+}
+
+// watchedPaths returns the sources of every job, shell, and image build in the
+// tasks. Artifacts are not watched, so that a task which writes its artifact
+// does not run again.
+func watchedPaths(options RunOptions) ([]string, error) {
+	options.Tasks = getTaskNames(options)
+	execEnv, err := newExecEnv(options.Config, options.EnvFiles)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := collectTasks(options, execEnv)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.NewExecuteContext(options.Config, options.Client, execEnv, options.Quiet)
+
+	seen := map[string]bool{}
+	paths := []string{}
+	for _, task := range tasks.All() {
+		resource, ok := tasks.Resource(task)
+		if !ok || !isIncremental(task) {
+			continue
+		}
+		for _, path := range sourceFiles(ctx, resource) {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths, nil
+}
+
+type watcher struct {
+	interval time.Duration
+	debounce time.Duration
+}
+
+// waitForChange returns after one of the paths is modified, created, or
+// removed, once the paths have not changed for the debounce period
+func (w *watcher) waitForChange(paths []string) {
+	last := snapshot(paths)
+	for {
+		time.Sleep(w.interval)
+		current := snapshot(paths)
+		if current == last {
+			continue
+		}
+		for {
+			time.Sleep(w.debounce)
+			next := snapshot(paths)
+			if next == current {
+				return
+			}
+			current = next
+		}
+	}
+}
+
+// snapshot returns the last modified time of each path
+func snapshot(paths []string) string {
+	buf := &bytes.Buffer{}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			fmt.Fprintf(buf, "%s missing\n", path)
+			continue
+		}
+		modified, err := fs.LastModified(path)
+		if err != nil {
+			fmt.Fprintf(buf, "%s %s\n", path, err)
+			continue
+		}
+		fmt.Fprintf(buf, "%s %d\n", path, modified.UnixNano())
+	}
+	return buf.String()
+}
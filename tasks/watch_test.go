@@ -0,0 +1,93 @@
+package tasks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForChange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "source")
+	assert.Nil(t, ioutil.WriteFile(source, []byte("one"), 0644))
+
+	done := make(chan struct{})
+	w := &watcher{interval: time.Millisecond, debounce: 5 * time.Millisecond}
+	go func() {
+		w.waitForChange([]string{source, filepath.Join(dir, "missing")})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("returned before a change")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	newer := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(source, newer, newer))
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("did not return after a change")
+	}
+}
+
+func TestSourceFilesDoesNotIncludeArtifacts(t *testing.T) {
+	ctx := context.NewExecuteContext(config.NewConfig(), nil, nil, false)
+	job := &config.JobConfig{Artifact: "dist/app", Sources: []string{"cmd/", "pkg/"}}
+
+	assert.Equal(t, []string{"cmd/", "pkg/"}, sourceFiles(ctx, job))
+	assert.Equal(t, []string{"cmd/", "pkg/", "dist/app"}, inputFiles(ctx, job))
+}
+
+func TestWatchSkipInitialRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "source")
+	assert.Nil(t, ioutil.WriteFile(source, []byte("one"), 0644))
+
+	conf, err := config.LoadFromBytes([]byte(fmt.Sprintf(
+		"image=alpine:\n  image: alpine\n"+
+			"job=test:\n  use: alpine\n  sources: [%s]\n", source)))
+	if !assert.Nil(t, err) {
+		return
+	}
+	conf.WorkingDir = dir
+
+	runs := make(chan struct{}, 10)
+	run := func(RunOptions) error {
+		select {
+		case runs <- struct{}{}:
+		default:
+		}
+		return nil
+	}
+	w := &watcher{interval: time.Millisecond, debounce: 5 * time.Millisecond}
+	options := RunOptions{Config: conf, Tasks: []string{"test"}, SkipInitialRun: true}
+	go w.watch(options, run)
+
+	select {
+	case <-runs:
+		t.Fatal("ran before a change")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	newer := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(source, newer, newer))
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("did not run after a change")
+	}
+}
@@ -0,0 +1,27 @@
+package tasks
+
+import (
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/logging"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+)
+
+// conditions skips the tasks of resources with a when condition which is
+// false. A skipped task does not modify anything, so the tasks which depend on
+// it treat it as up to date.
+type conditions struct {
+	config *config.Config
+	tasks  *TaskCollection
+	next   func(*context.ExecuteContext, iface.Task) error
+}
+
+func (c *conditions) runTask(ctx *context.ExecuteContext, task iface.Task) error {
+	if !c.tasks.Skipped(task) {
+		return c.next(ctx, task)
+	}
+	name := task.Name().Resource()
+	logging.Log.Infof("Skipping %q, when %q is false",
+		task.Name().Name(), c.config.OptionsFor(name).When)
+	return nil
+}
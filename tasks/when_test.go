@@ -0,0 +1,41 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/dnephin/dobi/config"
+	"github.com/dnephin/dobi/tasks/context"
+	"github.com/dnephin/dobi/tasks/iface"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionsSkipsTask(t *testing.T) {
+	conf := config.NewConfig()
+	conf.Options["push"] = &config.ResourceOptions{When: "{env.BRANCH} == main"}
+	tasks := newTaskCollection()
+	tasks.skipped["push"] = true
+
+	ran := []string{}
+	c := &conditions{
+		config: conf,
+		tasks:  tasks,
+		next: func(ctx *context.ExecuteContext, task iface.Task) error {
+			ran = append(ran, task.Name().Name())
+			return nil
+		},
+	}
+	assert.Nil(t, c.runTask(nil, &fakeTask{name: "push"}))
+	assert.Nil(t, c.runTask(nil, &fakeTask{name: "build"}))
+	assert.Equal(t, []string{"build:run"}, ran)
+}
+
+func TestTaskIsStaleSkipped(t *testing.T) {
+	tasks := newTaskCollection()
+	task := &fakeStaleTask{fakeTask: fakeTask{name: "push"}, stale: true}
+	tasks.add(task)
+	tasks.skipped["push"] = true
+
+	stale, err := taskIsStale(nil, tasks, task, map[string]bool{})
+	assert.Nil(t, err)
+	assert.False(t, stale)
+}
@@ -0,0 +1,72 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// checksumSkipDirs are directories which are not included in a checksum,
+// because their contents change without a change to the files they track
+var checksumSkipDirs = map[string]bool{
+	".dobi": true,
+	".git":  true,
+	".hg":   true,
+	".svn":  true,
+}
+
+// Checksum returns a sha256 checksum of the names and contents of all the files
+// and directories. The files in each directory are included in the checksum.
+// Modified times and permissions are not included, so the checksum only
+// changes when the contents of a file change, or when a file is added,
+// removed, or renamed.
+func Checksum(fileOrDir ...string) (string, error) {
+	sum := sha256.New()
+	for _, file := range fileOrDir {
+		walker := func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() && path != file && checksumSkipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			rel, err := filepath.Rel(file, path)
+			if err != nil {
+				return err
+			}
+			return writeChecksumEntry(sum, file, rel, info)
+		}
+		if err := filepath.Walk(file, walker); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+func writeChecksumEntry(sum hash.Hash, root, rel string, info os.FileInfo) error {
+	switch {
+	case info.IsDir():
+		_, err := fmt.Fprintf(sum, "dir %s %s\n", root, rel)
+		return err
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(filepath.Join(root, rel))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(sum, "link %s %s %s\n", root, rel, target)
+		return err
+	}
+
+	fmt.Fprintf(sum, "file %s %s %d\n", root, rel, info.Size())
+	file, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(sum, file)
+	return err
+}
@@ -0,0 +1,76 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecksum(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checksum-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	assert.Nil(t, os.MkdirAll(filepath.Join(src, "pkg"), 0755))
+	file := filepath.Join(src, "pkg", "main.go")
+	assert.Nil(t, ioutil.WriteFile(file, []byte("package main"), 0644))
+
+	checksum := func() string {
+		sum, err := Checksum(src)
+		assert.Nil(t, err)
+		return sum
+	}
+	original := checksum()
+
+	// the modified time is not part of the checksum
+	mtime := time.Now().AddDate(0, 0, 1)
+	assert.Nil(t, os.Chtimes(file, mtime, mtime))
+	assert.Equal(t, original, checksum())
+
+	// state and version control directories are skipped
+	assert.Nil(t, os.MkdirAll(filepath.Join(src, ".git"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(src, ".git", "HEAD"), []byte("ref"), 0644))
+	assert.Equal(t, original, checksum())
+
+	assert.Nil(t, ioutil.WriteFile(file, []byte("package other"), 0644))
+	changed := checksum()
+	assert.NotEqual(t, original, changed)
+
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(src, "new.go"), nil, 0644))
+	assert.NotEqual(t, changed, checksum())
+}
+
+func TestChecksumDirectoryOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checksum-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	checksumOf := func(names ...string) string {
+		assert.Nil(t, os.RemoveAll(src))
+		for _, name := range names {
+			path := filepath.Join(src, name)
+			assert.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+			assert.Nil(t, ioutil.WriteFile(path, []byte(name), 0644))
+		}
+		sum, err := Checksum(src)
+		assert.Nil(t, err)
+		return sum
+	}
+
+	// files are included in the same order, regardless of the order they
+	// were created
+	assert.Equal(t,
+		checksumOf("b/two", "a/one", "c"),
+		checksumOf("c", "a/one", "b/two"))
+}
+
+func TestChecksumMissingFile(t *testing.T) {
+	_, err := Checksum("/does/not/exist")
+	assert.Error(t, err)
+}
@@ -1,6 +1,8 @@
 package fs
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"time"
@@ -43,3 +45,22 @@ func LastModified(fileOrDir ...string) (time.Time, error) {
 	}
 	return latest, nil
 }
+
+// ValidateWritableDir returns an error if path is not a directory, or if files
+// can not be created in the directory.
+func ValidateWritableDir(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%q is not a directory", path)
+	}
+
+	file, err := ioutil.TempFile(path, ".dobi-write-test")
+	if err != nil {
+		return fmt.Errorf("%q is not writable: %s", path, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
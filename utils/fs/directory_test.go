@@ -53,6 +53,27 @@ func (s *DirectorySuite) TestLastModified() {
 	}
 }
 
+func (s *DirectorySuite) TestValidateWritableDir() {
+	s.Nil(ValidateWritableDir(s.path))
+
+	files, err := ioutil.ReadDir(s.path)
+	s.Nil(err)
+	s.Len(files, 0)
+}
+
+func (s *DirectorySuite) TestValidateWritableDirNotADirectory() {
+	file := filepath.Join(s.path, "file")
+	s.Require().Nil(touch(file, time.Now()))
+
+	err := ValidateWritableDir(file)
+	s.Error(err)
+	s.Contains(err.Error(), "is not a directory")
+}
+
+func (s *DirectorySuite) TestValidateWritableDirMissing() {
+	s.Error(ValidateWritableDir(filepath.Join(s.path, "missing")))
+}
+
 func touch(name string, mtime time.Time) error {
 	w, err := os.Create(name)
 	if err != nil {
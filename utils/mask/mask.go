@@ -0,0 +1,101 @@
+package mask
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sync"
+)
+
+// Redacted is the text which replaces masked output
+const Redacted = "******"
+
+// Masker redacts text which matches any of a list of patterns. A nil Masker
+// does not redact anything.
+type Masker struct {
+	patterns []*regexp.Regexp
+}
+
+// New returns a Masker for the list of regular expressions. It returns nil if
+// there are no patterns.
+func New(patterns []string) (*Masker, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	masker := &Masker{}
+	for _, pattern := range patterns {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		masker.patterns = append(masker.patterns, regex)
+	}
+	return masker, nil
+}
+
+// Mask returns raw with every match of the patterns replaced by Redacted
+func (m *Masker) Mask(raw []byte) []byte {
+	if m == nil {
+		return raw
+	}
+	for _, regex := range m.patterns {
+		raw = regex.ReplaceAllLiteral(raw, []byte(Redacted))
+	}
+	return raw
+}
+
+// MaskString returns raw with every match of the patterns replaced by Redacted
+func (m *Masker) MaskString(raw string) string {
+	if m == nil {
+		return raw
+	}
+	return string(m.Mask([]byte(raw)))
+}
+
+// Writer is an io.Writer which masks each line before writing it to the
+// underlying writer. Lines are buffered so that a match can not be split
+// across writes.
+type Writer struct {
+	out    io.Writer
+	masker *Masker
+	buff   bytes.Buffer
+	mu     sync.Mutex
+}
+
+// NewWriter returns a new Writer which writes masked lines to out
+func NewWriter(out io.Writer, masker *Masker) *Writer {
+	return &Writer{out: out, masker: masker}
+}
+
+// Write buffers p and writes any complete lines to the underlying writer
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buff.Write(p)
+	for {
+		index := bytes.IndexByte(w.buff.Bytes(), '\n')
+		if index < 0 {
+			return len(p), nil
+		}
+		if err := w.writeLine(w.buff.Next(index + 1)); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes any buffered partial line to the underlying writer
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buff.Len() == 0 {
+		return nil
+	}
+	return w.writeLine(w.buff.Next(w.buff.Len()))
+}
+
+func (w *Writer) writeLine(line []byte) error {
+	_, err := w.out.Write(w.masker.Mask(append([]byte{}, line...)))
+	return err
+}
@@ -0,0 +1,43 @@
+package mask
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInvalidPattern(t *testing.T) {
+	_, err := New([]string{"ok", "bad("})
+	assert.Error(t, err)
+}
+
+func TestMaskString(t *testing.T) {
+	masker, err := New([]string{"token-[0-9a-f]+", "hunter2"})
+	assert.Nil(t, err)
+	assert.Equal(t,
+		"auth ****** and ******!",
+		masker.MaskString("auth token-deadbeef and hunter2!"))
+}
+
+func TestMaskStringNilMasker(t *testing.T) {
+	masker, err := New(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "token-deadbeef", masker.MaskString("token-deadbeef"))
+}
+
+func TestWriterMasksSplitWrites(t *testing.T) {
+	masker, err := New([]string{"token-[0-9a-f]+"})
+	assert.Nil(t, err)
+	out := &bytes.Buffer{}
+	writer := NewWriter(out, masker)
+
+	writer.Write([]byte("using tok"))
+	writer.Write([]byte("en-dead"))
+	assert.Equal(t, "", out.String())
+	writer.Write([]byte("beef\nnext"))
+	assert.Equal(t, "using ******\n", out.String())
+
+	assert.Nil(t, writer.Flush())
+	assert.Equal(t, "using ******\nnext", out.String())
+}
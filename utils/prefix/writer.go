@@ -0,0 +1,56 @@
+package prefix
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// Writer is an io.Writer which buffers output and writes each complete line
+// to the underlying writer with a prefix. Writer is used to keep output from
+// concurrent tasks readable.
+type Writer struct {
+	out    io.Writer
+	prefix []byte
+	buff   bytes.Buffer
+	mu     sync.Mutex
+}
+
+// NewWriter returns a new Writer which writes lines to out with prefix
+func NewWriter(out io.Writer, prefix string) *Writer {
+	return &Writer{out: out, prefix: []byte(prefix)}
+}
+
+// Write buffers p and writes any complete lines to the underlying writer
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buff.Write(p)
+	for {
+		index := bytes.IndexByte(w.buff.Bytes(), '\n')
+		if index < 0 {
+			return len(p), nil
+		}
+		if err := w.writeLine(w.buff.Next(index + 1)); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes any buffered partial line to the underlying writer
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buff.Len() == 0 {
+		return nil
+	}
+	line := append(w.buff.Next(w.buff.Len()), '\n')
+	return w.writeLine(line)
+}
+
+func (w *Writer) writeLine(line []byte) error {
+	_, err := w.out.Write(append(append([]byte{}, w.prefix...), line...))
+	return err
+}
@@ -0,0 +1,31 @@
+package prefix
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterPrefixesCompleteLines(t *testing.T) {
+	out := &bytes.Buffer{}
+	writer := NewWriter(out, "[one] ")
+
+	writer.Write([]byte("first line\nsecond "))
+	assert.Equal(t, "[one] first line\n", out.String())
+
+	writer.Write([]byte("line\n"))
+	assert.Equal(t, "[one] first line\n[one] second line\n", out.String())
+}
+
+func TestWriterFlushPartialLine(t *testing.T) {
+	out := &bytes.Buffer{}
+	writer := NewWriter(out, "[one] ")
+
+	writer.Write([]byte("partial"))
+	assert.Equal(t, "", out.String())
+	assert.Nil(t, writer.Flush())
+	assert.Equal(t, "[one] partial\n", out.String())
+	assert.Nil(t, writer.Flush())
+	assert.Equal(t, "[one] partial\n", out.String())
+}